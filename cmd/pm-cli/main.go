@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -43,17 +44,22 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Run the command
-	err = ctx.Run(execCtx)
+	// Run the command, paging long output
+	if node := ctx.Selected(); node != nil && node.Target.CanAddr() {
+		execCtx.StartPager(node.Target.Addr().Interface())
+	}
+	err = execCtx.CheckTimeout(ctx.Run(execCtx))
+	execCtx.StopPager()
 	if err != nil {
+		// A queued message was already reported by the command
+		if errors.Is(err, cli.ErrQueued) {
+			os.Exit(cli.ExitCode(err))
+		}
 		if execCtx.Formatter.JSON {
-			execCtx.Formatter.PrintJSON(map[string]interface{}{
-				"success": false,
-				"error":   err.Error(),
-			})
+			execCtx.Formatter.PrintJSON(cli.ErrorJSON(err))
 		} else {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		}
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
@@ -0,0 +1,173 @@
+// Package cache stores mail list results on disk so that repeated listings
+// of an unchanged mailbox don't refetch every envelope.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const appName = "pm-cli"
+
+// State identifies a mailbox snapshot. Any change to these values means the
+// mailbox contents or flags may have changed and cached listings are stale.
+// HighestModSeq is 0 when the server lacks CONDSTORE.
+type State struct {
+	UIDValidity   uint32 `json:"uid_validity"`
+	UIDNext       uint32 `json:"uid_next"`
+	HighestModSeq uint64 `json:"highest_modseq"`
+	Messages      uint32 `json:"messages"`
+	Unseen        uint32 `json:"unseen"`
+}
+
+// Cacheable reports whether s changes whenever a listing would. Without
+// HIGHESTMODSEQ, starring or answering a message leaves every other value
+// as it was, so a cached listing could not be told apart from a stale one.
+func (s State) Cacheable() bool {
+	return s.HighestModSeq != 0
+}
+
+// Query identifies which listing of a mailbox was cached.
+type Query struct {
+	Account string `json:"account"`
+	Mailbox string `json:"mailbox"`
+	Limit   int    `json:"limit"`
+	Offset  int    `json:"offset"`
+	Unread  bool   `json:"unread"`
+	// Timezone is part of the key because cached dates are preformatted
+	Timezone string `json:"timezone,omitempty"`
+}
+
+type entry struct {
+	Query Query           `json:"query"`
+	State State           `json:"state"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Dir returns the directory holding cached listings.
+func Dir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	return filepath.Join(dir, appName, "envelopes"), nil
+}
+
+// path returns the cache file for q. There is one file per query, so a
+// stale entry is overwritten rather than accumulating.
+func path(q Query) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	key, err := json.Marshal(q)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(key)
+	return filepath.Join(dir, hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// Load decodes the cached listing for q into v. It reports false when there
+// is no entry or the entry was stored for a different mailbox state.
+func Load(q Query, state State, v interface{}) (bool, error) {
+	p, err := path(q)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return false, nil // Treat a corrupt entry as a miss
+	}
+	if e.Query != q || e.State != state {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// Save stores v as the listing for q at the given mailbox state.
+func Save(q Query, state State, v interface{}) error {
+	p, err := path(q)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+	data, err := json.Marshal(entry{Query: q, State: state, Data: payload})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temp file and rename so concurrent readers never see a
+	// partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(p), "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+// Clear removes all cached listings and returns how many were deleted.
+func Clear() (int, error) {
+	dir, err := Dir()
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return removed, fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
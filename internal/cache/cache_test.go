@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"os"
+	"testing"
+)
+
+type summary struct {
+	UID     uint32 `json:"uid"`
+	Subject string `json:"subject"`
+}
+
+func setCacheHome(t *testing.T) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CACHE_HOME", tmpDir)
+	t.Setenv("LocalAppData", tmpDir)
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	setCacheHome(t)
+
+	q := Query{Account: "user@proton.me", Mailbox: "INBOX", Limit: 20}
+	state := State{UIDValidity: 1, UIDNext: 42, Messages: 41, Unseen: 3}
+	want := []summary{{UID: 41, Subject: "Hello"}, {UID: 40, Subject: "World"}}
+
+	var got []summary
+	if hit, err := Load(q, state, &got); err != nil || hit {
+		t.Fatalf("Load() before Save = %v, %v; want miss", hit, err)
+	}
+
+	if err := Save(q, state, want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	hit, err := Load(q, state, &got)
+	if err != nil || !hit {
+		t.Fatalf("Load() = %v, %v; want hit", hit, err)
+	}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Load() data = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadInvalidatesOnStateChange(t *testing.T) {
+	setCacheHome(t)
+
+	q := Query{Account: "user@proton.me", Mailbox: "INBOX", Limit: 20}
+	state := State{UIDValidity: 1, UIDNext: 42, HighestModSeq: 100, Messages: 41, Unseen: 3}
+	if err := Save(q, state, []summary{{UID: 41}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		state State
+	}{
+		{"new message", State{UIDValidity: 1, UIDNext: 43, HighestModSeq: 100, Messages: 42, Unseen: 4}},
+		{"uidvalidity reset", State{UIDValidity: 2, UIDNext: 42, HighestModSeq: 100, Messages: 41, Unseen: 3}},
+		{"flag change", State{UIDValidity: 1, UIDNext: 42, HighestModSeq: 101, Messages: 41, Unseen: 3}},
+		{"expunge", State{UIDValidity: 1, UIDNext: 42, HighestModSeq: 100, Messages: 40, Unseen: 3}},
+		{"marked read", State{UIDValidity: 1, UIDNext: 42, HighestModSeq: 100, Messages: 41, Unseen: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []summary
+			hit, err := Load(q, tt.state, &got)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if hit {
+				t.Error("Load() should miss when mailbox state changed")
+			}
+		})
+	}
+
+	t.Run("different query", func(t *testing.T) {
+		var got []summary
+		other := q
+		other.Offset = 20
+		if hit, _ := Load(other, state, &got); hit {
+			t.Error("Load() should miss for a different page")
+		}
+	})
+}
+
+func TestStateCacheable(t *testing.T) {
+	if !(State{UIDValidity: 1, UIDNext: 42, HighestModSeq: 100}).Cacheable() {
+		t.Error("Cacheable() = false with HIGHESTMODSEQ")
+	}
+	// Flag changes are invisible without CONDSTORE
+	if (State{UIDValidity: 1, UIDNext: 42, Messages: 41, Unseen: 3}).Cacheable() {
+		t.Error("Cacheable() = true without HIGHESTMODSEQ")
+	}
+}
+
+func TestClear(t *testing.T) {
+	setCacheHome(t)
+
+	if n, err := Clear(); err != nil || n != 0 {
+		t.Fatalf("Clear() on empty cache = %d, %v; want 0, nil", n, err)
+	}
+
+	state := State{UIDValidity: 1, UIDNext: 2}
+	for _, mailbox := range []string{"INBOX", "Sent"} {
+		if err := Save(Query{Mailbox: mailbox}, state, []summary{}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	n, err := Clear()
+	if err != nil || n != 2 {
+		t.Fatalf("Clear() = %d, %v; want 2, nil", n, err)
+	}
+
+	dir, _ := Dir()
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("cache dir still has %d entries", len(entries))
+	}
+
+	var got []summary
+	if hit, _ := Load(Query{Mailbox: "INBOX"}, state, &got); hit {
+		t.Error("Load() after Clear() should miss")
+	}
+}
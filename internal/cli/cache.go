@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bscott/pm-cli/internal/cache"
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+// listMessagesCached returns the mailbox listing from the on-disk cache when
+// the mailbox is unchanged since it was stored, and refreshes the cache
+// otherwise. Servers without CONDSTORE are never cached. Cache problems
+// never fail the listing.
+func listMessagesCached(ctx *Context, client *imap.Client, mailbox string, limit, offset int, unreadOnly bool) ([]imap.MessageSummary, error) {
+	state, err := client.MailboxState(mailbox)
+	if err != nil {
+		ctx.Formatter.Verbosef("Skipping cache: %v", err)
+		return client.ListMessages(mailbox, limit, offset, unreadOnly)
+	}
+	cacheState := cache.State(*state)
+	if !cacheState.Cacheable() {
+		ctx.Formatter.Verbosef("Skipping cache: server does not report HIGHESTMODSEQ for %s", mailbox)
+		return client.ListMessages(mailbox, limit, offset, unreadOnly)
+	}
+
+	query := cache.Query{
+		Account:  ctx.Config.Bridge.Email,
+		Mailbox:  mailbox,
+		Limit:    limit,
+		Offset:   offset,
+		Unread:   unreadOnly,
+		Timezone: ctx.Config.Defaults.Timezone,
+	}
+
+	var messages []imap.MessageSummary
+	hit, err := cache.Load(query, cacheState, &messages)
+	if err != nil {
+		ctx.Formatter.Verbosef("Skipping cache: %v", err)
+	} else if hit {
+		ctx.Formatter.Verbosef("Using cached listing for %s", mailbox)
+		return messages, nil
+	}
+
+	messages, err = client.ListMessages(mailbox, limit, offset, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cache.Save(query, cacheState, messages); err != nil {
+		ctx.Formatter.Verbosef("Failed to update cache: %v", err)
+	}
+	return messages, nil
+}
+
+func (c *CacheClearCmd) Run(ctx *Context) error {
+	removed, err := cache.Clear()
+	if err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success": true,
+			"removed": removed,
+		})
+	}
+
+	ctx.Formatter.PrintSuccess(fmt.Sprintf("Removed %d cached listing(s)", removed))
+	return nil
+}
@@ -1,19 +1,37 @@
 package cli
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/bscott/pm-cli/internal/config"
 	"github.com/bscott/pm-cli/internal/output"
+	"golang.org/x/term"
 )
 
 var Version = "0.2.5"
 
+// Commit and BuildDate describe the build. Release builds set them with
+// -ldflags "-X github.com/bscott/pm-cli/internal/cli.Commit=<sha>
+// -X github.com/bscott/pm-cli/internal/cli.BuildDate=<RFC 3339 time>".
+var (
+	Commit    = ""
+	BuildDate = ""
+)
+
 type Globals struct {
-	JSON     bool   `help:"Output as JSON" name:"json"`
-	HelpJSON bool   `help:"Output command help as JSON (AI agent mode)" name:"help-json"`
-	Config   string `help:"Path to config file" short:"c" type:"path"`
-	Verbose  bool   `help:"Verbose output" short:"v"`
-	Quiet    bool   `help:"Suppress non-essential output" short:"q"`
-	NoColor  bool   `help:"Disable colored output" name:"no-color" env:"NO_COLOR"`
+	JSON       *bool         `help:"Output as JSON (default: defaults.format)" name:"json" negatable:""`
+	HelpJSON   bool          `help:"Output command help as JSON (AI agent mode)" name:"help-json"`
+	Config     string        `help:"Path to config file" short:"c" type:"path"`
+	Verbose    bool          `help:"Verbose output" short:"v"`
+	Quiet      bool          `help:"Suppress non-essential output" short:"q"`
+	NoColor    bool          `help:"Disable colored output" name:"no-color" env:"NO_COLOR"`
+	DateStyle  string        `help:"Date display style: absolute or relative (default: defaults.date_style)" name:"date-style"`
+	Retries    *int          `help:"Retries for transient Bridge failures (default: defaults.retries, 2)" name:"retries"`
+	RetryDelay time.Duration `help:"Wait before the first retry, doubled each attempt (default: defaults.retry_delay, 1s)" name:"retry-delay"`
+	Timeout    time.Duration `help:"Give up on Bridge after this long, e.g. 30s (default: defaults.timeout, none)" name:"timeout"`
+	NoPager    bool          `help:"Do not pipe long output through $PAGER" name:"no-pager"`
 }
 
 type CLI struct {
@@ -23,6 +41,8 @@ type CLI struct {
 	Mail     MailCmd     `cmd:"" help:"Email operations"`
 	Mailbox  MailboxCmd  `cmd:"" help:"Mailbox management"`
 	Contacts ContactsCmd `cmd:"" help:"Address book management"`
+	Cache    CacheCmd    `cmd:"" help:"Manage the local listing cache"`
+	Tui      TuiCmd      `cmd:"" help:"Browse mail in an interactive terminal UI"`
 	Version  VersionCmd  `cmd:"" help:"Show version information"`
 }
 
@@ -30,10 +50,41 @@ type Context struct {
 	Config    *config.Config
 	Formatter *output.Formatter
 	Globals   *Globals
+
+	pager *output.Pager
+}
+
+// unpaged is implemented by commands that prompt for input or stream
+// output as it happens, which must reach the terminal directly.
+type unpaged interface {
+	unpaged()
+}
+
+func (c *ConfigInitCmd) unpaged()    {}
+func (c *ConfigRestoreCmd) unpaged() {}
+func (c *MailWatchCmd) unpaged()     {}
+func (c *TuiCmd) unpaged()           {}
+
+// StartPager pipes the output of cmd, the command about to run, through
+// $PAGER once it outgrows the terminal. It does nothing for --no-pager,
+// --json, --quiet, output that is not a terminal, or unpaged commands.
+func (ctx *Context) StartPager(cmd interface{}) {
+	if _, ok := cmd.(unpaged); ok || ctx.Globals.NoPager {
+		return
+	}
+	ctx.pager = output.StartPager(ctx.Formatter)
+}
+
+// StopPager flushes paged output and waits for the pager to exit.
+func (ctx *Context) StopPager() {
+	ctx.pager.Stop(ctx.Formatter)
+	ctx.pager = nil
 }
 
 func NewContext(globals *Globals) (*Context, error) {
-	formatter := output.New(globals.JSON, globals.Verbose, globals.Quiet, globals.NoColor)
+	if globals.DateStyle != "" && !validDateStyle(globals.DateStyle) {
+		return nil, fmt.Errorf("--date-style must be 'absolute' or 'relative'")
+	}
 
 	var cfg *config.Config
 	var err error
@@ -51,6 +102,33 @@ func NewContext(globals *Globals) (*Context, error) {
 		cfg = config.DefaultConfig()
 	}
 
+	// --json and --no-json override defaults.format
+	jsonOutput := cfg.Defaults.Format == "json"
+	if globals.JSON != nil {
+		jsonOutput = *globals.JSON
+	}
+	// Colors are only for people: piped output stays plain
+	noColor := globals.NoColor || !term.IsTerminal(int(os.Stdout.Fd()))
+	formatter := output.New(jsonOutput, globals.Verbose, globals.Quiet, noColor)
+
+	if globals.Retries != nil || globals.RetryDelay != 0 {
+		if globals.Retries != nil && *globals.Retries < 0 {
+			return nil, fmt.Errorf("--retries must not be negative")
+		}
+		if globals.RetryDelay < 0 {
+			return nil, fmt.Errorf("--retry-delay must not be negative")
+		}
+		cfg.OverrideRetryPolicy(globals.Retries, globals.RetryDelay)
+	}
+
+	if globals.Timeout < 0 {
+		return nil, fmt.Errorf("--timeout must not be negative")
+	}
+	if globals.Timeout > 0 {
+		cfg.OverrideTimeout(globals.Timeout)
+	}
+	cfg.StartDeadline()
+
 	return &Context{
 		Config:    cfg,
 		Formatter: formatter,
@@ -65,9 +143,13 @@ type ConfigCmd struct {
 	Set      ConfigSetCmd      `cmd:"" help:"Set a configuration value"`
 	Validate ConfigValidateCmd `cmd:"" help:"Test Bridge connection"`
 	Doctor   ConfigDoctorCmd   `cmd:"" help:"Diagnose configuration issues"`
+	Backup   ConfigBackupCmd   `cmd:"" help:"Back up config, contacts and send history to a tar archive"`
+	Restore  ConfigRestoreCmd  `cmd:"" help:"Restore files from a config backup"`
 }
 
-type ConfigInitCmd struct{}
+type ConfigInitCmd struct {
+	CredentialStore string `help:"Where to store the Bridge password (keyring or file)" name:"credential-store" enum:"keyring,file" default:"keyring"`
+}
 
 type ConfigShowCmd struct{}
 
@@ -80,25 +162,83 @@ type ConfigValidateCmd struct{}
 
 type ConfigDoctorCmd struct{}
 
+type ConfigBackupCmd struct {
+	Output string `help:"Output archive path" short:"o" required:""`
+}
+
+type ConfigRestoreCmd struct {
+	File string `arg:"" help:"Backup archive to restore" type:"existingfile"`
+	Yes  bool   `help:"Skip confirmation prompt" short:"y"`
+}
+
 // MailCmd handles email operations
 type MailCmd struct {
-	List      MailListCmd      `cmd:"" help:"List messages in mailbox"`
-	Read      MailReadCmd      `cmd:"" help:"Read a specific message"`
-	Send      MailSendCmd      `cmd:"" help:"Compose and send email"`
-	Reply     MailReplyCmd     `cmd:"" help:"Reply to a message"`
-	Forward   MailForwardCmd   `cmd:"" help:"Forward a message"`
-	Delete    MailDeleteCmd    `cmd:"" help:"Delete message(s)"`
-	Move      MailMoveCmd      `cmd:"" help:"Move message to mailbox"`
-	Archive   MailArchiveCmd   `cmd:"" help:"Move message(s) to Archive"`
-	Flag      MailFlagCmd      `cmd:"" help:"Manage message flags"`
-	Search    MailSearchCmd    `cmd:"" help:"Search messages"`
-	Download  MailDownloadCmd  `cmd:"" help:"Download attachment"`
-	Draft     DraftCmd         `cmd:"" help:"Manage drafts"`
-	Thread    MailThreadCmd    `cmd:"" help:"Show conversation thread"`
-	Watch     MailWatchCmd     `cmd:"" help:"Watch for new messages"`
-	Label     LabelCmd         `cmd:"" help:"Manage message labels"`
-	Summarize MailSummarizeCmd `cmd:"" help:"Summarize message for AI processing"`
-	Extract   MailExtractCmd   `cmd:"" help:"Extract structured data from message"`
+	List        MailListCmd        `cmd:"" help:"List messages in mailbox"`
+	Count       MailCountCmd       `cmd:"" help:"Count messages in mailbox"`
+	Read        MailReadCmd        `cmd:"" help:"Read a specific message"`
+	Headers     MailHeadersCmd     `cmd:"" help:"Show message headers without fetching the body"`
+	Send        MailSendCmd        `cmd:"" help:"Compose and send email"`
+	Reply       MailReplyCmd       `cmd:"" help:"Reply to a message"`
+	Forward     MailForwardCmd     `cmd:"" help:"Forward a message"`
+	Delete      MailDeleteCmd      `cmd:"" help:"Delete message(s)"`
+	Move        MailMoveCmd        `cmd:"" help:"Move message to mailbox"`
+	Archive     MailArchiveCmd     `cmd:"" help:"Move message(s) to Archive"`
+	Transfer    MailTransferCmd    `cmd:"" help:"Copy or move message(s) to a mailbox of another account"`
+	Trash       MailTrashCmd       `cmd:"" help:"Move message(s) to Trash"`
+	Spam        MailSpamCmd        `cmd:"" help:"Report message(s) as spam and move them to Spam"`
+	NotSpam     MailNotSpamCmd     `cmd:"" help:"Report message(s) as not spam and move them to INBOX"`
+	Flag        MailFlagCmd        `cmd:"" help:"Set message flags, or show them when no change is given"`
+	Search      MailSearchCmd      `cmd:"" help:"Search messages"`
+	Download    MailDownloadCmd    `cmd:"" help:"Download attachment"`
+	Draft       DraftCmd           `cmd:"" help:"Manage drafts"`
+	Thread      MailThreadCmd      `cmd:"" help:"Show conversation thread"`
+	Watch       MailWatchCmd       `cmd:"" help:"Watch for new messages"`
+	Label       LabelCmd           `cmd:"" help:"Manage message labels"`
+	Summarize   MailSummarizeCmd   `cmd:"" help:"Summarize message for AI processing"`
+	Extract     MailExtractCmd     `cmd:"" help:"Extract structured data from message"`
+	Snooze      MailSnoozeCmd      `cmd:"" help:"Hide message(s) until a later time"`
+	Undo        MailUndoCmd        `cmd:"" help:"Undo the last move, archive or delete"`
+	Stats       MailStatsCmd       `cmd:"" help:"Show top senders by message count and size"`
+	Histogram   MailHistogramCmd   `cmd:"" help:"Count messages per day, week or month"`
+	Dedupe      MailDedupeCmd      `cmd:"" help:"Find duplicate messages and move extra copies to Trash"`
+	Outbox      OutboxCmd          `cmd:"" help:"Manage messages queued while Bridge was unreachable"`
+	Unsubscribe MailUnsubscribeCmd `cmd:"" help:"Unsubscribe from a mailing list using its List-Unsubscribe header"`
+}
+
+type MailCountCmd struct {
+	Mailbox string `help:"Mailbox to count" short:"m" default:"INBOX"`
+	Unread  bool   `help:"Print the unread count instead of the total"`
+}
+
+type MailSnoozeCmd struct {
+	IDs     []string `arg:"" optional:"" help:"Message sequence number(s) or uid:<uid> to snooze"`
+	Until   string   `help:"Wake time (YYYY-MM-DD, 'YYYY-MM-DD HH:MM' or RFC 3339)" xor:"when"`
+	In      string   `help:"Wake after a duration (e.g. 30m, 2h, 3d, 1w)" xor:"when"`
+	Mailbox string   `help:"Source mailbox (messages return here when woken)" short:"m" default:"INBOX"`
+	Folder  string   `help:"Folder that holds snoozed messages (created if missing)" default:"Folders/Snoozed"`
+	Process bool     `help:"Move messages whose wake time has passed back to their mailbox"`
+}
+
+type MailUndoCmd struct{}
+
+type MailStatsCmd struct {
+	Mailbox string `help:"Mailbox to analyze" short:"m" default:"INBOX"`
+	Top     int    `help:"Number of rows to show (0 = all)" default:"20"`
+	By      string `help:"Group by sender address or sender domain" enum:"sender,domain" default:"sender"`
+	Limit   int    `help:"Only analyze the N most recent messages (0 = all)" short:"n" default:"0"`
+}
+
+type MailHistogramCmd struct {
+	Mailbox string `help:"Mailbox to analyze" short:"m" default:"INBOX"`
+	Since   string `help:"Start of the period: a duration back from now (30d, 2w) or YYYY-MM-DD" default:"30d"`
+	By      string `help:"Bucket size" enum:"day,week,month" default:"day"`
+	Bars    bool   `help:"Draw an ASCII bar for each bucket"`
+}
+
+type MailDedupeCmd struct {
+	Mailbox string `help:"Mailbox to scan" short:"m" default:"INBOX"`
+	DryRun  bool   `help:"Only report duplicates (the default unless --yes is given)" name:"dry-run"`
+	Yes     bool   `help:"Move all but the newest copy of each duplicate to Trash" short:"y"`
 }
 
 type MailSummarizeCmd struct {
@@ -116,32 +256,49 @@ type LabelCmd struct {
 	List   LabelListCmd   `cmd:"" help:"List available labels"`
 	Add    LabelAddCmd    `cmd:"" help:"Add label to message(s)"`
 	Remove LabelRemoveCmd `cmd:"" help:"Remove label from message(s)"`
+	Create LabelCreateCmd `cmd:"" help:"Create a new label"`
+	Delete LabelDeleteCmd `cmd:"" help:"Delete a label"`
 }
 
 type LabelListCmd struct{}
 
 type LabelAddCmd struct {
-	IDs     []string `arg:"" help:"Message ID(s) to label"`
+	IDs     []string `arg:"" optional:"" help:"Message ID(s) to label"`
 	Label   string   `help:"Label name to add" short:"l" required:""`
+	Query   string   `help:"Label messages in the source mailbox matching search query (e.g., 'from:newsletters@example.com')"`
 	Mailbox string   `help:"Source mailbox" short:"m" default:"INBOX"`
 }
 
 type LabelRemoveCmd struct {
-	IDs   []string `arg:"" help:"Message ID(s) to unlabel"`
+	IDs   []string `arg:"" optional:"" help:"Message ID(s) to unlabel"`
 	Label string   `help:"Label name to remove" short:"l" required:""`
+	Query string   `help:"Unlabel messages in the label matching search query (e.g., 'subject:reminder')"`
+}
+
+type LabelCreateCmd struct {
+	Name string `arg:"" help:"Label name to create (without the Labels/ prefix)"`
+}
+
+type LabelDeleteCmd struct {
+	Name string `arg:"" help:"Label name to delete (without the Labels/ prefix)"`
 }
 
 type MailWatchCmd struct {
-	Mailbox  string `help:"Mailbox to watch" short:"m" default:"INBOX"`
-	Interval int    `help:"Poll interval in seconds" short:"i" default:"30"`
-	Unread   bool   `help:"Only notify for unread messages" default:"true"`
-	Exec     string `help:"Command to execute on new mail (use {} for message ID)" short:"e"`
-	Once     bool   `help:"Exit after first new message"`
+	Mailbox       []string `help:"Mailbox(es) to watch (repeatable or comma-separated)" short:"m" default:"INBOX"`
+	Interval      int      `help:"Poll interval in seconds" short:"i" default:"30"`
+	Unread        bool     `help:"Only notify for unread messages" default:"true"`
+	Exec          string   `help:"Command to execute on new mail (use {} for message ID)" short:"e"`
+	Once          bool     `help:"Exit after first new message"`
+	Notify        bool     `help:"Show a desktop notification for each new message"`
+	Webhook       string   `help:"POST a JSON payload to this URL for each new message"`
+	WebhookSecret string   `help:"Sign webhook payloads with HMAC-SHA256 using this secret" name:"webhook-secret" env:"PM_CLI_WEBHOOK_SECRET"`
+	Reset         bool     `help:"Forget where the last run stopped and only report mail arriving from now on"`
 }
 
 type MailThreadCmd struct {
 	ID      string `arg:"" help:"Message sequence number or uid:<uid> to show thread for"`
 	Mailbox string `help:"Mailbox to search" short:"m" default:"INBOX"`
+	Peek    bool   `help:"Fetch with BODY.PEEK so messages are not marked read"`
 }
 
 // DraftCmd handles draft management
@@ -149,50 +306,110 @@ type DraftCmd struct {
 	List   DraftListCmd   `cmd:"" help:"List all drafts"`
 	Create DraftCreateCmd `cmd:"" help:"Create a new draft"`
 	Edit   DraftEditCmd   `cmd:"" help:"Edit an existing draft"`
+	Send   DraftSendCmd   `cmd:"" help:"Send a draft"`
 	Delete DraftDeleteCmd `cmd:"" help:"Delete a draft"`
 }
 
+// OutboxCmd manages messages that mail send queued because the SMTP server
+// could not be reached.
+type OutboxCmd struct {
+	List    OutboxListCmd    `cmd:"" help:"List queued messages"`
+	Flush   OutboxFlushCmd   `cmd:"" help:"Send queued messages"`
+	Discard OutboxDiscardCmd `cmd:"" help:"Drop queued messages without sending them"`
+}
+
+type OutboxListCmd struct{}
+
+type OutboxFlushCmd struct {
+	IDs []string `arg:"" optional:"" help:"Queued message ID(s) to send (default: all)"`
+}
+
+type OutboxDiscardCmd struct {
+	IDs []string `arg:"" optional:"" help:"Queued message ID(s) to discard"`
+	All bool     `help:"Discard every queued message"`
+}
+
 type DraftListCmd struct {
-	Limit int `help:"Number of drafts" short:"n" default:"20"`
+	Mailbox string `help:"Drafts mailbox, for accounts where it has another name" short:"m" default:"Drafts"`
+	Limit   int    `help:"Number of drafts" short:"n" default:"20"`
+	Offset  int    `help:"Skip the N most recent drafts" default:"0"`
+	Page    int    `help:"Page number (1-based, combines with limit)" short:"p" default:"0"`
 }
 
 type DraftCreateCmd struct {
-	To      []string `help:"Recipient(s)" short:"t"`
-	CC      []string `help:"CC recipients"`
-	Subject string   `help:"Subject line" short:"s"`
-	Body    string   `help:"Body text" short:"b"`
-	Attach  []string `help:"Attachments" short:"a" type:"existingfile"`
+	Mailbox  string   `help:"Drafts mailbox, for accounts where it has another name" short:"m" default:"Drafts"`
+	To       []string `help:"Recipient(s)" short:"t"`
+	CC       []string `help:"CC recipients"`
+	Subject  string   `help:"Subject line" short:"s"`
+	Body     string   `help:"Body text" short:"b"`
+	BodyFile string   `help:"Read the body from a file instead of stdin (--body takes precedence)" name:"body-file" type:"existingfile"`
+	Attach   []string `help:"Attachments" short:"a" type:"existingfile"`
 }
 
 type DraftEditCmd struct {
-	ID      string   `arg:"" help:"Draft ID to edit"`
+	ID      string   `arg:"" help:"Draft ID or ref:<token> to edit"`
+	Mailbox string   `help:"Drafts mailbox, for accounts where it has another name" short:"m" default:"Drafts"`
 	To      []string `help:"Recipient(s)" short:"t"`
 	CC      []string `help:"CC recipients"`
 	Subject string   `help:"Subject line" short:"s"`
 	Body    string   `help:"Body text" short:"b"`
-	Attach  []string `help:"Attachments" short:"a" type:"existingfile"`
+	Attach  []string `help:"Attachments (replace the draft's existing attachments)" short:"a" type:"existingfile"`
+}
+
+type DraftSendCmd struct {
+	ID      string `arg:"" help:"Draft ID or ref:<token> to send"`
+	Mailbox string `help:"Drafts mailbox, for accounts where it has another name" short:"m" default:"Drafts"`
+	Keep    bool   `help:"Keep the draft after sending"`
+	Force   bool   `help:"Send attachments larger than defaults.max_attachment_size"`
 }
 
 type DraftDeleteCmd struct {
-	IDs []string `arg:"" help:"Draft ID(s) to delete"`
+	IDs     []string `arg:"" help:"Draft ID(s) or ref:<token>s to delete"`
+	Mailbox string   `help:"Drafts mailbox, for accounts where it has another name" short:"m" default:"Drafts"`
 }
 
 type MailListCmd struct {
-	Mailbox string `help:"Mailbox name" short:"m" default:"INBOX"`
-	Limit   int    `help:"Number of messages" short:"n" default:"20"`
-	Offset  int    `help:"Skip first N messages" default:"0"`
-	Page    int    `help:"Page number (1-based, combines with limit)" short:"p" default:"0"`
-	Unread  bool   `help:"Only show unread messages"`
+	Mailbox      string `help:"Mailbox name" short:"m" default:"INBOX"`
+	Limit        int    `help:"Number of messages (0 = defaults.limit)" short:"n" default:"20"`
+	All          bool   `help:"List every message, fetched in batches of 500"`
+	Offset       int    `help:"Skip first N messages" default:"0"`
+	Page         int    `help:"Page number (1-based, combines with limit)" short:"p" default:"0"`
+	Unread       bool   `help:"Only show unread messages"`
+	Starred      bool   `help:"Only show starred messages" aliases:"flagged"`
+	Answered     bool   `help:"Only show messages that have been replied to"`
+	ShowSize     bool   `help:"Show message size column" name:"show-size"`
+	ResolveNames bool   `help:"Show contact names for known senders" name:"resolve-names"`
+	NoCache      bool   `help:"Bypass the on-disk listing cache" name:"no-cache"`
+	Preview      bool   `help:"Show the start of each message body (one extra partial fetch)"`
 }
 
 type MailReadCmd struct {
-	ID          string `arg:"" help:"Message sequence number or uid:<uid>"`
-	Mailbox     string `help:"Mailbox name" short:"m"`
-	Raw         bool   `help:"Show raw message"`
-	Headers     bool   `help:"Include all headers"`
-	Attachments bool   `help:"List attachments"`
-	HTML        bool   `help:"Output HTML body instead of plain text"`
-	Unread      bool   `help:"Mark as unread after reading (remove \\\\Seen)" name:"unread"`
+	IDs         []string `arg:"" help:"Message sequence number(s), uid:<uid> or ref:<token>"`
+	Mailbox     string   `help:"Mailbox name" short:"m"`
+	Raw         bool     `help:"Show raw message"`
+	Headers     bool     `help:"Also show Bcc (when present), flags, UID and sequence number; JSON adds every header field as a headers map"`
+	Attachments bool     `help:"List attachments"`
+	HTML        bool     `help:"Output HTML body instead of plain text" xor:"body-format"`
+	Markdown    bool     `help:"Convert the HTML body to Markdown" xor:"body-format"`
+	Unread      bool     `help:"Mark as unread after reading (remove \\\\Seen)" name:"unread"`
+	NoQuotes    bool     `help:"Strip quoted replies and forwarded history" name:"no-quotes"`
+	Width       int      `help:"Wrap plain-text body at N columns (default: $COLUMNS or 80)" default:"0"`
+	Peek        bool     `help:"Fetch with BODY.PEEK so the message is not marked read"`
+	Out         string   `help:"Write the output to this file instead of stdout, replacing it atomically" type:"path"`
+}
+
+type MailHeadersCmd struct {
+	IDs     []string `arg:"" help:"Message sequence number(s), uid:<uid> or ref:<token>"`
+	Mailbox string   `help:"Mailbox name" short:"m"`
+	Fields  []string `help:"Header field to fetch; repeat for more (default: a common set)" name:"field" short:"f"`
+}
+
+type MailUnsubscribeCmd struct {
+	ID      string `arg:"" help:"Message sequence number, uid:<uid> or ref:<token>"`
+	Mailbox string `help:"Mailbox name" short:"m"`
+	Via     string `help:"Method: auto (one-click POST, then mailto, then the link), post, mail or url" enum:"auto,post,mail,url" default:"auto"`
+	Open    bool   `help:"Open an unsubscribe link in the browser instead of only printing it"`
+	DryRun  bool   `help:"Show which method and target would be used without acting" name:"dry-run"`
 }
 
 type MailSendCmd struct {
@@ -201,31 +418,51 @@ type MailSendCmd struct {
 	BCC            []string          `help:"BCC recipients"`
 	Subject        string            `help:"Subject line" short:"s"`
 	Body           string            `help:"Body text (or use stdin)" short:"b"`
+	BodyFile       string            `help:"Read the body from a file instead of stdin (--body takes precedence)" name:"body-file" type:"existingfile"`
 	Attach         []string          `help:"Attachments" short:"a" type:"existingfile"`
+	AttachStdin    string            `help:"Attach stdin as a file with this name" name:"attach-stdin"`
+	AttachType     []string          `help:"Content type override as NAME=TYPE (a bare TYPE applies to --attach-stdin)" name:"attach-type"`
 	IdempotencyKey string            `help:"Unique key to prevent duplicate sends" name:"idempotency-key"`
 	Template       string            `help:"Template file path" name:"template" type:"existingfile"`
 	Vars           map[string]string `help:"Template variables (key=value)" short:"V"`
+	Signature      string            `help:"Signature for this message (overrides defaults.signature)" xor:"signature"`
+	NoSignature    bool              `help:"Do not append a signature" name:"no-signature" xor:"signature"`
+	Force          bool              `help:"Send attachments larger than defaults.max_attachment_size"`
+	DryRun         bool              `help:"Print the composed MIME message instead of sending it" name:"dry-run"`
 }
 
 type MailReplyCmd struct {
-	ID             string   `arg:"" help:"Message sequence number or uid:<uid> to reply to"`
+	ID             string   `arg:"" help:"Message sequence number, uid:<uid> or ref:<token> to reply to"`
+	Mailbox        string   `help:"Mailbox of the original message (default: defaults.mailbox)" short:"m"`
 	All            bool     `help:"Reply to all recipients" name:"all"`
 	Body           string   `help:"Reply body" short:"b"`
+	BodyFile       string   `help:"Read the reply body from a file instead of stdin (--body takes precedence)" name:"body-file" type:"existingfile"`
 	Attach         []string `help:"Attachments" short:"a" type:"existingfile"`
+	Signature      string   `help:"Signature for this message (overrides defaults.signature)" xor:"signature"`
+	NoSignature    bool     `help:"Do not append a signature" name:"no-signature" xor:"signature"`
+	Force          bool     `help:"Send attachments larger than defaults.max_attachment_size"`
+	NoMarkAnswered bool     `help:"Do not flag the original message as answered" name:"no-mark-answered"`
 	IdempotencyKey string   `help:"Unique key to prevent duplicate sends" name:"idempotency-key"`
+	DryRun         bool     `help:"Print the composed MIME message instead of sending it" name:"dry-run"`
 }
 
 type MailForwardCmd struct {
-	ID             string   `arg:"" help:"Message sequence number or uid:<uid> to forward"`
+	ID             string   `arg:"" help:"Message sequence number, uid:<uid> or ref:<token> to forward"`
+	Mailbox        string   `help:"Mailbox of the original message (default: defaults.mailbox)" short:"m"`
 	To             []string `help:"Recipient(s)" short:"t" required:""`
 	Body           string   `help:"Additional message" short:"b"`
 	Attach         []string `help:"Additional attachments" short:"a" type:"existingfile"`
+	AsAttachment   bool     `help:"Attach the original as forwarded.eml instead of quoting it" name:"as-attachment"`
+	NoAttachments  bool     `help:"Do not re-attach the original's attachments" name:"no-attachments"`
+	Force          bool     `help:"Send attachments larger than defaults.max_attachment_size"`
 	IdempotencyKey string   `help:"Unique key to prevent duplicate sends" name:"idempotency-key"`
+	DryRun         bool     `help:"Print the composed MIME message instead of sending it" name:"dry-run"`
 }
 
 type MailDeleteCmd struct {
-	IDs       []string `arg:"" optional:"" help:"Message sequence number(s) or uid:<uid> to delete"`
+	IDs       []string `arg:"" optional:"" help:"Message sequence number(s), ranges like 100:150 or 1,5,10:*, uid:<uid> or ref:<token> to delete"`
 	Query     string   `help:"Delete messages matching search query (e.g., 'from:spam@example.com')"`
+	IDsFile   string   `help:"Read more IDs from a file, one per line ('-' for stdin)" name:"ids-file"`
 	Mailbox   string   `help:"Mailbox to operate on" short:"m" default:"INBOX"`
 	Permanent bool     `help:"Skip trash, delete permanently"`
 }
@@ -233,25 +470,55 @@ type MailDeleteCmd struct {
 type MailDownloadCmd struct {
 	ID    string `arg:"" help:"Message sequence number or uid:<uid>"`
 	Index int    `arg:"" help:"Attachment index (0-based)"`
-	Out   string `help:"Output path (default: original filename)" short:"o"`
+	Out   string `help:"Output path (default: original filename)" short:"o" xor:"dest"`
+	Dir   string `help:"Directory to save the attachment in under its original filename (default: current directory)" xor:"dest" type:"existingdir"`
+	Force bool   `help:"Overwrite an existing file"`
 }
 
 type MailMoveCmd struct {
-	IDs         []string `arg:"" optional:"" help:"Message sequence number(s) or uid:<uid> to move"`
+	IDs         []string `arg:"" optional:"" help:"Message sequence number(s), ranges like 100:150 or 1,5,10:*, uid:<uid> or ref:<token> to move"`
 	Destination string   `help:"Destination mailbox" short:"d" required:""`
 	Query       string   `help:"Move messages matching search query (e.g., 'subject:newsletter')"`
+	IDsFile     string   `help:"Read more IDs from a file, one per line ('-' for stdin)" name:"ids-file"`
 	Mailbox     string   `help:"Source mailbox" short:"m" default:"INBOX"`
 }
 
+type MailTransferCmd struct {
+	IDs      []string `arg:"" help:"Message sequence number(s), ranges, uid:<uid> or ref:<token> in the source mailbox"`
+	ToConfig string   `help:"Config file of the destination account" name:"to-config" type:"existingfile" required:""`
+	Mailbox  string   `help:"Source mailbox (default: defaults.mailbox)" short:"m"`
+	Dest     string   `help:"Destination mailbox (default: the destination account's defaults.mailbox)"`
+	Move     bool     `help:"Delete the source messages once they are stored in the destination"`
+}
+
 type MailArchiveCmd struct {
-	IDs     []string `arg:"" optional:"" help:"Message sequence number(s) or uid:<uid> to archive"`
+	IDs     []string `arg:"" optional:"" help:"Message sequence number(s), ranges like 100:150, or uid:<uid> to archive"`
 	Query   string   `help:"Archive messages matching search query (e.g., 'subject:newsletter')"`
 	Mailbox string   `help:"Source mailbox" short:"m" default:"INBOX"`
 }
 
+type MailTrashCmd struct {
+	IDs     []string `arg:"" optional:"" help:"Message sequence number(s), ranges like 100:150, or uid:<uid> to move to Trash"`
+	Query   string   `help:"Trash messages matching search query (e.g., 'from:spam@example.com')"`
+	Mailbox string   `help:"Source mailbox" short:"m" default:"INBOX"`
+}
+
+type MailSpamCmd struct {
+	IDs     []string `arg:"" optional:"" help:"Message sequence number(s) or uid:<uid> to report as spam"`
+	Query   string   `help:"Report messages matching search query (e.g., 'from:spam@example.com')"`
+	Mailbox string   `help:"Source mailbox" short:"m" default:"INBOX"`
+}
+
+type MailNotSpamCmd struct {
+	IDs     []string `arg:"" optional:"" help:"Message sequence number(s) or uid:<uid> to report as not spam"`
+	Query   string   `help:"Report messages matching search query (e.g., 'from:friend@example.com')"`
+	Mailbox string   `help:"Source mailbox" short:"m" default:"Spam"`
+}
+
 type MailFlagCmd struct {
-	IDs     []string `arg:"" optional:"" help:"Message sequence number(s) or uid:<uid>"`
+	IDs     []string `arg:"" optional:"" help:"Message sequence number(s), ranges like 100:150 or 1,5,10:*, uid:<uid> or ref:<token>"`
 	Query   string   `help:"Flag messages matching search query (e.g., 'from:user@example.com')"`
+	IDsFile string   `help:"Read more IDs from a file, one per line ('-' for stdin)" name:"ids-file"`
 	Mailbox string   `help:"Mailbox to operate on" short:"m" default:"INBOX"`
 	Read    bool     `help:"Mark as read" xor:"read"`
 	Unread  bool     `help:"Mark as unread" xor:"read"`
@@ -262,18 +529,34 @@ type MailFlagCmd struct {
 type MailSearchCmd struct {
 	Query          string `arg:"" optional:"" help:"Search query (searches body text)"`
 	Mailbox        string `help:"Mailbox to search" short:"m" default:"INBOX"`
-	From           string `help:"Filter by sender"`
+	From           string `help:"Filter by sender (substring; '@example.com' matches a whole domain)"`
 	To             string `help:"Filter by recipient"`
+	Cc             string `help:"Filter by Cc recipient"`
+	Bcc            string `help:"Filter by Bcc recipient (sent mail only)"`
 	Subject        string `help:"Filter by subject"`
 	Body           string `help:"Search in message body"`
 	Since          string `help:"Messages since date (YYYY-MM-DD)"`
 	Before         string `help:"Messages before date (YYYY-MM-DD)"`
 	HasAttachments bool   `help:"Only messages with attachments" name:"has-attachments"`
-	LargerThan     string `help:"Messages larger than size (e.g., 1M, 500K)" name:"larger-than"`
-	SmallerThan    string `help:"Messages smaller than size (e.g., 10M, 1K)" name:"smaller-than"`
+	LargerThan     string `help:"Messages larger than size (e.g., 1.5M, 500K, 2048)" name:"larger-than"`
+	SmallerThan    string `help:"Messages smaller than size (e.g., 10M, 1K); with --larger-than, one size range even under --or" name:"smaller-than"`
 	And            bool   `help:"Combine filters with AND (default)" name:"and" xor:"logic" default:"true"`
 	Or             bool   `help:"Combine filters with OR" name:"or" xor:"logic"`
 	Not            bool   `help:"Negate the search query" name:"not"`
+	NotFrom        string `help:"Exclude messages from this sender" name:"not-from"`
+	NotTo          string `help:"Exclude messages to this recipient" name:"not-to"`
+	NotSubject     string `help:"Exclude messages with this subject" name:"not-subject"`
+	NotBody        string `help:"Exclude messages containing this body text" name:"not-body"`
+	Starred        bool   `help:"Only starred messages" xor:"starred"`
+	Unstarred      bool   `help:"Only messages that are not starred" xor:"starred"`
+	Unread         bool   `help:"Only unread messages" xor:"seen"`
+	Read           bool   `help:"Only read messages" xor:"seen"`
+	Answered       bool   `help:"Only messages that have been replied to" xor:"answered"`
+	Unanswered     bool   `help:"Only messages that have not been replied to" xor:"answered"`
+	RawSearch      string `help:"Raw IMAP SEARCH string (e.g. 'SINCE 1-Jan-2024 NOT KEYWORD $Phishing'); replaces the query and filters" name:"raw-search"`
+	Limit          int    `help:"Maximum number of results (0 = all)" short:"n" default:"50"`
+	Offset         int    `help:"Skip the N newest matches" default:"0"`
+	Page           int    `help:"Page number (1-based, combines with limit)" short:"p" default:"0"`
 }
 
 // MailboxCmd handles mailbox management
@@ -281,9 +564,14 @@ type MailboxCmd struct {
 	List   MailboxListCmd   `cmd:"" help:"List all mailboxes/folders"`
 	Create MailboxCreateCmd `cmd:"" help:"Create new mailbox"`
 	Delete MailboxDeleteCmd `cmd:"" help:"Delete mailbox"`
+	Quota  MailboxQuotaCmd  `cmd:"" help:"Show storage quota usage"`
 }
 
-type MailboxListCmd struct{}
+type MailboxListCmd struct {
+	Tree        bool `help:"Show mailboxes as a folder hierarchy"`
+	Counts      bool `help:"Show message and unread counts for each mailbox"`
+	Concurrency int  `help:"Parallel IMAP connections used by --counts (max 8)" default:"4"`
+}
 
 type MailboxCreateCmd struct {
 	Name string `arg:"" help:"Mailbox name to create"`
@@ -293,15 +581,34 @@ type MailboxDeleteCmd struct {
 	Name string `arg:"" help:"Mailbox name to delete"`
 }
 
+type MailboxQuotaCmd struct{}
+
+// CacheCmd manages the on-disk listing cache
+type CacheCmd struct {
+	Clear CacheClearCmd `cmd:"" help:"Remove all cached listings"`
+}
+
+type CacheClearCmd struct{}
+
+// TuiCmd opens the interactive terminal UI
+type TuiCmd struct {
+	Mailbox string `help:"Mailbox to open (default: defaults.mailbox)" short:"m"`
+	Limit   int    `help:"Number of messages to load" short:"n" default:"100"`
+}
+
 // VersionCmd shows version information
 type VersionCmd struct{}
 
 // ContactsCmd handles address book management
 type ContactsCmd struct {
-	List   ContactsListCmd   `cmd:"" help:"List all contacts"`
-	Search ContactsSearchCmd `cmd:"" help:"Search contacts"`
-	Add    ContactsAddCmd    `cmd:"" help:"Add a contact"`
-	Remove ContactsRemoveCmd `cmd:"" help:"Remove a contact"`
+	List    ContactsListCmd    `cmd:"" help:"List all contacts"`
+	Search  ContactsSearchCmd  `cmd:"" help:"Search contacts"`
+	Add     ContactsAddCmd     `cmd:"" help:"Add a contact"`
+	Remove  ContactsRemoveCmd  `cmd:"" help:"Remove a contact"`
+	Update  ContactsUpdateCmd  `cmd:"" help:"Update a contact's name"`
+	Import  ContactsImportCmd  `cmd:"" help:"Import contacts from a vCard file"`
+	Export  ContactsExportCmd  `cmd:"" help:"Export contacts as vCard"`
+	Harvest ContactsHarvestCmd `cmd:"" help:"Add recipients of sent mail to contacts"`
 }
 
 type ContactsListCmd struct{}
@@ -318,3 +625,21 @@ type ContactsAddCmd struct {
 type ContactsRemoveCmd struct {
 	Email string `arg:"" help:"Contact email address to remove"`
 }
+
+type ContactsUpdateCmd struct {
+	Email string `arg:"" help:"Contact email address to update"`
+	Name  string `help:"New display name" short:"n" name:"name" required:""`
+}
+
+type ContactsImportCmd struct {
+	File string `arg:"" help:"vCard (.vcf) file to import" type:"existingfile"`
+}
+
+type ContactsExportCmd struct {
+	Output string `help:"Output file (default: stdout)" short:"o"`
+}
+
+type ContactsHarvestCmd struct {
+	Mailbox string `help:"Mailbox to scan" short:"m" default:"Sent"`
+	Limit   int    `help:"Number of recent messages to scan (0 for all)" short:"n" default:"200"`
+}
@@ -1,9 +1,13 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
 	"testing"
 
 	"github.com/bscott/pm-cli/internal/config"
+	"github.com/bscott/pm-cli/internal/output"
 )
 
 func TestVersion(t *testing.T) {
@@ -14,14 +18,14 @@ func TestVersion(t *testing.T) {
 
 func TestGlobalsStruct(t *testing.T) {
 	globals := Globals{
-		JSON:     true,
+		JSON:     boolPtr(true),
 		HelpJSON: true,
 		Config:   "/path/to/config.yaml",
 		Verbose:  true,
 		Quiet:    false,
 	}
 
-	if !globals.JSON {
+	if globals.JSON == nil || !*globals.JSON {
 		t.Error("JSON should be true")
 	}
 	if !globals.HelpJSON {
@@ -40,7 +44,7 @@ func TestGlobalsStruct(t *testing.T) {
 
 func TestNewContext(t *testing.T) {
 	globals := &Globals{
-		JSON:    true,
+		JSON:    boolPtr(true),
 		Verbose: true,
 		Quiet:   false,
 	}
@@ -83,6 +87,51 @@ func TestNewContextWithConfigPath(t *testing.T) {
 	}
 }
 
+func boolPtr(b bool) *bool { return &b }
+
+func TestNewContextDefaultFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := config.DefaultConfig()
+	cfg.Defaults.Format = "json"
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	ctx, err := NewContext(&Globals{Config: path})
+	if err != nil {
+		t.Fatalf("NewContext() error = %v", err)
+	}
+	var buf bytes.Buffer
+	ctx.Formatter.Writer = &buf
+	if err := (&VersionCmd{}).Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil || out["version"] != Version {
+		t.Errorf("version output with defaults.format=json = %q, want JSON", buf.String())
+	}
+
+	ctx, err = NewContext(&Globals{Config: path, JSON: boolPtr(false)})
+	if err != nil {
+		t.Fatalf("NewContext(--no-json) error = %v", err)
+	}
+	if ctx.Formatter.JSON {
+		t.Error("expected --no-json to override defaults.format=json")
+	}
+
+	cfg.Defaults.Format = "text"
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	ctx, err = NewContext(&Globals{Config: path, JSON: boolPtr(true)})
+	if err != nil {
+		t.Fatalf("NewContext(--json) error = %v", err)
+	}
+	if !ctx.Formatter.JSON {
+		t.Error("expected --json to override defaults.format=text")
+	}
+}
+
 func TestContextStruct(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Bridge.Email = "test@example.com"
@@ -116,7 +165,7 @@ func TestMailListCmdDefaults(t *testing.T) {
 
 func TestMailReadCmdOptions(t *testing.T) {
 	cmd := MailReadCmd{
-		ID:          "123",
+		IDs:         []string{"123"},
 		Mailbox:     "Archive",
 		Raw:         true,
 		Headers:     true,
@@ -124,8 +173,8 @@ func TestMailReadCmdOptions(t *testing.T) {
 		Unread:      true,
 	}
 
-	if cmd.ID != "123" {
-		t.Errorf("ID = %q, want %q", cmd.ID, "123")
+	if len(cmd.IDs) != 1 || cmd.IDs[0] != "123" {
+		t.Errorf("IDs = %v, want [123]", cmd.IDs)
 	}
 	if cmd.Mailbox != "Archive" {
 		t.Errorf("Mailbox = %q, want %q", cmd.Mailbox, "Archive")
@@ -337,3 +386,28 @@ func TestConfigSetCmdOptions(t *testing.T) {
 		t.Errorf("Value = %q, want %q", cmd.Value, "user@protonmail.com")
 	}
 }
+
+func TestVersionJSONBuildMetadata(t *testing.T) {
+	defer func(commit, date string) { Commit, BuildDate = commit, date }(Commit, BuildDate)
+	Commit, BuildDate = "abc1234", "2024-06-01T12:00:00Z"
+
+	var buf bytes.Buffer
+	ctx := &Context{Formatter: output.New(true, false, false, true)}
+	ctx.Formatter.Writer = &buf
+	if err := (&VersionCmd{}).Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("version output is not JSON: %v\n%s", err, buf.String())
+	}
+	if out["commit"] != "abc1234" || out["build_date"] != "2024-06-01T12:00:00Z" {
+		t.Errorf("version JSON = %v, want the ldflags commit and build date", out)
+	}
+
+	Commit, BuildDate = "", ""
+	if commit, date := buildMetadata(); commit == "" || date == "" {
+		t.Errorf("buildMetadata() = %q, %q; want a fallback when unset", commit, date)
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/tls"
 	"fmt"
 	"net"
@@ -27,6 +28,9 @@ func (c *ConfigInitCmd) Run(ctx *Context) error {
 
 	reader := bufio.NewReader(os.Stdin)
 	cfg := config.DefaultConfig()
+	if c.CredentialStore == config.CredentialStoreFile {
+		cfg.Bridge.CredentialStore = config.CredentialStoreFile
+	}
 
 	// Email
 	fmt.Printf("ProtonMail email address: ")
@@ -93,6 +97,20 @@ func (c *ConfigInitCmd) Run(ctx *Context) error {
 		return fmt.Errorf("bridge password is required")
 	}
 
+	// Passphrase for the encrypted credentials file
+	if cfg.UsesFileCredentials() && os.Getenv(config.SecretEnvVar) == "" {
+		fmt.Printf("Passphrase to encrypt the password (export %s to reuse it): ", config.SecretEnvVar)
+		secretBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if len(secretBytes) == 0 {
+			return fmt.Errorf("passphrase is required for the file credential store")
+		}
+		os.Setenv(config.SecretEnvVar, string(secretBytes))
+	}
+
 	// Save config
 	configPath, err := config.ConfigPath()
 	if err != nil {
@@ -103,14 +121,19 @@ func (c *ConfigInitCmd) Run(ctx *Context) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	// Store password in keyring
+	// Store password in the configured credential store
 	if err := cfg.SetPassword(password); err != nil {
-		return fmt.Errorf("failed to store password in keyring: %w", err)
+		return fmt.Errorf("failed to store password in %s: %w", cfg.CredentialStoreName(), err)
 	}
 
 	fmt.Println()
 	fmt.Printf("Configuration saved to %s\n", configPath)
-	fmt.Println("Password stored securely in system keyring.")
+	if cfg.UsesFileCredentials() {
+		fmt.Println("Password stored in encrypted credentials file.")
+		fmt.Printf("Set %s to the same passphrase when running pm-cli.\n", config.SecretEnvVar)
+	} else {
+		fmt.Println("Password stored securely in system keyring.")
+	}
 	fmt.Println()
 	fmt.Println("Test your connection with: pm-cli mailbox list")
 
@@ -125,16 +148,30 @@ func (c *ConfigShowCmd) Run(ctx *Context) error {
 	if ctx.Formatter.JSON {
 		return ctx.Formatter.PrintJSON(map[string]interface{}{
 			"bridge": map[string]interface{}{
-				"imap_host": ctx.Config.Bridge.IMAPHost,
-				"imap_port": ctx.Config.Bridge.IMAPPort,
-				"smtp_host": ctx.Config.Bridge.SMTPHost,
-				"smtp_port": ctx.Config.Bridge.SMTPPort,
-				"email":     ctx.Config.Bridge.Email,
+				"imap_host":        ctx.Config.Bridge.IMAPHost,
+				"imap_port":        ctx.Config.Bridge.IMAPPort,
+				"smtp_host":        ctx.Config.Bridge.SMTPHost,
+				"smtp_port":        ctx.Config.Bridge.SMTPPort,
+				"email":            ctx.Config.Bridge.Email,
+				"credential_store": ctx.Config.CredentialStoreName(),
+				"tls_verify":       ctx.Config.TLSVerify(ctx.Config.Bridge.IMAPHost),
+				"ca_cert":          ctx.Config.Bridge.CACert,
+				"imap_security":    ctx.Config.IMAPSecurity(),
+				"smtp_security":    ctx.Config.SMTPSecurity(),
 			},
 			"defaults": map[string]interface{}{
-				"mailbox": ctx.Config.Defaults.Mailbox,
-				"limit":   ctx.Config.Defaults.Limit,
-				"format":  ctx.Config.Defaults.Format,
+				"mailbox":             ctx.Config.Defaults.Mailbox,
+				"limit":               ctx.Config.Defaults.Limit,
+				"format":              ctx.Config.Defaults.Format,
+				"date_style":          ctx.dateStyle(),
+				"timezone":            ctx.Config.Defaults.Timezone,
+				"signature":           ctx.Config.Defaults.Signature,
+				"max_attachment_size": ctx.Config.Defaults.MaxAttachmentSize,
+				"archive_mailbox":     ctx.Config.ArchiveMailbox(),
+				"trash_mailbox":       ctx.Config.TrashMailbox(),
+				"retries":             ctx.Config.RetryPolicy().Retries,
+				"retry_delay":         ctx.Config.RetryPolicy().Delay.String(),
+				"timeout":             ctx.Config.Timeout().String(),
 			},
 		})
 	}
@@ -148,12 +185,47 @@ func (c *ConfigShowCmd) Run(ctx *Context) error {
 	fmt.Printf("  SMTP Host: %s\n", ctx.Config.Bridge.SMTPHost)
 	fmt.Printf("  SMTP Port: %d\n", ctx.Config.Bridge.SMTPPort)
 	fmt.Printf("  Email:     %s\n", ctx.Config.Bridge.Email)
+	fmt.Printf("  Store:     %s\n", ctx.Config.CredentialStoreName())
+	if ctx.Config.TLSVerify(ctx.Config.Bridge.IMAPHost) {
+		fmt.Println("  TLS:       verified")
+	} else {
+		fmt.Println("  TLS:       not verified (loopback only)")
+	}
+	if ctx.Config.Bridge.CACert != "" {
+		fmt.Printf("  CA cert:   %s\n", ctx.Config.Bridge.CACert)
+	}
+	if ctx.Config.IMAPSecurity() != config.SecuritySTARTTLS || ctx.Config.SMTPSecurity() != config.SecuritySTARTTLS {
+		fmt.Printf("  Security:  IMAP %s, SMTP %s\n", ctx.Config.IMAPSecurity(), ctx.Config.SMTPSecurity())
+	}
 
 	fmt.Println()
 	fmt.Println("Defaults:")
 	fmt.Printf("  Mailbox: %s\n", ctx.Config.Defaults.Mailbox)
 	fmt.Printf("  Limit:   %d\n", ctx.Config.Defaults.Limit)
 	fmt.Printf("  Format:  %s\n", ctx.Config.Defaults.Format)
+	fmt.Printf("  Dates:   %s\n", ctx.dateStyle())
+	if ctx.Config.Defaults.Timezone != "" {
+		fmt.Printf("  Timezone: %s\n", ctx.Config.Defaults.Timezone)
+	} else {
+		fmt.Println("  Timezone: local")
+	}
+	if ctx.Config.Defaults.Signature != "" {
+		fmt.Println("  Signature:")
+		for _, line := range strings.Split(ctx.Config.Defaults.Signature, "\n") {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+	fmt.Printf("  Archive: %s\n", ctx.Config.ArchiveMailbox())
+	fmt.Printf("  Trash:   %s\n", ctx.Config.TrashMailbox())
+	fmt.Printf("  Retries: %d (first after %s)\n", ctx.Config.RetryPolicy().Retries, ctx.Config.RetryPolicy().Delay)
+	if timeout := ctx.Config.Timeout(); timeout > 0 {
+		fmt.Printf("  Timeout: %s\n", timeout)
+	} else {
+		fmt.Println("  Timeout: none")
+	}
+	if ctx.Config.Defaults.MaxAttachmentSize != "" {
+		fmt.Printf("  Max attachment size: %s\n", ctx.Config.Defaults.MaxAttachmentSize)
+	}
 
 	// Check if password is set
 	_, err := ctx.Config.GetPassword()
@@ -161,7 +233,7 @@ func (c *ConfigShowCmd) Run(ctx *Context) error {
 	if err != nil {
 		fmt.Println("Password: not set (run 'pm-cli config init' to set)")
 	} else {
-		fmt.Println("Password: ********** (stored in keyring)")
+		fmt.Printf("Password: ********** (stored in %s)\n", ctx.Config.CredentialStoreName())
 	}
 
 	return nil
@@ -200,6 +272,33 @@ func (c *ConfigSetCmd) Run(ctx *Context) error {
 			ctx.Config.Bridge.SMTPPort = port
 		case "email":
 			ctx.Config.Bridge.Email = c.Value
+		case "credential_store":
+			if c.Value != config.CredentialStoreKeyring && c.Value != config.CredentialStoreFile {
+				return fmt.Errorf("credential_store must be 'keyring' or 'file'")
+			}
+			ctx.Config.Bridge.CredentialStore = c.Value
+		case "tls_verify":
+			verify, err := strconv.ParseBool(c.Value)
+			if err != nil {
+				return fmt.Errorf("tls_verify must be 'true' or 'false'")
+			}
+			ctx.Config.Bridge.TLSVerify = &verify
+		case "ca_cert":
+			if c.Value != "" {
+				if err := config.CheckCACert(c.Value); err != nil {
+					return err
+				}
+			}
+			ctx.Config.Bridge.CACert = c.Value
+		case "imap_security", "smtp_security":
+			if !config.ValidSecurity(c.Value) {
+				return fmt.Errorf("%s must be 'starttls', 'tls' or 'none'", key)
+			}
+			if key == "imap_security" {
+				ctx.Config.Bridge.IMAPSecurity = c.Value
+			} else {
+				ctx.Config.Bridge.SMTPSecurity = c.Value
+			}
 		default:
 			return fmt.Errorf("unknown bridge key: %s", key)
 		}
@@ -218,6 +317,48 @@ func (c *ConfigSetCmd) Run(ctx *Context) error {
 				return fmt.Errorf("format must be 'text' or 'json'")
 			}
 			ctx.Config.Defaults.Format = c.Value
+		case "date_style":
+			if !validDateStyle(c.Value) {
+				return fmt.Errorf("date_style must be 'absolute' or 'relative'")
+			}
+			ctx.Config.Defaults.DateStyle = c.Value
+		case "timezone":
+			if c.Value != "" {
+				if _, err := time.LoadLocation(c.Value); err != nil {
+					return fmt.Errorf("invalid timezone %q - use an IANA name such as America/New_York", c.Value)
+				}
+			}
+			ctx.Config.Defaults.Timezone = c.Value
+		case "archive_mailbox":
+			ctx.Config.Defaults.ArchiveMailbox = c.Value
+		case "trash_mailbox":
+			ctx.Config.Defaults.TrashMailbox = c.Value
+		case "max_attachment_size":
+			if c.Value != "" && c.Value != "0" && parseSize(c.Value) <= 0 {
+				return fmt.Errorf("invalid max_attachment_size %q - use a size such as 25M, or 0 to disable", c.Value)
+			}
+			ctx.Config.Defaults.MaxAttachmentSize = c.Value
+		case "retries":
+			retries, err := strconv.Atoi(c.Value)
+			if err != nil || retries < 0 {
+				return fmt.Errorf("invalid retries value %q - use a whole number, or 0 to disable", c.Value)
+			}
+			ctx.Config.Defaults.Retries = &retries
+		case "retry_delay":
+			if d, err := time.ParseDuration(c.Value); err != nil || d < 0 {
+				return fmt.Errorf("invalid retry_delay %q - use a duration such as 500ms or 2s", c.Value)
+			}
+			ctx.Config.Defaults.RetryDelay = c.Value
+		case "timeout":
+			if c.Value != "" {
+				if d, err := time.ParseDuration(c.Value); err != nil || d < 0 {
+					return fmt.Errorf("invalid timeout %q - use a duration such as 30s, or 0 for no limit", c.Value)
+				}
+			}
+			ctx.Config.Defaults.Timeout = c.Value
+		case "signature":
+			// Allow "\n" so multi-line signatures can be set from the shell
+			ctx.Config.Defaults.Signature = strings.ReplaceAll(c.Value, `\n`, "\n")
 		default:
 			return fmt.Errorf("unknown defaults key: %s", key)
 		}
@@ -347,24 +488,46 @@ func (c *ConfigDoctorCmd) Run(ctx *Context) error {
 		printResult("ok", fmt.Sprintf("Email configured: %s", cfg.Bridge.Email), "")
 	}
 
-	// Check 4: Password exists in keyring
+	// Check 4: Password exists in the credential store
+	passwordCheck := "Password in keyring"
+	if cfg.UsesFileCredentials() {
+		passwordCheck = "Password in credentials file"
+	}
 	if cfg.Bridge.Email != "" {
 		_, err := cfg.GetPassword()
 		if err != nil {
-			addResult("Password in keyring", "fail", "password not found in keyring")
-			printResult("fail", "Password in keyring", "password not found in keyring")
+			msg := "password not found in keyring"
+			if cfg.UsesFileCredentials() {
+				msg = err.Error()
+			}
+			addResult(passwordCheck, "fail", msg)
+			printResult("fail", passwordCheck, msg)
 		} else {
-			addResult("Password in keyring", "ok", "")
-			printResult("ok", "Password in keyring", "")
+			addResult(passwordCheck, "ok", "")
+			printResult("ok", passwordCheck, "")
 		}
 	} else {
-		addResult("Password in keyring", "fail", "cannot check - email not configured")
-		printResult("fail", "Password in keyring", "cannot check - email not configured")
+		addResult(passwordCheck, "fail", "cannot check - email not configured")
+		printResult("fail", passwordCheck, "cannot check - email not configured")
+	}
+
+	// Check 5: Keyring is available and writable
+	backend := config.KeyringBackend()
+	if cfg.UsesFileCredentials() {
+		addResult("Keyring writable", "ok", "not used - file credential store selected")
+		printResult("ok", "Keyring writable", "not used - file credential store selected")
+	} else if err := config.ProbeKeyring(); err != nil {
+		msg := fmt.Sprintf("%s: %v - %s", backend, err, config.KeyringHint())
+		addResult("Keyring writable", "fail", msg)
+		printResult("fail", "Keyring writable", msg)
+	} else {
+		addResult("Keyring writable", "ok", backend)
+		printResult("ok", fmt.Sprintf("Keyring writable (%s)", backend), "")
 	}
 
-	// Check 5: IMAP port is reachable
+	// Check 6: IMAP port is reachable
 	imapAddr := net.JoinHostPort(cfg.Bridge.IMAPHost, strconv.Itoa(cfg.Bridge.IMAPPort))
-	conn, err := net.DialTimeout("tcp", imapAddr, 5*time.Second)
+	conn, err := net.DialTimeout("tcp", imapAddr, cfg.ConnectTimeout())
 	if err != nil {
 		addResult("IMAP port reachable", "fail", fmt.Sprintf("cannot connect to %s - is Proton Bridge running?", imapAddr))
 		printResult("fail", fmt.Sprintf("IMAP port reachable (%s)", imapAddr), "is Proton Bridge running?")
@@ -374,10 +537,10 @@ func (c *ConfigDoctorCmd) Run(ctx *Context) error {
 		printResult("ok", fmt.Sprintf("IMAP port reachable (%s)", imapAddr), "")
 	}
 
-	// Check 6: SMTP port is reachable
+	// Check 7: SMTP port is reachable
 	smtpAddr := net.JoinHostPort(cfg.Bridge.SMTPHost, strconv.Itoa(cfg.Bridge.SMTPPort))
 	smtpReachable := false
-	conn, err = net.DialTimeout("tcp", smtpAddr, pmsmtp.ConnectTimeout)
+	conn, err = net.DialTimeout("tcp", smtpAddr, cfg.ConnectTimeout())
 	if err != nil {
 		addResult("SMTP port reachable", "fail", fmt.Sprintf("cannot connect to %s - is Proton Bridge running?", smtpAddr))
 		printResult("fail", fmt.Sprintf("SMTP port reachable (%s)", smtpAddr), "is Proton Bridge running?")
@@ -388,7 +551,10 @@ func (c *ConfigDoctorCmd) Run(ctx *Context) error {
 		printResult("ok", fmt.Sprintf("SMTP port reachable (%s)", smtpAddr), "")
 	}
 
-	// Check 7: IMAP login succeeds
+	// Check 8: IMAP login succeeds
+	var mailboxes []imap.MailboxInfo
+	var listErr error
+	imapLoggedIn := false
 	if cfg.Bridge.Email != "" {
 		imapClient, err := imap.NewClient(cfg)
 		if err != nil {
@@ -400,7 +566,9 @@ func (c *ConfigDoctorCmd) Run(ctx *Context) error {
 				addResult("IMAP login succeeds", "fail", err.Error())
 				printResult("fail", "IMAP login succeeds", err.Error())
 			} else {
+				mailboxes, listErr = imapClient.ListMailboxes()
 				imapClient.Close()
+				imapLoggedIn = true
 				addResult("IMAP login succeeds", "ok", "")
 				printResult("ok", "IMAP login succeeds", "")
 			}
@@ -410,7 +578,52 @@ func (c *ConfigDoctorCmd) Run(ctx *Context) error {
 		printResult("fail", "IMAP login succeeds", "cannot test - email not configured")
 	}
 
-	// Check 8: SMTP connection succeeds
+	// Check 9: Default mailbox exists
+	// Check 10: Labels folder exists
+	labelsRoot := strings.TrimSuffix(labelPrefix, "/")
+	switch {
+	case !imapLoggedIn:
+		addResult("Default mailbox exists", "fail", "cannot test - IMAP login failed")
+		printResult("fail", "Default mailbox exists", "cannot test - IMAP login failed")
+		addResult("Labels folder exists", "fail", "cannot test - IMAP login failed")
+		printResult("fail", "Labels folder exists", "cannot test - IMAP login failed")
+	case listErr != nil:
+		addResult("Default mailbox exists", "fail", listErr.Error())
+		printResult("fail", "Default mailbox exists", listErr.Error())
+		addResult("Labels folder exists", "fail", listErr.Error())
+		printResult("fail", "Labels folder exists", listErr.Error())
+	default:
+		names := make([]string, len(mailboxes))
+		for i, mb := range mailboxes {
+			names[i] = mb.Name
+		}
+
+		if found, suggestion := findMailbox(cfg.Defaults.Mailbox, names); found {
+			addResult("Default mailbox exists", "ok", cfg.Defaults.Mailbox)
+			printResult("ok", fmt.Sprintf("Default mailbox exists: %s", cfg.Defaults.Mailbox), "")
+		} else {
+			msg := fmt.Sprintf("mailbox %q not found", cfg.Defaults.Mailbox)
+			if suggestion != "" {
+				msg += fmt.Sprintf(" - did you mean %q?", suggestion)
+			}
+			addResult("Default mailbox exists", "fail", msg)
+			printResult("fail", "Default mailbox exists", msg)
+		}
+
+		if found, suggestion := findMailbox(labelsRoot, names); found {
+			addResult("Labels folder exists", "ok", labelsRoot)
+			printResult("ok", "Labels folder exists", "")
+		} else {
+			msg := fmt.Sprintf("%q not found - label commands will not work", labelsRoot)
+			if suggestion != "" {
+				msg += fmt.Sprintf(" (closest: %q)", suggestion)
+			}
+			addResult("Labels folder exists", "fail", msg)
+			printResult("fail", "Labels folder exists", msg)
+		}
+	}
+
+	// Check 11: SMTP connection succeeds
 	if cfg.Bridge.Email != "" {
 		if !smtpReachable {
 			addResult("SMTP connection succeeds", "fail", "cannot test - SMTP port not reachable")
@@ -418,30 +631,27 @@ func (c *ConfigDoctorCmd) Run(ctx *Context) error {
 		} else {
 			password, err := cfg.GetPassword()
 			if err == nil {
-				client, err := pmsmtp.DialClient(smtpAddr, cfg.Bridge.SMTPHost)
+				var client *netsmtp.Client
+				err := config.CheckSecurity(cfg.SMTPSecurity(), cfg.Bridge.SMTPHost)
+				if err == nil {
+					var tlsConfig *tls.Config
+					if tlsConfig, err = cfg.TLSConfig(cfg.Bridge.SMTPHost); err == nil {
+						client, err = pmsmtp.Dial(smtpAddr, cfg.Bridge.SMTPHost, cfg.SMTPSecurity(), tlsConfig, cfg.ConnectTimeout(), cfg.Deadline())
+					}
+				}
 				if err != nil {
 					addResult("SMTP connection succeeds", "fail", err.Error())
 					printResult("fail", "SMTP connection succeeds", err.Error())
 				} else {
-					tlsConfig := &tls.Config{
-						InsecureSkipVerify: true,
-						ServerName:         cfg.Bridge.SMTPHost,
-					}
-					if err := client.StartTLS(tlsConfig); err != nil {
-						client.Close()
-						addResult("SMTP connection succeeds", "fail", fmt.Sprintf("STARTTLS failed: %s", err.Error()))
-						printResult("fail", "SMTP connection succeeds", fmt.Sprintf("STARTTLS failed: %s", err.Error()))
+					auth := pmsmtp.PlainAuth("", cfg.Bridge.Email, password, cfg.Bridge.SMTPHost)
+					if err := client.Auth(auth); err != nil {
+						addResult("SMTP connection succeeds", "fail", err.Error())
+						printResult("fail", "SMTP connection succeeds", err.Error())
 					} else {
-						auth := netsmtp.PlainAuth("", cfg.Bridge.Email, password, cfg.Bridge.SMTPHost)
-						if err := client.Auth(auth); err != nil {
-							addResult("SMTP connection succeeds", "fail", err.Error())
-							printResult("fail", "SMTP connection succeeds", err.Error())
-						} else {
-							addResult("SMTP connection succeeds", "ok", "")
-							printResult("ok", "SMTP connection succeeds", "")
-						}
-						client.Close()
+						addResult("SMTP connection succeeds", "ok", "")
+						printResult("ok", "SMTP connection succeeds", "")
 					}
+					client.Close()
 				}
 			} else {
 				addResult("SMTP connection succeeds", "fail", "cannot test - password not available")
@@ -469,3 +679,117 @@ func (c *ConfigDoctorCmd) Run(ctx *Context) error {
 
 	return nil
 }
+
+// findMailbox reports whether name is in mailboxes. INBOX is matched
+// case-insensitively as required by RFC 3501. When not found, the closest
+// mailbox name by edit distance is returned as a suggestion.
+func findMailbox(name string, mailboxes []string) (found bool, suggestion string) {
+	best := -1
+	for _, mb := range mailboxes {
+		if mb == name || (strings.EqualFold(name, "INBOX") && strings.EqualFold(mb, "INBOX")) {
+			return true, ""
+		}
+		d := levenshtein(strings.ToLower(name), strings.ToLower(mb))
+		if best == -1 || d < best {
+			best = d
+			suggestion = mb
+		}
+	}
+	return false, suggestion
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func (c *ConfigBackupCmd) Run(ctx *Context) error {
+	var buf bytes.Buffer
+	included, err := config.WriteBackup(&buf, ctx.Globals.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(c.Output, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", c.Output, err)
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success": true,
+			"file":    c.Output,
+			"files":   included,
+		})
+	}
+
+	fmt.Printf("Backed up %s to %s\n", strings.Join(included, ", "), c.Output)
+	fmt.Println("The Bridge password is not included in the backup.")
+	return nil
+}
+
+func (c *ConfigRestoreCmd) Run(ctx *Context) error {
+	f, err := os.Open(c.File)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer f.Close()
+
+	files, err := config.ReadBackup(f)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	for _, name := range config.BackupFiles {
+		if _, ok := files[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	if !c.Yes {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return fmt.Errorf("restore overwrites existing files - pass --yes to confirm")
+		}
+		dir, _ := config.ConfigDir()
+		fmt.Printf("Restore %s to %s, overwriting existing files? [y/N]: ", strings.Join(names, ", "), dir)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Println("Restore cancelled.")
+			return nil
+		}
+	}
+
+	restored, err := config.RestoreBackup(files, ctx.Globals.Config)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success": true,
+			"files":   restored,
+		})
+	}
+
+	fmt.Printf("Restored %s\n", strings.Join(restored, ", "))
+	fmt.Println("Re-enter your Bridge password with 'pm-cli config init' - it is never included in backups.")
+	return nil
+}
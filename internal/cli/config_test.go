@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/bscott/pm-cli/internal/config"
 	"github.com/bscott/pm-cli/internal/output"
@@ -41,7 +42,7 @@ func TestConfigShowCmdRunJSON(t *testing.T) {
 	ctx := &Context{
 		Config:    cfg,
 		Formatter: formatter,
-		Globals:   &Globals{JSON: true},
+		Globals:   &Globals{JSON: boolPtr(true)},
 	}
 
 	err := cmd.Run(ctx)
@@ -153,6 +154,38 @@ func TestConfigSetCmdRunBridgeKeys(t *testing.T) {
 				return c.Bridge.Email == "new@example.com"
 			},
 		},
+		{
+			name:  "set credential_store",
+			key:   "bridge.credential_store",
+			value: "file",
+			checker: func(c *config.Config) bool {
+				return c.Bridge.CredentialStore == "file"
+			},
+		},
+		{
+			name:  "set tls_verify",
+			key:   "bridge.tls_verify",
+			value: "true",
+			checker: func(c *config.Config) bool {
+				return c.TLSVerify("127.0.0.1")
+			},
+		},
+		{
+			name:  "set imap_security",
+			key:   "bridge.imap_security",
+			value: "tls",
+			checker: func(c *config.Config) bool {
+				return c.IMAPSecurity() == config.SecurityTLS && c.SMTPSecurity() == config.SecuritySTARTTLS
+			},
+		},
+		{
+			name:  "set smtp_security",
+			key:   "bridge.smtp_security",
+			value: "none",
+			checker: func(c *config.Config) bool {
+				return c.SMTPSecurity() == config.SecurityNone
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -233,6 +266,78 @@ func TestConfigSetCmdRunDefaultsKeys(t *testing.T) {
 				return c.Defaults.Format == "json"
 			},
 		},
+		{
+			name:  "set timezone",
+			key:   "defaults.timezone",
+			value: "America/New_York",
+			checker: func(c *config.Config) bool {
+				return c.Defaults.Timezone == "America/New_York"
+			},
+		},
+		{
+			name:  "set date_style relative",
+			key:   "defaults.date_style",
+			value: "relative",
+			checker: func(c *config.Config) bool {
+				return c.Defaults.DateStyle == "relative"
+			},
+		},
+		{
+			name:  "set multi-line signature",
+			key:   "defaults.signature",
+			value: `Jane Doe\nAcme Corp`,
+			checker: func(c *config.Config) bool {
+				return c.Defaults.Signature == "Jane Doe\nAcme Corp"
+			},
+		},
+		{
+			name:  "set max_attachment_size",
+			key:   "defaults.max_attachment_size",
+			value: "10M",
+			checker: func(c *config.Config) bool {
+				return c.Defaults.MaxAttachmentSize == "10M"
+			},
+		},
+		{
+			name:  "set trash_mailbox",
+			key:   "defaults.trash_mailbox",
+			value: "Folders/Bin",
+			checker: func(c *config.Config) bool {
+				return c.TrashMailbox() == "Folders/Bin"
+			},
+		},
+		{
+			name:  "set retries",
+			key:   "defaults.retries",
+			value: "0",
+			checker: func(c *config.Config) bool {
+				return c.Defaults.Retries != nil && c.RetryPolicy().Retries == 0
+			},
+		},
+		{
+			name:  "set retry_delay",
+			key:   "defaults.retry_delay",
+			value: "250ms",
+			checker: func(c *config.Config) bool {
+				return c.RetryPolicy().Delay == 250*time.Millisecond
+			},
+		},
+		{
+			name:  "set timeout",
+			key:   "defaults.timeout",
+			value: "45s",
+			checker: func(c *config.Config) bool {
+				return c.Timeout() == 45*time.Second
+			},
+		},
+		{
+			name:  "disable max_attachment_size",
+			key:   "defaults.max_attachment_size",
+			value: "0",
+			checker: func(c *config.Config) bool {
+				return c.Defaults.MaxAttachmentSize == "0"
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -320,6 +425,91 @@ func TestConfigSetCmdRunInvalidFormatValue(t *testing.T) {
 	}
 }
 
+func TestConfigSetCmdRunInvalidMaxAttachmentSize(t *testing.T) {
+	cmd := &ConfigSetCmd{
+		Key:   "defaults.max_attachment_size",
+		Value: "huge",
+	}
+
+	ctx := &Context{
+		Config:    config.DefaultConfig(),
+		Formatter: output.New(false, false, false, false),
+		Globals:   &Globals{},
+	}
+
+	err := cmd.Run(ctx)
+	if err == nil {
+		t.Error("expected error for invalid max_attachment_size")
+	}
+}
+
+func TestConfigSetCmdRunInvalidValues(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"defaults.retries", "-1"},
+		{"defaults.retries", "many"},
+		{"defaults.retry_delay", "soon"},
+		{"defaults.retry_delay", "-1s"},
+		{"defaults.timeout", "forever"},
+		{"bridge.tls_verify", "maybe"},
+		{"bridge.imap_security", "ssl"},
+		{"bridge.smtp_security", "STARTTLS"},
+		{"bridge.ca_cert", "/nonexistent/bridge.pem"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			cmd := &ConfigSetCmd{Key: tt.key, Value: tt.value}
+			ctx := &Context{
+				Config:    config.DefaultConfig(),
+				Formatter: output.New(false, false, false, false),
+				Globals:   &Globals{},
+			}
+			if err := cmd.Run(ctx); err == nil {
+				t.Errorf("expected error for %s %q", tt.key, tt.value)
+			}
+		})
+	}
+}
+
+func TestConfigSetCmdRunInvalidTimezone(t *testing.T) {
+	cmd := &ConfigSetCmd{
+		Key:   "defaults.timezone",
+		Value: "Mars/Olympus_Mons",
+	}
+
+	ctx := &Context{
+		Config:    config.DefaultConfig(),
+		Formatter: output.New(false, false, false, false),
+		Globals:   &Globals{},
+	}
+
+	err := cmd.Run(ctx)
+	if err == nil {
+		t.Error("expected error for invalid timezone")
+	}
+}
+
+func TestConfigSetCmdRunInvalidCredentialStore(t *testing.T) {
+	cmd := &ConfigSetCmd{
+		Key:   "bridge.credential_store",
+		Value: "vault", // Invalid, must be 'keyring' or 'file'
+	}
+
+	ctx := &Context{
+		Config:    config.DefaultConfig(),
+		Formatter: output.New(false, false, false, false),
+		Globals:   &Globals{},
+	}
+
+	err := cmd.Run(ctx)
+	if err == nil {
+		t.Error("expected error for invalid credential store")
+	}
+}
+
 func TestConfigSetCmdCreatesDefaultConfig(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "pm-cli-test-*")
 	if err != nil {
@@ -394,7 +584,7 @@ func TestConfigDoctorSkipsSMTPAuthWhenSMTPPortUnreachable(t *testing.T) {
 	ctx := &Context{
 		Config:    cfg,
 		Formatter: formatter,
-		Globals:   &Globals{JSON: true},
+		Globals:   &Globals{JSON: boolPtr(true)},
 	}
 
 	if err := cmd.Run(ctx); err != nil {
@@ -430,3 +620,91 @@ func TestConfigDoctorSkipsSMTPAuthWhenSMTPPortUnreachable(t *testing.T) {
 		t.Fatal("expected SMTP connection succeeds check in doctor output")
 	}
 }
+
+func TestFindMailbox(t *testing.T) {
+	mailboxes := []string{"INBOX", "Sent", "Archive", "Labels", "Labels/Work", "Folders/Receipts"}
+
+	tests := []struct {
+		name           string
+		mailbox        string
+		wantFound      bool
+		wantSuggestion string
+	}{
+		{"exact match", "Archive", true, ""},
+		{"inbox case-insensitive", "inbox", true, ""},
+		{"labels parent", "Labels", true, ""},
+		{"typo suggests closest", "Archve", false, "Archive"},
+		{"case mismatch is not found", "sent", false, "Sent"},
+		{"nested folder typo", "Folders/Reciepts", false, "Folders/Receipts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, suggestion := findMailbox(tt.mailbox, mailboxes)
+			if found != tt.wantFound {
+				t.Errorf("findMailbox(%q) found = %v, want %v", tt.mailbox, found, tt.wantFound)
+			}
+			if suggestion != tt.wantSuggestion {
+				t.Errorf("findMailbox(%q) suggestion = %q, want %q", tt.mailbox, suggestion, tt.wantSuggestion)
+			}
+		})
+	}
+}
+
+func TestConfigDoctorSkipsMailboxChecksWhenIMAPUnavailable(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("XDG_CONFIG_HOME", tmpHome)
+
+	cfg := config.DefaultConfig()
+	cfg.Bridge.Email = "test@example.com"
+	cfg.Bridge.IMAPHost = "127.0.0.1"
+	cfg.Bridge.IMAPPort = 1
+	cfg.Bridge.SMTPHost = "127.0.0.1"
+	cfg.Bridge.SMTPPort = 1
+
+	var buf bytes.Buffer
+	formatter := output.New(true, false, false, false)
+	formatter.Writer = &buf
+
+	cmd := &ConfigDoctorCmd{}
+	ctx := &Context{
+		Config:    cfg,
+		Formatter: formatter,
+		Globals:   &Globals{JSON: boolPtr(true)},
+	}
+
+	if err := cmd.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var result struct {
+		Checks []struct {
+			Name    string `json:"name"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v", err)
+	}
+
+	for _, name := range []string{"Default mailbox exists", "Labels folder exists"} {
+		found := false
+		for _, check := range result.Checks {
+			if check.Name != name {
+				continue
+			}
+			found = true
+			if check.Status != "fail" {
+				t.Errorf("%s status = %q, want %q", name, check.Status, "fail")
+			}
+			if check.Message != "cannot test - IMAP login failed" {
+				t.Errorf("%s message = %q, want %q", name, check.Message, "cannot test - IMAP login failed")
+			}
+		}
+		if !found {
+			t.Errorf("expected %q check in doctor output", name)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package cli
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/bscott/pm-cli/internal/contacts"
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/safetext"
 )
 
 func (c *ContactsListCmd) Run(ctx *Context) error {
@@ -107,7 +112,7 @@ func (c *ContactsRemoveCmd) Run(ctx *Context) error {
 	// Get contact info before removal for display
 	contact := store.Get(c.Email)
 	if contact == nil {
-		return fmt.Errorf("contact with email %s not found", c.Email)
+		return notFoundf("contact with email %s not found", c.Email)
 	}
 
 	if err := store.Remove(c.Email); err != nil {
@@ -130,3 +135,234 @@ func (c *ContactsRemoveCmd) Run(ctx *Context) error {
 
 	return nil
 }
+
+func (c *ContactsUpdateCmd) Run(ctx *Context) error {
+	store, err := contacts.Load()
+	if err != nil {
+		return err
+	}
+
+	if err := store.Update(c.Email, c.Name); err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success": true,
+			"message": "Contact updated",
+			"email":   c.Email,
+			"name":    c.Name,
+		})
+	}
+
+	fmt.Printf("Updated contact: %s <%s>\n", c.Name, c.Email)
+	return nil
+}
+
+func (c *ContactsImportCmd) Run(ctx *Context) error {
+	f, err := os.Open(c.File)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", c.File, err)
+	}
+	defer f.Close()
+
+	cards, err := contacts.ParseVCards(f)
+	if err != nil {
+		return err
+	}
+
+	store, err := contacts.Load()
+	if err != nil {
+		return err
+	}
+
+	result, err := store.Import(cards)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		invalid := result.Invalid
+		if invalid == nil {
+			invalid = []contacts.ImportError{}
+		}
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success": true,
+			"file":    c.File,
+			"cards":   len(cards),
+			"added":   result.Added,
+			"skipped": result.Skipped,
+			"invalid": invalid,
+		})
+	}
+
+	for _, e := range result.Invalid {
+		fmt.Fprintf(os.Stderr, "Warning: not imported: %s\n", safetext.SanitizeForTerminal(e.Error))
+	}
+	summary := fmt.Sprintf("Imported %d contact(s) from %s (%d skipped as duplicates", result.Added, c.File, result.Skipped)
+	if len(result.Invalid) > 0 {
+		summary += fmt.Sprintf(", %d invalid", len(result.Invalid))
+	}
+	fmt.Println(summary + ").")
+	return nil
+}
+
+func (c *ContactsExportCmd) Run(ctx *Context) error {
+	store, err := contacts.Load()
+	if err != nil {
+		return err
+	}
+
+	contactList := store.List()
+
+	if c.Output == "" {
+		return contacts.WriteVCards(os.Stdout, contactList)
+	}
+
+	var buf bytes.Buffer
+	if err := contacts.WriteVCards(&buf, contactList); err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.Output, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", c.Output, err)
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success": true,
+			"file":    c.Output,
+			"count":   len(contactList),
+		})
+	}
+
+	fmt.Printf("Exported %d contact(s) to %s\n", len(contactList), c.Output)
+	return nil
+}
+
+func (c *ContactsHarvestCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx.Formatter.Verbosef("Scanning %s for recipients...", c.Mailbox)
+
+	recipients, err := client.ListRecipients(c.Mailbox, c.Limit)
+	if err != nil {
+		return err
+	}
+
+	store, err := contacts.Load()
+	if err != nil {
+		return err
+	}
+
+	// Recipients are newest first, so the most recent display name wins
+	seen := make(map[string]bool)
+	var cards []contacts.VCard
+	var added []contacts.Contact
+	for _, r := range recipients {
+		email := strings.ToLower(strings.TrimSpace(r.Email))
+		if email == "" || seen[email] || strings.EqualFold(email, ctx.Config.Bridge.Email) {
+			continue
+		}
+		seen[email] = true
+		if store.Get(email) != nil {
+			continue
+		}
+		cards = append(cards, contacts.VCard{Name: r.Name, Emails: []string{email}})
+		added = append(added, contacts.Contact{Email: email, Name: r.Name})
+	}
+
+	if _, err := store.Import(cards); err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success":  true,
+			"mailbox":  c.Mailbox,
+			"scanned":  len(recipients),
+			"added":    added,
+			"count":    len(added),
+			"existing": len(seen) - len(added),
+		})
+	}
+
+	if len(added) == 0 {
+		fmt.Printf("No new contacts found in %s.\n", c.Mailbox)
+		return nil
+	}
+
+	fmt.Printf("Added %d contact(s) from %s:\n\n", len(added), c.Mailbox)
+
+	table := ctx.Formatter.NewTable("EMAIL", "NAME")
+	for _, contact := range added {
+		table.AddRow(contact.Email, safetext.SanitizeForTerminal(contact.Name))
+	}
+	table.Flush()
+
+	return nil
+}
+
+// resolveRecipients expands recipients that are not email addresses using
+// the contacts store. The store is only loaded when something needs resolving.
+func resolveRecipients(recipients []string) ([]string, error) {
+	var store *contacts.Store
+	resolved := make([]string, 0, len(recipients))
+
+	for _, r := range recipients {
+		if strings.Contains(r, "@") {
+			resolved = append(resolved, r)
+			continue
+		}
+
+		if store == nil {
+			var err error
+			store, err = contacts.Load()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		email, err := store.Resolve(r)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, email)
+	}
+
+	return resolved, nil
+}
+
+// resolveSenderNames replaces the From of each message with the stored
+// contact name when the sender address is in the address book.
+func resolveSenderNames(messages []imap.MessageSummary) error {
+	store, err := contacts.Load()
+	if err != nil {
+		return err
+	}
+	if store.Count() == 0 {
+		return nil
+	}
+
+	for i := range messages {
+		if messages[i].FromAddress == "" {
+			continue
+		}
+		if contact := store.Get(messages[i].FromAddress); contact != nil && contact.Name != "" {
+			messages[i].From = contact.Name
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bscott/pm-cli/internal/contacts"
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+func TestContactsHarvestCmdRunWithoutConfig(t *testing.T) {
+	cmd := &ContactsHarvestCmd{
+		Mailbox: "Sent",
+		Limit:   10,
+	}
+
+	globals := &Globals{}
+	ctx, _ := NewContext(globals)
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	err := cmd.Run(ctx)
+	if err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
+
+func TestResolveSenderNames(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("XDG_CONFIG_HOME", tmpHome)
+
+	store, err := contacts.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := store.Add("jane@example.com", "Jane Doe"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	messages := []imap.MessageSummary{
+		{From: "jane@example.com", FromAddress: "jane@example.com"},
+		{From: "Stranger", FromAddress: "stranger@example.com"},
+	}
+
+	if err := resolveSenderNames(messages); err != nil {
+		t.Fatalf("resolveSenderNames() error = %v", err)
+	}
+
+	if messages[0].From != "Jane Doe" {
+		t.Errorf("messages[0].From = %q, want %q", messages[0].From, "Jane Doe")
+	}
+	if messages[1].From != "Stranger" {
+		t.Errorf("messages[1].From = %q, want %q", messages[1].From, "Stranger")
+	}
+}
+
+func TestContactsUpdateCmdRunNotFound(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("XDG_CONFIG_HOME", tmpHome)
+
+	cmd := &ContactsUpdateCmd{
+		Email: "missing@example.com",
+		Name:  "Nobody",
+	}
+
+	ctx, _ := NewContext(&Globals{})
+	err := cmd.Run(ctx)
+	if err == nil {
+		t.Fatal("expected error when contact does not exist")
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		t.Errorf("error = %q, want it to mention not found", err)
+	}
+}
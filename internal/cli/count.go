@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+func (c *MailCountCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// STATUS returns the counts without selecting the mailbox or fetching
+	// any envelopes
+	status, err := client.Status(c.Mailbox)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"mailbox": c.Mailbox,
+			"total":   status.Messages,
+			"unread":  status.Unseen,
+		})
+	}
+
+	// A bare number, so the output can be used directly in scripts
+	if c.Unread {
+		fmt.Println(status.Unseen)
+	} else {
+		fmt.Println(status.Messages)
+	}
+	return nil
+}
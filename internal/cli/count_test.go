@@ -0,0 +1,14 @@
+package cli
+
+import "testing"
+
+func TestMailCountCmdRunWithoutConfig(t *testing.T) {
+	cmd := &MailCountCmd{Mailbox: "INBOX", Unread: true}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	if err := cmd.Run(ctx); err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dateStyleAbsolute = "absolute"
+	dateStyleRelative = "relative"
+)
+
+// validDateStyle reports whether s is an accepted date_style value.
+func validDateStyle(s string) bool {
+	return s == dateStyleAbsolute || s == dateStyleRelative
+}
+
+// dateStyle returns the effective date style: --date-style wins over
+// defaults.date_style, and anything unset means absolute.
+func (ctx *Context) dateStyle() string {
+	if ctx.Globals != nil && ctx.Globals.DateStyle != "" {
+		return ctx.Globals.DateStyle
+	}
+	if ctx.Config != nil && ctx.Config.Defaults.DateStyle != "" {
+		return ctx.Config.Defaults.DateStyle
+	}
+	return dateStyleAbsolute
+}
+
+// displayDate renders a message date for text output. iso is the RFC 3339
+// DateISO value and formatted is the preformatted Date, which is used for
+// the absolute style or when iso is missing.
+func (ctx *Context) displayDate(iso, formatted string) string {
+	if ctx.dateStyle() != dateStyleRelative || iso == "" {
+		return formatted
+	}
+	t, err := time.Parse(time.RFC3339, iso)
+	if err != nil {
+		return formatted
+	}
+
+	// Count calendar days in the configured display zone
+	loc := time.Local
+	if ctx.Config != nil {
+		loc, _ = ctx.Config.Location()
+	}
+	return relativeDate(t, time.Now().In(loc))
+}
+
+// readDate renders the Date header line of a single message. The relative
+// style keeps the absolute timestamp alongside, since a message view has
+// room for both.
+func readDate(ctx *Context, iso, formatted string) string {
+	display := ctx.displayDate(iso, formatted)
+	if display == formatted {
+		return formatted
+	}
+	return fmt.Sprintf("%s (%s)", formatted, display)
+}
+
+// relativeDate renders t relative to now, e.g. "5m ago", "2h ago",
+// "yesterday", "3 days ago". Calendar days are compared in now's location.
+func relativeDate(t, now time.Time) string {
+	t = t.In(now.Location())
+	d := now.Sub(t)
+	if d < time.Minute {
+		return "just now"
+	}
+
+	days := calendarDays(t, now)
+	switch {
+	case days == 0 && d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case days == 0:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	case days == 1:
+		return "yesterday"
+	case days < 7:
+		return fmt.Sprintf("%d days ago", days)
+	case days < 30:
+		return plural(days/7, "week") + " ago"
+	case days < 365:
+		return plural(days/30, "month") + " ago"
+	default:
+		return plural(days/365, "year") + " ago"
+	}
+}
+
+// calendarDays counts midnights between t and now.
+func calendarDays(t, now time.Time) int {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+	start := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// parseRelativeDuration parses durations such as "30m", "2h", "3d" or "1w".
+// Plain Go durations like "1h30m" are accepted too.
+func parseRelativeDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	unit := s[len(s)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q - use e.g. 30m, 2h, 3d or 1w", s)
+		}
+		days := n
+		if unit == 'w' {
+			days = n * 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q - use e.g. 30m, 2h, 3d or 1w", s)
+	}
+	return d, nil
+}
+
+// parseWakeTime resolves --until or --in into an absolute time. --until
+// accepts a date (midnight in loc), "YYYY-MM-DD HH:MM" in loc, or RFC 3339.
+func parseWakeTime(until, in string, now time.Time, loc *time.Location) (time.Time, error) {
+	if in != "" {
+		d, err := parseRelativeDuration(in)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(d), nil
+	}
+
+	until = strings.TrimSpace(until)
+	if t, err := time.Parse(time.RFC3339, until); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, until, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q - use YYYY-MM-DD, 'YYYY-MM-DD HH:MM' or RFC 3339", until)
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/config"
+)
+
+func TestRelativeDate(t *testing.T) {
+	now := time.Date(2024, 3, 15, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{"seconds ago", now.Add(-30 * time.Second), "just now"},
+		{"future", now.Add(time.Hour), "just now"},
+		{"minutes ago", now.Add(-5 * time.Minute), "5m ago"},
+		{"hours ago", now.Add(-2 * time.Hour), "2h ago"},
+		{"earlier today", time.Date(2024, 3, 15, 0, 30, 0, 0, time.UTC), "13h ago"},
+		{"yesterday late", time.Date(2024, 3, 14, 23, 30, 0, 0, time.UTC), "yesterday"},
+		{"yesterday early", time.Date(2024, 3, 14, 1, 0, 0, 0, time.UTC), "yesterday"},
+		{"days ago", now.AddDate(0, 0, -3), "3 days ago"},
+		{"one week", now.AddDate(0, 0, -7), "1 week ago"},
+		{"weeks ago", now.AddDate(0, 0, -20), "2 weeks ago"},
+		{"months ago", now.AddDate(0, 0, -65), "2 months ago"},
+		{"years ago", now.AddDate(-2, 0, 0), "2 years ago"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := relativeDate(tt.t, now); got != tt.want {
+				t.Errorf("relativeDate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisplayDate(t *testing.T) {
+	iso := time.Now().Add(-10 * time.Second).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name      string
+		flag      string
+		configVal string
+		iso       string
+		want      string
+	}{
+		{"default is absolute", "", "", iso, "2024-01-01 10:00"},
+		{"config relative", "", "relative", iso, "just now"},
+		{"flag overrides config", "absolute", "relative", iso, "2024-01-01 10:00"},
+		{"flag relative", "relative", "", iso, "just now"},
+		{"missing iso falls back", "relative", "", "", "2024-01-01 10:00"},
+		{"invalid iso falls back", "relative", "", "garbage", "2024-01-01 10:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Defaults.DateStyle = tt.configVal
+			ctx := &Context{Config: cfg, Globals: &Globals{DateStyle: tt.flag}}
+
+			if got := ctx.displayDate(tt.iso, "2024-01-01 10:00"); got != tt.want {
+				t.Errorf("displayDate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewContextRejectsInvalidDateStyle(t *testing.T) {
+	if _, err := NewContext(&Globals{DateStyle: "fuzzy"}); err == nil {
+		t.Error("NewContext() should reject an unknown --date-style")
+	}
+}
+
+func TestParseRelativeDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30m", 30 * time.Minute, false},
+		{"2h", 2 * time.Hour, false},
+		{"3d", 72 * time.Hour, false},
+		{"1w", 7 * 24 * time.Hour, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"3D", 72 * time.Hour, false},
+		{"", 0, true},
+		{"d", 0, true},
+		{"-2d", 0, true},
+		{"0h", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseRelativeDuration(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRelativeDuration(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRelativeDuration(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWakeTime(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		until   string
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"relative days", "", "3d", now.Add(72 * time.Hour), false},
+		{"date is midnight in zone", "2024-06-01", "", time.Date(2024, 6, 1, 0, 0, 0, 0, ny), false},
+		{"date and time", "2024-06-01 09:30", "", time.Date(2024, 6, 1, 9, 30, 0, 0, ny), false},
+		{"rfc3339", "2024-06-01T09:30:00Z", "", time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC), false},
+		{"garbage", "next tuesday", "", time.Time{}, true},
+		{"bad duration", "", "3x", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWakeTime(tt.until, tt.in, now, ny)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWakeTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseWakeTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
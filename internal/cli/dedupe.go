@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/safetext"
+	"github.com/bscott/pm-cli/internal/undo"
+)
+
+// duplicateGroup is a set of messages that are copies of each other. Keep
+// is the newest copy (highest UID); Remove holds the rest.
+type duplicateGroup struct {
+	Key     string   `json:"key"`
+	Subject string   `json:"subject"`
+	From    string   `json:"from"`
+	Keep    uint32   `json:"keep"`
+	Remove  []uint32 `json:"remove"`
+}
+
+func (c *MailDedupeCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx.Formatter.Verbosef("Fetching envelopes from %s...", c.Mailbox)
+	messages, err := client.ListMessages(c.Mailbox, math.MaxInt32, 0, false)
+	if err != nil {
+		return err
+	}
+
+	groups := findDuplicates(messages)
+	var ids []string
+	var refs []imap.MessageRef
+	byUID := make(map[uint32]imap.MessageSummary, len(messages))
+	for _, msg := range messages {
+		byUID[msg.UID] = msg
+	}
+	for _, g := range groups {
+		for _, uid := range g.Remove {
+			ids = append(ids, fmt.Sprintf("uid:%d", uid))
+			msg := byUID[uid]
+			refs = append(refs, imap.MessageRef{UID: uid, MessageID: msg.MessageID, Subject: msg.Subject, From: msg.FromAddress})
+		}
+	}
+
+	trash := ctx.Config.TrashMailbox()
+	dryRun := c.DryRun || !c.Yes
+	if !dryRun && len(ids) > 0 {
+		destUIDs, err := client.MoveMessagesWithUIDs(c.Mailbox, ids, trash)
+		if err != nil {
+			return err
+		}
+		recordAction(ctx, &undo.Action{
+			Command:     undo.CommandMove,
+			Mailbox:     c.Mailbox,
+			Destination: trash,
+		}, refs, destUIDs)
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success":    true,
+			"mailbox":    c.Mailbox,
+			"scanned":    len(messages),
+			"groups":     groups,
+			"duplicates": len(ids),
+			"dry_run":    dryRun,
+		})
+	}
+
+	if len(groups) == 0 {
+		fmt.Printf("No duplicates found in %d message(s).\n", len(messages))
+		return nil
+	}
+
+	for _, g := range groups {
+		fmt.Printf("%s\n", ctx.Formatter.Bold(safetext.SanitizeForTerminal(g.Subject)))
+		fmt.Printf("  From: %s\n", safetext.SanitizeForTerminal(g.From))
+		fmt.Printf("  Keep: uid:%d\n", g.Keep)
+		for _, uid := range g.Remove {
+			fmt.Printf("  Remove: uid:%d\n", uid)
+		}
+	}
+	fmt.Println()
+
+	if dryRun {
+		fmt.Printf("%d duplicate(s) in %d group(s). Run with --yes to move them to %s.\n", len(ids), len(groups), trash)
+		return nil
+	}
+	fmt.Printf("%d duplicate(s) moved to %s. Run 'pm-cli mail undo' to restore them.\n", len(ids), trash)
+	return nil
+}
+
+// findDuplicates groups messages by duplicateKey and returns the groups
+// with more than one member, largest first.
+func findDuplicates(messages []imap.MessageSummary) []duplicateGroup {
+	byKey := make(map[string][]imap.MessageSummary)
+	var order []string
+	for _, msg := range messages {
+		key := duplicateKey(msg)
+		if _, ok := byKey[key]; !ok {
+			order = append(order, key)
+		}
+		byKey[key] = append(byKey[key], msg)
+	}
+
+	groups := []duplicateGroup{}
+	for _, key := range order {
+		members := byKey[key]
+		if len(members) < 2 {
+			continue
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].UID > members[j].UID })
+
+		g := duplicateGroup{
+			Key:     key,
+			Subject: members[0].Subject,
+			From:    members[0].FromAddress,
+			Keep:    members[0].UID,
+		}
+		for _, m := range members[1:] {
+			g.Remove = append(g.Remove, m.UID)
+		}
+		groups = append(groups, g)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i].Remove) > len(groups[j].Remove) })
+	return groups
+}
+
+// duplicateKey identifies a message by its Message-ID, or by a hash of its
+// normalized subject, sender, date and size when it has none.
+func duplicateKey(msg imap.MessageSummary) string {
+	if id := strings.Trim(strings.TrimSpace(msg.MessageID), "<>"); id != "" {
+		return "message-id:" + id
+	}
+
+	subject := strings.Join(strings.Fields(strings.ToLower(msg.Subject)), " ")
+	parts := []string{
+		subject,
+		strings.ToLower(msg.FromAddress),
+		msg.DateISO,
+		strconv.FormatInt(msg.Size, 10),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return "hash:" + hex.EncodeToString(sum[:8])
+}
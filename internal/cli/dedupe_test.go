@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+func TestDuplicateKey(t *testing.T) {
+	base := imap.MessageSummary{
+		Subject:     "Weekly  Report",
+		FromAddress: "Boss@example.com",
+		DateISO:     "2024-06-01T09:00:00Z",
+		Size:        1234,
+	}
+
+	withID := base
+	withID.MessageID = "<abc@example.com>"
+	if got := duplicateKey(withID); got != "message-id:abc@example.com" {
+		t.Errorf("duplicateKey(with Message-ID) = %q", got)
+	}
+
+	normalized := base
+	normalized.Subject = "weekly report"
+	normalized.FromAddress = "boss@example.com"
+	if duplicateKey(base) != duplicateKey(normalized) {
+		t.Error("expected subject and sender to be normalized before hashing")
+	}
+
+	resized := base
+	resized.Size = 999
+	if duplicateKey(base) == duplicateKey(resized) {
+		t.Error("expected different sizes to produce different keys")
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	messages := []imap.MessageSummary{
+		{UID: 9, MessageID: "a@example.com", Subject: "A"},
+		{UID: 8, MessageID: "b@example.com", Subject: "B"},
+		{UID: 7, MessageID: "a@example.com", Subject: "A"},
+		{UID: 5, Subject: "No ID", FromAddress: "x@example.com", DateISO: "2024-06-01T09:00:00Z", Size: 10},
+		{UID: 4, MessageID: "a@example.com", Subject: "A"},
+		{UID: 3, Subject: "No ID", FromAddress: "x@example.com", DateISO: "2024-06-01T09:00:00Z", Size: 10},
+		{UID: 2, MessageID: "c@example.com", Subject: "C"},
+	}
+
+	groups := findDuplicates(messages)
+	if len(groups) != 2 {
+		t.Fatalf("findDuplicates() returned %d groups, want 2: %+v", len(groups), groups)
+	}
+
+	if groups[0].Keep != 9 || len(groups[0].Remove) != 2 || groups[0].Remove[0] != 7 || groups[0].Remove[1] != 4 {
+		t.Errorf("groups[0] = %+v, want keep 9, remove [7 4]", groups[0])
+	}
+	if groups[1].Keep != 5 || len(groups[1].Remove) != 1 || groups[1].Remove[0] != 3 {
+		t.Errorf("groups[1] = %+v, want keep 5, remove [3]", groups[1])
+	}
+
+	if got := findDuplicates(nil); got == nil || len(got) != 0 {
+		t.Errorf("findDuplicates(nil) = %v, want empty slice", got)
+	}
+}
+
+func TestMailDedupeCmdRunWithoutConfig(t *testing.T) {
+	cmd := &MailDedupeCmd{Mailbox: "INBOX"}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	if err := cmd.Run(ctx); err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
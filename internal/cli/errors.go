@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/outbox"
+	"github.com/bscott/pm-cli/internal/smtp"
+)
+
+// Exit codes returned by pm-cli, so scripts can tell failures apart.
+// Invalid arguments exit with 80.
+const (
+	ExitOK            = 0
+	ExitError         = 1
+	ExitNotConfigured = 2
+	ExitNotFound      = 3
+	ExitConnection    = 4
+	ExitTimeout       = 5
+	ExitStaleRef      = 6
+	ExitQueued        = 7
+)
+
+var (
+	// ErrNotConfigured is returned by commands that need a configured
+	// account when there is none.
+	ErrNotConfigured = errors.New("not configured - run 'pm-cli config init' first")
+	// ErrNotFound matches errors about a message, mailbox, label or contact
+	// that does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrTimeout matches the error of a command that ran past --timeout.
+	ErrTimeout = errors.New("operation timed out")
+	// ErrQueued is returned by mail send when the message went to the
+	// outbox instead of being sent. The command has already said so, so
+	// it only sets the exit code and is not printed as a failure.
+	ErrQueued = errors.New("message queued in the outbox, not sent")
+)
+
+// notFoundError is an error with its own message that matches ErrNotFound.
+type notFoundError struct {
+	msg string
+}
+
+func (e *notFoundError) Error() string { return e.msg }
+
+func (e *notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// notFoundf formats an error that matches ErrNotFound.
+func notFoundf(format string, args ...interface{}) error {
+	return &notFoundError{msg: fmt.Sprintf(format, args...)}
+}
+
+// timeoutError reports how long a timed-out command was allowed to run.
+type timeoutError struct {
+	after time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("operation timed out after %s", e.after)
+}
+
+func (e *timeoutError) Is(target error) bool { return target == ErrTimeout }
+
+// CheckTimeout returns a timeout error in place of err when the command
+// failed after its deadline passed. Whatever the connection reported when
+// it was cut off is less useful than saying the command took too long.
+func (ctx *Context) CheckTimeout(err error) error {
+	if err == nil {
+		return nil
+	}
+	deadline := ctx.Config.Deadline()
+	if deadline.IsZero() || time.Now().Before(deadline) {
+		return err
+	}
+	return &timeoutError{after: ctx.Config.Timeout()}
+}
+
+// ExitCode maps an error returned by a command to the process exit code.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrNotConfigured):
+		return ExitNotConfigured
+	case errors.Is(err, ErrNotFound), errors.Is(err, outbox.ErrNotFound), imap.IsNotFound(err):
+		return ExitNotFound
+	case errors.Is(err, imap.ErrConnect), errors.Is(err, smtp.ErrConnect):
+		return ExitConnection
+	case errors.Is(err, ErrTimeout):
+		return ExitTimeout
+	case errors.Is(err, imap.ErrStaleRef):
+		return ExitStaleRef
+	case errors.Is(err, ErrQueued):
+		return ExitQueued
+	}
+	return ExitError
+}
+
+// errorCodes lists the machine-readable error codes, one per exit code.
+// They are part of the JSON output and must not change.
+var errorCodes = []ErrorCodeSchema{
+	{Code: "error", ExitCode: ExitError, Description: "Any other failure"},
+	{Code: "not_configured", ExitCode: ExitNotConfigured, Description: "No account configured - run 'pm-cli config init'"},
+	{Code: "not_found", ExitCode: ExitNotFound, Description: "The message, mailbox, label or contact does not exist"},
+	{Code: "connection_failed", ExitCode: ExitConnection, Description: "Proton Bridge could not be reached"},
+	{Code: "timeout", ExitCode: ExitTimeout, Description: "The command ran past --timeout (defaults.timeout)"},
+	{Code: "stale_ref", ExitCode: ExitStaleRef, Description: "A ref: token's mailbox was rebuilt (UIDVALIDITY changed); look the message up again"},
+	{Code: "queued", ExitCode: ExitQueued, Description: "The SMTP server was unreachable, so mail send queued the message in the outbox instead of sending it"},
+}
+
+// ErrorCode returns the stable error code for err, as reported in the
+// error_code field of JSON output.
+func ErrorCode(err error) string {
+	exitCode := ExitCode(err)
+	for _, c := range errorCodes {
+		if c.ExitCode == exitCode {
+			return c.Code
+		}
+	}
+	return "error"
+}
+
+// ErrorJSON is the JSON object printed when a command fails in --json mode.
+// error keeps the plain message so existing consumers are unaffected.
+func ErrorJSON(err error) map[string]interface{} {
+	return map[string]interface{}{
+		"success":    false,
+		"error":      err.Error(),
+		"error_code": ErrorCode(err),
+		"exit_code":  ExitCode(err),
+	}
+}
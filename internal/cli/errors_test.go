@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/smtp"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"generic", errors.New("boom"), ExitError},
+		{"not configured", ErrNotConfigured, ExitNotConfigured},
+		{"wrapped not configured", fmt.Errorf("send: %w", ErrNotConfigured), ExitNotConfigured},
+		{"label not found", notFoundf("label '%s' does not exist", "Work"), ExitNotFound},
+		{"message not found", fmt.Errorf("failed to get draft: %w", fmt.Errorf("%w: %s", imap.ErrMessageNotFound, "9")), ExitNotFound},
+		{"mailbox empty", imap.ErrMailboxEmpty, ExitNotFound},
+		{"imap connect", fmt.Errorf("%w: %w", imap.ErrConnect, errors.New("connection refused")), ExitConnection},
+		{"smtp connect", fmt.Errorf("%w: %w", smtp.ErrConnect, errors.New("connection refused")), ExitConnection},
+		{"timeout", &timeoutError{after: 30 * time.Second}, ExitTimeout},
+		{"stale ref", fmt.Errorf("%w: UIDVALIDITY of INBOX changed", imap.ErrStaleRef), ExitStaleRef},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotFoundfKeepsMessage(t *testing.T) {
+	err := notFoundf("contact with email %s not found", "a@example.com")
+	if err.Error() != "contact with email a@example.com not found" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("expected notFoundf error to match ErrNotFound")
+	}
+}
+
+func TestRunWithoutConfigReturnsErrNotConfigured(t *testing.T) {
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	if err := (&MailCountCmd{Mailbox: "INBOX"}).Run(ctx); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Run() error = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestErrorJSON(t *testing.T) {
+	got := ErrorJSON(fmt.Errorf("failed to get draft: %w", fmt.Errorf("%w: %s", imap.ErrMessageNotFound, "9")))
+	want := map[string]interface{}{
+		"success":    false,
+		"error":      "failed to get draft: message not found: 9",
+		"error_code": "not_found",
+		"exit_code":  ExitNotFound,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ErrorJSON() = %v, want %v", got, want)
+	}
+
+	if code := ErrorCode(errors.New("boom")); code != "error" {
+		t.Errorf("ErrorCode(generic) = %q, want %q", code, "error")
+	}
+}
+
+func TestErrorCodesCoverExitCodes(t *testing.T) {
+	byExit := make(map[int]string)
+	for _, c := range errorCodes {
+		if prev, ok := byExit[c.ExitCode]; ok {
+			t.Errorf("exit code %d has two error codes: %q and %q", c.ExitCode, prev, c.Code)
+		}
+		byExit[c.ExitCode] = c.Code
+	}
+	for _, exitCode := range []int{ExitError, ExitNotConfigured, ExitNotFound, ExitConnection, ExitTimeout, ExitStaleRef, ExitQueued} {
+		if _, ok := byExit[exitCode]; !ok {
+			t.Errorf("no error code for exit code %d", exitCode)
+		}
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	ctx, err := NewContext(&Globals{Timeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewContext() error = %v", err)
+	}
+
+	cut := errors.New("in response: unexpected EOF")
+	if got := ctx.CheckTimeout(cut); got != cut {
+		t.Errorf("CheckTimeout() before the deadline = %v, want the error unchanged", got)
+	}
+	if ctx.CheckTimeout(nil) != nil {
+		t.Error("CheckTimeout(nil) must stay nil")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	got := ctx.CheckTimeout(cut)
+	if !errors.Is(got, ErrTimeout) || got.Error() != "operation timed out after 10ms" {
+		t.Errorf("CheckTimeout() after the deadline = %v, want a timeout error", got)
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+const otpBody = `Hi Jane,
+
+Your verification code is 482913. It expires in 10 minutes.
+
+If you did not request this, ignore this email.
+Order 55512 was not affected.`
+
+const signInBody = `Use the following one-time passcode to sign in:
+
+    K7P2QX
+
+Reference: SUPPORT-1234
+`
+
+const shippingBody = `Your order has shipped!
+
+Order number: 112-5550123-9876543
+Carrier: UPS
+Tracking number: 1Z999AA10123456784
+Estimated delivery: Friday, June 14
+
+Track your package: https://www.ups.com/track?tracknum=1Z999AA10123456784.
+Questions? Contact support@shop.example.com.`
+
+func TestExtractCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"code in sentence", otpBody, []string{"482913"}},
+		{"code on next line", signInBody, []string{"K7P2QX"}},
+		{"grouped digits", "Your Acme security code: 123-456", []string{"123-456"}},
+		{"no code wording", shippingBody, nil},
+		{"capitalized words are not codes", "Enter the CODE BELOW to continue", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractCodes(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractCodes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractKeyValues(t *testing.T) {
+	got := extractKeyValues(shippingBody)
+	want := map[string]string{
+		"Order number":       "112-5550123-9876543",
+		"Carrier":            "UPS",
+		"Tracking number":    "1Z999AA10123456784",
+		"Estimated delivery": "Friday, June 14",
+		"Track your package": "https://www.ups.com/track?tracknum=1Z999AA10123456784.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("extractKeyValues() = %v, want %v", got, want)
+	}
+
+	if got := extractKeyValues(signInBody); !reflect.DeepEqual(got, map[string]string{"Reference": "SUPPORT-1234"}) {
+		t.Errorf("extractKeyValues(signIn) = %v", got)
+	}
+
+	// URLs, times and sentences ending in a colon are not pairs
+	if got := extractKeyValues("See https://example.com\nMeet at 10:30\nUse the following code:\n"); len(got) != 0 {
+		t.Errorf("extractKeyValues() = %v, want empty", got)
+	}
+}
+
+func TestExtractURLsAndEmailsFromTransactionalMail(t *testing.T) {
+	if got := extractURLs(shippingBody); !reflect.DeepEqual(got, []string{"https://www.ups.com/track?tracknum=1Z999AA10123456784"}) {
+		t.Errorf("extractURLs() = %v", got)
+	}
+	if got := extractEmails(shippingBody); !reflect.DeepEqual(got, []string{"support@shop.example.com"}) {
+		t.Errorf("extractEmails() = %v", got)
+	}
+}
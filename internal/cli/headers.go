@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/safetext"
+	"github.com/emersion/go-message"
+	"github.com/emersion/go-message/textproto"
+)
+
+// defaultHeaderFields are fetched by 'mail headers' when no --field is
+// given: the addressing and threading fields plus the ones that tell
+// bulk and automated mail apart.
+var defaultHeaderFields = []string{
+	"From", "Reply-To", "To", "Cc", "Subject", "Date",
+	"Message-ID", "In-Reply-To", "References",
+	"List-Id", "List-Unsubscribe", "Precedence", "Auto-Submitted",
+}
+
+func (c *MailHeadersCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	fields := c.Fields
+	if len(fields) == 0 {
+		fields = defaultHeaderFields
+	}
+	for _, field := range fields {
+		if !validHeaderName(field) {
+			return fmt.Errorf("invalid header field name %q", field)
+		}
+	}
+
+	mailbox := c.Mailbox
+	if mailbox == "" {
+		mailbox = ctx.Config.Defaults.Mailbox
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	mailbox, ids, err := client.ResolveRefs(mailbox, c.IDs)
+	if err != nil {
+		return err
+	}
+
+	messages, err := client.GetHeaders(mailbox, ids, fields)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		outputs := make([]map[string]interface{}, len(messages))
+		for i, msg := range messages {
+			outputs[i] = map[string]interface{}{
+				"uid":        msg.UID,
+				"seq_num":    msg.SeqNum,
+				"ref":        msg.Ref,
+				"message_id": msg.MessageID,
+				"from":       msg.From,
+				"to":         msg.To,
+				"cc":         msg.CC,
+				"bcc":        msg.Bcc,
+				"subject":    msg.Subject,
+				"date":       msg.Date,
+				"date_iso":   msg.DateISO,
+				"flags":      msg.Flags,
+				"headers":    headerFieldValues(msg.RawBody, fields),
+			}
+		}
+		if len(c.IDs) == 1 {
+			return ctx.Formatter.PrintJSON(outputs[0])
+		}
+		return ctx.Formatter.PrintJSON(outputs)
+	}
+
+	for i, msg := range messages {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("uid:%d (seq %d)\n", msg.UID, msg.SeqNum)
+		fmt.Printf("Flags: %s\n", safetext.SanitizeForTerminal(strings.Join(msg.Flags, ", ")))
+		values := headerFieldValues(msg.RawBody, fields)
+		for _, field := range fields {
+			if value, ok := values[field]; ok {
+				fmt.Printf("%s: %s\n", field, safetext.SanitizeForTerminal(value))
+			}
+		}
+	}
+	return nil
+}
+
+// headerFieldValues parses a header block and returns the decoded value of
+// each of fields that is present, keyed by the name as given. A field that
+// appears more than once has its values joined with ", ".
+func headerFieldValues(raw []byte, fields []string) map[string]string {
+	values := make(map[string]string)
+	h, err := textproto.ReadHeader(bufio.NewReader(bytes.NewReader(raw)))
+	if err != nil && h.Len() == 0 {
+		return values
+	}
+	header := message.Header{Header: h}
+
+	for _, field := range fields {
+		var decoded []string
+		for fs := header.FieldsByKey(field); fs.Next(); {
+			text, err := fs.Text()
+			if err != nil {
+				// Unknown charset: keep the undecoded value
+				text = fs.Value()
+			}
+			decoded = append(decoded, text)
+		}
+		if len(decoded) > 0 {
+			values[field] = strings.Join(decoded, ", ")
+		}
+	}
+	return values
+}
+
+// headerMap parses the header block of a raw message into every field
+// and its decoded values. Names are matched case-insensitively and keyed
+// by their first spelling in the message; each maps to an array, so
+// repeated fields such as Received keep every value in message order. The
+// order of the fields themselves is not kept.
+func headerMap(raw []byte) map[string][]string {
+	values := make(map[string][]string)
+	h, err := textproto.ReadHeader(bufio.NewReader(bytes.NewReader(raw)))
+	if err != nil && h.Len() == 0 {
+		return values
+	}
+	header := message.Header{Header: h}
+
+	keys := make(map[string]string)
+	for fs := header.Fields(); fs.Next(); {
+		key, ok := keys[strings.ToLower(fs.Key())]
+		if !ok {
+			key = fs.Key()
+			keys[strings.ToLower(key)] = key
+		}
+		text, err := fs.Text()
+		if err != nil {
+			// Unknown charset: keep the undecoded value
+			text = fs.Value()
+		}
+		values[key] = append(values[key], text)
+	}
+	return values
+}
+
+// validHeaderName reports whether name is a valid header field name: one or
+// more printable ASCII characters other than space and colon.
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] <= ' ' || name[i] >= 0x7f || name[i] == ':' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+func TestHeaderFieldValues(t *testing.T) {
+	raw := []byte("Subject: =?UTF-8?Q?Caf=C3=A9_menu?=\r\n" +
+		"Received: from a\r\n" +
+		"List-Id: News <news.example.com>\r\n" +
+		"Received: from b\r\n" +
+		"\r\n")
+
+	got := headerFieldValues(raw, []string{"subject", "Received", "List-Id", "Precedence"})
+	want := map[string]string{
+		"subject":  "Café menu",
+		"Received": "from a, from b",
+		"List-Id":  "News <news.example.com>",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("headerFieldValues() = %q, want %q", got, want)
+	}
+
+	if got := headerFieldValues(nil, []string{"Subject"}); len(got) != 0 {
+		t.Errorf("headerFieldValues(nil) = %q, want empty", got)
+	}
+}
+
+func TestMessageJSONHeaders(t *testing.T) {
+	msg := &imap.Message{UID: 7, RawBody: []byte("From: a@example.com\r\n" +
+		"Received: from a\r\n" +
+		"X-Spam-Score: 2.5\r\n" +
+		"received: from b\r\n" +
+		"Subject: =?UTF-8?Q?Caf=C3=A9?=\r\n" +
+		"\r\n" +
+		"Body\r\n")}
+
+	got := (&MailReadCmd{Headers: true}).messageJSON(msg)["headers"]
+	want := map[string][]string{
+		"From":         {"a@example.com"},
+		"Received":     {"from a", "from b"},
+		"X-Spam-Score": {"2.5"},
+		"Subject":      {"Café"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("messageJSON() headers = %q, want %q", got, want)
+	}
+
+	if _, ok := (&MailReadCmd{}).messageJSON(msg)["headers"]; ok {
+		t.Error("headers should only be included with --headers")
+	}
+}
+
+func TestValidHeaderName(t *testing.T) {
+	for _, name := range []string{"Subject", "X-Spam-Score", "List-Unsubscribe-Post"} {
+		if !validHeaderName(name) {
+			t.Errorf("validHeaderName(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"", "Sub ject", "Subject:", "X-é", "A)\r\nB"} {
+		if validHeaderName(name) {
+			t.Errorf("validHeaderName(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestMailHeadersCmdRunWithoutConfig(t *testing.T) {
+	cmd := &MailHeadersCmd{IDs: []string{"1"}}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	if err := cmd.Run(ctx); err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
@@ -8,11 +8,19 @@ import (
 )
 
 type HelpSchema struct {
-	Name        string          `json:"name"`
-	Version     string          `json:"version"`
-	Description string          `json:"description"`
-	Commands    []CommandSchema `json:"commands"`
-	GlobalFlags []FlagSchema    `json:"global_flags"`
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Description string            `json:"description"`
+	Commands    []CommandSchema   `json:"commands"`
+	GlobalFlags []FlagSchema      `json:"global_flags"`
+	ErrorCodes  []ErrorCodeSchema `json:"error_codes"`
+}
+
+// ErrorCodeSchema describes an error_code value of JSON error output.
+type ErrorCodeSchema struct {
+	Code        string `json:"code"`
+	ExitCode    int    `json:"exit_code"`
+	Description string `json:"description"`
 }
 
 type CommandSchema struct {
@@ -47,6 +55,7 @@ func GenerateHelpJSON(cli *CLI) ([]byte, error) {
 		Description: "ProtonMail CLI via Proton Bridge IMAP/SMTP",
 		GlobalFlags: extractGlobalFlags(),
 		Commands:    extractCommands(cli),
+		ErrorCodes:  errorCodes,
 	}
 
 	return json.MarshalIndent(schema, "", "  ")
@@ -54,11 +63,17 @@ func GenerateHelpJSON(cli *CLI) ([]byte, error) {
 
 func extractGlobalFlags() []FlagSchema {
 	return []FlagSchema{
-		{Name: "--json", Type: "bool", Description: "Output as JSON (applies to all commands)"},
+		{Name: "--json", Type: "bool", Description: "Output as JSON (applies to all commands; default: defaults.format). --no-json forces text"},
 		{Name: "--help-json", Type: "bool", Description: "Output command help as JSON (AI agent mode)"},
 		{Name: "--config", Short: "-c", Type: "string", Description: "Path to config file"},
 		{Name: "--verbose", Short: "-v", Type: "bool", Description: "Verbose output"},
 		{Name: "--quiet", Short: "-q", Type: "bool", Description: "Suppress non-essential output"},
+		{Name: "--no-color", Type: "bool", Description: "Disable colored output (also NO_COLOR; colors are always off when stdout is not a terminal)"},
+		{Name: "--date-style", Type: "string", Description: "Date display style: absolute or relative (overrides defaults.date_style)"},
+		{Name: "--retries", Type: "int", Description: "Retries for transient Bridge failures (overrides defaults.retries, default 2; 0 disables)"},
+		{Name: "--retry-delay", Type: "duration", Description: "Wait before the first retry, doubled each attempt (overrides defaults.retry_delay, default 1s)"},
+		{Name: "--timeout", Type: "duration", Description: "Give up on Bridge after this long, e.g. 30s; also the connect timeout (overrides defaults.timeout, default none)"},
+		{Name: "--no-pager", Type: "bool", Description: "Do not pipe long output through $PAGER (paging only happens on a terminal, never with --json or --quiet)"},
 	}
 }
 
@@ -68,9 +83,18 @@ func extractCommands(cli *CLI) []CommandSchema {
 		extractMailCommands(),
 		extractMailboxCommands(),
 		extractLabelCommands(),
+		{
+			Name:        "tui",
+			Description: "Browse mail in an interactive terminal UI (needs a terminal; no JSON output)",
+			Flags: []FlagSchema{
+				{Name: "--mailbox", Short: "-m", Type: "string", Description: "Mailbox to open (default: defaults.mailbox)"},
+				{Name: "--limit", Short: "-n", Type: "int", Default: "100", Description: "Messages to load"},
+			},
+			Examples: []string{"pm-cli tui", "pm-cli tui -m Archive -n 200"},
+		},
 		{
 			Name:        "version",
-			Description: "Show version information",
+			Description: "Show version, commit and build information",
 			Examples:    []string{"pm-cli version", "pm-cli version --json"},
 		},
 	}
@@ -84,7 +108,10 @@ func extractConfigCommands() CommandSchema {
 			{
 				Name:        "config init",
 				Description: "Interactive setup wizard for Proton Bridge connection",
-				Examples:    []string{"pm-cli config init"},
+				Flags: []FlagSchema{
+					{Name: "--credential-store", Type: "string", Default: "keyring", Description: "Where to store the Bridge password (keyring or file)"},
+				},
+				Examples: []string{"pm-cli config init", "pm-cli config init --credential-store file"},
 			},
 			{
 				Name:        "config show",
@@ -104,6 +131,25 @@ func extractConfigCommands() CommandSchema {
 					"pm-cli config set bridge.email user@protonmail.com",
 				},
 			},
+			{
+				Name:        "config backup",
+				Description: "Back up config.yaml, contacts.json and idempotency.json to a tar archive (the Bridge password is not included)",
+				Flags: []FlagSchema{
+					{Name: "--output", Short: "-o", Type: "string", Required: true, Description: "Output archive path"},
+				},
+				Examples: []string{"pm-cli config backup -o backup.tar"},
+			},
+			{
+				Name:        "config restore",
+				Description: "Restore files from a config backup; the Bridge password must be re-entered afterwards",
+				Args: []ArgSchema{
+					{Name: "file", Type: "string", Required: true, Description: "Backup archive to restore"},
+				},
+				Flags: []FlagSchema{
+					{Name: "--yes", Short: "-y", Type: "bool", Description: "Skip confirmation prompt"},
+				},
+				Examples: []string{"pm-cli config restore backup.tar", "pm-cli config restore backup.tar --yes"},
+			},
 		},
 	}
 }
@@ -118,28 +164,43 @@ func extractMailCommands() CommandSchema {
 				Description: "List messages in mailbox",
 				Flags: []FlagSchema{
 					{Name: "--mailbox", Short: "-m", Type: "string", Default: "INBOX", Description: "Mailbox name"},
-					{Name: "--limit", Short: "-n", Type: "int", Default: "20", Description: "Number of messages to show"},
+					{Name: "--limit", Short: "-n", Type: "int", Default: "20", Description: "Number of messages to show (0 = defaults.limit)"},
+					{Name: "--all", Type: "bool", Description: "List every message, fetched in batches of 500"},
 					{Name: "--unread", Type: "bool", Description: "Only show unread messages"},
+					{Name: "--starred", Type: "bool", Description: "Only show starred messages (alias --flagged); ANDed with --unread"},
+					{Name: "--answered", Type: "bool", Description: "Only show messages that have been replied to"},
+					{Name: "--show-size", Type: "bool", Description: "Show message size column"},
+					{Name: "--resolve-names", Type: "bool", Description: "Show contact names for known senders"},
+					{Name: "--no-cache", Type: "bool", Description: "Bypass the on-disk listing cache"},
+					{Name: "--preview", Type: "bool", Description: "Show the start of each message body"},
 				},
 				Examples: []string{
 					"pm-cli mail list",
+					"pm-cli mail list --preview",
 					"pm-cli mail list --unread --json",
+					"pm-cli mail list --starred --unread",
 					"pm-cli mail list -m Sent -n 10",
+					"pm-cli mail list -m Archive --all",
 				},
 			},
 			{
 				Name:        "mail read",
-				Description: "Read a specific message",
+				Description: "Read one or more messages",
 				Args: []ArgSchema{
-					{Name: "id", Type: "string", Required: true, Description: "Message sequence number or uid:<uid>"},
+					{Name: "ids", Type: "[]string", Required: true, Description: "Message sequence number(s), uid:<uid>, or ref:<token> from the ref field of JSON output"},
 				},
 				Flags: []FlagSchema{
 					{Name: "--mailbox", Short: "-m", Type: "string", Description: "Mailbox name (defaults to configured mailbox)"},
 					{Name: "--raw", Type: "bool", Description: "Show raw message"},
-					{Name: "--headers", Type: "bool", Description: "Include all headers"},
+					{Name: "--headers", Type: "bool", Description: "Also show Bcc (when present), flags, UID and sequence number; JSON adds every header field as a headers map"},
 					{Name: "--attachments", Type: "bool", Description: "List attachments only"},
 					{Name: "--html", Type: "bool", Description: "Output HTML body instead of plain text"},
+					{Name: "--markdown", Type: "bool", Description: "Convert the HTML body to Markdown"},
 					{Name: "--unread", Type: "bool", Description: "Mark as unread after reading (remove \\Seen)"},
+					{Name: "--no-quotes", Type: "bool", Description: "Strip quoted replies and forwarded history"},
+					{Name: "--width", Type: "int", Description: "Wrap plain-text body at N columns (default: $COLUMNS or 80)"},
+					{Name: "--peek", Type: "bool", Description: "Fetch with BODY.PEEK so the message is not marked read"},
+					{Name: "--out", Type: "string", Description: "Write the output to this file (atomic rename, parent dirs created) instead of stdout"},
 				},
 				Examples: []string{
 					"pm-cli mail read 123",
@@ -148,6 +209,43 @@ func extractMailCommands() CommandSchema {
 					"pm-cli mail read 123 --json",
 					"pm-cli mail read 123 --raw",
 					"pm-cli mail read 123 --unread",
+					"pm-cli mail read 123 --peek --json",
+					"pm-cli mail read 123 --markdown",
+					"pm-cli mail read 10 11 12 --json",
+					"pm-cli mail read 123 --json --out artifacts/123.json",
+				},
+			},
+			{
+				Name:        "mail headers",
+				Description: "Show envelope, flags and selected header fields without fetching the body or marking messages read",
+				Args: []ArgSchema{
+					{Name: "ids", Type: "[]string", Required: true, Description: "Message sequence number(s) or uid:<uid>"},
+				},
+				Flags: []FlagSchema{
+					{Name: "--mailbox", Short: "-m", Type: "string", Description: "Mailbox name"},
+					{Name: "--field", Short: "-f", Type: "[]string", Description: "Header field to fetch; repeat for more (default: From, Reply-To, To, Cc, Subject, Date, Message-ID, In-Reply-To, References, List-Id, List-Unsubscribe, Precedence, Auto-Submitted)"},
+				},
+				Examples: []string{
+					"pm-cli mail headers 123",
+					"pm-cli mail headers 10 11 12 --json",
+					"pm-cli mail headers uid:456 -f List-Id -f X-Spam-Score --json",
+				},
+			},
+			{
+				Name:        "mail unsubscribe",
+				Description: "Unsubscribe using the List-Unsubscribe header: RFC 8058 one-click POST, then mailto, then printing the link",
+				Args: []ArgSchema{
+					{Name: "id", Type: "string", Required: true, Description: "Message sequence number, uid:<uid> or ref:<token>"},
+				},
+				Flags: []FlagSchema{
+					{Name: "--mailbox", Short: "-m", Type: "string", Description: "Mailbox name"},
+					{Name: "--via", Type: "string", Default: "auto", Description: "Method: auto, post, mail or url"},
+					{Name: "--open", Type: "bool", Description: "Open an unsubscribe link in the browser"},
+					{Name: "--dry-run", Type: "bool", Description: "Show the method and target without acting"},
+				},
+				Examples: []string{
+					"pm-cli mail unsubscribe 123 --dry-run --json",
+					"pm-cli mail unsubscribe 123",
 				},
 			},
 			{
@@ -159,45 +257,61 @@ func extractMailCommands() CommandSchema {
 					{Name: "--bcc", Type: "[]string", Description: "BCC recipients"},
 					{Name: "--subject", Short: "-s", Type: "string", Required: true, Description: "Subject line"},
 					{Name: "--body", Short: "-b", Type: "string", Description: "Body text (or use stdin)"},
+					{Name: "--body-file", Type: "string", Description: "Read the body from a file instead of stdin (--body takes precedence)"},
 					{Name: "--attach", Short: "-a", Type: "[]string", Description: "Attachment file paths"},
+					{Name: "--attach-stdin", Type: "string", Description: "Attach stdin as a file with this name (body must come from --body, --body-file or --template)"},
+					{Name: "--attach-type", Type: "[]string", Description: "Content type override as NAME=TYPE (a bare TYPE applies to --attach-stdin)"},
+					{Name: "--dry-run", Type: "bool", Description: "Print the composed MIME message instead of sending it"},
+					{Name: "--signature", Type: "string", Description: "Signature for this message (overrides defaults.signature)"},
+					{Name: "--no-signature", Type: "bool", Description: "Do not append a signature"},
+					{Name: "--force", Type: "bool", Description: "Send attachments larger than defaults.max_attachment_size"},
 				},
 				Examples: []string{
 					"pm-cli mail send -t user@example.com -s 'Hello' -b 'Message body'",
 					"echo 'Body from stdin' | pm-cli mail send -t user@example.com -s 'Hello'",
 					"pm-cli mail send -t user@example.com -s 'With attachment' -a file.pdf",
+					"pm-cli mail send -t user@example.com -s 'Report' --body-file report.txt",
+					"./export.sh | pm-cli mail send -t user@example.com -s 'Export' -b 'Attached' --attach-stdin export.csv",
 				},
 			},
 			{
 				Name:        "mail delete",
 				Description: "Delete message(s)",
 				Args: []ArgSchema{
-					{Name: "ids", Type: "[]string", Required: true, Description: "Message sequence number(s) or uid:<uid>"},
+					{Name: "ids", Type: "[]string", Required: true, Description: "Message sequence number(s), ranges (100:150, 1,5,10:*) or uid:<uid>"},
 				},
 				Flags: []FlagSchema{
 					{Name: "--permanent", Type: "bool", Description: "Skip trash, delete permanently"},
+					{Name: "--ids-file", Type: "string", Description: "File of message IDs, one per line, merged with the ids argument ('-' for stdin)"},
 				},
 				Examples: []string{
 					"pm-cli mail delete 123",
 					"pm-cli mail delete 123 456 789",
+					"pm-cli mail delete 100:150",
 					"pm-cli mail delete 123 --permanent",
+					"pm-cli mail delete --ids-file ids.txt",
 				},
 			},
 			{
 				Name:        "mail move",
 				Description: "Move message to mailbox",
 				Args: []ArgSchema{
-					{Name: "id", Type: "string", Required: true, Description: "Message sequence number or uid:<uid> to move"},
+					{Name: "id", Type: "string", Required: true, Description: "Message sequence number, range (100:150, 1,5,10:*) or uid:<uid> to move"},
 					{Name: "mailbox", Type: "string", Required: true, Description: "Destination mailbox"},
 				},
+				Flags: []FlagSchema{
+					{Name: "--ids-file", Type: "string", Description: "File of message IDs, one per line, merged with the ids argument ('-' for stdin)"},
+				},
 				Examples: []string{
 					"pm-cli mail move 123 Archive",
 					"pm-cli mail move uid:456 Archive",
+					"pm-cli mail move 100:* Archive",
 					"pm-cli mail move 123 'Custom Folder'",
 				},
 			},
 			{
 				Name:        "mail archive",
-				Description: "Move message(s) to Archive",
+				Description: "Move message(s) to Archive (defaults.archive_mailbox)",
 				Args: []ArgSchema{
 					{Name: "ids", Type: "[]string", Required: true, Description: "Message sequence number(s) or uid:<uid>"},
 				},
@@ -207,22 +321,199 @@ func extractMailCommands() CommandSchema {
 					"pm-cli mail archive uid:456",
 				},
 			},
+			{
+				Name:        "mail trash",
+				Description: "Move message(s) to Trash (defaults.trash_mailbox)",
+				Args: []ArgSchema{
+					{Name: "ids", Type: "[]string", Required: true, Description: "Message sequence number(s) or uid:<uid>"},
+				},
+				Flags: []FlagSchema{
+					{Name: "--query", Type: "string", Description: "Trash messages matching a search query"},
+					{Name: "--mailbox", Short: "-m", Type: "string", Default: "INBOX", Description: "Source mailbox"},
+				},
+				Examples: []string{
+					"pm-cli mail trash 123",
+					"pm-cli mail trash --query 'from:spam@example.com'",
+				},
+			},
+			{
+				Name:        "mail transfer",
+				Description: "Copy or move message(s) to a mailbox of another account",
+				Args: []ArgSchema{
+					{Name: "ids", Type: "[]string", Required: true, Description: "Message sequence number(s), ranges, uid:<uid> or ref:<token> in the source mailbox"},
+				},
+				Flags: []FlagSchema{
+					{Name: "--to-config", Type: "string", Required: true, Description: "Config file of the destination account"},
+					{Name: "--mailbox", Short: "-m", Type: "string", Description: "Source mailbox (default: defaults.mailbox)"},
+					{Name: "--dest", Type: "string", Description: "Destination mailbox (default: the destination account's defaults.mailbox)"},
+					{Name: "--move", Type: "bool", Default: "false", Description: "Delete the source messages once they are stored in the destination"},
+				},
+				Examples: []string{
+					"pm-cli mail transfer 123 --to-config work.yaml",
+					"pm-cli mail transfer 100:150 --to-config work.yaml --dest Archive --move",
+				},
+			},
+			{
+				Name:        "mail spam",
+				Description: "Set the $Junk keyword on message(s) and move them to Spam",
+				Args: []ArgSchema{
+					{Name: "ids", Type: "[]string", Required: true, Description: "Message sequence number(s) or uid:<uid>"},
+				},
+				Flags: []FlagSchema{
+					{Name: "--query", Type: "string", Description: "Report messages matching a search query"},
+					{Name: "--mailbox", Short: "-m", Type: "string", Default: "INBOX", Description: "Source mailbox"},
+				},
+				Examples: []string{
+					"pm-cli mail spam 123",
+					"pm-cli mail spam --query 'from:promo@example.com'",
+				},
+			},
+			{
+				Name:        "mail not-spam",
+				Description: "Set the $NotJunk keyword on message(s) and move them back to INBOX",
+				Args: []ArgSchema{
+					{Name: "ids", Type: "[]string", Required: true, Description: "Message sequence number(s) or uid:<uid>"},
+				},
+				Flags: []FlagSchema{
+					{Name: "--query", Type: "string", Description: "Report messages matching a search query"},
+					{Name: "--mailbox", Short: "-m", Type: "string", Default: "Spam", Description: "Source mailbox"},
+				},
+				Examples: []string{
+					"pm-cli mail not-spam uid:456",
+				},
+			},
+			{
+				Name:        "mail undo",
+				Description: "Undo the last move, archive or delete (permanent deletes cannot be undone)",
+				Examples: []string{
+					"pm-cli mail undo",
+					"pm-cli mail undo --json",
+				},
+			},
+			{
+				Name:        "mail snooze",
+				Description: "Move message(s) to a snooze folder until a wake time; --process moves due messages back",
+				Args: []ArgSchema{
+					{Name: "ids", Type: "[]string", Required: false, Description: "Message sequence number(s) or uid:<uid> to snooze"},
+				},
+				Flags: []FlagSchema{
+					{Name: "--until", Type: "string", Description: "Wake time (YYYY-MM-DD, 'YYYY-MM-DD HH:MM' or RFC 3339)"},
+					{Name: "--in", Type: "string", Description: "Wake after a duration (30m, 2h, 3d, 1w)"},
+					{Name: "--mailbox", Short: "-m", Type: "string", Default: "INBOX", Description: "Source mailbox (messages return here when woken)"},
+					{Name: "--folder", Type: "string", Default: "Folders/Snoozed", Description: "Folder that holds snoozed messages (created if missing)"},
+					{Name: "--process", Type: "bool", Description: "Move messages whose wake time has passed back to their mailbox"},
+				},
+				Examples: []string{
+					"pm-cli mail snooze 123 --until 2024-06-01",
+					"pm-cli mail snooze 123 --in 3d",
+					"pm-cli mail snooze --process --json",
+				},
+			},
+			{
+				Name:        "mail count",
+				Description: "Count messages in a mailbox using STATUS, without fetching envelopes",
+				Flags: []FlagSchema{
+					{Name: "--mailbox", Short: "-m", Type: "string", Default: "INBOX", Description: "Mailbox to count"},
+					{Name: "--unread", Type: "bool", Description: "Print the unread count instead of the total"},
+				},
+				Examples: []string{
+					"pm-cli mail count -m INBOX --unread",
+					"pm-cli mail count --json",
+				},
+			},
+			{
+				Name:        "mail stats",
+				Description: "Rank senders (or sender domains) by message count and total size",
+				Flags: []FlagSchema{
+					{Name: "--mailbox", Short: "-m", Type: "string", Default: "INBOX", Description: "Mailbox to analyze"},
+					{Name: "--top", Type: "int", Default: "20", Description: "Number of rows to show (0 = all)"},
+					{Name: "--by", Type: "string", Default: "sender", Description: "Group by: sender, domain"},
+					{Name: "--limit", Short: "-n", Type: "int", Default: "0", Description: "Only analyze the N most recent messages (0 = all)"},
+				},
+				Examples: []string{
+					"pm-cli mail stats --top 10",
+					"pm-cli mail stats --by domain --json",
+				},
+			},
+			{
+				Name:        "mail histogram",
+				Description: "Count messages per day, week or month by arrival date",
+				Flags: []FlagSchema{
+					{Name: "--mailbox", Short: "-m", Type: "string", Default: "INBOX", Description: "Mailbox to analyze"},
+					{Name: "--since", Type: "string", Default: "30d", Description: "Start: duration back from now (30d, 2w) or YYYY-MM-DD"},
+					{Name: "--by", Type: "string", Default: "day", Description: "Bucket size: day, week, month"},
+					{Name: "--bars", Type: "bool", Description: "Draw an ASCII bar for each bucket"},
+				},
+				Examples: []string{
+					"pm-cli mail histogram --since 2w --bars",
+					"pm-cli mail histogram --by month --since 2024-01-01 --json",
+				},
+			},
+			{
+				Name:        "mail dedupe",
+				Description: "Find duplicate messages (by Message-ID, or subject/sender/date/size hash); dry run unless --yes",
+				Flags: []FlagSchema{
+					{Name: "--mailbox", Short: "-m", Type: "string", Default: "INBOX", Description: "Mailbox to scan"},
+					{Name: "--dry-run", Type: "bool", Description: "Only report duplicates (overrides --yes)"},
+					{Name: "--yes", Short: "-y", Type: "bool", Description: "Move all but the newest copy of each duplicate to Trash"},
+				},
+				Examples: []string{
+					"pm-cli mail dedupe -m INBOX",
+					"pm-cli mail dedupe -m INBOX --yes",
+				},
+			},
+			{
+				Name:        "mail outbox list",
+				Description: "List messages mail send queued while the SMTP server was unreachable",
+				Examples: []string{
+					"pm-cli mail outbox list --json",
+				},
+			},
+			{
+				Name:        "mail outbox flush",
+				Description: "Send queued messages, removing each one once sent; stops at the first connection failure",
+				Args: []ArgSchema{
+					{Name: "ids", Type: "[]string", Required: false, Description: "Queued message ID(s) to send (default: all)"},
+				},
+				Examples: []string{
+					"pm-cli mail outbox flush",
+					"pm-cli mail outbox flush 20240601T090000-a1b2c3",
+				},
+			},
+			{
+				Name:        "mail outbox discard",
+				Description: "Remove queued messages without sending them",
+				Args: []ArgSchema{
+					{Name: "ids", Type: "[]string", Required: false, Description: "Queued message ID(s) to discard"},
+				},
+				Flags: []FlagSchema{
+					{Name: "--all", Type: "bool", Description: "Discard every queued message"},
+				},
+				Examples: []string{
+					"pm-cli mail outbox discard 20240601T090000-a1b2c3",
+					"pm-cli mail outbox discard --all",
+				},
+			},
 			{
 				Name:        "mail flag",
-				Description: "Manage message flags",
+				Description: "Set message flags, or show the current flags when no change is given",
 				Args: []ArgSchema{
-					{Name: "id", Type: "string", Required: true, Description: "Message sequence number or uid:<uid>"},
+					{Name: "id", Type: "string", Required: true, Description: "Message sequence number, range (100:150, 1,5,10:*) or uid:<uid>"},
 				},
 				Flags: []FlagSchema{
 					{Name: "--read", Type: "bool", Description: "Mark as read"},
 					{Name: "--unread", Type: "bool", Description: "Mark as unread"},
 					{Name: "--star", Type: "bool", Description: "Add star"},
 					{Name: "--unstar", Type: "bool", Description: "Remove star"},
+					{Name: "--ids-file", Type: "string", Description: "File of message IDs, one per line, merged with the ids argument ('-' for stdin)"},
 				},
 				Examples: []string{
 					"pm-cli mail flag 123 --read",
 					"pm-cli mail flag 123 --star",
 					"pm-cli mail flag 123 --unread --unstar",
+					"pm-cli mail flag 1,5,10:20 --read",
+					"compute-ids | pm-cli mail flag --ids-file - --read",
+					"pm-cli mail flag 123 --json",
 				},
 			},
 			{
@@ -233,15 +524,30 @@ func extractMailCommands() CommandSchema {
 				},
 				Flags: []FlagSchema{
 					{Name: "--mailbox", Short: "-m", Type: "string", Default: "INBOX", Description: "Mailbox to search"},
-					{Name: "--from", Type: "string", Description: "Filter by sender"},
+					{Name: "--from", Type: "string", Description: "Filter by sender (substring; '@example.com' matches a domain)"},
+					{Name: "--to", Type: "string", Description: "Filter by recipient"},
+					{Name: "--cc", Type: "string", Description: "Filter by Cc recipient"},
+					{Name: "--bcc", Type: "string", Description: "Filter by Bcc recipient (sent mail only)"},
 					{Name: "--subject", Type: "string", Description: "Filter by subject"},
 					{Name: "--since", Type: "string", Description: "Messages since date (YYYY-MM-DD)"},
 					{Name: "--before", Type: "string", Description: "Messages before date (YYYY-MM-DD)"},
+					{Name: "--not-from", Type: "string", Description: "Exclude messages from this sender"},
+					{Name: "--not-to", Type: "string", Description: "Exclude messages to this recipient"},
+					{Name: "--not-subject", Type: "string", Description: "Exclude messages with this subject"},
+					{Name: "--not-body", Type: "string", Description: "Exclude messages containing this body text"},
+					{Name: "--starred", Type: "bool", Description: "Only starred messages (--unstarred for the opposite)"},
+					{Name: "--unread", Type: "bool", Description: "Only unread messages (--read for the opposite)"},
+					{Name: "--answered", Type: "bool", Description: "Only replied-to messages (--unanswered for the opposite)"},
+					{Name: "--limit", Short: "-n", Type: "int", Default: "50", Description: "Maximum number of results, newest first (0 = all)"},
+					{Name: "--offset", Type: "int", Default: "0", Description: "Skip the N newest matches"},
+					{Name: "--page", Short: "-p", Type: "int", Description: "Page number (1-based, combines with limit)"},
+					{Name: "--raw-search", Type: "string", Description: "Raw IMAP SEARCH string; replaces the query and filter flags"},
 				},
 				Examples: []string{
 					"pm-cli mail search 'meeting'",
 					"pm-cli mail search 'invoice' --from accounts@example.com",
 					"pm-cli mail search '' --since 2024-01-01 --json",
+					"pm-cli mail search --raw-search 'SINCE 1-Jan-2024 NOT KEYWORD $Phishing'",
 				},
 			},
 		},
@@ -256,7 +562,12 @@ func extractMailboxCommands() CommandSchema {
 			{
 				Name:        "mailbox list",
 				Description: "List all mailboxes/folders",
-				Examples:    []string{"pm-cli mailbox list", "pm-cli mailbox list --json"},
+				Flags: []FlagSchema{
+					{Name: "--tree", Type: "bool", Description: "Show mailboxes as a folder hierarchy"},
+					{Name: "--counts", Type: "bool", Description: "Show message and unread counts for each mailbox"},
+					{Name: "--concurrency", Type: "int", Default: "4", Description: "Parallel IMAP connections used by --counts (max 8)"},
+				},
+				Examples: []string{"pm-cli mailbox list", "pm-cli mailbox list --json", "pm-cli mailbox list --counts", "pm-cli mailbox list --tree"},
 			},
 			{
 				Name:        "mailbox create",
@@ -274,6 +585,11 @@ func extractMailboxCommands() CommandSchema {
 				},
 				Examples: []string{"pm-cli mailbox delete 'Old Folder'"},
 			},
+			{
+				Name:        "mailbox quota",
+				Description: "Show storage quota usage",
+				Examples:    []string{"pm-cli mailbox quota", "pm-cli mailbox quota --json"},
+			},
 		},
 	}
 }
@@ -292,31 +608,51 @@ func extractLabelCommands() CommandSchema {
 				Name:        "mail label add",
 				Description: "Add a label to message(s)",
 				Args: []ArgSchema{
-					{Name: "ids", Type: "[]string", Required: true, Description: "Message ID(s) to label"},
+					{Name: "ids", Type: "[]string", Required: false, Description: "Message ID(s) to label (or use --query)"},
 				},
 				Flags: []FlagSchema{
 					{Name: "--label", Short: "-l", Type: "string", Required: true, Description: "Label name to add"},
+					{Name: "--query", Type: "string", Description: "Label messages in the source mailbox matching this search query"},
 					{Name: "--mailbox", Short: "-m", Type: "string", Default: "INBOX", Description: "Source mailbox"},
 				},
 				Examples: []string{
 					"pm-cli mail label add 123 -l Important",
 					"pm-cli mail label add 123 456 -l 'Work/Projects'",
 					"pm-cli mail label add 123 -l Todo -m Archive",
+					"pm-cli mail label add -l Newsletters --query 'from:newsletters@example.com'",
 				},
 			},
 			{
 				Name:        "mail label remove",
 				Description: "Remove a label from message(s)",
 				Args: []ArgSchema{
-					{Name: "ids", Type: "[]string", Required: true, Description: "Message ID(s) to unlabel"},
+					{Name: "ids", Type: "[]string", Required: false, Description: "Message ID(s) in the label folder to unlabel (or use --query)"},
 				},
 				Flags: []FlagSchema{
 					{Name: "--label", Short: "-l", Type: "string", Required: true, Description: "Label name to remove"},
+					{Name: "--query", Type: "string", Description: "Unlabel messages in the label folder matching this search query"},
 				},
 				Examples: []string{
 					"pm-cli mail label remove 123 -l Important",
 					"pm-cli mail label remove 123 456 -l Todo",
+					"pm-cli mail label remove -l Todo --query 'subject:reminder'",
+				},
+			},
+			{
+				Name:        "mail label create",
+				Description: "Create a new label (Labels/<name> folder)",
+				Args: []ArgSchema{
+					{Name: "name", Type: "string", Required: true, Description: "Label name without the Labels/ prefix; must not contain '/'"},
+				},
+				Examples: []string{"pm-cli mail label create Receipts"},
+			},
+			{
+				Name:        "mail label delete",
+				Description: "Delete a label (messages keep their primary folder)",
+				Args: []ArgSchema{
+					{Name: "name", Type: "string", Required: true, Description: "Label name without the Labels/ prefix"},
 				},
+				Examples: []string{"pm-cli mail label delete Receipts"},
 			},
 		},
 	}
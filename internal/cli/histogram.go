@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+// histogramBarWidth is the length of the longest bar drawn by --bars.
+const histogramBarWidth = 40
+
+// dateBucket is the number of messages that arrived in one day, week or
+// month. Date is the first day of the bucket.
+type dateBucket struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+func (c *MailHistogramCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	loc, _ := ctx.Config.Location()
+	now := time.Now().In(loc)
+	since, err := parseSince(c.Since, now, loc)
+	if err != nil {
+		return err
+	}
+	if since.After(now) {
+		return fmt.Errorf("--since %s is in the future", c.Since)
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx.Formatter.Verbosef("Fetching arrival dates from %s since %s...", c.Mailbox, since.Format("2006-01-02"))
+	dates, err := client.InternalDates(c.Mailbox, since)
+	if err != nil {
+		return err
+	}
+
+	buckets, total := bucketDates(dates, since, now, c.By)
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"mailbox": c.Mailbox,
+			"by":      c.By,
+			"since":   since.Format("2006-01-02"),
+			"total":   total,
+			"buckets": buckets,
+		})
+	}
+
+	headers := []string{strings.ToUpper(c.By), "COUNT"}
+	if c.Bars {
+		headers = append(headers, "")
+	}
+	maxCount := 0
+	for _, b := range buckets {
+		if b.Count > maxCount {
+			maxCount = b.Count
+		}
+	}
+
+	table := ctx.Formatter.NewTable(headers...)
+	for _, b := range buckets {
+		row := []string{b.Date, fmt.Sprintf("%d", b.Count)}
+		if c.Bars {
+			row = append(row, histogramBar(b.Count, maxCount))
+		}
+		table.AddRow(row...)
+	}
+	table.Flush()
+
+	fmt.Printf("\n%d message(s) in %s since %s.\n", total, c.Mailbox, since.Format("2006-01-02"))
+	return nil
+}
+
+// parseSince resolves --since: a duration back from now ("30d", "2w",
+// "12h") or a date in loc.
+func parseSince(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if d, err := parseRelativeDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(s), loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q - use a duration such as 30d or 2w, or YYYY-MM-DD", s)
+}
+
+// bucketStart returns the first moment of the day, ISO week (starting on
+// Monday) or month that contains t, in t's location.
+func bucketStart(t time.Time, by string) time.Time {
+	y, m, d := t.Date()
+	switch by {
+	case "week":
+		day := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+		return day.AddDate(0, 0, -offset)
+	case "month":
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// nextBucket returns the start of the bucket after start.
+func nextBucket(start time.Time, by string) time.Time {
+	switch by {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// bucketDates counts dates per bucket from the bucket of since through the
+// bucket of now, oldest first. Empty buckets are kept so the overview has
+// no gaps. Dates before since's day or after now are not counted.
+func bucketDates(dates []time.Time, since, now time.Time, by string) ([]dateBucket, int) {
+	loc := now.Location()
+	first := bucketStart(since.In(loc), by)
+	last := bucketStart(now, by)
+
+	layout := "2006-01-02"
+	if by == "month" {
+		layout = "2006-01"
+	}
+
+	var buckets []dateBucket
+	index := make(map[string]int)
+	for start := first; !start.After(last); start = nextBucket(start, by) {
+		key := start.Format(layout)
+		index[key] = len(buckets)
+		buckets = append(buckets, dateBucket{Date: key})
+	}
+
+	sinceDay := bucketStart(since.In(loc), "day")
+	total := 0
+	for _, t := range dates {
+		t = t.In(loc)
+		if t.Before(sinceDay) || t.After(now) {
+			continue
+		}
+		if i, ok := index[bucketStart(t, by).Format(layout)]; ok {
+			buckets[i].Count++
+			total++
+		}
+	}
+	return buckets, total
+}
+
+// histogramBar draws count as a row of # scaled so that max fills
+// histogramBarWidth. Any nonzero count gets at least one #.
+func histogramBar(count, max int) string {
+	if count == 0 || max == 0 {
+		return ""
+	}
+	n := count * histogramBarWidth / max
+	if n == 0 {
+		n = 1
+	}
+	return strings.Repeat("#", n)
+}
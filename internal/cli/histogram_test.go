@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	if got, err := parseSince("30d", now, time.UTC); err != nil || !got.Equal(now.AddDate(0, 0, -30)) {
+		t.Errorf("parseSince(30d) = %v, %v", got, err)
+	}
+	if got, err := parseSince("2024-06-01", now, time.UTC); err != nil || !got.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseSince(2024-06-01) = %v, %v", got, err)
+	}
+	if _, err := parseSince("last week", now, time.UTC); err == nil {
+		t.Error("expected an error for an unknown --since")
+	}
+}
+
+func TestBucketDates(t *testing.T) {
+	now := time.Date(2024, 6, 12, 18, 0, 0, 0, time.UTC) // Wednesday
+	since := time.Date(2024, 6, 9, 15, 0, 0, 0, time.UTC)
+	dates := []time.Time{
+		time.Date(2024, 6, 8, 23, 0, 0, 0, time.UTC), // before since's day
+		time.Date(2024, 6, 9, 8, 0, 0, 0, time.UTC),  // earlier on since's day
+		time.Date(2024, 6, 11, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 12, 17, 0, 0, 0, time.UTC),
+	}
+
+	buckets, total := bucketDates(dates, since, now, "day")
+	want := []dateBucket{
+		{"2024-06-09", 1}, {"2024-06-10", 0}, {"2024-06-11", 2}, {"2024-06-12", 1},
+	}
+	if !reflect.DeepEqual(buckets, want) || total != 4 {
+		t.Errorf("bucketDates(day) = %v (%d), want %v (4)", buckets, total, want)
+	}
+
+	// Sunday the 9th belongs to the week starting Monday the 3rd
+	buckets, _ = bucketDates(dates, since, now, "week")
+	want = []dateBucket{{"2024-06-03", 1}, {"2024-06-10", 3}}
+	if !reflect.DeepEqual(buckets, want) {
+		t.Errorf("bucketDates(week) = %v, want %v", buckets, want)
+	}
+
+	buckets, _ = bucketDates(dates, time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC), now, "month")
+	want = []dateBucket{{"2024-05", 0}, {"2024-06", 5}}
+	if !reflect.DeepEqual(buckets, want) {
+		t.Errorf("bucketDates(month) = %v, want %v", buckets, want)
+	}
+}
+
+func TestHistogramBar(t *testing.T) {
+	if got := histogramBar(10, 10); len(got) != histogramBarWidth {
+		t.Errorf("histogramBar(max) has %d characters, want %d", len(got), histogramBarWidth)
+	}
+	if got := histogramBar(1, 1000); got != "#" {
+		t.Errorf("histogramBar(1, 1000) = %q, want a single #", got)
+	}
+	if got := histogramBar(0, 10); got != "" {
+		t.Errorf("histogramBar(0, 10) = %q, want empty", got)
+	}
+}
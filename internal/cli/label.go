@@ -13,7 +13,7 @@ const labelPrefix = "Labels/"
 // folders under the "Labels/" parent folder.
 func (c *LabelListCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -57,7 +57,7 @@ func (c *LabelListCmd) Run(ctx *Context) error {
 	if len(labels) == 0 {
 		fmt.Println("No labels found.")
 		fmt.Println("\nNote: Proton Mail labels appear as folders under 'Labels/'.")
-		fmt.Println("Create labels in the Proton Mail web interface or mobile app.")
+		fmt.Println("Create one with 'pm-cli mail label create <name>'.")
 		return nil
 	}
 
@@ -80,11 +80,11 @@ type LabelInfo struct {
 // corresponding Labels/LabelName folder.
 func (c *LabelAddCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
-	if len(c.IDs) == 0 {
-		return fmt.Errorf("no message IDs specified")
+	if len(c.IDs) == 0 && c.Query == "" {
+		return fmt.Errorf("provide message ID(s), or use --query to match messages")
 	}
 
 	if c.Label == "" {
@@ -105,41 +105,47 @@ func (c *LabelAddCmd) Run(ctx *Context) error {
 	labelPath := labelPrefix + c.Label
 
 	// Verify the label exists
-	mailboxes, err := client.ListMailboxes()
+	exists, err := labelExists(client, labelPath)
 	if err != nil {
 		return err
 	}
 
-	labelExists := false
-	for _, mb := range mailboxes {
-		if mb.Name == labelPath {
-			labelExists = true
-			break
-		}
+	if !exists {
+		return notFoundf("label '%s' does not exist. Use 'pm-cli mail label list' to see available labels", c.Label)
 	}
 
-	if !labelExists {
-		return fmt.Errorf("label '%s' does not exist. Use 'pm-cli mail label list' to see available labels", c.Label)
+	ids := c.IDs
+	if c.Query != "" {
+		if ids, err = searchLabelTargets(ctx, client, c.Mailbox, c.Query); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return printNoLabelMatches(ctx, c.Label)
+		}
 	}
 
-	ctx.Formatter.Verbosef("Adding label '%s' to %d message(s)...", c.Label, len(c.IDs))
+	ctx.Formatter.Verbosef("Adding label '%s' to %d message(s)...", c.Label, len(ids))
 
 	// Copy messages to the label folder (this adds the label without removing from source)
-	if err := client.CopyMessages(c.Mailbox, c.IDs, labelPath); err != nil {
+	if err := client.CopyMessages(c.Mailbox, ids, labelPath); err != nil {
 		return err
 	}
 
 	if ctx.Formatter.JSON {
-		return ctx.Formatter.PrintJSON(map[string]interface{}{
+		result := map[string]interface{}{
 			"success": true,
 			"label":   c.Label,
-			"ids":     c.IDs,
-			"count":   len(c.IDs),
-			"message": fmt.Sprintf("Label '%s' added to %d message(s)", c.Label, len(c.IDs)),
-		})
+			"ids":     ids,
+			"count":   len(ids),
+			"message": fmt.Sprintf("Label '%s' added to %d message(s)", c.Label, len(ids)),
+		}
+		if c.Query != "" {
+			result["query"] = c.Query
+		}
+		return ctx.Formatter.PrintJSON(result)
 	}
 
-	fmt.Printf("Label '%s' added to %d message(s).\n", c.Label, len(c.IDs))
+	fmt.Printf("Label '%s' added to %d message(s).\n", c.Label, len(ids))
 	return nil
 }
 
@@ -147,11 +153,11 @@ func (c *LabelAddCmd) Run(ctx *Context) error {
 // The message must be accessed from within the label folder to remove it.
 func (c *LabelRemoveCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
-	if len(c.IDs) == 0 {
-		return fmt.Errorf("no message IDs specified")
+	if len(c.IDs) == 0 && c.Query == "" {
+		return fmt.Errorf("provide message ID(s), or use --query to match messages")
 	}
 
 	if c.Label == "" {
@@ -171,24 +177,190 @@ func (c *LabelRemoveCmd) Run(ctx *Context) error {
 	// Build full label path
 	labelPath := labelPrefix + c.Label
 
-	ctx.Formatter.Verbosef("Removing label '%s' from %d message(s)...", c.Label, len(c.IDs))
+	// The IDs, and so the query, refer to the label folder itself
+	ids := c.IDs
+	if c.Query != "" {
+		if ids, err = searchLabelTargets(ctx, client, labelPath, c.Query); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return printNoLabelMatches(ctx, c.Label)
+		}
+	}
+
+	ctx.Formatter.Verbosef("Removing label '%s' from %d message(s)...", c.Label, len(ids))
 
 	// Delete messages from the label folder. This removes the label but keeps the
 	// message in its primary folder (INBOX, Archive, etc.)
-	if err := client.DeleteMessages(labelPath, c.IDs, true); err != nil {
+	if err := client.DeleteMessages(labelPath, ids, true); err != nil {
 		return fmt.Errorf("failed to remove label: %w", err)
 	}
 
 	if ctx.Formatter.JSON {
-		return ctx.Formatter.PrintJSON(map[string]interface{}{
+		result := map[string]interface{}{
 			"success": true,
 			"label":   c.Label,
-			"ids":     c.IDs,
-			"count":   len(c.IDs),
-			"message": fmt.Sprintf("Label '%s' removed from %d message(s)", c.Label, len(c.IDs)),
+			"ids":     ids,
+			"count":   len(ids),
+			"message": fmt.Sprintf("Label '%s' removed from %d message(s)", c.Label, len(ids)),
+		}
+		if c.Query != "" {
+			result["query"] = c.Query
+		}
+		return ctx.Formatter.PrintJSON(result)
+	}
+
+	fmt.Printf("Label '%s' removed from %d message(s).\n", c.Label, len(ids))
+	return nil
+}
+
+// searchLabelTargets returns the messages in mailbox matching the --query
+// of label add or label remove.
+func searchLabelTargets(ctx *Context, client *imap.Client, mailbox, query string) ([]string, error) {
+	ids, err := client.SearchIDs(mailbox, parseQueryToSearchOptions(query))
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	ctx.Formatter.Verbosef("Query matched %d message(s)", len(ids))
+	return ids, nil
+}
+
+// printNoLabelMatches reports a --query that matched nothing, which is not
+// an error.
+func printNoLabelMatches(ctx *Context, label string) error {
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success": true,
+			"label":   label,
+			"ids":     []string{},
+			"count":   0,
+			"message": "No messages matched the query",
+		})
+	}
+	fmt.Println("No messages matched the query.")
+	return nil
+}
+
+// validateLabelName rejects names that would not map to a single
+// Labels/<name> folder.
+func validateLabelName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("label name cannot be empty")
+	}
+	if strings.HasPrefix(strings.ToLower(name), strings.ToLower(labelPrefix)) {
+		return fmt.Errorf("label name should not include the '%s' prefix - use '%s'", labelPrefix, name[len(labelPrefix):])
+	}
+	if strings.Contains(name, "/") {
+		return fmt.Errorf("label name cannot contain '/' (it would create a nested folder)")
+	}
+	return nil
+}
+
+// labelExists reports whether the Labels/<name> folder exists.
+func labelExists(client *imap.Client, labelPath string) (bool, error) {
+	mailboxes, err := client.ListMailboxes()
+	if err != nil {
+		return false, err
+	}
+	for _, mb := range mailboxes {
+		if mb.Name == labelPath {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Run creates a label by creating the corresponding Labels/<name> folder.
+func (c *LabelCreateCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	if err := validateLabelName(c.Name); err != nil {
+		return err
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	labelPath := labelPrefix + c.Name
+
+	exists, err := labelExists(client, labelPath)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("label '%s' already exists", c.Name)
+	}
+
+	if err := client.CreateMailbox(labelPath); err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success":   true,
+			"label":     c.Name,
+			"full_path": labelPath,
+			"message":   "Label created",
+		})
+	}
+
+	fmt.Printf("Label '%s' created.\n", c.Name)
+	return nil
+}
+
+// Run deletes a label by deleting its Labels/<name> folder. Messages keep
+// their primary folder; only the label is removed.
+func (c *LabelDeleteCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	if err := validateLabelName(c.Name); err != nil {
+		return err
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	labelPath := labelPrefix + c.Name
+
+	exists, err := labelExists(client, labelPath)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return notFoundf("label '%s' does not exist. Use 'pm-cli mail label list' to see available labels", c.Name)
+	}
+
+	if err := client.DeleteMailbox(labelPath); err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success":   true,
+			"label":     c.Name,
+			"full_path": labelPath,
+			"message":   "Label deleted",
 		})
 	}
 
-	fmt.Printf("Label '%s' removed from %d message(s).\n", c.Label, len(c.IDs))
+	fmt.Printf("Label '%s' deleted.\n", c.Name)
 	return nil
 }
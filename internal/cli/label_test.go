@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLabelName(t *testing.T) {
+	tests := []struct {
+		name    string
+		label   string
+		wantErr bool
+	}{
+		{"simple", "Receipts", false},
+		{"with spaces", "Work Projects", false},
+		{"empty", "", true},
+		{"whitespace only", "   ", true},
+		{"includes prefix", "Labels/Receipts", true},
+		{"includes prefix lowercase", "labels/Receipts", true},
+		{"nested", "Work/Projects", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLabelName(tt.label)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateLabelName(%q) error = %v, wantErr %v", tt.label, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLabelCreateCmdRunWithoutConfig(t *testing.T) {
+	cmd := &LabelCreateCmd{Name: "Receipts"}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	if err := cmd.Run(ctx); err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
+
+func TestLabelDeleteCmdRunWithoutConfig(t *testing.T) {
+	cmd := &LabelDeleteCmd{Name: "Receipts"}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	if err := cmd.Run(ctx); err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
+
+func TestLabelCmdsRequireIDsOrQuery(t *testing.T) {
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "user@example.com"
+
+	if err := (&LabelAddCmd{Label: "Todo"}).Run(ctx); err == nil || !strings.Contains(err.Error(), "--query") {
+		t.Errorf("label add without IDs or --query error = %v, want a hint about --query", err)
+	}
+	if err := (&LabelRemoveCmd{Label: "Todo"}).Run(ctx); err == nil || !strings.Contains(err.Error(), "--query") {
+		t.Errorf("label remove without IDs or --query error = %v, want a hint about --query", err)
+	}
+}
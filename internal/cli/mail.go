@@ -3,29 +3,61 @@ package cli
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
 	"io"
+	"math"
+	"mime"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/bscott/pm-cli/internal/config"
 	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/output"
 	"github.com/bscott/pm-cli/internal/safetext"
 	"github.com/bscott/pm-cli/internal/smtp"
+	"github.com/bscott/pm-cli/internal/undo"
+	"github.com/bscott/pm-cli/internal/watchstate"
+	"github.com/emersion/go-message"
+	_ "github.com/emersion/go-message/charset" // register charsets for body decoding
 	"github.com/emersion/go-message/mail"
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	// listChunkSize is how many messages --all fetches at a time.
+	listChunkSize = 500
+	// largeMailboxWarning is the mailbox size above which listing all
+	// messages prints a warning first.
+	largeMailboxWarning = 10000
+)
+
+// pageOffset returns the number of messages to skip for --offset and
+// --page. A page, counted from 1, overrides the offset.
+func pageOffset(offset, page, limit int) int {
+	if page > 0 {
+		return (page - 1) * limit
+	}
+	return offset
+}
+
 func (c *MailListCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
 	mailbox := c.Mailbox
@@ -34,9 +66,19 @@ func (c *MailListCmd) Run(ctx *Context) error {
 	}
 
 	limit := c.Limit
+	if limit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
 	if limit == 0 {
 		limit = ctx.Config.Defaults.Limit
 	}
+	// From here on a limit of 0 lists the whole mailbox
+	if c.All {
+		if c.Page > 0 {
+			return fmt.Errorf("--page cannot be combined with --all")
+		}
+		limit = 0
+	}
 
 	client, err := imap.NewClient(ctx.Config)
 	if err != nil {
@@ -50,17 +92,31 @@ func (c *MailListCmd) Run(ctx *Context) error {
 
 	ctx.Formatter.Verbosef("Fetching messages from %s...", mailbox)
 
-	// Calculate offset from page if specified
-	offset := c.Offset
-	if c.Page > 0 {
-		offset = (c.Page - 1) * limit
+	offset := pageOffset(c.Offset, c.Page, limit)
+
+	if limit == 0 && !c.Starred && !c.Answered {
+		return c.listAll(ctx, client, mailbox, offset)
 	}
 
-	messages, err := client.ListMessages(mailbox, limit, offset, c.Unread)
+	var messages []imap.MessageSummary
+	total := -1
+	if c.Starred || c.Answered {
+		// Let the server pick the matches, so a page is full even when
+		// few messages carry the flag
+		messages, total, err = client.Search(mailbox, c.flagSearch(limit, offset))
+	} else if c.NoCache {
+		messages, err = client.ListMessages(mailbox, limit, offset, c.Unread)
+	} else {
+		messages, err = listMessagesCached(ctx, client, mailbox, limit, offset, c.Unread)
+	}
 	if err != nil {
 		return err
 	}
 
+	if err := c.decorate(client, mailbox, messages); err != nil {
+		return err
+	}
+
 	if ctx.Formatter.JSON {
 		result := map[string]interface{}{
 			"mailbox":  mailbox,
@@ -72,22 +128,105 @@ func (c *MailListCmd) Run(ctx *Context) error {
 		if c.Page > 0 {
 			result["page"] = c.Page
 		}
+		if total >= 0 {
+			result["total"] = total
+		}
 		return ctx.Formatter.PrintJSON(result)
 	}
 
 	if len(messages) == 0 {
-		fmt.Printf("No %smessages in %s\n", func() string {
-			if c.Unread {
-				return "unread "
-			}
-			return ""
-		}(), mailbox)
+		fmt.Printf("No %smessages in %s\n", c.filterWords(), mailbox)
 		return nil
 	}
 
 	fmt.Printf("Messages in %s (%d):\n\n", mailbox, len(messages))
 
-	table := ctx.Formatter.NewTable("ID", "FLAGS", "FROM", "SUBJECT", "DATE")
+	table := c.newTable(ctx)
+	c.addRows(ctx, table, messages)
+	table.Flush()
+
+	return nil
+}
+
+// listAll lists every message for --all. Batches of listChunkSize are
+// printed as they arrive; JSON output still gathers them into one document.
+func (c *MailListCmd) listAll(ctx *Context, client *imap.Client, mailbox string, offset int) error {
+	if state, err := client.MailboxState(mailbox); err != nil {
+		ctx.Formatter.Verbosef("Cannot count messages in %s: %v", mailbox, err)
+	} else if state.Messages > largeMailboxWarning {
+		fmt.Fprintf(os.Stderr, "Warning: listing all %d messages in %s; this may take a while\n", state.Messages, mailbox)
+	}
+
+	messages := []imap.MessageSummary{}
+	var table *output.TableWriter
+	count := 0
+	err := client.ListMessagesChunked(mailbox, offset, listChunkSize, c.Unread, func(batch []imap.MessageSummary) error {
+		if err := c.decorate(client, mailbox, batch); err != nil {
+			return err
+		}
+		count += len(batch)
+		if ctx.Formatter.JSON {
+			messages = append(messages, batch...)
+			return nil
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if table == nil {
+			fmt.Printf("Messages in %s:\n\n", mailbox)
+			table = c.newTable(ctx)
+		}
+		c.addRows(ctx, table, batch)
+		table.Flush()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"mailbox":  mailbox,
+			"count":    len(messages),
+			"messages": messages,
+			"offset":   offset,
+			"limit":    0,
+		})
+	}
+
+	if count == 0 {
+		fmt.Printf("No %smessages in %s\n", c.filterWords(), mailbox)
+		return nil
+	}
+	fmt.Printf("\n%d message(s).\n", count)
+	return nil
+}
+
+// decorate adds the sender names and previews asked for by
+// --resolve-names and --preview.
+func (c *MailListCmd) decorate(client *imap.Client, mailbox string, messages []imap.MessageSummary) error {
+	if c.ResolveNames {
+		if err := resolveSenderNames(messages); err != nil {
+			return err
+		}
+	}
+	if c.Preview {
+		if err := addPreviews(client, mailbox, messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *MailListCmd) newTable(ctx *Context) *output.TableWriter {
+	headers := []string{"ID", "FLAGS", "FROM", "SUBJECT", "DATE"}
+	if c.ShowSize {
+		headers = append(headers, "SIZE")
+	}
+	return ctx.Formatter.NewTable(headers...)
+}
+
+func (c *MailListCmd) addRows(ctx *Context, table *output.TableWriter, messages []imap.MessageSummary) {
 	for _, msg := range messages {
 		flags := ""
 		if !msg.Seen {
@@ -110,22 +249,85 @@ func (c *MailListCmd) Run(ctx *Context) error {
 			from = from[:22] + "..."
 		}
 
-		table.AddRow(
+		row := []string{
 			fmt.Sprintf("%d", msg.SeqNum),
 			flags,
 			from,
 			subject,
-			msg.Date,
-		)
+			ctx.displayDate(msg.DateISO, msg.Date),
+		}
+		if c.ShowSize {
+			row = append(row, formatSize(msg.Size))
+		}
+		table.AddRow(row...)
+
+		if msg.Preview != "" {
+			table.AddNote("    " + ctx.Formatter.MutedText(previewSnippet(safetext.SanitizeForTerminal(msg.Preview), previewDisplayLength)))
+		}
 	}
-	table.Flush()
+}
 
-	return nil
+// flagSearch returns the search for the --starred and --answered filters,
+// ANDed with --unread.
+func (c *MailListCmd) flagSearch(limit, offset int) imap.SearchOptions {
+	return imap.SearchOptions{
+		Flagged:  triState(c.Starred, false),
+		Answered: triState(c.Answered, false),
+		Seen:     triState(false, c.Unread),
+		Limit:    limit,
+		Offset:   offset,
+	}
+}
+
+// filterWords describes the active flag filters for the empty listing
+// message, e.g. "unread starred ".
+func (c *MailListCmd) filterWords() string {
+	var words string
+	if c.Unread {
+		words += "unread "
+	}
+	if c.Starred {
+		words += "starred "
+	}
+	if c.Answered {
+		words += "answered "
+	}
+	return words
 }
 
 func (c *MailReadCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
+	}
+	if c.Out == "" {
+		return c.read(ctx)
+	}
+
+	// Render into memory without colors, so a failed read leaves no
+	// partial file and the artifact holds nothing but the output
+	var buf bytes.Buffer
+	stdout := ctx.Formatter
+	file := *stdout
+	file.Writer = &buf
+	file.NoColor = true
+	file.Verbose = false
+	ctx.Formatter = &file
+	err := c.read(ctx)
+	ctx.Formatter = stdout
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(c.Out, buf.Bytes()); err != nil {
+		return err
+	}
+	ctx.Formatter.Verbosef("Wrote %s", c.Out)
+	return nil
+}
+
+func (c *MailReadCmd) read(ctx *Context) error {
+	if len(c.IDs) == 0 {
+		return fmt.Errorf("no message IDs specified")
 	}
 
 	mailbox := c.Mailbox
@@ -143,33 +345,47 @@ func (c *MailReadCmd) Run(ctx *Context) error {
 	}
 	defer client.Close()
 
+	mailbox, ids, err := client.ResolveRefs(mailbox, c.IDs)
+	if err != nil {
+		return err
+	}
+
 	// Handle --attachments flag: list attachments only
 	if c.Attachments {
-		attachments, err := client.GetAttachments(mailbox, c.ID)
+		if len(c.IDs) > 1 {
+			return fmt.Errorf("--attachments accepts a single message ID")
+		}
+
+		attachments, err := client.GetAttachments(mailbox, ids[0])
 		if err != nil {
 			return err
 		}
 
 		if ctx.Formatter.JSON {
 			return ctx.Formatter.PrintJSON(map[string]interface{}{
-				"message_id":  c.ID,
+				"message_id":  c.IDs[0],
 				"attachments": attachments,
 				"count":       len(attachments),
 			})
 		}
 
 		if len(attachments) == 0 {
-			fmt.Println("No attachments found.")
+			fmt.Fprintln(ctx.Formatter.Writer, "No attachments found.")
 			return nil
 		}
 
-		fmt.Printf("Attachments (%d):\n\n", len(attachments))
-		table := ctx.Formatter.NewTable("INDEX", "FILENAME", "TYPE", "SIZE")
+		fmt.Fprintf(ctx.Formatter.Writer, "Attachments (%d):\n\n", len(attachments))
+		table := ctx.Formatter.NewTable("INDEX", "FILENAME", "TYPE", "ENCODING", "SIZE")
 		for _, att := range attachments {
+			contentType := att.ContentType
+			if att.GuessedType != "" {
+				contentType = fmt.Sprintf("%s (likely %s)", att.ContentType, att.GuessedType)
+			}
 			table.AddRow(
 				fmt.Sprintf("%d", att.Index),
 				safetext.SanitizeForTerminal(att.Filename),
-				safetext.SanitizeForTerminal(att.ContentType),
+				safetext.SanitizeForTerminal(contentType),
+				safetext.SanitizeForTerminal(att.Encoding),
 				formatSize(att.Size),
 			)
 		}
@@ -177,129 +393,263 @@ func (c *MailReadCmd) Run(ctx *Context) error {
 		return nil
 	}
 
-	msg, err := client.GetMessage(mailbox, c.ID)
-	if err != nil {
-		return err
+	var messages []*imap.Message
+	if len(c.IDs) == 1 {
+		getMessage := client.GetMessage
+		if c.Peek {
+			getMessage = client.PeekMessage
+		}
+		msg, err := getMessage(mailbox, ids[0])
+		if err != nil {
+			return err
+		}
+		messages = []*imap.Message{msg}
+	} else {
+		// Fetch all requested messages in a single FETCH round-trip
+		getMessages := client.GetMessages
+		if c.Peek {
+			getMessages = client.PeekMessages
+		}
+		messages, err = getMessages(mailbox, ids)
+		if err != nil {
+			return err
+		}
 	}
 
 	if c.Unread {
-		unreadID := fmt.Sprintf("uid:%d", msg.UID)
-		if err := client.SetFlags(mailbox, unreadID, false, true, false, false); err != nil {
+		unreadIDs := make([]string, len(messages))
+		for i, msg := range messages {
+			unreadIDs[i] = fmt.Sprintf("uid:%d", msg.UID)
+		}
+		if err := client.SetFlagsMultiple(mailbox, unreadIDs, false, true, false, false); err != nil {
 			return fmt.Errorf("failed to mark message as unread after reading: %w", err)
 		}
-		msg.Flags = normalizeFlagsForUnread(msg.Flags)
+		for _, msg := range messages {
+			msg.Flags = normalizeFlagsForUnread(msg.Flags)
+		}
 	}
 
 	if ctx.Formatter.JSON {
-		output := map[string]interface{}{
-			"uid":           msg.UID,
-			"seq_num":       msg.SeqNum,
-			"message_id":    msg.MessageID,
-			"from":          msg.From,
-			"to":            msg.To,
-			"cc":            msg.CC,
-			"subject":       msg.Subject,
-			"date":          msg.Date,
-			"flags":         msg.Flags,
-			"marked_unread": c.Unread,
+		if len(c.IDs) == 1 {
+			return ctx.Formatter.PrintJSON(c.messageJSON(messages[0]))
 		}
+		outputs := make([]map[string]interface{}, len(messages))
+		for i, msg := range messages {
+			outputs[i] = c.messageJSON(msg)
+		}
+		return ctx.Formatter.PrintJSON(outputs)
+	}
 
-		// Parse body
-		if len(msg.RawBody) > 0 {
-			textBody, htmlBody := parseMessageBody(msg.RawBody)
-			if textBody != "" {
-				output["body"] = textBody
-			}
-			if htmlBody != "" {
-				output["html_body"] = htmlBody
-			}
-			if c.Raw {
-				output["raw"] = string(msg.RawBody)
-			}
+	for i, msg := range messages {
+		if i > 0 {
+			fmt.Fprintf(ctx.Formatter.Writer, "\n%s\n\n", ctx.Formatter.MutedText(strings.Repeat("=", 60)))
 		}
+		c.printMessage(ctx, msg)
+	}
+
+	return nil
+}
 
-		return ctx.Formatter.PrintJSON(output)
+// messageJSON builds the JSON object emitted for a single message by mail read.
+func (c *MailReadCmd) messageJSON(msg *imap.Message) map[string]interface{} {
+	output := map[string]interface{}{
+		"uid":           msg.UID,
+		"seq_num":       msg.SeqNum,
+		"ref":           msg.Ref,
+		"message_id":    msg.MessageID,
+		"from":          msg.From,
+		"to":            msg.To,
+		"cc":            msg.CC,
+		"bcc":           msg.Bcc,
+		"subject":       msg.Subject,
+		"date":          msg.Date,
+		"flags":         msg.Flags,
+		"status":        imap.MessageStatus(msg.Flags),
+		"marked_unread": c.Unread,
 	}
 
-	// Text output
+	// Parse body
+	if len(msg.RawBody) > 0 {
+		textBody, htmlBody := parseMessageBody(msg.RawBody)
+		if textBody != "" {
+			output["body"] = textBody
+		}
+		if htmlBody != "" {
+			output["html_body"] = htmlBody
+		}
+		if c.NoQuotes {
+			plain := textBody
+			if plain == "" && htmlBody != "" {
+				plain = htmlToText(htmlBody)
+			}
+			output["body_stripped"] = stripQuotedText(plain)
+		}
+		if c.Markdown {
+			output["body_markdown"] = markdownBody(textBody, htmlBody, c.NoQuotes)
+		}
+		if c.Raw {
+			output["raw"] = string(msg.RawBody)
+		}
+		if c.Headers {
+			output["headers"] = headerMap(msg.RawBody)
+		}
+	}
+
+	return output
+}
+
+// printMessage writes the text rendering of a single message. Unless
+// colors are off, header labels and the separator are colored, quoted
+// lines dimmed and URLs highlighted.
+func (c *MailReadCmd) printMessage(ctx *Context, msg *imap.Message) {
+	f := ctx.Formatter
+	w := f.Writer
 	if c.Raw {
-		fmt.Println(string(msg.RawBody))
-		return nil
+		fmt.Fprintln(w, string(msg.RawBody))
+		return
 	}
 
 	// Sanitize every field derived from the received email before printing.
 	// An attacker sending an email can embed ANSI/OSC escape sequences in
 	// headers and body; writing them to a TTY lets them obscure output or
-	// spoof terminal hyperlinks.
-	fmt.Printf("From:    %s\n", safetext.SanitizeForTerminal(msg.From))
-	fmt.Printf("To:      %s\n", safetext.SanitizeForTerminal(strings.Join(msg.To, ", ")))
+	// spoof terminal hyperlinks. Our own colors are added afterwards.
+	header := func(label, value string) {
+		fmt.Fprintf(w, "%s %s\n", f.InfoText(fmt.Sprintf("%-8s", label)), value)
+	}
+	header("From:", safetext.SanitizeForTerminal(msg.From))
+	header("To:", safetext.SanitizeForTerminal(strings.Join(msg.To, ", ")))
 	if len(msg.CC) > 0 {
-		fmt.Printf("CC:      %s\n", safetext.SanitizeForTerminal(strings.Join(msg.CC, ", ")))
+		header("CC:", safetext.SanitizeForTerminal(strings.Join(msg.CC, ", ")))
 	}
-	fmt.Printf("Date:    %s\n", msg.Date)
-	fmt.Printf("Subject: %s\n", safetext.SanitizeForTerminal(msg.Subject))
+	header("Date:", readDate(ctx, msg.DateISO, msg.Date))
+	header("Subject:", f.Bold(safetext.SanitizeForTerminal(msg.Subject)))
 	if msg.MessageID != "" {
-		fmt.Printf("Message-ID: %s\n", safetext.SanitizeForTerminal(msg.MessageID))
+		header("Message-ID:", safetext.SanitizeForTerminal(msg.MessageID))
 	}
 
 	if c.Headers {
-		fmt.Printf("Flags:   %s\n", safetext.SanitizeForTerminal(strings.Join(msg.Flags, ", ")))
-		fmt.Printf("UID:     %d\n", msg.UID)
-		fmt.Printf("Seq:     %d\n", msg.SeqNum)
+		if len(msg.Bcc) > 0 {
+			header("BCC:", safetext.SanitizeForTerminal(strings.Join(msg.Bcc, ", ")))
+		}
+		header("Flags:", safetext.SanitizeForTerminal(strings.Join(msg.Flags, ", ")))
+		header("UID:", fmt.Sprintf("%d", msg.UID))
+		header("Seq:", fmt.Sprintf("%d", msg.SeqNum))
 	}
 
-	fmt.Println()
-	fmt.Println(strings.Repeat("-", 60))
-	fmt.Println()
+	fmt.Fprintf(w, "\n%s\n\n", f.MutedText(strings.Repeat("-", 60)))
 
 	// Parse and display body
 	if len(msg.RawBody) > 0 {
 		textBody, htmlBody := parseMessageBody(msg.RawBody)
 
-		if c.HTML {
+		if c.Markdown {
+			if md := markdownBody(textBody, htmlBody, c.NoQuotes); md != "" {
+				fmt.Fprintln(w, safetext.SanitizeForTerminal(md))
+			} else {
+				fmt.Fprintln(w, "[No body content]")
+			}
+		} else if c.HTML {
 			// Output HTML body directly
 			if htmlBody != "" {
-				fmt.Println(safetext.SanitizeForTerminal(htmlBody))
+				fmt.Fprintln(w, safetext.SanitizeForTerminal(htmlBody))
 			} else if textBody != "" {
 				// No HTML, output text
-				fmt.Println(safetext.SanitizeForTerminal(textBody))
+				fmt.Fprintln(w, safetext.SanitizeForTerminal(textBody))
 			} else {
-				fmt.Println("[No body content]")
+				fmt.Fprintln(w, "[No body content]")
 			}
 		} else {
 			// Default: output plain text
 			if textBody != "" {
-				fmt.Println(safetext.SanitizeForTerminal(textBody))
+				if c.NoQuotes {
+					textBody = stripQuotedText(textBody)
+				}
+				fmt.Fprintln(w, highlightBody(f, safetext.SanitizeForTerminal(wrapText(textBody, c.wrapWidth()))))
 			} else if htmlBody != "" {
 				// Convert HTML to plain text
 				text := htmlToText(htmlBody)
+				if c.NoQuotes {
+					text = stripQuotedText(text)
+				}
 				if text != "" {
-					fmt.Println(safetext.SanitizeForTerminal(text))
+					fmt.Fprintln(w, highlightBody(f, safetext.SanitizeForTerminal(wrapText(text, c.wrapWidth()))))
 				} else {
-					fmt.Println("[HTML content - use --html to view]")
+					fmt.Fprintln(w, "[HTML content - use --html to view]")
 				}
 			} else {
-				fmt.Println("[No body content]")
+				fmt.Fprintln(w, "[No body content]")
 			}
 		}
 	}
 
 	if c.Unread {
-		fmt.Println()
-		fmt.Println("[marked as unread]")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "[marked as unread]")
 	}
+}
 
-	return nil
+// bodyURLRegex matches the URLs highlighted in plain-text bodies.
+var bodyURLRegex = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// highlightBody dims quoted lines and colors URLs in a sanitized plain-text
+// body. It returns text unchanged when colors are off.
+func highlightBody(f *output.Formatter, text string) string {
+	if f.NoColor || f.JSON {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimLeft(line, " "), ">") {
+			lines[i] = f.MutedText(line)
+			continue
+		}
+		lines[i] = bodyURLRegex.ReplaceAllStringFunc(line, func(url string) string {
+			return f.Color(output.Blue, url)
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownBody renders a body as Markdown: the HTML part converted when
+// there is one, otherwise the plain text as is. It is not wrapped, since
+// that would break list items and links.
+func markdownBody(textBody, htmlBody string, noQuotes bool) string {
+	body := textBody
+	if htmlBody != "" {
+		body = htmlToMarkdown(htmlBody)
+	}
+	if noQuotes {
+		body = stripQuotedText(body)
+	}
+	return strings.TrimSpace(body)
+}
+
+// wrapWidth returns the column to wrap plain-text bodies at: --width if
+// set, otherwise $COLUMNS, otherwise 80.
+func (c *MailReadCmd) wrapWidth() int {
+	if c.Width > 0 {
+		return c.Width
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return 80
 }
 
 func (c *MailSendCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
-	// Check idempotency key
-	if c.IdempotencyKey != "" {
-		used, err := config.CheckIdempotencyKey(c.IdempotencyKey)
+	contentTypes, err := parseAttachTypes(c.AttachType, c.Attach, c.AttachStdin)
+	if err != nil {
+		return err
+	}
+
+	// Claim idempotency key; it is released again if the send does not happen
+	sent := false
+	if c.IdempotencyKey != "" && !c.DryRun {
+		used, err := config.ClaimIdempotencyKey(c.IdempotencyKey)
 		if err != nil {
 			return fmt.Errorf("idempotency check failed: %w", err)
 		}
@@ -315,6 +665,13 @@ func (c *MailSendCmd) Run(ctx *Context) error {
 			fmt.Println("Email already sent (idempotency key matched).")
 			return nil
 		}
+		defer func() {
+			if !sent {
+				if err := config.ReleaseIdempotencyKey(c.IdempotencyKey); err != nil {
+					ctx.Formatter.Verbosef("Warning: failed to release idempotency key: %v", err)
+				}
+			}
+		}()
 	}
 
 	// Initialize from command-line flags
@@ -322,7 +679,10 @@ func (c *MailSendCmd) Run(ctx *Context) error {
 	cc := c.CC
 	bcc := c.BCC
 	subject := c.Subject
-	body := c.Body
+	body, err := bodyOrFile(c.Body, c.BodyFile)
+	if err != nil {
+		return err
+	}
 
 	// Process template if provided
 	if c.Template != "" {
@@ -349,8 +709,19 @@ func (c *MailSendCmd) Run(ctx *Context) error {
 		}
 	}
 
-	// Read body from stdin if not provided
-	if body == "" {
+	// Stdin is either the attachment (--attach-stdin) or the body, never both
+	var stdinAttachment []smtp.Attachment
+	if c.AttachStdin != "" {
+		if body == "" {
+			return fmt.Errorf("--attach-stdin reads the attachment from stdin - provide the body with --body, --body-file or --template")
+		}
+		att, err := readStdinAttachment(c.AttachStdin)
+		if err != nil {
+			return err
+		}
+		stdinAttachment = []smtp.Attachment{att}
+	} else if body == "" {
+		// Read body from stdin if not provided
 		stat, _ := os.Stdin.Stat()
 		if (stat.Mode() & os.ModeCharDevice) == 0 {
 			scanner := bufio.NewScanner(os.Stdin)
@@ -366,11 +737,43 @@ func (c *MailSendCmd) Run(ctx *Context) error {
 	if len(to) == 0 {
 		return fmt.Errorf("no recipients specified - use --to or provide in template")
 	}
+
+	// Expand contact names into addresses
+	if to, err = resolveRecipients(to); err != nil {
+		return err
+	}
+	if cc, err = resolveRecipients(cc); err != nil {
+		return err
+	}
+	if bcc, err = resolveRecipients(bcc); err != nil {
+		return err
+	}
+	if err := smtp.ValidateAddresses(append(append(append([]string{}, to...), cc...), bcc...)); err != nil {
+		return err
+	}
 	if subject == "" {
 		return fmt.Errorf("no subject specified - use --subject or provide in template")
 	}
 	if body == "" {
-		return fmt.Errorf("no message body provided - use --body, --template, or pipe via stdin")
+		return fmt.Errorf("no message body provided - use --body, --body-file, --template, or pipe via stdin")
+	}
+	body = appendSignature(body, ctx.signature(c.Signature, c.NoSignature))
+
+	msg := &smtp.Message{
+		From:              ctx.Config.Bridge.Email,
+		To:                to,
+		CC:                cc,
+		BCC:               bcc,
+		Subject:           subject,
+		Body:              body,
+		Attachments:       c.Attach,
+		AttachmentData:    stdinAttachment,
+		ContentTypes:      contentTypes,
+		MaxAttachmentSize: ctx.maxAttachmentSize(c.Force),
+	}
+
+	if c.DryRun {
+		return printDryRun(ctx, msg)
 	}
 
 	password, err := ctx.Config.GetPassword()
@@ -380,29 +783,18 @@ func (c *MailSendCmd) Run(ctx *Context) error {
 
 	smtpClient := smtp.NewClient(ctx.Config, password)
 
-	msg := &smtp.Message{
-		From:        ctx.Config.Bridge.Email,
-		To:          to,
-		CC:          cc,
-		BCC:         bcc,
-		Subject:     subject,
-		Body:        body,
-		Attachments: c.Attach,
-	}
-
 	ctx.Formatter.Verbosef("Sending email to %s...", strings.Join(to, ", "))
 
 	if err := smtpClient.Send(msg); err != nil {
+		// Queued messages go out on 'mail outbox flush', so the key stays
+		// claimed; anything else releases it for a retry
+		queued, err := queueUnsent(ctx, msg, err)
+		sent = queued
 		return err
 	}
 
-	// Record idempotency key after successful send
-	if c.IdempotencyKey != "" {
-		if err := config.RecordIdempotencyKey(c.IdempotencyKey); err != nil {
-			// Log but don't fail - email was already sent
-			ctx.Formatter.Verbosef("Warning: failed to record idempotency key: %v", err)
-		}
-	}
+	// Keep the idempotency key claimed now that the message is out
+	sent = true
 
 	if ctx.Formatter.JSON {
 		result := map[string]interface{}{
@@ -424,6 +816,133 @@ func (c *MailSendCmd) Run(ctx *Context) error {
 	return nil
 }
 
+// defaultMaxAttachmentSize applies when defaults.max_attachment_size is
+// unset; it matches Proton's 25 MB message limit.
+const defaultMaxAttachmentSize = 25 << 20
+
+// maxAttachmentSize returns the per-attachment limit in bytes for outgoing
+// mail, or 0 for no limit when --force is given or the limit is "0".
+func (ctx *Context) maxAttachmentSize(force bool) int64 {
+	if force {
+		return 0
+	}
+	if ctx.Config == nil || ctx.Config.Defaults.MaxAttachmentSize == "" {
+		return defaultMaxAttachmentSize
+	}
+	return parseSize(ctx.Config.Defaults.MaxAttachmentSize)
+}
+
+// withForceHint points at --force when a send failed on the attachment size
+// limit.
+func withForceHint(err error) error {
+	var tooLarge *smtp.AttachmentTooLargeError
+	if errors.As(err, &tooLarge) {
+		return fmt.Errorf("%w - use --force to send it anyway, or raise defaults.max_attachment_size", err)
+	}
+	return err
+}
+
+// printDryRun prints msg as it would be sent, for the --dry-run flag of the
+// sending commands. Nothing is sent and no password is needed. The text
+// output drops control characters (including CR); JSON keeps the exact bytes.
+func printDryRun(ctx *Context, msg *smtp.Message) error {
+	data, err := smtp.NewClient(ctx.Config, "").Compose(msg)
+	if err != nil {
+		return withForceHint(err)
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"dry_run": true,
+			"mime":    string(data),
+		})
+	}
+
+	// Quoted text in replies and forwards comes from received mail
+	fmt.Print(safetext.SanitizeForTerminal(string(data)))
+	return nil
+}
+
+// bodyOrFile returns body, or when it is empty the contents of bodyFile
+// without trailing line breaks. Callers read stdin only when both are
+// unset, so --body wins over --body-file, which wins over stdin. An empty
+// file is an error rather than a reason to wait on stdin.
+func bodyOrFile(body, bodyFile string) (string, error) {
+	if body != "" || bodyFile == "" {
+		return body, nil
+	}
+	data, err := os.ReadFile(bodyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read --body-file: %w", err)
+	}
+	text := strings.TrimRight(string(data), "\r\n")
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("--body-file %s is empty", bodyFile)
+	}
+	return text, nil
+}
+
+// readStdinAttachment reads all of stdin as an attachment called name. The
+// name is reduced to its base so it cannot carry a path.
+func readStdinAttachment(name string) (smtp.Attachment, error) {
+	filename := filepath.Base(name)
+	if filename == "." || filename == string(filepath.Separator) {
+		return smtp.Attachment{}, fmt.Errorf("invalid --attach-stdin name %q", name)
+	}
+
+	stat, _ := os.Stdin.Stat()
+	if stat != nil && stat.Mode()&os.ModeCharDevice != 0 {
+		return smtp.Attachment{}, fmt.Errorf("--attach-stdin needs the attachment piped via stdin")
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return smtp.Attachment{}, fmt.Errorf("failed to read attachment from stdin: %w", err)
+	}
+
+	return smtp.Attachment{Filename: filename, Data: data}, nil
+}
+
+// parseAttachTypes turns --attach-type values into content types keyed by
+// attachment filename. NAME may be the path given to --attach or its base
+// name; a bare TYPE applies to the --attach-stdin attachment. Every entry
+// must name an attachment and a valid media type.
+func parseAttachTypes(values, attach []string, stdinName string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	names := make(map[string]bool)
+	for _, path := range attach {
+		names[filepath.Base(path)] = true
+	}
+	if stdinName != "" {
+		names[filepath.Base(stdinName)] = true
+	}
+
+	types := make(map[string]string, len(values))
+	for _, v := range values {
+		name, contentType, ok := strings.Cut(v, "=")
+		if !ok {
+			if stdinName == "" {
+				return nil, fmt.Errorf("--attach-type %q needs the form NAME=TYPE (a bare TYPE requires --attach-stdin)", v)
+			}
+			name, contentType = stdinName, v
+		}
+		name = filepath.Base(strings.TrimSpace(name))
+		contentType = strings.TrimSpace(contentType)
+
+		if !names[name] {
+			return nil, fmt.Errorf("--attach-type %q does not match any attachment", v)
+		}
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return nil, fmt.Errorf("invalid --attach-type %q: %w", v, err)
+		}
+		types[name] = contentType
+	}
+	return types, nil
+}
+
 // emailTemplate represents parsed template content.
 type emailTemplate struct {
 	To      []string
@@ -516,12 +1035,17 @@ func parseRecipientField(field interface{}) []string {
 
 func (c *MailDeleteCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
+	}
+
+	ids, err := mergeIDsFile(c.IDs, c.IDsFile)
+	if err != nil {
+		return err
 	}
 
 	// Require either IDs or query
-	if len(c.IDs) == 0 && c.Query == "" {
-		return fmt.Errorf("provide message ID(s) or use --query to match messages")
+	if len(ids) == 0 && c.Query == "" {
+		return fmt.Errorf("provide message ID(s), --ids-file, or use --query to match messages")
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -538,8 +1062,14 @@ func (c *MailDeleteCmd) Run(ctx *Context) error {
 	if mailbox == "" {
 		mailbox = ctx.Config.Defaults.Mailbox
 	}
-
-	ids := c.IDs
+
+	mailbox, ids, err = client.ResolveRefs(mailbox, ids)
+	if err != nil {
+		return err
+	}
+	if ids, err = client.ExpandIDs(mailbox, ids); err != nil {
+		return err
+	}
 
 	// If query is provided, search for matching messages
 	if c.Query != "" {
@@ -563,10 +1093,18 @@ func (c *MailDeleteCmd) Run(ctx *Context) error {
 		ctx.Formatter.Verbosef("Query matched %d message(s)", len(ids))
 	}
 
+	refs := messageRefsForUndo(ctx, client, mailbox, ids)
+
 	if err := client.DeleteMessages(mailbox, ids, c.Permanent); err != nil {
 		return err
 	}
 
+	recordAction(ctx, &undo.Action{
+		Command:   undo.CommandDelete,
+		Mailbox:   mailbox,
+		Permanent: c.Permanent,
+	}, refs, nil)
+
 	if ctx.Formatter.JSON {
 		return ctx.Formatter.PrintJSON(map[string]interface{}{
 			"success":   true,
@@ -586,12 +1124,17 @@ func (c *MailDeleteCmd) Run(ctx *Context) error {
 
 func (c *MailMoveCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
+	}
+
+	ids, err := mergeIDsFile(c.IDs, c.IDsFile)
+	if err != nil {
+		return err
 	}
 
 	// Require either IDs or query
-	if len(c.IDs) == 0 && c.Query == "" {
-		return fmt.Errorf("provide message ID(s) or use --query to match messages")
+	if len(ids) == 0 && c.Query == "" {
+		return fmt.Errorf("provide message ID(s), --ids-file, or use --query to match messages")
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -609,7 +1152,13 @@ func (c *MailMoveCmd) Run(ctx *Context) error {
 		mailbox = ctx.Config.Defaults.Mailbox
 	}
 
-	ids := c.IDs
+	mailbox, ids, err = client.ResolveRefs(mailbox, ids)
+	if err != nil {
+		return err
+	}
+	if ids, err = client.ExpandIDs(mailbox, ids); err != nil {
+		return err
+	}
 
 	// If query is provided, search for matching messages
 	if c.Query != "" {
@@ -634,10 +1183,19 @@ func (c *MailMoveCmd) Run(ctx *Context) error {
 		ctx.Formatter.Verbosef("Query matched %d message(s)", len(ids))
 	}
 
-	if err := client.MoveMessages(mailbox, ids, c.Destination); err != nil {
+	refs := messageRefsForUndo(ctx, client, mailbox, ids)
+
+	destUIDs, err := client.MoveMessagesWithUIDs(mailbox, ids, c.Destination)
+	if err != nil {
 		return err
 	}
 
+	recordAction(ctx, &undo.Action{
+		Command:     undo.CommandMove,
+		Mailbox:     mailbox,
+		Destination: c.Destination,
+	}, refs, destUIDs)
+
 	if ctx.Formatter.JSON {
 		return ctx.Formatter.PrintJSON(map[string]interface{}{
 			"success":     true,
@@ -652,14 +1210,31 @@ func (c *MailMoveCmd) Run(ctx *Context) error {
 }
 
 func (c *MailArchiveCmd) Run(ctx *Context) error {
-	moveCmd := c.toMoveCmd()
+	moveCmd := c.toMoveCmd(ctx.Config.ArchiveMailbox())
+	return moveCmd.Run(ctx)
+}
+
+func (c *MailArchiveCmd) toMoveCmd(destination string) MailMoveCmd {
+	return MailMoveCmd{
+		IDs:         c.IDs,
+		Destination: destination,
+		Query:       c.Query,
+		Mailbox:     c.Mailbox,
+	}
+}
+
+// MailTrashCmd moves messages to the Trash mailbox. Unlike 'mail delete',
+// it is an ordinary move, so it does not depend on Bridge expunge
+// behaviour and works with a custom defaults.trash_mailbox.
+func (c *MailTrashCmd) Run(ctx *Context) error {
+	moveCmd := c.toMoveCmd(ctx.Config.TrashMailbox())
 	return moveCmd.Run(ctx)
 }
 
-func (c *MailArchiveCmd) toMoveCmd() MailMoveCmd {
+func (c *MailTrashCmd) toMoveCmd(destination string) MailMoveCmd {
 	return MailMoveCmd{
 		IDs:         c.IDs,
-		Destination: "Archive",
+		Destination: destination,
 		Query:       c.Query,
 		Mailbox:     c.Mailbox,
 	}
@@ -667,16 +1242,20 @@ func (c *MailArchiveCmd) toMoveCmd() MailMoveCmd {
 
 func (c *MailFlagCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
-	if !c.Read && !c.Unread && !c.Star && !c.Unstar {
-		return fmt.Errorf("no flags specified - use --read, --unread, --star, or --unstar")
+	// Without a change to make, the command shows the current flags
+	inspect := !c.Read && !c.Unread && !c.Star && !c.Unstar
+
+	ids, err := mergeIDsFile(c.IDs, c.IDsFile)
+	if err != nil {
+		return err
 	}
 
 	// Require either IDs or query
-	if len(c.IDs) == 0 && c.Query == "" {
-		return fmt.Errorf("provide message ID(s) or use --query to match messages")
+	if len(ids) == 0 && c.Query == "" {
+		return fmt.Errorf("provide message ID(s), --ids-file, or use --query to match messages")
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -694,7 +1273,13 @@ func (c *MailFlagCmd) Run(ctx *Context) error {
 		mailbox = ctx.Config.Defaults.Mailbox
 	}
 
-	ids := c.IDs
+	mailbox, ids, err = client.ResolveRefs(mailbox, ids)
+	if err != nil {
+		return err
+	}
+	if ids, err = client.ExpandIDs(mailbox, ids); err != nil {
+		return err
+	}
 
 	// If query is provided, search for matching messages
 	if c.Query != "" {
@@ -718,6 +1303,10 @@ func (c *MailFlagCmd) Run(ctx *Context) error {
 		ctx.Formatter.Verbosef("Query matched %d message(s)", len(ids))
 	}
 
+	if inspect {
+		return printFlags(ctx, client, mailbox, ids)
+	}
+
 	if err := client.SetFlagsMultiple(mailbox, ids, c.Read, c.Unread, c.Star, c.Unstar); err != nil {
 		return err
 	}
@@ -752,9 +1341,68 @@ func (c *MailFlagCmd) Run(ctx *Context) error {
 	return nil
 }
 
+// messageFlags is one message in the output of mail flag without changes.
+type messageFlags struct {
+	ID      uint32   `json:"id"`
+	UID     uint32   `json:"uid"`
+	Ref     string   `json:"ref"`
+	Subject string   `json:"subject"`
+	Flags   []string `json:"flags"`
+	Status  []string `json:"status"`
+}
+
+// printFlags shows the flags and keywords that ids currently carry.
+func printFlags(ctx *Context, client *imap.Client, mailbox string, ids []string) error {
+	messages, err := client.GetFlags(mailbox, ids)
+	if err != nil {
+		return err
+	}
+
+	result := make([]messageFlags, len(messages))
+	for i, msg := range messages {
+		flags := msg.Flags
+		if flags == nil {
+			flags = []string{}
+		}
+		result[i] = messageFlags{ID: msg.SeqNum, UID: msg.UID, Ref: msg.Ref, Subject: msg.Subject, Flags: flags, Status: imap.MessageStatus(flags)}
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"mailbox":  mailbox,
+			"count":    len(result),
+			"messages": result,
+		})
+	}
+
+	table := ctx.Formatter.NewTable("ID", "UID", "FLAGS", "SUBJECT")
+	for _, m := range result {
+		flags := strings.Join(m.Flags, " ")
+		if flags == "" {
+			flags = "-"
+		}
+		subject := safetext.SanitizeForTerminal(m.Subject)
+		if len(subject) > 50 {
+			subject = subject[:47] + "..."
+		}
+		table.AddRow(fmt.Sprintf("%d", m.ID), fmt.Sprintf("%d", m.UID), safetext.SanitizeForTerminal(flags), subject)
+	}
+	table.Flush()
+	return nil
+}
+
 func (c *MailSearchCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
+	}
+
+	if c.RawSearch != "" {
+		if c.hasFilters() {
+			return fmt.Errorf("--raw-search cannot be combined with a query or filter flags")
+		}
+		if err := imap.ValidateRawSearch(c.RawSearch); err != nil {
+			return fmt.Errorf("invalid --raw-search: %w", err)
+		}
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -772,6 +1420,8 @@ func (c *MailSearchCmd) Run(ctx *Context) error {
 		Query:          c.Query,
 		From:           c.From,
 		To:             c.To,
+		Cc:             c.Cc,
+		Bcc:            c.Bcc,
 		Subject:        c.Subject,
 		Body:           c.Body,
 		Since:          c.Since,
@@ -781,28 +1431,53 @@ func (c *MailSearchCmd) Run(ctx *Context) error {
 		SmallerThan:    parseSize(c.SmallerThan),
 		UseOr:          c.Or,
 		Negate:         c.Not,
-	}
-
-	messages, err := client.Search(c.Mailbox, opts)
+		NotFrom:        c.NotFrom,
+		NotTo:          c.NotTo,
+		NotSubject:     c.NotSubject,
+		NotBody:        c.NotBody,
+		Flagged:        triState(c.Starred, c.Unstarred),
+		Seen:           triState(c.Read, c.Unread),
+		Answered:       triState(c.Answered, c.Unanswered),
+		Raw:            c.RawSearch,
+		Limit:          c.Limit,
+		Offset:         pageOffset(c.Offset, c.Page, c.Limit),
+	}
+
+	messages, total, err := client.Search(c.Mailbox, opts)
 	if err != nil {
 		return err
 	}
 
 	if ctx.Formatter.JSON {
-		return ctx.Formatter.PrintJSON(map[string]interface{}{
+		result := map[string]interface{}{
 			"query":    c.Query,
 			"mailbox":  c.Mailbox,
 			"count":    len(messages),
+			"total":    total,
+			"offset":   opts.Offset,
+			"limit":    c.Limit,
 			"messages": messages,
-		})
+		}
+		if c.Page > 0 {
+			result["page"] = c.Page
+		}
+		return ctx.Formatter.PrintJSON(result)
 	}
 
 	if len(messages) == 0 {
+		if total > 0 {
+			fmt.Printf("Found %d message(s), none at offset %d.\n", total, opts.Offset)
+			return nil
+		}
 		fmt.Println("No messages found.")
 		return nil
 	}
 
-	fmt.Printf("Found %d message(s):\n\n", len(messages))
+	if len(messages) < total {
+		fmt.Printf("Found %d message(s), showing %d-%d:\n\n", total, opts.Offset+1, opts.Offset+len(messages))
+	} else {
+		fmt.Printf("Found %d message(s):\n\n", total)
+	}
 
 	table := ctx.Formatter.NewTable("ID", "FLAGS", "FROM", "SUBJECT", "DATE")
 	for _, msg := range messages {
@@ -832,7 +1507,7 @@ func (c *MailSearchCmd) Run(ctx *Context) error {
 			flags,
 			from,
 			subject,
-			msg.Date,
+			ctx.displayDate(msg.DateISO, msg.Date),
 		)
 	}
 	table.Flush()
@@ -840,14 +1515,38 @@ func (c *MailSearchCmd) Run(ctx *Context) error {
 	return nil
 }
 
+// hasFilters reports whether a query or any structured filter flag is set.
+func (c *MailSearchCmd) hasFilters() bool {
+	return c.Query != "" || c.From != "" || c.To != "" || c.Cc != "" || c.Bcc != "" || c.Subject != "" || c.Body != "" ||
+		c.Since != "" || c.Before != "" || c.HasAttachments || c.LargerThan != "" ||
+		c.SmallerThan != "" || c.Or || c.Not || c.NotFrom != "" || c.NotTo != "" ||
+		c.NotSubject != "" || c.NotBody != "" || c.Starred || c.Unstarred ||
+		c.Unread || c.Read || c.Answered || c.Unanswered
+}
+
+// triState maps a pair of mutually exclusive flags to a filter: true when
+// yes is set, false when no is set, nil when neither is.
+func triState(yes, no bool) *bool {
+	switch {
+	case yes:
+		return &yes
+	case no:
+		v := false
+		return &v
+	default:
+		return nil
+	}
+}
+
 func (c *MailReplyCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
-	// Check idempotency key
-	if c.IdempotencyKey != "" {
-		used, err := config.CheckIdempotencyKey(c.IdempotencyKey)
+	// Claim idempotency key; it is released again if the send does not happen
+	sent := false
+	if c.IdempotencyKey != "" && !c.DryRun {
+		used, err := config.ClaimIdempotencyKey(c.IdempotencyKey)
 		if err != nil {
 			return fmt.Errorf("idempotency check failed: %w", err)
 		}
@@ -863,6 +1562,13 @@ func (c *MailReplyCmd) Run(ctx *Context) error {
 			fmt.Println("Reply already sent (idempotency key matched).")
 			return nil
 		}
+		defer func() {
+			if !sent {
+				if err := config.ReleaseIdempotencyKey(c.IdempotencyKey); err != nil {
+					ctx.Formatter.Verbosef("Warning: failed to release idempotency key: %v", err)
+				}
+			}
+		}()
 	}
 
 	// Fetch original message
@@ -876,7 +1582,11 @@ func (c *MailReplyCmd) Run(ctx *Context) error {
 	}
 	defer client.Close()
 
-	msg, err := client.GetMessage(ctx.Config.Defaults.Mailbox, c.ID)
+	mailbox, id, err := originalMessageRef(ctx, client, c.Mailbox, c.ID)
+	if err != nil {
+		return err
+	}
+	msg, err := client.GetMessage(mailbox, id)
 	if err != nil {
 		return err
 	}
@@ -925,7 +1635,10 @@ func (c *MailReplyCmd) Run(ctx *Context) error {
 	quotedBody := strings.Join(quotedLines, "\n")
 
 	// Construct full body with reply text
-	body := c.Body
+	body, err := bodyOrFile(c.Body, c.BodyFile)
+	if err != nil {
+		return err
+	}
 	if body == "" {
 		// Read from stdin if available
 		stat, _ := os.Stdin.Stat()
@@ -940,8 +1653,10 @@ func (c *MailReplyCmd) Run(ctx *Context) error {
 	}
 
 	if body == "" {
-		return fmt.Errorf("no reply body provided - use --body or pipe via stdin")
+		return fmt.Errorf("no reply body provided - use --body, --body-file, or pipe via stdin")
 	}
+	// The signature goes after the reply text, above the quoted original
+	body = appendSignature(body, ctx.signature(c.Signature, c.NoSignature))
 
 	fullBody := body + "\n\nOn " + msg.Date + ", " + msg.From + " wrote:\n" + quotedBody
 
@@ -951,13 +1666,6 @@ func (c *MailReplyCmd) Run(ctx *Context) error {
 		references = msg.MessageID
 	}
 
-	password, err := ctx.Config.GetPassword()
-	if err != nil {
-		return err
-	}
-
-	smtpClient := smtp.NewClient(ctx.Config, password)
-
 	replyMsg := &smtp.Message{
 		From:        ctx.Config.Bridge.Email,
 		To:          recipients,
@@ -967,30 +1675,51 @@ func (c *MailReplyCmd) Run(ctx *Context) error {
 		Attachments: c.Attach,
 		InReplyTo:   msg.MessageID,
 		References:  references,
+
+		MaxAttachmentSize: ctx.maxAttachmentSize(c.Force),
+	}
+
+	if c.DryRun {
+		return printDryRun(ctx, replyMsg)
+	}
+
+	password, err := ctx.Config.GetPassword()
+	if err != nil {
+		return err
 	}
 
+	smtpClient := smtp.NewClient(ctx.Config, password)
+
 	ctx.Formatter.Verbosef("Sending reply to %s...", strings.Join(recipients, ", "))
 
 	if err := smtpClient.Send(replyMsg); err != nil {
-		return err
+		return withForceHint(err)
 	}
 
-	// Record idempotency key after successful send
-	if c.IdempotencyKey != "" {
-		if err := config.RecordIdempotencyKey(c.IdempotencyKey); err != nil {
-			ctx.Formatter.Verbosef("Warning: failed to record idempotency key: %v", err)
+	// Keep the idempotency key claimed now that the message is out
+	sent = true
+
+	// Flag the original by UID, since sequence numbers may have shifted
+	// while sending. The reply is already out, so a failure only warns.
+	markedAnswered := false
+	if !c.NoMarkAnswered {
+		if err := client.MarkAnswered(mailbox, []string{fmt.Sprintf("uid:%d", msg.UID)}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: reply sent but the original was not marked as answered: %v\n", err)
+		} else {
+			markedAnswered = true
 		}
 	}
 
 	if ctx.Formatter.JSON {
 		result := map[string]interface{}{
-			"success":     true,
-			"message":     "Reply sent successfully",
-			"to":          recipients,
-			"cc":          ccRecipients,
-			"subject":     subject,
-			"in_reply_to": msg.MessageID,
-			"reply_all":   c.All,
+			"success":         true,
+			"message":         "Reply sent successfully",
+			"to":              recipients,
+			"cc":              ccRecipients,
+			"subject":         subject,
+			"in_reply_to":     msg.MessageID,
+			"reply_all":       c.All,
+			"marked_answered": markedAnswered,
 		}
 		if c.IdempotencyKey != "" {
 			result["idempotency_key"] = c.IdempotencyKey
@@ -1002,14 +1731,29 @@ func (c *MailReplyCmd) Run(ctx *Context) error {
 	return nil
 }
 
+// originalMessageRef resolves the message a reply or forward is based on:
+// id in mailbox, which defaults to defaults.mailbox, or the mailbox a
+// ref: token names.
+func originalMessageRef(ctx *Context, client *imap.Client, mailbox, id string) (string, string, error) {
+	if mailbox == "" {
+		mailbox = ctx.Config.Defaults.Mailbox
+	}
+	mailbox, ids, err := client.ResolveRefs(mailbox, []string{id})
+	if err != nil {
+		return "", "", err
+	}
+	return mailbox, ids[0], nil
+}
+
 func (c *MailForwardCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
-	// Check idempotency key
-	if c.IdempotencyKey != "" {
-		used, err := config.CheckIdempotencyKey(c.IdempotencyKey)
+	// Claim idempotency key; it is released again if the send does not happen
+	sent := false
+	if c.IdempotencyKey != "" && !c.DryRun {
+		used, err := config.ClaimIdempotencyKey(c.IdempotencyKey)
 		if err != nil {
 			return fmt.Errorf("idempotency check failed: %w", err)
 		}
@@ -1025,6 +1769,22 @@ func (c *MailForwardCmd) Run(ctx *Context) error {
 			fmt.Println("Forward already sent (idempotency key matched).")
 			return nil
 		}
+		defer func() {
+			if !sent {
+				if err := config.ReleaseIdempotencyKey(c.IdempotencyKey); err != nil {
+					ctx.Formatter.Verbosef("Warning: failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+	}
+
+	// Expand contact names into addresses
+	to, err := resolveRecipients(c.To)
+	if err != nil {
+		return err
+	}
+	if err := smtp.ValidateAddresses(to); err != nil {
+		return err
 	}
 
 	// Fetch original message
@@ -1038,7 +1798,11 @@ func (c *MailForwardCmd) Run(ctx *Context) error {
 	}
 	defer client.Close()
 
-	msg, err := client.GetMessage(ctx.Config.Defaults.Mailbox, c.ID)
+	mailbox, id, err := originalMessageRef(ctx, client, c.Mailbox, c.ID)
+	if err != nil {
+		return err
+	}
+	msg, err := client.GetMessage(mailbox, id)
 	if err != nil {
 		return err
 	}
@@ -1049,21 +1813,6 @@ func (c *MailForwardCmd) Run(ctx *Context) error {
 		subject = "Fwd: " + subject
 	}
 
-	// Get the body text from original message
-	textBody, htmlBody := parseMessageBody(msg.RawBody)
-	originalBody := textBody
-	if originalBody == "" && htmlBody != "" {
-		originalBody = htmlToText(htmlBody)
-	}
-
-	// Build forwarded message body
-	forwardHeader := "---------- Forwarded message ----------\n"
-	forwardHeader += "From: " + msg.From + "\n"
-	forwardHeader += "Date: " + msg.Date + "\n"
-	forwardHeader += "Subject: " + msg.Subject + "\n"
-	forwardHeader += "To: " + strings.Join(msg.To, ", ") + "\n"
-	forwardHeader += "\n"
-
 	// Add user's message if provided
 	body := c.Body
 	if body == "" {
@@ -1079,11 +1828,62 @@ func (c *MailForwardCmd) Run(ctx *Context) error {
 		}
 	}
 
+	// Either attach the original untouched, or quote its text in the body
 	var fullBody string
-	if body != "" {
-		fullBody = body + "\n\n" + forwardHeader + originalBody
+	var forwarded []smtp.Attachment
+	if c.AsAttachment {
+		if len(msg.RawBody) == 0 {
+			return fmt.Errorf("message %s has no content to attach", c.ID)
+		}
+		fullBody = body
+		forwarded = []smtp.Attachment{{
+			Filename:    "forwarded.eml",
+			ContentType: "message/rfc822",
+			Data:        msg.RawBody,
+		}}
 	} else {
-		fullBody = forwardHeader + originalBody
+		// Get the body text from original message
+		textBody, htmlBody := parseMessageBody(msg.RawBody)
+		originalBody := textBody
+		if originalBody == "" && htmlBody != "" {
+			originalBody = htmlToText(htmlBody)
+		}
+
+		// Build forwarded message body
+		forwardHeader := "---------- Forwarded message ----------\n"
+		forwardHeader += "From: " + msg.From + "\n"
+		forwardHeader += "Date: " + msg.Date + "\n"
+		forwardHeader += "Subject: " + msg.Subject + "\n"
+		forwardHeader += "To: " + strings.Join(msg.To, ", ") + "\n"
+		forwardHeader += "\n"
+
+		if body != "" {
+			fullBody = body + "\n\n" + forwardHeader + originalBody
+		} else {
+			fullBody = forwardHeader + originalBody
+		}
+
+		if !c.NoAttachments {
+			var total int64
+			forwarded, total = forwardedAttachments(msg.RawBody)
+			if total > forwardAttachmentWarnSize {
+				fmt.Fprintf(os.Stderr, "Warning: original attachments total %s; the message may exceed the server's size limit (use --no-attachments to leave them out)\n", formatSize(total))
+			}
+		}
+	}
+
+	fwdMsg := &smtp.Message{
+		From:              ctx.Config.Bridge.Email,
+		To:                to,
+		Subject:           subject,
+		Body:              fullBody,
+		Attachments:       c.Attach,
+		AttachmentData:    forwarded,
+		MaxAttachmentSize: ctx.maxAttachmentSize(c.Force),
+	}
+
+	if c.DryRun {
+		return printDryRun(ctx, fwdMsg)
 	}
 
 	password, err := ctx.Config.GetPassword()
@@ -1093,35 +1893,27 @@ func (c *MailForwardCmd) Run(ctx *Context) error {
 
 	smtpClient := smtp.NewClient(ctx.Config, password)
 
-	fwdMsg := &smtp.Message{
-		From:        ctx.Config.Bridge.Email,
-		To:          c.To,
-		Subject:     subject,
-		Body:        fullBody,
-		Attachments: c.Attach,
-	}
-
-	ctx.Formatter.Verbosef("Forwarding email to %s...", strings.Join(c.To, ", "))
+	ctx.Formatter.Verbosef("Forwarding email to %s...", strings.Join(to, ", "))
 
 	if err := smtpClient.Send(fwdMsg); err != nil {
-		return err
+		return withForceHint(err)
 	}
 
-	// Record idempotency key after successful send
-	if c.IdempotencyKey != "" {
-		if err := config.RecordIdempotencyKey(c.IdempotencyKey); err != nil {
-			ctx.Formatter.Verbosef("Warning: failed to record idempotency key: %v", err)
-		}
-	}
+	// Keep the idempotency key claimed now that the message is out
+	sent = true
 
 	if ctx.Formatter.JSON {
 		result := map[string]interface{}{
 			"success":          true,
 			"message":          "Email forwarded successfully",
-			"to":               c.To,
+			"to":               to,
 			"subject":          subject,
 			"original_from":    msg.From,
 			"original_subject": msg.Subject,
+			"as_attachment":    c.AsAttachment,
+		}
+		if !c.AsAttachment {
+			result["attachments_forwarded"] = len(forwarded)
 		}
 		if c.IdempotencyKey != "" {
 			result["idempotency_key"] = c.IdempotencyKey
@@ -1133,6 +1925,34 @@ func (c *MailForwardCmd) Run(ctx *Context) error {
 	return nil
 }
 
+// forwardAttachmentWarnSize is the combined size of forwarded attachments
+// above which mail forward warns; Proton rejects messages over 25 MB.
+const forwardAttachmentWarnSize = 25 << 20
+
+// forwardedAttachments decodes the attachments of a raw message so they can
+// be sent again, and returns their combined size.
+func forwardedAttachments(rawBody []byte) ([]smtp.Attachment, int64) {
+	var result []smtp.Attachment
+	var total int64
+	for _, att := range parseAttachments(rawBody) {
+		filename := filepath.Base(att.Filename)
+		if att.Filename == "" || filename == "." || filename == string(filepath.Separator) {
+			filename = fmt.Sprintf("attachment_%d", att.Index)
+		}
+
+		// Re-render the type so only the media type and its parameters are
+		// copied from the original header
+		contentType := ""
+		if mediaType, params, err := mime.ParseMediaType(att.ContentType); err == nil {
+			contentType = mime.FormatMediaType(mediaType, params)
+		}
+
+		result = append(result, smtp.Attachment{Filename: filename, ContentType: contentType, Data: att.Data})
+		total += att.Size
+	}
+	return result, total
+}
+
 // formatSize returns a human-readable size string
 func formatSize(bytes int64) string {
 	const unit = 1024
@@ -1160,7 +1980,7 @@ func extractEmailAddress(addr string) string {
 
 func parseMessageBody(rawBody []byte) (textBody, htmlBody string) {
 	reader, err := mail.CreateReader(bytes.NewReader(rawBody))
-	if err != nil {
+	if err != nil && !message.IsUnknownCharset(err) {
 		// Fallback: treat as plain text
 		return string(rawBody), ""
 	}
@@ -1177,20 +1997,29 @@ func parseMessageBody(rawBody []byte) (textBody, htmlBody string) {
 		if err == io.EOF {
 			break
 		}
-		if err != nil {
+		// An unknown charset still yields a usable part with the raw bytes
+		if err != nil && !message.IsUnknownCharset(err) {
 			break
 		}
 		foundParts = true
 
+		// NextPart walks nested multiparts (e.g. multipart/alternative
+		// inside multipart/mixed) depth-first, so the first inline text
+		// parts are the message body. Text files attached to the message
+		// must not replace it.
+		if _, ok := part.Header.(*mail.AttachmentHeader); ok {
+			continue
+		}
+
 		partContentType := part.Header.Get("Content-Type")
 
 		switch {
-		case strings.HasPrefix(partContentType, "text/plain"):
+		case strings.HasPrefix(partContentType, "text/plain") && textBody == "":
 			body, err := io.ReadAll(part.Body)
 			if err == nil {
 				textBody = string(body)
 			}
-		case strings.HasPrefix(partContentType, "text/html"):
+		case strings.HasPrefix(partContentType, "text/html") && htmlBody == "":
 			body, err := io.ReadAll(part.Body)
 			if err == nil {
 				htmlBody = string(body)
@@ -1219,15 +2048,81 @@ func parseMessageBody(rawBody []byte) (textBody, htmlBody string) {
 		}
 	}
 
+	if htmlBody != "" && isStubText(textBody, htmlBody) {
+		textBody = ""
+	}
 	return textBody, htmlBody
 }
 
-// parseQueryString parses a query string like "from:user@example.com subject:test"
-// into from, subject, and body components.
-// Supports from:, subject:, and body: prefixes. Unprefixed terms search the body.
-func parseQueryString(query string) (from, subject, body string) {
-	// Simple parser for key:value pairs
-	var bodyParts []string
+// stubTextLength is the length below which a text part that has an HTML
+// alternative is checked for being a placeholder.
+const stubTextLength = 64
+
+// isStubText reports whether a text/plain part only stands in for the HTML
+// one: it is blank, or a short line such as "View this email in your
+// browser" while the HTML carries more than twice as much text. Callers
+// then render the HTML instead.
+func isStubText(textBody, htmlBody string) bool {
+	text := strings.TrimSpace(textBody)
+	if text == "" {
+		return true
+	}
+	if utf8.RuneCountInString(text) >= stubTextLength {
+		return false
+	}
+	html := strings.TrimSpace(htmlToText(htmlBody))
+	return utf8.RuneCountInString(html) > 2*utf8.RuneCountInString(text)
+}
+
+// mergeIDsFile returns ids followed by the message IDs listed one per line
+// in path, or on stdin when path is "-". Blank lines and IDs already seen
+// are skipped, so the result can go straight into one IMAP command.
+func mergeIDsFile(ids []string, path string) ([]string, error) {
+	if path == "" {
+		return ids, nil
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read IDs file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	seen := make(map[string]bool, len(ids))
+	merged := make([]string, 0, len(ids))
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			merged = append(merged, id)
+		}
+	}
+	for _, id := range ids {
+		add(id)
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			add(id)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read IDs file: %w", err)
+	}
+	return merged, nil
+}
+
+// parseQueryToSearchOptions parses a query string like
+// "from:user@example.com subject:!lunch" into SearchOptions.
+// Supports from:, subject:, and body: prefixes; a "!" after the colon negates
+// that term. Unprefixed terms search the body.
+func parseQueryToSearchOptions(query string) imap.SearchOptions {
+	var opts imap.SearchOptions
+	var bodyParts, notBodyParts []string
 
 	// Handle quoted strings and key:value pairs
 	parts := splitQueryParts(query)
@@ -1238,27 +2133,36 @@ func parseQueryString(query string) (from, subject, body string) {
 			continue
 		}
 
-		if strings.HasPrefix(strings.ToLower(part), "from:") {
-			from = strings.TrimPrefix(part, "from:")
-			from = strings.TrimPrefix(from, "FROM:")
-			from = strings.Trim(from, "\"")
-		} else if strings.HasPrefix(strings.ToLower(part), "subject:") {
-			subject = strings.TrimPrefix(part, "subject:")
-			subject = strings.TrimPrefix(subject, "SUBJECT:")
-			subject = strings.Trim(subject, "\"")
-		} else if strings.HasPrefix(strings.ToLower(part), "body:") {
-			bodyTerm := strings.TrimPrefix(part, "body:")
-			bodyTerm = strings.TrimPrefix(bodyTerm, "BODY:")
-			bodyTerm = strings.Trim(bodyTerm, "\"")
-			bodyParts = append(bodyParts, bodyTerm)
-		} else {
+		key, value, hasKey := strings.Cut(part, ":")
+		key = strings.ToLower(key)
+		if !hasKey || (key != "from" && key != "subject" && key != "body") {
 			// Unprefixed terms are body searches
 			bodyParts = append(bodyParts, strings.Trim(part, "\""))
+			continue
 		}
-	}
 
-	body = strings.Join(bodyParts, " ")
-	return from, subject, body
+		negate := strings.HasPrefix(value, "!")
+		value = strings.Trim(strings.TrimPrefix(value, "!"), "\"")
+
+		switch {
+		case key == "from" && negate:
+			opts.NotFrom = value
+		case key == "from":
+			opts.From = value
+		case key == "subject" && negate:
+			opts.NotSubject = value
+		case key == "subject":
+			opts.Subject = value
+		case key == "body" && negate:
+			notBodyParts = append(notBodyParts, value)
+		default:
+			bodyParts = append(bodyParts, value)
+		}
+	}
+
+	opts.Query = strings.Join(bodyParts, " ")
+	opts.NotBody = strings.Join(notBodyParts, " ")
+	return opts
 }
 
 // splitQueryParts splits a query string respecting quoted strings.
@@ -1290,46 +2194,180 @@ func splitQueryParts(query string) []string {
 	return parts
 }
 
+var (
+	htmlStyleRegex      = regexp.MustCompile(`(?is)<style[^>]*>.*?</style>`)
+	htmlScriptRegex     = regexp.MustCompile(`(?is)<script[^>]*>.*?</script>`)
+	htmlCellRegex       = regexp.MustCompile(`(?i)</t[dh]>\s*<t[dh](\s[^>]*)?>`)
+	htmlTableEndRegex   = regexp.MustCompile(`(?i)</table>`)
+	htmlListItemRegex   = regexp.MustCompile(`(?i)<li(\s[^>]*)?>`)
+	htmlBlockEndRegex   = regexp.MustCompile(`(?i)</(p|div|tr|ul|ol|h[1-6])>`)
+	htmlBrRegex         = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlLinkRegex       = regexp.MustCompile(`(?i)<a[^>]+href=["']([^"']+)["'][^>]*>([^<]*)</a>`)
+	htmlTagRegex        = regexp.MustCompile(`<[^>]+>`)
+	textSpacesRegex     = regexp.MustCompile(`[ \t]+`)
+	textLineEdgesRegex  = regexp.MustCompile(`(?m)^ +| +$`)
+	textBlankLinesRegex = regexp.MustCompile(`\n{3,}`)
+)
+
 // htmlToText converts HTML to plain text by stripping tags and decoding entities
 func htmlToText(htmlContent string) string {
 	// Remove style and script blocks
-	reStyle := regexp.MustCompile(`(?is)<style[^>]*>.*?</style>`)
-	reScript := regexp.MustCompile(`(?is)<script[^>]*>.*?</script>`)
-	text := reStyle.ReplaceAllString(htmlContent, "")
-	text = reScript.ReplaceAllString(text, "")
+	text := htmlStyleRegex.ReplaceAllString(htmlContent, "")
+	text = htmlScriptRegex.ReplaceAllString(text, "")
+
+	// Separate adjacent table cells, and leave a blank line after tables
+	text = htmlCellRegex.ReplaceAllString(text, " | ")
+	text = htmlTableEndRegex.ReplaceAllString(text, "\n\n")
+
+	// Prefix list items with a bullet
+	text = htmlListItemRegex.ReplaceAllString(text, "\n- ")
 
 	// Replace common block elements with newlines
-	reBlock := regexp.MustCompile(`(?i)</(p|div|tr|li|h[1-6])>`)
-	text = reBlock.ReplaceAllString(text, "\n")
+	text = htmlBlockEndRegex.ReplaceAllString(text, "\n")
 
 	// Replace <br> with newlines
-	reBr := regexp.MustCompile(`(?i)<br\s*/?>`)
-	text = reBr.ReplaceAllString(text, "\n")
+	text = htmlBrRegex.ReplaceAllString(text, "\n")
 
 	// Extract link URLs
-	reLink := regexp.MustCompile(`(?i)<a[^>]+href=["']([^"']+)["'][^>]*>([^<]*)</a>`)
-	text = reLink.ReplaceAllString(text, "$2 [$1]")
+	text = htmlLinkRegex.ReplaceAllString(text, "$2 [$1]")
 
 	// Remove all remaining HTML tags
-	reTags := regexp.MustCompile(`<[^>]+>`)
-	text = reTags.ReplaceAllString(text, "")
+	text = htmlTagRegex.ReplaceAllString(text, "")
 
 	// Decode HTML entities
 	text = html.UnescapeString(text)
 
 	// Clean up whitespace
-	reSpaces := regexp.MustCompile(`[ \t]+`)
-	text = reSpaces.ReplaceAllString(text, " ")
+	text = textSpacesRegex.ReplaceAllString(text, " ")
+	text = textLineEdgesRegex.ReplaceAllString(text, "")
+	text = textBlankLinesRegex.ReplaceAllString(text, "\n\n")
+
+	return strings.TrimSpace(text)
+}
+
+// wrapText word-wraps each line of s to at most width columns, preserving
+// existing line breaks. A width of 0 or less disables wrapping.
+func wrapText(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = wrapLine(line, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapLine word-wraps a single line. Words longer than width (typically
+// URLs) are kept whole on their own line rather than being split.
+func wrapLine(line string, width int) string {
+	if utf8.RuneCountInString(line) <= width {
+		return line
+	}
+
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return line
+	}
+
+	var b strings.Builder
+	lineLen := 0
+	for _, word := range words {
+		wordLen := utf8.RuneCountInString(word)
+		if lineLen > 0 && lineLen+1+wordLen > width {
+			b.WriteString("\n")
+			lineLen = 0
+		}
+		if lineLen > 0 {
+			b.WriteString(" ")
+			lineLen++
+		}
+		b.WriteString(word)
+		lineLen += wordLen
+	}
+	return b.String()
+}
+
+var (
+	quoteAttributionRegex = regexp.MustCompile(`(?i)^On\s.+wrote:\s*$`)
+	quoteSeparatorRegex   = regexp.MustCompile(`(?i)^-{2,}\s*(Forwarded message|Original Message)\s*-{2,}$`)
+)
+
+// stripQuotedText removes quoted history from a message body so only the
+// latest message remains. Lines starting with ">" are dropped, and
+// everything from an "On ... wrote:" attribution or a forwarded/original
+// message separator onwards is cut.
+func stripQuotedText(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	var kept []string
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+
+		if quoteSeparatorRegex.MatchString(trimmed) || quoteAttributionRegex.MatchString(trimmed) {
+			break
+		}
+
+		// Mail clients often wrap long attributions across two lines
+		if strings.HasPrefix(trimmed, "On ") && i+1 < len(lines) {
+			joined := trimmed + " " + strings.TrimSpace(lines[i+1])
+			if quoteAttributionRegex.MatchString(joined) {
+				break
+			}
+		}
+
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
 
-	reNewlines := regexp.MustCompile(`\n{3,}`)
-	text = reNewlines.ReplaceAllString(text, "\n\n")
+		kept = append(kept, lines[i])
+	}
+
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// denoiseBody reduces a body to the text its sender wrote: quoted history
+// and the signature are removed, trailing spaces are trimmed and runs of
+// blank lines are collapsed.
+func denoiseBody(body string) string {
+	body = stripSignature(stripQuotedText(body))
+
+	var kept []string
+	blank := false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
 
-	return strings.TrimSpace(text)
+// threadRefs lists the Message-IDs a message refers to, oldest first and
+// without duplicates.
+func threadRefs(msg *imap.Message) []string {
+	refs := []string{}
+	seen := make(map[string]bool)
+	for _, id := range append(append([]string{}, msg.References...), msg.InReplyTo) {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		refs = append(refs, id)
+	}
+	return refs
 }
 
 func (c *MailDownloadCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -1359,18 +2397,15 @@ func (c *MailDownloadCmd) Run(ctx *Context) error {
 
 	attachment := attachments[c.Index]
 
-	// Determine output path — sanitize MIME filename to prevent path traversal (CWE-22)
+	// Determine output path — the MIME filename is attacker-controlled, so
+	// only its sanitized base name is used, inside --dir (CWE-22)
 	outPath := c.Out
 	if outPath == "" {
-		outPath = filepath.Base(attachment.Filename)
-		if outPath == "" || outPath == "." {
-			outPath = fmt.Sprintf("attachment_%d", c.Index)
-		}
+		outPath = filepath.Join(c.Dir, safeAttachmentFilename(attachment.Filename, c.Index))
 	}
 
-	// Write file
-	if err := os.WriteFile(outPath, attachment.Data, 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	if err := writeNewFile(outPath, attachment.Data, c.Force); err != nil {
+		return err
 	}
 
 	if ctx.Formatter.JSON {
@@ -1390,6 +2425,79 @@ func (c *MailDownloadCmd) Run(ctx *Context) error {
 	return nil
 }
 
+// safeAttachmentFilename reduces an attachment's filename to a plain file
+// name that cannot leave the target directory. Both / and \ count as
+// separators, control characters are dropped, and names that are empty or
+// only dots fall back to attachment_<index>.
+func safeAttachmentFilename(name string, index int) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = path.Base(name)
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if strings.Trim(name, ".") == "" || name == "/" {
+		return fmt.Sprintf("attachment_%d", index)
+	}
+	return name
+}
+
+// writeNewFile writes data to name, refusing to replace an existing file
+// unless force is set.
+func writeNewFile(name string, data []byte, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(name, flags, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s already exists - use --force to overwrite it", name)
+		}
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces name with data by writing a temporary file in
+// the same directory and renaming it, so readers see either the old
+// content or all of the new. Missing parent directories are created. The
+// file is readable only by the user, since it usually holds mail.
+func writeFileAtomic(name string, data []byte) error {
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(name)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
 func parseAttachments(rawBody []byte) []imap.Attachment {
 	var attachments []imap.Attachment
 	reader, err := mail.CreateReader(bytes.NewReader(rawBody))
@@ -1442,7 +2550,8 @@ func parseAttachments(rawBody []byte) []imap.Attachment {
 	return attachments
 }
 
-// parseSize parses size strings like "1M", "500K", "1G" into bytes
+// parseSize parses size strings like "1.5M", "500K", "2G" or a bare byte
+// count into bytes. Invalid input yields 0.
 func parseSize(s string) int64 {
 	if s == "" {
 		return 0
@@ -1488,26 +2597,18 @@ func parseSize(s string) int64 {
 		}
 	}
 
-	var value int64
-	fmt.Sscanf(s, "%d", &value)
-	return value * multiplier
-}
-
-// parseQueryToSearchOptions converts a query string to SearchOptions
-func parseQueryToSearchOptions(query string) imap.SearchOptions {
-	from, subject, body := parseQueryString(query)
-	return imap.SearchOptions{
-		Query:   body,
-		From:    from,
-		Subject: subject,
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || value < 0 {
+		return 0
 	}
+	return int64(math.Round(value * float64(multiplier)))
 }
 
 // Draft command handlers
 
 func (c *DraftListCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
 	limit := c.Limit
@@ -1525,19 +2626,28 @@ func (c *DraftListCmd) Run(ctx *Context) error {
 	}
 	defer client.Close()
 
-	ctx.Formatter.Verbosef("Fetching drafts...")
+	mailbox := draftsMailbox(c.Mailbox)
+	offset := pageOffset(c.Offset, c.Page, limit)
 
-	drafts, err := client.ListDrafts(limit)
+	ctx.Formatter.Verbosef("Fetching drafts from %s...", mailbox)
+
+	drafts, err := client.ListDrafts(mailbox, limit, offset)
 	if err != nil {
 		return err
 	}
 
 	if ctx.Formatter.JSON {
-		return ctx.Formatter.PrintJSON(map[string]interface{}{
-			"mailbox":  "Drafts",
+		result := map[string]interface{}{
+			"mailbox":  mailbox,
 			"count":    len(drafts),
 			"messages": drafts,
-		})
+			"offset":   offset,
+			"limit":    limit,
+		}
+		if c.Page > 0 {
+			result["page"] = c.Page
+		}
+		return ctx.Formatter.PrintJSON(result)
 	}
 
 	if len(drafts) == 0 {
@@ -1552,7 +2662,7 @@ func (c *DraftListCmd) Run(ctx *Context) error {
 			fmt.Sprintf("%d", d.SeqNum),
 			d.From, // From field contains the draft's To header
 			truncate(d.Subject, 40),
-			d.Date,
+			ctx.displayDate(d.DateISO, d.Date),
 		)
 	}
 
@@ -1560,12 +2670,40 @@ func (c *DraftListCmd) Run(ctx *Context) error {
 	return nil
 }
 
+// draftsMailbox returns the drafts mailbox given with -m, or Proton's.
+func draftsMailbox(mailbox string) string {
+	if mailbox == "" {
+		return imap.DraftsMailbox
+	}
+	return mailbox
+}
+
+// resolveDraftRef resolves the draft ID given to a draft command, which may
+// be a ref: token pointing into another mailbox than -m.
+func resolveDraftRef(client *imap.Client, mailbox, id string) (string, string, error) {
+	mailbox, ids, err := client.ResolveRefs(draftsMailbox(mailbox), []string{id})
+	if err != nil {
+		return "", "", err
+	}
+	return mailbox, ids[0], nil
+}
+
+// composeDraft builds a draft as a complete MIME message, using the same
+// composer as mail send so that attachments survive in the web UI.
+func composeDraft(ctx *Context, msg *smtp.Message) ([]byte, error) {
+	msg.From = ctx.Config.Bridge.Email
+	return smtp.NewClient(ctx.Config, "").Compose(msg)
+}
+
 func (c *DraftCreateCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
-	body := c.Body
+	body, err := bodyOrFile(c.Body, c.BodyFile)
+	if err != nil {
+		return err
+	}
 	if body == "" {
 		// Read from stdin if no body provided
 		stat, _ := os.Stdin.Stat()
@@ -1588,23 +2726,28 @@ func (c *DraftCreateCmd) Run(ctx *Context) error {
 	}
 	defer client.Close()
 
-	draft := &imap.Draft{
-		To:      c.To,
-		CC:      c.CC,
-		Subject: c.Subject,
-		Body:    body,
+	message, err := composeDraft(ctx, &smtp.Message{
+		To:          c.To,
+		CC:          c.CC,
+		Subject:     c.Subject,
+		Body:        body,
+		Attachments: c.Attach,
+	})
+	if err != nil {
+		return err
 	}
 
-	uid, err := client.CreateDraft(draft)
+	uid, err := client.AppendDraft(draftsMailbox(c.Mailbox), message)
 	if err != nil {
 		return err
 	}
 
 	if ctx.Formatter.JSON {
 		return ctx.Formatter.PrintJSON(map[string]interface{}{
-			"success": true,
-			"message": "Draft created",
-			"uid":     uid,
+			"success":     true,
+			"message":     "Draft created",
+			"uid":         uid,
+			"attachments": len(c.Attach),
 		})
 	}
 
@@ -1614,7 +2757,7 @@ func (c *DraftCreateCmd) Run(ctx *Context) error {
 
 func (c *DraftEditCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -1627,8 +2770,13 @@ func (c *DraftEditCmd) Run(ctx *Context) error {
 	}
 	defer client.Close()
 
+	mailbox, id, err := resolveDraftRef(client, c.Mailbox, c.ID)
+	if err != nil {
+		return err
+	}
+
 	// Get existing draft to merge with new values
-	existing, err := client.GetDraft(c.ID)
+	existing, err := client.GetDraft(mailbox, id)
 	if err != nil {
 		return fmt.Errorf("failed to get draft: %w", err)
 	}
@@ -1651,26 +2799,39 @@ func (c *DraftEditCmd) Run(ctx *Context) error {
 
 	body := c.Body
 	if body == "" {
-		body = existing.TextBody
+		body = draftBody(existing)
+	}
+
+	// --attach replaces the draft's attachments; without it they are kept
+	msg := &smtp.Message{
+		To:          to,
+		CC:          cc,
+		Subject:     subject,
+		Body:        body,
+		Attachments: c.Attach,
+	}
+	attachments := len(c.Attach)
+	if len(c.Attach) == 0 {
+		msg.AttachmentData, _ = forwardedAttachments(existing.RawBody)
+		attachments = len(msg.AttachmentData)
 	}
 
-	draft := &imap.Draft{
-		To:      to,
-		CC:      cc,
-		Subject: subject,
-		Body:    body,
+	message, err := composeDraft(ctx, msg)
+	if err != nil {
+		return err
 	}
 
-	uid, err := client.UpdateDraft(c.ID, draft)
+	uid, err := client.ReplaceDraft(mailbox, id, message)
 	if err != nil {
 		return err
 	}
 
 	if ctx.Formatter.JSON {
 		return ctx.Formatter.PrintJSON(map[string]interface{}{
-			"success": true,
-			"message": "Draft updated",
-			"uid":     uid,
+			"success":     true,
+			"message":     "Draft updated",
+			"uid":         uid,
+			"attachments": attachments,
 		})
 	}
 
@@ -1678,9 +2839,116 @@ func (c *DraftEditCmd) Run(ctx *Context) error {
 	return nil
 }
 
+// draftBody returns the text body of a stored draft without the line break
+// the composer adds after it, so editing a draft does not grow it.
+func draftBody(draft *imap.Message) string {
+	body, _ := parseMessageBody(draft.RawBody)
+	return strings.TrimRight(body, "\r\n")
+}
+
+// draftMessage rebuilds an outgoing message from a stored draft, keeping
+// its recipients, subject, body, threading headers and attachments.
+func draftMessage(ctx *Context, draft *imap.Message) (*smtp.Message, error) {
+	if len(draft.To) == 0 && len(draft.CC) == 0 {
+		return nil, fmt.Errorf("draft has no recipients - add them with 'pm-cli mail draft edit <id> --to'")
+	}
+
+	to := make([]string, len(draft.To))
+	for i, addr := range draft.To {
+		to[i] = extractEmailAddress(addr)
+	}
+	cc := make([]string, len(draft.CC))
+	for i, addr := range draft.CC {
+		cc[i] = extractEmailAddress(addr)
+	}
+	if err := smtp.ValidateAddresses(append(append([]string{}, to...), cc...)); err != nil {
+		return nil, err
+	}
+
+	attachments, _ := forwardedAttachments(draft.RawBody)
+
+	return &smtp.Message{
+		From:           ctx.Config.Bridge.Email,
+		To:             to,
+		CC:             cc,
+		Subject:        draft.Subject,
+		Body:           draftBody(draft),
+		AttachmentData: attachments,
+		InReplyTo:      draft.InReplyTo,
+		References:     strings.Join(threadRefs(draft), " "),
+	}, nil
+}
+
+func (c *DraftSendCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	mailbox, id, err := resolveDraftRef(client, c.Mailbox, c.ID)
+	if err != nil {
+		return err
+	}
+
+	draft, err := client.GetDraft(mailbox, id)
+	if err != nil {
+		return fmt.Errorf("failed to get draft: %w", err)
+	}
+
+	msg, err := draftMessage(ctx, draft)
+	if err != nil {
+		return err
+	}
+	msg.MaxAttachmentSize = ctx.maxAttachmentSize(c.Force)
+
+	password, err := ctx.Config.GetPassword()
+	if err != nil {
+		return err
+	}
+
+	ctx.Formatter.Verbosef("Sending draft to %s...", strings.Join(append(append([]string{}, msg.To...), msg.CC...), ", "))
+
+	if err := smtp.NewClient(ctx.Config, password).Send(msg); err != nil {
+		return withForceHint(err)
+	}
+
+	// The message is out, so a failed cleanup is only a warning
+	deleted := false
+	if !c.Keep {
+		if err := client.DeleteDraft(mailbox, []string{fmt.Sprintf("uid:%d", draft.UID)}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: draft sent but could not be deleted: %v\n", err)
+		} else {
+			deleted = true
+		}
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success":       true,
+			"message":       "Draft sent",
+			"to":            msg.To,
+			"subject":       msg.Subject,
+			"attachments":   len(msg.AttachmentData),
+			"draft_deleted": deleted,
+		})
+	}
+
+	ctx.Formatter.PrintSuccess("Draft sent")
+	return nil
+}
+
 func (c *DraftDeleteCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
 	if len(c.IDs) == 0 {
@@ -1697,7 +2965,11 @@ func (c *DraftDeleteCmd) Run(ctx *Context) error {
 	}
 	defer client.Close()
 
-	if err := client.DeleteDraft(c.IDs); err != nil {
+	mailbox, ids, err := client.ResolveRefs(draftsMailbox(c.Mailbox), c.IDs)
+	if err != nil {
+		return err
+	}
+	if err := client.DeleteDraft(mailbox, ids); err != nil {
 		return err
 	}
 
@@ -1721,9 +2993,46 @@ func truncate(s string, max int) string {
 	return s[:max-3] + "..."
 }
 
+// watchedMessage is a new message found by mail watch, with the mailbox it
+// arrived in.
+type watchedMessage struct {
+	Mailbox string
+	imap.MessageSummary
+}
+
+// watchMailboxes returns the --mailbox values with duplicates and empty
+// entries removed, in the order given.
+func (c *MailWatchCmd) watchMailboxes() []string {
+	var mailboxes []string
+	seen := make(map[string]bool)
+	for _, mailbox := range c.Mailbox {
+		mailbox = strings.TrimSpace(mailbox)
+		if mailbox == "" || seen[mailbox] {
+			continue
+		}
+		seen[mailbox] = true
+		mailboxes = append(mailboxes, mailbox)
+	}
+	return mailboxes
+}
+
 func (c *MailWatchCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
+	}
+
+	mailboxes := c.watchMailboxes()
+	if len(mailboxes) == 0 {
+		return fmt.Errorf("no mailbox to watch - use --mailbox")
+	}
+
+	var webhook *webhookSender
+	if c.Webhook != "" {
+		if err := validateWebhookURL(c.Webhook); err != nil {
+			return err
+		}
+		webhook = newWebhookSender(&http.Client{Timeout: 10 * time.Second}, c.Webhook, c.WebhookSecret)
+		defer webhook.Close(webhookDrainTimeout)
 	}
 
 	// Set up signal handling for graceful shutdown
@@ -1731,23 +3040,32 @@ func (c *MailWatchCmd) Run(ctx *Context) error {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(sigChan)
 
-	// Track seen UIDs
-	seenUIDs := make(map[uint32]bool)
-
-	// Initial population of seen UIDs
-	if err := c.populateSeenUIDs(ctx, seenUIDs); err != nil {
+	// The per-mailbox marks survive restarts, so mail that arrived while
+	// watch was not running is reported and older mail is not
+	state, err := watchstate.Load()
+	if err != nil {
+		return err
+	}
+	account := ctx.Config.Bridge.Email
+	if err := c.loadMarks(ctx, state, mailboxes); err != nil {
 		return fmt.Errorf("failed to get initial messages: %w", err)
 	}
+	saveWatchState(state)
 
-	ctx.Formatter.Verbosef("Watching %s for new messages (poll interval: %ds)", c.Mailbox, c.Interval)
+	watching := strings.Join(mailboxes, ", ")
+	ctx.Formatter.Verbosef("Watching %s for new messages (poll interval: %ds)", watching, c.Interval)
 
 	if !ctx.Formatter.JSON {
-		fmt.Printf("Watching %s for new messages (Ctrl+C to stop)...\n", c.Mailbox)
+		fmt.Printf("Watching %s for new messages (Ctrl+C to stop)...\n", watching)
 	}
 
 	ticker := time.NewTicker(time.Duration(c.Interval) * time.Second)
 	defer ticker.Stop()
 
+	// Check once right away to catch up on mail that arrived while down
+	check := make(chan struct{}, 1)
+	check <- struct{}{}
+
 	for {
 		select {
 		case <-sigChan:
@@ -1757,35 +3075,59 @@ func (c *MailWatchCmd) Run(ctx *Context) error {
 			return nil
 
 		case <-ticker.C:
-			newMessages, err := c.checkForNewMessages(ctx, seenUIDs)
+			select {
+			case check <- struct{}{}:
+			default:
+			}
+
+		case <-check:
+			// --timeout limits each poll, not how long watch runs
+			ctx.Config.StartDeadline()
+			newMessages, err := c.checkForNewMessages(ctx, state, mailboxes)
 			if err != nil {
-				ctx.Formatter.Verbosef("Error checking messages: %v", err)
+				ctx.Formatter.Verbosef("Error checking messages: %v", ctx.CheckTimeout(err))
 				continue
 			}
 
 			for _, msg := range newMessages {
+				// The mark moves past every new message, reported or not
+				advanceWatchMark(state, account, msg.Mailbox, msg.UID)
+
 				// Skip read messages if --unread is set
 				if c.Unread && msg.Seen {
 					continue
 				}
 
-				// Mark as seen for future polls
-				seenUIDs[msg.UID] = true
-
 				// Output the new message
 				if ctx.Formatter.JSON {
-					ctx.Formatter.PrintJSON(map[string]interface{}{
-						"event":   "new_message",
-						"mailbox": c.Mailbox,
-						"message": msg,
-					})
+					ctx.Formatter.PrintJSON(watchEvent(msg))
 				} else {
 					fmt.Printf("\n[NEW] %s\n", msg.Date)
+					if len(mailboxes) > 1 {
+						fmt.Printf("  Mailbox: %s\n", safetext.SanitizeForTerminal(msg.Mailbox))
+					}
 					fmt.Printf("  From:    %s\n", safetext.SanitizeForTerminal(msg.From))
 					fmt.Printf("  Subject: %s\n", safetext.SanitizeForTerminal(msg.Subject))
 					fmt.Printf("  ID:      %d\n", msg.SeqNum)
 				}
 
+				if c.Notify {
+					sendDesktopNotification(
+						"New mail from "+safetext.SanitizeHeaderValue(msg.From),
+						safetext.SanitizeHeaderValue(msg.Subject),
+					)
+				}
+
+				if webhook != nil {
+					webhook.Send(webhookPayload{
+						UID:     msg.UID,
+						From:    msg.From,
+						Subject: msg.Subject,
+						Date:    msg.Date,
+						Mailbox: msg.Mailbox,
+					})
+				}
+
 				// Execute command if specified
 				if c.Exec != "" {
 					c.executeCommand(ctx, msg)
@@ -1793,14 +3135,43 @@ func (c *MailWatchCmd) Run(ctx *Context) error {
 
 				// Exit after first message if --once is set
 				if c.Once {
+					saveWatchState(state)
 					return nil
 				}
 			}
+			saveWatchState(state)
 		}
 	}
 }
 
-func (c *MailWatchCmd) populateSeenUIDs(ctx *Context, seenUIDs map[uint32]bool) error {
+// watchEvent is the JSON object describing a new message, printed with
+// --json and piped to the --exec command.
+func watchEvent(msg watchedMessage) map[string]interface{} {
+	return map[string]interface{}{
+		"event":   "new_message",
+		"mailbox": msg.Mailbox,
+		"message": msg.MessageSummary,
+	}
+}
+
+// startMark picks the mark a mailbox is watched from. A stored mark is used
+// while the mailbox keeps its UIDVALIDITY; otherwise (first run, --reset,
+// or the server renumbered the mailbox) watching starts after the newest
+// existing message.
+func startMark(status *imap.MailboxState, stored watchstate.Mark, ok bool) watchstate.Mark {
+	if ok && stored.UIDValidity == status.UIDValidity && stored.LastUID < status.UIDNext {
+		return stored
+	}
+	mark := watchstate.Mark{UIDValidity: status.UIDValidity}
+	if status.UIDNext > 0 {
+		mark.LastUID = status.UIDNext - 1
+	}
+	return mark
+}
+
+// loadMarks sets the starting mark of every watched mailbox, dropping the
+// stored ones first when --reset is given.
+func (c *MailWatchCmd) loadMarks(ctx *Context, state *watchstate.Store, mailboxes []string) error {
 	client, err := imap.NewClient(ctx.Config)
 	if err != nil {
 		return err
@@ -1811,20 +3182,54 @@ func (c *MailWatchCmd) populateSeenUIDs(ctx *Context, seenUIDs map[uint32]bool)
 	}
 	defer client.Close()
 
-	// Get existing messages (reasonable limit)
-	messages, err := client.ListMessages(c.Mailbox, 100, 0, false)
-	if err != nil {
-		return err
-	}
+	account := ctx.Config.Bridge.Email
+	for _, mailbox := range mailboxes {
+		if c.Reset {
+			state.Reset(account, mailbox)
+		}
 
-	for _, msg := range messages {
-		seenUIDs[msg.UID] = true
+		status, err := client.MailboxState(mailbox)
+		if err != nil {
+			return err
+		}
+		stored, ok := state.Get(account, mailbox)
+		mark := startMark(status, stored, ok)
+		if ok && mark.UIDValidity == stored.UIDValidity && mark.LastUID == stored.LastUID {
+			ctx.Formatter.Verbosef("Resuming %s after UID %d", mailbox, mark.LastUID)
+			continue
+		}
+		if ok {
+			ctx.Formatter.Verbosef("Stored state for %s is stale (UIDVALIDITY changed), starting from now", mailbox)
+		}
+		mark.UpdatedAt = time.Now()
+		state.Set(account, mailbox, mark)
 	}
 
 	return nil
 }
 
-func (c *MailWatchCmd) checkForNewMessages(ctx *Context, seenUIDs map[uint32]bool) ([]imap.MessageSummary, error) {
+// advanceWatchMark records uid as handled in mailbox.
+func advanceWatchMark(state *watchstate.Store, account, mailbox string, uid uint32) {
+	mark, _ := state.Get(account, mailbox)
+	if uid > mark.LastUID {
+		mark.LastUID = uid
+		mark.UpdatedAt = time.Now()
+		state.Set(account, mailbox, mark)
+	}
+}
+
+// saveWatchState writes the marks. Watching carries on when this fails;
+// the cost is re-reporting mail after a restart.
+func saveWatchState(state *watchstate.Store) {
+	if err := state.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// checkForNewMessages polls every mailbox over a single connection and
+// returns the messages past each mailbox's mark, oldest first. A mailbox
+// that fails is reported and skipped so the others are still checked.
+func (c *MailWatchCmd) checkForNewMessages(ctx *Context, state *watchstate.Store, mailboxes []string) ([]watchedMessage, error) {
 	client, err := imap.NewClient(ctx.Config)
 	if err != nil {
 		return nil, err
@@ -1835,23 +3240,59 @@ func (c *MailWatchCmd) checkForNewMessages(ctx *Context, seenUIDs map[uint32]boo
 	}
 	defer client.Close()
 
-	// Get recent messages
-	messages, err := client.ListMessages(c.Mailbox, 50, 0, false)
-	if err != nil {
-		return nil, err
-	}
+	account := ctx.Config.Bridge.Email
+	var newMessages []watchedMessage
+	for _, mailbox := range mailboxes {
+		status, err := client.MailboxState(mailbox)
+		if err != nil {
+			ctx.Formatter.Verbosef("Error checking %s: %v", mailbox, err)
+			continue
+		}
 
-	var newMessages []imap.MessageSummary
-	for _, msg := range messages {
-		if !seenUIDs[msg.UID] {
-			newMessages = append(newMessages, msg)
+		mark, _ := state.Get(account, mailbox)
+		if status.UIDValidity != mark.UIDValidity {
+			// UIDs from before the change mean nothing now
+			ctx.Formatter.Verbosef("UIDVALIDITY of %s changed, starting from now", mailbox)
+			mark = startMark(status, watchstate.Mark{}, false)
+			mark.UpdatedAt = time.Now()
+			state.Set(account, mailbox, mark)
+			continue
+		}
+		if status.UIDNext <= mark.LastUID+1 {
+			continue
+		}
+
+		// Everything past the mark, so a long downtime loses nothing
+		messages, err := client.ListMessagesAfter(mailbox, mark.LastUID)
+		if err != nil {
+			ctx.Formatter.Verbosef("Error checking %s: %v", mailbox, err)
+			continue
+		}
+
+		for _, msg := range messagesAfter(messages, mark.LastUID) {
+			newMessages = append(newMessages, watchedMessage{Mailbox: mailbox, MessageSummary: msg})
 		}
 	}
 
 	return newMessages, nil
 }
 
-func (c *MailWatchCmd) executeCommand(ctx *Context, msg imap.MessageSummary) {
+// messagesAfter returns the messages with a UID above lastUID, oldest
+// first.
+func messagesAfter(messages []imap.MessageSummary, lastUID uint32) []imap.MessageSummary {
+	var result []imap.MessageSummary
+	for _, msg := range messages {
+		if msg.UID > lastUID {
+			result = append(result, msg)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UID < result[j].UID
+	})
+	return result
+}
+
+func (c *MailWatchCmd) executeCommand(ctx *Context, msg watchedMessage) {
 	// Replace {} with the (numeric, validated) sequence number. Do NOT
 	// add substitution tokens for email-derived string data (From, Subject,
 	// Message-ID, etc.) because this command is passed through `sh -c`;
@@ -1866,9 +3307,19 @@ func (c *MailWatchCmd) executeCommand(ctx *Context, msg imap.MessageSummary) {
 	cmd.Env = append(os.Environ(),
 		fmt.Sprintf("PM_MSG_SEQ=%d", msg.SeqNum),
 		fmt.Sprintf("PM_MSG_UID=%d", msg.UID),
+		"PM_MSG_MAILBOX="+safetext.SanitizeHeaderValue(msg.Mailbox),
 		"PM_MSG_FROM="+safetext.SanitizeHeaderValue(msg.From),
 		"PM_MSG_SUBJECT="+safetext.SanitizeHeaderValue(msg.Subject),
+		// Short names for hooks
+		fmt.Sprintf("PM_UID=%d", msg.UID),
+		"PM_FROM="+safetext.SanitizeHeaderValue(msg.From),
+		"PM_SUBJECT="+safetext.SanitizeHeaderValue(msg.Subject),
 	)
+	// The same event as --json prints, so the hook need not fetch the
+	// message again. The command may ignore it.
+	if event, err := json.Marshal(watchEvent(msg)); err == nil {
+		cmd.Stdin = bytes.NewReader(append(event, '\n'))
+	}
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
@@ -1879,7 +3330,7 @@ func (c *MailWatchCmd) executeCommand(ctx *Context, msg imap.MessageSummary) {
 
 func (c *MailThreadCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -1894,7 +3345,7 @@ func (c *MailThreadCmd) Run(ctx *Context) error {
 
 	ctx.Formatter.Verbosef("Fetching conversation thread...")
 
-	thread, err := client.GetThread(c.Mailbox, c.ID)
+	thread, err := client.GetThread(c.Mailbox, c.ID, c.Peek)
 	if err != nil {
 		return err
 	}
@@ -1929,7 +3380,7 @@ func (c *MailThreadCmd) Run(ctx *Context) error {
 		}
 		fmt.Printf("\nFrom:    %s\n", safetext.SanitizeForTerminal(msg.From))
 		fmt.Printf("To:      %s\n", safetext.SanitizeForTerminal(msg.To))
-		fmt.Printf("Date:    %s\n", msg.Date)
+		fmt.Printf("Date:    %s\n", readDate(ctx, msg.DateISO, msg.Date))
 		fmt.Printf("Subject: %s\n", safetext.SanitizeForTerminal(msg.Subject))
 		if !msg.Seen {
 			fmt.Print("[UNREAD] ")
@@ -1954,7 +3405,7 @@ func (c *MailThreadCmd) Run(ctx *Context) error {
 
 func (c *MailSummarizeCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -1979,7 +3430,13 @@ func (c *MailSummarizeCmd) Run(ctx *Context) error {
 		body = htmlToText(htmlBody)
 	}
 
-	// Build structured summary
+	attachmentNames := []string{}
+	for _, att := range msg.Attachments {
+		attachmentNames = append(attachmentNames, att.Filename)
+	}
+
+	// Build structured summary. body_text is the denoised body, meant to be
+	// passed to a language model as is.
 	summary := map[string]interface{}{
 		"id":               msg.SeqNum,
 		"uid":              msg.UID,
@@ -1995,8 +3452,11 @@ func (c *MailSummarizeCmd) Run(ctx *Context) error {
 		"flagged":          containsString(msg.Flags, "\\Flagged"),
 		"body_preview":     truncateBody(body, 500),
 		"body_length":      len(body),
+		"body_text":        denoiseBody(body),
 		"has_attachments":  len(msg.Attachments) > 0,
 		"attachment_count": len(msg.Attachments),
+		"attachment_names": attachmentNames,
+		"thread_refs":      threadRefs(msg),
 	}
 
 	if len(msg.Attachments) > 0 {
@@ -2017,7 +3477,7 @@ func (c *MailSummarizeCmd) Run(ctx *Context) error {
 
 func (c *MailExtractCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -2051,17 +3511,16 @@ func (c *MailExtractCmd) Run(ctx *Context) error {
 		"date_iso": msg.DateISO,
 	}
 
-	// Extract email addresses mentioned in body
+	// urls, emails, codes and kv are always present so automations can
+	// rely on the keys
 	emails := extractEmails(body)
 	if len(emails) > 0 {
 		extracted["mentioned_emails"] = emails
 	}
-
-	// Extract URLs
-	urls := extractURLs(body)
-	if len(urls) > 0 {
-		extracted["urls"] = urls
-	}
+	extracted["emails"] = nonNil(emails)
+	extracted["urls"] = nonNil(extractURLs(body))
+	extracted["codes"] = nonNil(extractCodes(body))
+	extracted["kv"] = extractKeyValues(body)
 
 	// Extract dates mentioned in text
 	dates := extractDates(body)
@@ -2189,6 +3648,73 @@ func extractActionItems(text string) []string {
 	return items
 }
 
+// codeKeywordRegex matches the wording that introduces a one-time code.
+var codeKeywordRegex = regexp.MustCompile(`(?i)\b(code|otp|passcode|pin|one-time|verification|verify|2fa|security code|token)\b`)
+
+// codeRegex matches code-like tokens: 4-8 digits, digits split in two
+// groups ("123 456", "123-456"), or 6-8 uppercase letters and digits.
+var codeRegex = regexp.MustCompile(`\b(\d{3,4}[- ]\d{3,4}|\d{4,8}|[A-Z0-9]{6,8})\b`)
+
+// extractCodes finds verification and one-time codes. A token only counts
+// when it is on a line mentioning a code, or on the line that follows one,
+// which keeps order numbers and prices out.
+func extractCodes(text string) []string {
+	var codes []string
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if !codeKeywordRegex.MatchString(line) {
+			continue
+		}
+		candidates := []string{line}
+		for j := i + 1; j < len(lines); j++ {
+			if strings.TrimSpace(lines[j]) != "" {
+				candidates = append(candidates, lines[j])
+				break
+			}
+		}
+		for _, candidate := range candidates {
+			for _, token := range codeRegex.FindAllString(candidate, -1) {
+				// Mixed tokens must contain a digit, so words in capitals
+				// are not taken for codes
+				if strings.ContainsAny(token, "0123456789") {
+					codes = append(codes, token)
+				}
+			}
+		}
+	}
+	return uniqueStrings(codes)
+}
+
+// keyValueRegex matches "Key: value" lines. The space after the colon
+// keeps URLs and times from matching.
+var keyValueRegex = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9 #&/().'-]{0,39}?)\s*:\s+(\S.*)$`)
+
+// extractKeyValues collects "Key: value" lines such as "Order number: 123"
+// or "Tracking: 1Z999". The first value for a key wins.
+func extractKeyValues(text string) map[string]string {
+	kv := make(map[string]string)
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		m := keyValueRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		key := strings.TrimSpace(m[1])
+		if _, ok := kv[key]; !ok {
+			kv[key] = strings.TrimSpace(m[2])
+		}
+	}
+	return kv
+}
+
+// nonNil returns s, or an empty slice when s is nil, so that JSON output
+// has [] rather than null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
 func uniqueStrings(slice []string) []string {
 	seen := make(map[string]bool)
 	var result []string
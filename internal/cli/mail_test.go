@@ -1,8 +1,19 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/bscott/pm-cli/internal/config"
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/output"
+	"github.com/bscott/pm-cli/internal/smtp"
+	"github.com/bscott/pm-cli/internal/watchstate"
 )
 
 func TestFormatSize(t *testing.T) {
@@ -32,6 +43,37 @@ func TestFormatSize(t *testing.T) {
 	}
 }
 
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"", 0},
+		{"0", 0},
+		{"1500", 1500},
+		{"500K", 500 * 1024},
+		{"500kb", 500 * 1024},
+		{"1M", 1024 * 1024},
+		{"1.5M", 1572864},
+		{"1.5MB", 1572864},
+		{"2G", 2 * 1024 * 1024 * 1024},
+		{"0.5G", 512 * 1024 * 1024},
+		{" 10k ", 10 * 1024},
+		{"100B", 100},
+		{"abc", 0},
+		{"-1M", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := parseSize(tt.input)
+			if got != tt.want {
+				t.Errorf("parseSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestExtractEmailAddress(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -117,6 +159,18 @@ func TestHtmlToText(t *testing.T) {
 			contains: []string{"Item 1", "Item 2"},
 			excludes: []string{"<ul>", "<li>", "</li>"},
 		},
+		{
+			name:     "list items get bullets",
+			input:    "<ul><li>Item 1</li><li class=\"x\">Item 2</li></ul>",
+			contains: []string{"- Item 1\n- Item 2"},
+			excludes: []string{"<li", "\n\n- Item 2"},
+		},
+		{
+			name:     "table cells are separated",
+			input:    "<table><tr><th>Item</th><th>Price</th></tr><tr><td>Widget</td><td align=\"right\">$5.00</td></tr></table><p>Thanks</p>",
+			contains: []string{"Item | Price\nWidget | $5.00", "$5.00\n\nThanks"},
+			excludes: []string{"<td", "ItemPrice", "Widget$5.00"},
+		},
 		{
 			name:     "empty input",
 			input:    "",
@@ -144,6 +198,262 @@ func TestHtmlToText(t *testing.T) {
 	}
 }
 
+func TestDenoiseBody(t *testing.T) {
+	body := "Sounds good.  \n\n\n\nSee you at 3.\n\n-- \nAlice\nAcme Corp\n\nOn Mon, Alice wrote:\n> Meeting?"
+	if got, want := denoiseBody(body), "Sounds good.\n\nSee you at 3."; got != want {
+		t.Errorf("denoiseBody() = %q, want %q", got, want)
+	}
+}
+
+func TestThreadRefs(t *testing.T) {
+	msg := &imap.Message{
+		InReplyTo:  "<b@example.com>",
+		References: []string{"<a@example.com>", "<b@example.com>"},
+	}
+	if got := threadRefs(msg); !reflect.DeepEqual(got, []string{"<a@example.com>", "<b@example.com>"}) {
+		t.Errorf("threadRefs() = %v", got)
+	}
+	if got := threadRefs(&imap.Message{}); got == nil || len(got) != 0 {
+		t.Errorf("threadRefs(empty) = %v, want empty slice", got)
+	}
+}
+
+func TestStripQuotedText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "no quotes",
+			input: "Just a plain message.\nSecond line.",
+			want:  "Just a plain message.\nSecond line.",
+		},
+		{
+			name:  "reply generated by mail reply",
+			input: "Sounds good, see you then.\n\nOn 2024-01-15 10:30, Alice <alice@example.com> wrote:\n> Are we still on for lunch?\n> \n> Alice",
+			want:  "Sounds good, see you then.",
+		},
+		{
+			name:  "forward generated by mail forward",
+			input: "FYI\n\n---------- Forwarded message ----------\nFrom: Bob <bob@example.com>\nDate: 2024-01-15 10:30\nSubject: Report\nTo: me@example.com\n\nSee attached.",
+			want:  "FYI",
+		},
+		{
+			name:  "wrapped attribution",
+			input: "Thanks!\n\nOn Mon, Jan 15, 2024 at 10:30 AM Alice Example\n<alice@example.com> wrote:\n> Hi",
+			want:  "Thanks!",
+		},
+		{
+			name:  "inline quotes are dropped",
+			input: "> question one\nanswer one\n> question two\nanswer two",
+			want:  "answer one\nanswer two",
+		},
+		{
+			name:  "outlook original message",
+			input: "Approved.\n\n-----Original Message-----\nFrom: Carol",
+			want:  "Approved.",
+		},
+		{
+			name:  "crlf line endings",
+			input: "Reply\r\n\r\nOn 2024-01-15 10:30, Alice wrote:\r\n> Hi\r\n",
+			want:  "Reply",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stripQuotedText(tt.input)
+			if got != tt.want {
+				t.Errorf("stripQuotedText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightBody(t *testing.T) {
+	text := "See https://example.com/a?b=1 now.\n> quoted https://old.example\n  > nested"
+
+	plain := output.New(false, false, false, true)
+	if got := highlightBody(plain, text); got != text {
+		t.Errorf("highlightBody() with colors off = %q, want the text unchanged", got)
+	}
+
+	color := output.New(false, false, false, false)
+	want := "See " + output.Blue + "https://example.com/a?b=1" + output.Reset + " now.\n" +
+		output.Gray + "> quoted https://old.example" + output.Reset + "\n" +
+		output.Gray + "  > nested" + output.Reset
+	if got := highlightBody(color, text); got != want {
+		t.Errorf("highlightBody() = %q, want %q", got, want)
+	}
+}
+
+func TestPrintMessageColors(t *testing.T) {
+	msg := &imap.Message{
+		From:    "Alice <alice@example.com>",
+		To:      []string{"bob@example.com"},
+		Subject: "Hi \x1b[31mthere",
+		RawBody: []byte("Content-Type: text/plain\r\n\r\nHello\r\n"),
+	}
+
+	for _, noColor := range []bool{false, true} {
+		var buf bytes.Buffer
+		ctx, _ := NewContext(&Globals{})
+		ctx.Formatter = output.New(false, false, false, noColor)
+		ctx.Formatter.Writer = &buf
+		(&MailReadCmd{}).printMessage(ctx, msg)
+
+		out := buf.String()
+		if strings.Contains(out, "\x1b[31m") {
+			t.Errorf("noColor=%v: the subject's escape sequence reached the output:\n%q", noColor, out)
+		}
+		hasColor := strings.Contains(out, output.Cyan+"From:   "+output.Reset)
+		if hasColor == noColor {
+			t.Errorf("noColor=%v: colored From label = %v in %q", noColor, hasColor, out)
+		}
+		if noColor && !strings.HasPrefix(out, "From:    Alice <alice@example.com>\n") {
+			t.Errorf("plain output starts %q, want the unchanged header layout", out)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		width int
+		want  string
+	}{
+		{
+			name:  "short line unchanged",
+			input: "hello world",
+			width: 80,
+			want:  "hello world",
+		},
+		{
+			name:  "wraps at word boundary",
+			input: "the quick brown fox jumps over the lazy dog",
+			width: 15,
+			want:  "the quick brown\nfox jumps over\nthe lazy dog",
+		},
+		{
+			name:  "preserves existing newlines",
+			input: "first line\n\nsecond paragraph here",
+			width: 10,
+			want:  "first line\n\nsecond\nparagraph\nhere",
+		},
+		{
+			name:  "long url kept intact",
+			input: "see https://example.com/a/very/long/path/that/exceeds/width for details",
+			width: 20,
+			want:  "see\nhttps://example.com/a/very/long/path/that/exceeds/width\nfor details",
+		},
+		{
+			name:  "zero width disables wrapping",
+			input: "the quick brown fox",
+			width: 0,
+			want:  "the quick brown fox",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapText(tt.input, tt.width)
+			if got != tt.want {
+				t.Errorf("wrapText(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMailReadCmdWrapWidth(t *testing.T) {
+	t.Setenv("COLUMNS", "")
+	if got := (&MailReadCmd{}).wrapWidth(); got != 80 {
+		t.Errorf("wrapWidth() default = %d, want 80", got)
+	}
+
+	t.Setenv("COLUMNS", "120")
+	if got := (&MailReadCmd{}).wrapWidth(); got != 120 {
+		t.Errorf("wrapWidth() with COLUMNS = %d, want 120", got)
+	}
+
+	if got := (&MailReadCmd{Width: 60}).wrapWidth(); got != 60 {
+		t.Errorf("wrapWidth() with --width = %d, want 60", got)
+	}
+}
+
+// mixedAlternativeFixture is a multipart/mixed message wrapping a
+// multipart/alternative body, followed by a text/plain attachment.
+const mixedAlternativeFixture = "From: sender@example.com\r\n" +
+	"To: recipient@example.com\r\n" +
+	"Subject: Nested\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Plain body text\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>HTML body text</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; name=\"notes.txt\"\r\n" +
+	"Content-Disposition: attachment; filename=\"notes.txt\"\r\n" +
+	"\r\n" +
+	"Attachment text\r\n" +
+	"--outer--\r\n"
+
+func TestParseMessageBodyIgnoresTextAttachments(t *testing.T) {
+	textBody, _ := parseMessageBody([]byte(mixedAlternativeFixture))
+	if containsStr(textBody, "Attachment text") {
+		t.Errorf("textBody should not contain attachment content, got %q", textBody)
+	}
+}
+
+func TestForwardedAttachments(t *testing.T) {
+	raw := "From: sender@example.com\r\n" +
+		"Subject: Invoice\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"b\"\r\n" +
+		"\r\n" +
+		"--b\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"See attached\r\n" +
+		"--b\r\n" +
+		"Content-Type: application/pdf; name=\"invoice.pdf\"\r\n" +
+		"Content-Disposition: attachment; filename=\"../../invoice.pdf\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"JVBERi0xLjQ=\r\n" +
+		"--b--\r\n"
+
+	attachments, total := forwardedAttachments([]byte(raw))
+	if len(attachments) != 1 {
+		t.Fatalf("forwardedAttachments() returned %d attachments, want 1", len(attachments))
+	}
+	att := attachments[0]
+	if att.Filename != "invoice.pdf" {
+		t.Errorf("Filename = %q, want %q", att.Filename, "invoice.pdf")
+	}
+	if att.ContentType != `application/pdf; name=invoice.pdf` {
+		t.Errorf("ContentType = %q", att.ContentType)
+	}
+	if string(att.Data) != "%PDF-1.4" || total != int64(len("%PDF-1.4")) {
+		t.Errorf("Data = %q, total = %d; want decoded content", att.Data, total)
+	}
+
+	if got, _ := forwardedAttachments([]byte(mixedAlternativeFixture)); len(got) != 1 || got[0].Filename != "notes.txt" {
+		t.Errorf("forwardedAttachments(mixedAlternativeFixture) = %+v", got)
+	}
+}
+
 func TestParseMessageBody(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -173,6 +483,38 @@ func TestParseMessageBody(t *testing.T) {
 			wantText: true, // Falls back to treating as plain text
 			wantHTML: false,
 		},
+		{
+			name:         "latin-1 body",
+			rawBody:      []byte("Content-Type: text/plain; charset=ISO-8859-1\r\n\r\nCaf\xe9 cr\xe8me"),
+			wantText:     true,
+			textContains: "Café crème",
+		},
+		{
+			name:         "windows-1252 body",
+			rawBody:      []byte("Content-Type: text/plain; charset=windows-1252\r\n\r\n\x93quoted\x94 \x80100"),
+			wantText:     true,
+			textContains: "\u201cquoted\u201d €100",
+		},
+		{
+			name:         "base64 body",
+			rawBody:      []byte("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\nSGVsbG8sIFfDtnJsZCE=\r\n"),
+			wantText:     true,
+			textContains: "Hello, Wörld!",
+		},
+		{
+			name:         "quoted-printable latin-1 html",
+			rawBody:      []byte("Content-Type: text/html; charset=iso-8859-1\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n<p>Gr=FC=DFe</p>"),
+			wantHTML:     true,
+			htmlContains: "<p>Grüße</p>",
+		},
+		{
+			name:         "mixed wrapping alternative",
+			rawBody:      []byte(mixedAlternativeFixture),
+			wantText:     true,
+			wantHTML:     true,
+			textContains: "Plain body text",
+			htmlContains: "<p>HTML body text</p>",
+		},
 	}
 
 	for _, tt := range tests {
@@ -247,7 +589,7 @@ func TestMailListCmdRunWithoutConfig(t *testing.T) {
 
 func TestMailReadCmdRunWithoutConfig(t *testing.T) {
 	cmd := &MailReadCmd{
-		ID: "1",
+		IDs: []string{"1"},
 	}
 
 	globals := &Globals{}
@@ -320,7 +662,7 @@ func TestMailArchiveCmdToMoveCmd(t *testing.T) {
 		Mailbox: "INBOX",
 	}
 
-	moveCmd := cmd.toMoveCmd()
+	moveCmd := cmd.toMoveCmd(config.DefaultConfig().ArchiveMailbox())
 
 	if moveCmd.Destination != "Archive" {
 		t.Fatalf("destination = %q, want %q", moveCmd.Destination, "Archive")
@@ -336,6 +678,28 @@ func TestMailArchiveCmdToMoveCmd(t *testing.T) {
 	}
 }
 
+func TestMailTrashCmdToMoveCmd(t *testing.T) {
+	cmd := &MailTrashCmd{
+		IDs:     []string{"1", "uid:42"},
+		Query:   "from:spam@example.com",
+		Mailbox: "Sent",
+	}
+
+	cfg := config.DefaultConfig()
+	if got := cmd.toMoveCmd(cfg.TrashMailbox()).Destination; got != "Trash" {
+		t.Fatalf("destination = %q, want %q", got, "Trash")
+	}
+
+	cfg.Defaults.TrashMailbox = "Folders/Bin"
+	moveCmd := cmd.toMoveCmd(cfg.TrashMailbox())
+	if moveCmd.Destination != "Folders/Bin" {
+		t.Fatalf("destination = %q, want %q", moveCmd.Destination, "Folders/Bin")
+	}
+	if !reflect.DeepEqual(moveCmd.IDs, cmd.IDs) || moveCmd.Query != cmd.Query || moveCmd.Mailbox != cmd.Mailbox {
+		t.Fatalf("toMoveCmd() = %+v", moveCmd)
+	}
+}
+
 func TestMailSendCmdRunWithoutConfig(t *testing.T) {
 	cmd := &MailSendCmd{
 		To:      []string{"recipient@example.com"},
@@ -372,6 +736,348 @@ func TestMailSendCmdRunWithoutBody(t *testing.T) {
 	}
 }
 
+func TestMailSendCmdAttachStdinValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  MailSendCmd
+		want string
+	}{
+		{"bare type without stdin", MailSendCmd{Body: "b", AttachType: []string{"text/csv"}}, "requires --attach-stdin"},
+		{"invalid type", MailSendCmd{Body: "b", AttachStdin: "data", AttachType: []string{"text/csv\r\nBcc: x"}}, "invalid --attach-type"},
+		{"body from stdin", MailSendCmd{To: []string{"r@example.com"}, Subject: "s", AttachStdin: "report.csv"}, "provide the body with --body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := NewContext(&Globals{})
+			ctx.Config.Bridge.Email = "sender@example.com"
+
+			err := tt.cmd.Run(ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Run() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMailSendCmdDryRun(t *testing.T) {
+	cmd := &MailSendCmd{
+		To:             []string{"recipient@example.com"},
+		Subject:        "Dry run",
+		Body:           "Not sent",
+		IdempotencyKey: "dry-run-key",
+		DryRun:         true,
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Bridge.Email = "sender@example.com"
+	cfg.Bridge.SMTPHost = "smtp.invalid" // would be refused if dialed
+
+	var buf bytes.Buffer
+	formatter := output.New(true, false, false, false)
+	formatter.Writer = &buf
+	ctx := &Context{Config: cfg, Formatter: formatter, Globals: &Globals{JSON: boolPtr(true)}}
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := cmd.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var result struct {
+		DryRun bool   `json:"dry_run"`
+		MIME   string `json:"mime"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, buf.String())
+	}
+	if !result.DryRun {
+		t.Error("expected dry_run to be true")
+	}
+	for _, want := range []string{"From: sender@example.com\r\n", "To: recipient@example.com\r\n", "Subject: Dry run\r\n", "Not sent"} {
+		if !strings.Contains(result.MIME, want) {
+			t.Errorf("mime missing %q:\n%s", want, result.MIME)
+		}
+	}
+
+	// A dry run must not use up the idempotency key
+	used, err := config.ClaimIdempotencyKey("dry-run-key")
+	if err != nil {
+		t.Fatalf("ClaimIdempotencyKey() error = %v", err)
+	}
+	if used {
+		t.Error("dry run claimed the idempotency key")
+	}
+}
+
+func TestMailSendBodyPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	bodyFile := filepath.Join(dir, "body.txt")
+	if err := os.WriteFile(bodyFile, []byte("from file\n\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	emptyFile := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	template := filepath.Join(dir, "note.tmpl")
+	if err := os.WriteFile(template, []byte("---\nsubject: Note\n---\nfrom template\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		bodyFile string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{name: "body wins over everything", body: "from flag", bodyFile: bodyFile, template: template, want: "from flag"},
+		{name: "body file wins over template and stdin", bodyFile: bodyFile, template: template, want: "from file"},
+		{name: "template wins over stdin", template: template, want: "from template"},
+		{name: "stdin", want: "from stdin"},
+		{name: "empty body file", bodyFile: emptyFile, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatalf("os.Pipe() error = %v", err)
+			}
+			oldStdin := os.Stdin
+			os.Stdin = r
+			t.Cleanup(func() { os.Stdin = oldStdin })
+			w.Write([]byte("from stdin\n"))
+			w.Close()
+
+			cmd := &MailSendCmd{
+				To:       []string{"recipient@example.com"},
+				Subject:  "Precedence",
+				Body:     tt.body,
+				BodyFile: tt.bodyFile,
+				Template: tt.template,
+				DryRun:   true,
+			}
+			var buf bytes.Buffer
+			ctx, _ := NewContext(&Globals{})
+			ctx.Config.Bridge.Email = "sender@example.com"
+			ctx.Formatter.JSON = true
+			ctx.Formatter.Writer = &buf
+
+			err = cmd.Run(ctx)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			var result struct {
+				MIME string `json:"mime"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+				t.Fatalf("invalid JSON output: %v", err)
+			}
+			for _, source := range []string{"from flag", "from file", "from template", "from stdin"} {
+				if got := strings.Contains(result.MIME, source); got != (source == tt.want) {
+					t.Errorf("mime contains %q = %v, want the body %q only:\n%s", source, got, tt.want, result.MIME)
+				}
+			}
+		})
+	}
+}
+
+func TestMailSendCmdRejectsInvalidRecipients(t *testing.T) {
+	cmd := &MailSendCmd{
+		To:      []string{"user@exmaple,com"},
+		CC:      []string{"Jane Doe <jane@example.com>"},
+		BCC:     []string{"x@example.com\nBcc: attacker@evil.example"},
+		Subject: "Test",
+		Body:    "Body",
+		DryRun:  true,
+	}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "sender@example.com"
+
+	err := cmd.Run(ctx)
+	if err == nil {
+		t.Fatal("expected error for invalid recipients")
+	}
+	if !strings.Contains(err.Error(), "user@exmaple,com") || !strings.Contains(err.Error(), "attacker@evil.example") {
+		t.Errorf("error %q should list both bad addresses", err)
+	}
+	if strings.Contains(err.Error(), "jane@example.com") {
+		t.Errorf("error %q should not list the valid address", err)
+	}
+}
+
+func TestMaxAttachmentSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		force bool
+		want  int64
+	}{
+		{"unset uses default", "", false, defaultMaxAttachmentSize},
+		{"configured", "10M", false, 10 << 20},
+		{"disabled", "0", false, 0},
+		{"force", "10M", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := NewContext(&Globals{})
+			ctx.Config.Defaults.MaxAttachmentSize = tt.value
+			if got := ctx.maxAttachmentSize(tt.force); got != tt.want {
+				t.Errorf("maxAttachmentSize(%v) = %d, want %d", tt.force, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMailSendCmdAttachmentTooLarge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, make([]byte, 2048), 0600); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	cmd := &MailSendCmd{
+		To:      []string{"recipient@example.com"},
+		Subject: "Big",
+		Body:    "Attached",
+		Attach:  []string{path},
+		DryRun:  true,
+	}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "sender@example.com"
+	ctx.Config.Defaults.MaxAttachmentSize = "1K"
+
+	err := cmd.Run(ctx)
+	if err == nil {
+		t.Fatal("expected error for oversized attachment")
+	}
+	if !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("error %q should name the file and mention --force", err)
+	}
+
+	cmd.Force = true
+	ctx.Formatter.Writer = &bytes.Buffer{}
+	ctx.Formatter.JSON = true
+	if err := cmd.Run(ctx); err != nil {
+		t.Errorf("Run() with --force error = %v", err)
+	}
+}
+
+func TestParseAttachTypes(t *testing.T) {
+	attach := []string{"/tmp/out/data", "notes.txt"}
+
+	t.Run("valid", func(t *testing.T) {
+		got, err := parseAttachTypes([]string{"data=text/csv", "/tmp/out/notes.txt = text/markdown", "application/gzip"}, attach, "backup")
+		if err != nil {
+			t.Fatalf("parseAttachTypes() error = %v", err)
+		}
+		want := map[string]string{"data": "text/csv", "notes.txt": "text/markdown", "backup": "application/gzip"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parseAttachTypes() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		if got, err := parseAttachTypes(nil, attach, ""); got != nil || err != nil {
+			t.Errorf("parseAttachTypes(nil) = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	errorCases := []struct {
+		name   string
+		values []string
+		stdin  string
+	}{
+		{"unknown attachment", []string{"other.csv=text/csv"}, ""},
+		{"bare type without stdin", []string{"text/csv"}, ""},
+		{"invalid media type", []string{"data=not a type"}, ""},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseAttachTypes(tt.values, attach, tt.stdin); err == nil {
+				t.Errorf("parseAttachTypes(%v) expected error", tt.values)
+			}
+		})
+	}
+}
+
+func TestReadStdinAttachment(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error = %v", err)
+	}
+	oldStdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = oldStdin })
+
+	go func() {
+		w.Write([]byte("a,b\n1,2\n"))
+		w.Close()
+	}()
+
+	att, err := readStdinAttachment("../reports/report.csv")
+	if err != nil {
+		t.Fatalf("readStdinAttachment() error = %v", err)
+	}
+	if att.Filename != "report.csv" {
+		t.Errorf("Filename = %q, want %q", att.Filename, "report.csv")
+	}
+	if string(att.Data) != "a,b\n1,2\n" {
+		t.Errorf("Data = %q", att.Data)
+	}
+}
+
+func TestMergeIDsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ids.txt")
+	if err := os.WriteFile(path, []byte("uid:3\n\n  uid:7 \r\nuid:1\nuid:7\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := mergeIDsFile([]string{"uid:1", "uid:2"}, path)
+	if err != nil {
+		t.Fatalf("mergeIDsFile() error = %v", err)
+	}
+	want := []string{"uid:1", "uid:2", "uid:3", "uid:7"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeIDsFile() = %v, want %v", got, want)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error = %v", err)
+	}
+	oldStdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = oldStdin })
+	go func() {
+		w.Write([]byte("4\n5\n"))
+		w.Close()
+	}()
+
+	got, err = mergeIDsFile(nil, "-")
+	if err != nil {
+		t.Fatalf("mergeIDsFile(stdin) error = %v", err)
+	}
+	if want := []string{"4", "5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeIDsFile(stdin) = %v, want %v", got, want)
+	}
+
+	if _, err := mergeIDsFile(nil, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing IDs file")
+	}
+}
+
 func TestMailDeleteCmdRunWithoutConfig(t *testing.T) {
 	cmd := &MailDeleteCmd{
 		IDs: []string{"1"},
@@ -404,21 +1110,16 @@ func TestMailMoveCmdRunWithoutConfig(t *testing.T) {
 }
 
 func TestMailFlagCmdRunWithoutFlags(t *testing.T) {
-	cmd := &MailFlagCmd{
-		IDs:    []string{"1"},
-		Read:   false,
-		Unread: false,
-		Star:   false,
-		Unstar: false,
-	}
+	// Without flags the command inspects, which still needs messages
+	cmd := &MailFlagCmd{}
 
 	globals := &Globals{}
 	ctx, _ := NewContext(globals)
 	ctx.Config.Bridge.Email = "test@example.com"
 
 	err := cmd.Run(ctx)
-	if err == nil {
-		t.Error("expected error when no flags specified")
+	if err == nil || !strings.Contains(err.Error(), "message ID") {
+		t.Errorf("Run() = %v, want an error asking for message IDs", err)
 	}
 }
 
@@ -454,6 +1155,68 @@ func TestMailSearchCmdRunWithoutConfig(t *testing.T) {
 	}
 }
 
+func TestParseQueryToSearchOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  imap.SearchOptions
+	}{
+		{"plain terms", "hello world", imap.SearchOptions{Query: "hello world"}},
+		{"prefixed", "from:boss@example.com subject:report", imap.SearchOptions{From: "boss@example.com", Subject: "report"}},
+		{"uppercase prefix", "FROM:boss@example.com", imap.SearchOptions{From: "boss@example.com"}},
+		{"quoted value", `subject:"weekly report"`, imap.SearchOptions{Subject: "weekly report"}},
+		{"negated subject", "from:boss subject:!lunch", imap.SearchOptions{From: "boss", NotSubject: "lunch"}},
+		{"negated from", "from:!noreply@example.com invoice", imap.SearchOptions{Query: "invoice", NotFrom: "noreply@example.com"}},
+		{"negated quoted body", `body:!"do not reply" body:order`, imap.SearchOptions{Query: "order", NotBody: "do not reply"}},
+		{"unknown prefix is body text", "http://example.com", imap.SearchOptions{Query: "http://example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseQueryToSearchOptions(tt.query)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseQueryToSearchOptions(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTriState(t *testing.T) {
+	if got := triState(false, false); got != nil {
+		t.Errorf("triState(false, false) = %v, want nil", *got)
+	}
+	if got := triState(true, false); got == nil || !*got {
+		t.Errorf("triState(true, false) = %v, want true", got)
+	}
+	if got := triState(false, true); got == nil || *got {
+		t.Errorf("triState(false, true) = %v, want false", got)
+	}
+}
+
+func TestMailSearchCmdRawSearchValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     MailSearchCmd
+		wantErr string
+	}{
+		{"combined with query", MailSearchCmd{Query: "invoice", RawSearch: "UNSEEN"}, "cannot be combined"},
+		{"combined with filter", MailSearchCmd{From: "a@example.com", RawSearch: "UNSEEN"}, "cannot be combined"},
+		{"invalid raw search", MailSearchCmd{RawSearch: "SINCE yesterday"}, "invalid --raw-search"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := NewContext(&Globals{})
+			ctx.Config.Bridge.Email = "user@example.com"
+
+			err := tt.cmd.Run(ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestMailReplyCmdRunWithoutConfig(t *testing.T) {
 	cmd := &MailReplyCmd{
 		ID:   "1",
@@ -501,3 +1264,356 @@ func TestMailDownloadCmdRunWithoutConfig(t *testing.T) {
 		t.Error("expected error when email not configured")
 	}
 }
+
+func TestDraftMessage(t *testing.T) {
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "me@example.com"
+
+	path := filepath.Join(t.TempDir(), "notes.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4 test"), 0600); err != nil {
+		t.Fatalf("failed to create attachment: %v", err)
+	}
+	raw, err := composeDraft(ctx, &smtp.Message{
+		To:          []string{"bob@example.com"},
+		Subject:     "Notes",
+		Body:        "See attached.",
+		Attachments: []string{path},
+	})
+	if err != nil {
+		t.Fatalf("composeDraft() error = %v", err)
+	}
+
+	draft := &imap.Message{
+		To:        []string{"Bob <bob@example.com>"},
+		CC:        []string{"carol@example.com"},
+		Subject:   "Notes",
+		InReplyTo: "<orig@example.com>",
+		RawBody:   raw,
+	}
+	msg, err := draftMessage(ctx, draft)
+	if err != nil {
+		t.Fatalf("draftMessage() error = %v", err)
+	}
+	if msg.From != "me@example.com" || !reflect.DeepEqual(msg.To, []string{"bob@example.com"}) || !reflect.DeepEqual(msg.CC, []string{"carol@example.com"}) {
+		t.Errorf("addresses = from %q, to %v, cc %v", msg.From, msg.To, msg.CC)
+	}
+	if msg.Body != "See attached." {
+		t.Errorf("Body = %q, want %q", msg.Body, "See attached.")
+	}
+	if msg.InReplyTo != "<orig@example.com>" || msg.References != "<orig@example.com>" {
+		t.Errorf("threading = %q / %q", msg.InReplyTo, msg.References)
+	}
+	if len(msg.AttachmentData) != 1 || msg.AttachmentData[0].Filename != "notes.pdf" || string(msg.AttachmentData[0].Data) != "%PDF-1.4 test" {
+		t.Errorf("AttachmentData = %+v", msg.AttachmentData)
+	}
+
+	if _, err := draftMessage(ctx, &imap.Message{Subject: "No one"}); err == nil {
+		t.Error("expected error for a draft without recipients")
+	}
+}
+
+func TestDraftSendCmdRunWithoutConfig(t *testing.T) {
+	cmd := &DraftSendCmd{ID: "1"}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	if err := cmd.Run(ctx); err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
+
+func TestMailWatchCmdMailboxes(t *testing.T) {
+	tests := []struct {
+		name    string
+		mailbox []string
+		want    []string
+	}{
+		{"default", []string{"INBOX"}, []string{"INBOX"}},
+		{"several", []string{"INBOX", "Labels/Work"}, []string{"INBOX", "Labels/Work"}},
+		{"duplicates and blanks", []string{"INBOX", " ", "Labels/Work", "INBOX", " Labels/Work "}, []string{"INBOX", "Labels/Work"}},
+		{"none", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &MailWatchCmd{Mailbox: tt.mailbox}
+			if got := cmd.watchMailboxes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("watchMailboxes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartMark(t *testing.T) {
+	status := &imap.MailboxState{UIDValidity: 7, UIDNext: 121}
+
+	tests := []struct {
+		name   string
+		stored watchstate.Mark
+		ok     bool
+		want   uint32
+	}{
+		{"first run starts at the newest message", watchstate.Mark{}, false, 120},
+		{"stored mark is resumed", watchstate.Mark{UIDValidity: 7, LastUID: 100}, true, 100},
+		{"UIDVALIDITY changed", watchstate.Mark{UIDValidity: 6, LastUID: 100}, true, 120},
+		{"mark beyond UIDNEXT", watchstate.Mark{UIDValidity: 7, LastUID: 500}, true, 120},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mark := startMark(status, tt.stored, tt.ok)
+			if mark.UIDValidity != 7 || mark.LastUID != tt.want {
+				t.Errorf("startMark() = %+v, want UIDVALIDITY 7, last UID %d", mark, tt.want)
+			}
+		})
+	}
+
+	if mark := startMark(&imap.MailboxState{UIDValidity: 1}, watchstate.Mark{}, false); mark.LastUID != 0 {
+		t.Errorf("startMark() without UIDNEXT = %+v, want last UID 0", mark)
+	}
+}
+
+func TestMessagesAfter(t *testing.T) {
+	// ListMessages returns the newest message first
+	messages := []imap.MessageSummary{{UID: 104}, {UID: 103}, {UID: 101}, {UID: 100}, {UID: 98}}
+
+	var got []uint32
+	for _, msg := range messagesAfter(messages, 100) {
+		got = append(got, msg.UID)
+	}
+	if want := []uint32{101, 103, 104}; !reflect.DeepEqual(got, want) {
+		t.Errorf("messagesAfter() UIDs = %v, want %v", got, want)
+	}
+
+	if got := messagesAfter(messages, 104); len(got) != 0 {
+		t.Errorf("messagesAfter() past the newest = %+v, want none", got)
+	}
+}
+
+func TestAdvanceWatchMark(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	state, err := watchstate.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	state.Set("me@example.com", "INBOX", watchstate.Mark{UIDValidity: 7, LastUID: 100})
+
+	advanceWatchMark(state, "me@example.com", "INBOX", 103)
+	advanceWatchMark(state, "me@example.com", "INBOX", 101)
+	if mark, _ := state.Get("me@example.com", "INBOX"); mark.UIDValidity != 7 || mark.LastUID != 103 {
+		t.Errorf("mark = %+v, want UIDVALIDITY 7, last UID 103", mark)
+	}
+}
+
+func TestMailWatchCmdExecuteCommand(t *testing.T) {
+	dir := t.TempDir()
+	stdinFile := filepath.Join(dir, "stdin.json")
+	envFile := filepath.Join(dir, "env.txt")
+
+	cmd := &MailWatchCmd{Exec: "cat > " + stdinFile + "; printf '%s|%s|%s|{}' \"$PM_UID\" \"$PM_FROM\" \"$PM_SUBJECT\" > " + envFile}
+	ctx, _ := NewContext(&Globals{})
+
+	cmd.executeCommand(ctx, watchedMessage{
+		Mailbox: "Labels/Work",
+		MessageSummary: imap.MessageSummary{
+			UID:     42,
+			SeqNum:  7,
+			From:    "Alice",
+			Subject: "Hello\r\nX-Injected: yes",
+		},
+	})
+
+	env, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatalf("command did not run: %v", err)
+	}
+	if got, want := string(env), "42|Alice|HelloX-Injected: yes|7"; got != want {
+		t.Errorf("env = %q, want %q", got, want)
+	}
+
+	data, err := os.ReadFile(stdinFile)
+	if err != nil {
+		t.Fatalf("failed to read stdin copy: %v", err)
+	}
+	var event struct {
+		Event   string              `json:"event"`
+		Mailbox string              `json:"mailbox"`
+		Message imap.MessageSummary `json:"message"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("stdin is not JSON: %v\n%s", err, data)
+	}
+	if event.Event != "new_message" || event.Mailbox != "Labels/Work" || event.Message.UID != 42 || event.Message.Subject != "Hello\r\nX-Injected: yes" {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestSafeAttachmentFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     string
+	}{
+		{"plain", "report.pdf", "report.pdf"},
+		{"parent traversal", "../evil", "evil"},
+		{"deep traversal", "../../../../etc/passwd", "passwd"},
+		{"absolute path", "/etc/cron.d/evil", "evil"},
+		{"windows traversal", `..\..\evil.bat`, "evil.bat"},
+		{"windows absolute", `C:\Windows\evil.dll`, "evil.dll"},
+		{"dot dot", "..", "attachment_2"},
+		{"dots only", "...", "attachment_2"},
+		{"trailing slash", "evil/", "evil"},
+		{"root", "/", "attachment_2"},
+		{"empty", "", "attachment_2"},
+		{"control characters", "in\x1b[2Jvoice\n.pdf", "in[2Jvoice.pdf"},
+		{"hidden file kept", ".bashrc", ".bashrc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := safeAttachmentFilename(tt.filename, 2); got != tt.want {
+				t.Errorf("safeAttachmentFilename(%q) = %q, want %q", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteNewFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, safeAttachmentFilename("../evil", 0))
+
+	if err := writeNewFile(target, []byte("first"), false); err != nil {
+		t.Fatalf("writeNewFile() error = %v", err)
+	}
+	if filepath.Dir(target) != dir {
+		t.Fatalf("attachment written outside %s: %s", dir, target)
+	}
+
+	err := writeNewFile(target, []byte("second"), false)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("writeNewFile() over an existing file error = %v, want a --force hint", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "first" {
+		t.Errorf("existing file was modified: %q", data)
+	}
+
+	if err := writeNewFile(target, []byte("second"), true); err != nil {
+		t.Fatalf("writeNewFile(force) error = %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "second" {
+		t.Errorf("file = %q, want %q", data, "second")
+	}
+}
+
+func TestMailListFlagSearch(t *testing.T) {
+	opts := (&MailListCmd{Starred: true, Unread: true}).flagSearch(20, 40)
+	if opts.Flagged == nil || !*opts.Flagged || opts.Seen == nil || *opts.Seen || opts.Answered != nil {
+		t.Errorf("flagSearch(--starred --unread) = %+v, want FLAGGED and UNSEEN only", opts)
+	}
+	if opts.Limit != 20 || opts.Offset != 40 {
+		t.Errorf("flagSearch() window = %d/%d, want 20/40", opts.Limit, opts.Offset)
+	}
+
+	opts = (&MailListCmd{Answered: true}).flagSearch(10, 0)
+	if opts.Answered == nil || !*opts.Answered || opts.Flagged != nil || opts.Seen != nil {
+		t.Errorf("flagSearch(--answered) = %+v, want ANSWERED only", opts)
+	}
+
+	if got := (&MailListCmd{Unread: true, Starred: true}).filterWords(); got != "unread starred " {
+		t.Errorf("filterWords() = %q", got)
+	}
+}
+
+func TestMailListRejectsBadLimit(t *testing.T) {
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "test@example.com"
+
+	for _, cmd := range []*MailListCmd{
+		{Limit: -1},
+		{All: true, Page: 2},
+	} {
+		if err := cmd.Run(ctx); err == nil || !strings.Contains(err.Error(), "--") {
+			t.Errorf("Run(limit %d, all %v, page %d) = %v, want a flag error", cmd.Limit, cmd.All, cmd.Page, err)
+		}
+	}
+}
+
+func TestParseMessageBodyStubTextFallsBackToHTML(t *testing.T) {
+	alternative := func(text string) []byte {
+		return []byte("MIME-Version: 1.0\r\n" +
+			"Content-Type: multipart/alternative; boundary=\"alt\"\r\n" +
+			"\r\n" +
+			"--alt\r\n" +
+			"Content-Type: text/plain; charset=utf-8\r\n" +
+			"\r\n" +
+			text + "\r\n" +
+			"--alt\r\n" +
+			"Content-Type: text/html; charset=utf-8\r\n" +
+			"\r\n" +
+			"<p>Your order has shipped and will arrive on Thursday. Track it from your account page.</p>\r\n" +
+			"--alt--\r\n")
+	}
+
+	for name, text := range map[string]string{
+		"whitespace only": " \r\n\t\r\n ",
+		"view in browser": "View this email in your browser",
+	} {
+		textBody, htmlBody := parseMessageBody(alternative(text))
+		if textBody != "" || htmlBody == "" {
+			t.Errorf("%s: parseMessageBody() text = %q, want it dropped for the HTML part", name, textBody)
+		}
+	}
+
+	real := "Your order has shipped and will arrive on Thursday. Track it from your account page."
+	if textBody, _ := parseMessageBody(alternative(real)); !containsStr(textBody, "arrive on Thursday") {
+		t.Errorf("parseMessageBody() text = %q, want the real text part kept", textBody)
+	}
+
+	cmd := &MailReadCmd{}
+	ctx := &Context{Formatter: output.New(false, false, false, true), Config: config.DefaultConfig()}
+	var buf bytes.Buffer
+	ctx.Formatter.Writer = &buf
+	cmd.printMessage(ctx, &imap.Message{RawBody: alternative("   ")})
+	if !containsStr(buf.String(), "Your order has shipped") {
+		t.Errorf("printMessage() body = %q, want the HTML converted to text", buf.String())
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "out", "nested", "message.json")
+	if err := writeFileAtomic(target, []byte(`{"uid":1}`)); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	if err := writeFileAtomic(target, []byte(`{"uid":2}`)); err != nil {
+		t.Fatalf("writeFileAtomic(replace) error = %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != `{"uid":2}` {
+		t.Errorf("file = %q, want the second write", data)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(target))
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want no temp files left behind", len(entries))
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestPageOffset(t *testing.T) {
+	tests := []struct{ offset, page, limit, want int }{
+		{0, 0, 20, 0},
+		{15, 0, 20, 15},
+		{15, 3, 20, 40}, // the page wins over --offset
+		{0, 1, 20, 0},
+	}
+	for _, tt := range tests {
+		if got := pageOffset(tt.offset, tt.page, tt.limit); got != tt.want {
+			t.Errorf("pageOffset(%d, %d, %d) = %d, want %d", tt.offset, tt.page, tt.limit, got, tt.want)
+		}
+	}
+}
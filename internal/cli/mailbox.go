@@ -1,14 +1,17 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/bscott/pm-cli/internal/imap"
 )
 
 func (c *MailboxListCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -28,7 +31,20 @@ func (c *MailboxListCmd) Run(ctx *Context) error {
 		return err
 	}
 
+	// JSON output always carries each mailbox's UIDVALIDITY, so clients
+	// can tell when UIDs they stored were invalidated
+	if c.Counts || (ctx.Formatter.JSON && missingUIDValidity(mailboxes)) {
+		ctx.Formatter.Verbosef("Fetching mailbox status...")
+		fetchMailboxStatus(ctx, client, mailboxes, c.Concurrency, c.Counts)
+	}
+
 	if ctx.Formatter.JSON {
+		if c.Tree {
+			return ctx.Formatter.PrintJSON(map[string]interface{}{
+				"count":     len(mailboxes),
+				"mailboxes": buildMailboxTree(mailboxes),
+			})
+		}
 		return ctx.Formatter.PrintJSON(map[string]interface{}{
 			"count":     len(mailboxes),
 			"mailboxes": mailboxes,
@@ -42,20 +58,161 @@ func (c *MailboxListCmd) Run(ctx *Context) error {
 
 	fmt.Printf("Mailboxes (%d):\n\n", len(mailboxes))
 
+	if c.Tree {
+		printMailboxTree(buildMailboxTree(mailboxes), 1)
+		return nil
+	}
+
 	for _, mb := range mailboxes {
-		attrs := ""
-		if len(mb.Attributes) > 0 {
-			attrs = fmt.Sprintf(" [%s]", formatAttributes(mb.Attributes))
-		}
-		fmt.Printf("  %s%s\n", mb.Name, attrs)
+		fmt.Printf("  %s\n", mailboxLine(mb, mb.Name))
 	}
 
 	return nil
 }
 
+// mailboxLine formats mb for text output under the given display name.
+func mailboxLine(mb imap.MailboxInfo, name string) string {
+	attrs := ""
+	if len(mb.Attributes) > 0 {
+		attrs = fmt.Sprintf(" [%s]", formatAttributes(mb.Attributes))
+	}
+	counts := ""
+	if mb.Status != nil {
+		counts = fmt.Sprintf(" (%d messages, %d unread)", mb.Status.Messages, mb.Status.Unseen)
+	}
+	return name + counts + attrs
+}
+
+// mailboxNode is a mailbox in the folder hierarchy. Label is the last
+// component of the name.
+type mailboxNode struct {
+	imap.MailboxInfo
+	Label    string         `json:"label"`
+	Children []*mailboxNode `json:"children,omitempty"`
+}
+
+// buildMailboxTree nests mailboxes by splitting their names on the server's
+// delimiter, so that Labels/Work ends up under Labels. Parents the server
+// did not list are added as \Noselect nodes. Server order is kept.
+func buildMailboxTree(mailboxes []imap.MailboxInfo) []*mailboxNode {
+	var roots []*mailboxNode
+	byName := make(map[string]*mailboxNode)
+
+	for _, mb := range mailboxes {
+		parts := []string{mb.Name}
+		if mb.Delimiter != "" {
+			parts = strings.Split(mb.Name, mb.Delimiter)
+		}
+
+		var parent *mailboxNode
+		for i, part := range parts {
+			name := strings.Join(parts[:i+1], mb.Delimiter)
+			node, ok := byName[name]
+			if !ok {
+				node = &mailboxNode{
+					MailboxInfo: imap.MailboxInfo{Name: name, Delimiter: mb.Delimiter, Attributes: []string{`\Noselect`}},
+					Label:       part,
+				}
+				byName[name] = node
+				if parent == nil {
+					roots = append(roots, node)
+				} else {
+					parent.Children = append(parent.Children, node)
+				}
+			}
+			parent = node
+		}
+		parent.MailboxInfo = mb
+	}
+
+	return roots
+}
+
+func printMailboxTree(nodes []*mailboxNode, depth int) {
+	for _, node := range nodes {
+		fmt.Printf("%s%s\n", strings.Repeat("  ", depth), mailboxLine(node.MailboxInfo, node.Label))
+		printMailboxTree(node.Children, depth+1)
+	}
+}
+
+// fetchMailboxStatus fills in UIDValidity, and with counts also Status, for
+// every selectable mailbox. Counts issue the STATUS commands in parallel over
+// separate connections, falling back to client when none can be opened;
+// UIDValidity alone is read over client. Mailboxes that fail are reported on
+// stderr and left without them.
+func fetchMailboxStatus(ctx *Context, client *imap.Client, mailboxes []imap.MailboxInfo, concurrency int, counts bool) {
+	var selectable []int
+	for i, mb := range mailboxes {
+		if isSelectable(mb) {
+			selectable = append(selectable, i)
+		}
+	}
+
+	status := func(client *imap.Client, i int) (*imap.MailboxStatus, error) {
+		return client.Status(mailboxes[selectable[i]].Name)
+	}
+
+	var statuses []*imap.MailboxStatus
+	var errs []error
+	if counts {
+		var err error
+		statuses, errs, err = imap.RunParallel(ctx.Config, client, concurrency, len(selectable), status)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	} else {
+		statuses = make([]*imap.MailboxStatus, len(selectable))
+		errs = make([]error, len(selectable))
+		for j := range selectable {
+			statuses[j], errs[j] = status(client, j)
+		}
+	}
+
+	for j, i := range selectable {
+		if errs[j] != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", errs[j])
+			continue
+		}
+		mailboxes[i].UIDValidity = statuses[j].UIDValidity
+		if counts {
+			mailboxes[i].Status = statuses[j]
+		}
+	}
+}
+
+// missingUIDValidity reports whether a selectable mailbox came back from
+// LIST without its UIDVALIDITY, as it does when the server lacks
+// LIST-STATUS.
+func missingUIDValidity(mailboxes []imap.MailboxInfo) bool {
+	for _, mb := range mailboxes {
+		if isSelectable(mb) && mb.UIDValidity == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func isSelectable(mb imap.MailboxInfo) bool {
+	return !hasAttribute(mb.Attributes, `\Noselect`) && !hasAttribute(mb.Attributes, `\NonExistent`)
+}
+
+func hasAttribute(attrs []string, want string) bool {
+	for _, attr := range attrs {
+		if strings.EqualFold(attr, want) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *MailboxCreateCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
+	}
+
+	name, err := imap.NormalizeMailboxName(c.Name)
+	if err != nil {
+		return err
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -68,25 +225,30 @@ func (c *MailboxCreateCmd) Run(ctx *Context) error {
 	}
 	defer client.Close()
 
-	if err := client.CreateMailbox(c.Name); err != nil {
+	if err := client.CreateMailbox(name); err != nil {
 		return err
 	}
 
 	if ctx.Formatter.JSON {
 		return ctx.Formatter.PrintJSON(map[string]interface{}{
 			"success": true,
-			"mailbox": c.Name,
+			"mailbox": name,
 			"message": "Mailbox created",
 		})
 	}
 
-	fmt.Printf("Mailbox '%s' created.\n", c.Name)
+	fmt.Printf("Mailbox '%s' created.\n", name)
 	return nil
 }
 
 func (c *MailboxDeleteCmd) Run(ctx *Context) error {
 	if ctx.Config.Bridge.Email == "" {
-		return fmt.Errorf("not configured - run 'pm-cli config init' first")
+		return ErrNotConfigured
+	}
+
+	name, err := imap.NormalizeMailboxName(c.Name)
+	if err != nil {
+		return err
 	}
 
 	client, err := imap.NewClient(ctx.Config)
@@ -99,22 +261,104 @@ func (c *MailboxDeleteCmd) Run(ctx *Context) error {
 	}
 	defer client.Close()
 
-	if err := client.DeleteMailbox(c.Name); err != nil {
+	if err := client.DeleteMailbox(name); err != nil {
 		return err
 	}
 
 	if ctx.Formatter.JSON {
 		return ctx.Formatter.PrintJSON(map[string]interface{}{
 			"success": true,
-			"mailbox": c.Name,
+			"mailbox": name,
 			"message": "Mailbox deleted",
 		})
 	}
 
-	fmt.Printf("Mailbox '%s' deleted.\n", c.Name)
+	fmt.Printf("Mailbox '%s' deleted.\n", name)
+	return nil
+}
+
+func (c *MailboxQuotaCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx.Formatter.Verbosef("Fetching quota...")
+
+	quota, err := client.Quota()
+	if errors.Is(err, imap.ErrQuotaNotSupported) {
+		if ctx.Formatter.JSON {
+			return ctx.Formatter.PrintJSON(map[string]interface{}{
+				"supported": false,
+				"message":   "Server does not advertise QUOTA",
+			})
+		}
+		fmt.Println("Quota information is not available: the server does not advertise QUOTA.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"supported": true,
+			"quota":     quota,
+		})
+	}
+
+	if quota.Root != "" {
+		fmt.Printf("Quota root: %s\n\n", quota.Root)
+	}
+
+	if quota.StorageLimit > 0 {
+		fmt.Printf("Storage:  %s %s of %s\n",
+			quotaBar(quota.StorageUsed, quota.StorageLimit, 30),
+			formatSize(quota.StorageUsed), formatSize(quota.StorageLimit))
+	} else {
+		fmt.Printf("Storage:  %s (no limit)\n", formatSize(quota.StorageUsed))
+	}
+
+	if quota.MessagesLimit > 0 {
+		fmt.Printf("Messages: %s %d of %d\n",
+			quotaBar(quota.MessagesUsed, quota.MessagesLimit, 30),
+			quota.MessagesUsed, quota.MessagesLimit)
+	} else if quota.MessagesUsed > 0 {
+		fmt.Printf("Messages: %d (no limit)\n", quota.MessagesUsed)
+	}
+
 	return nil
 }
 
+// quotaBar renders usage as a fixed-width bar followed by a percentage,
+// e.g. "[#######-------]  50.0%".
+func quotaBar(used, limit int64, width int) string {
+	if limit <= 0 || width <= 0 {
+		return ""
+	}
+
+	ratio := float64(used) / float64(limit)
+	if ratio < 0 {
+		ratio = 0
+	}
+	filled := int(ratio * float64(width))
+	if filled > width {
+		filled = width
+	}
+
+	return fmt.Sprintf("[%s%s] %5.1f%%",
+		strings.Repeat("#", filled), strings.Repeat("-", width-filled), ratio*100)
+}
+
 func formatAttributes(attrs []string) string {
 	if len(attrs) == 0 {
 		return ""
@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/bscott/pm-cli/internal/imap"
 )
 
 func TestMailboxListCmdRunWithoutEmail(t *testing.T) {
@@ -48,6 +51,44 @@ func TestMailboxDeleteCmdRunWithoutConfig(t *testing.T) {
 	}
 }
 
+func TestMailboxQuotaCmdRunWithoutConfig(t *testing.T) {
+	cmd := &MailboxQuotaCmd{}
+
+	globals := &Globals{}
+	ctx, _ := NewContext(globals)
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	err := cmd.Run(ctx)
+	if err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
+
+func TestQuotaBar(t *testing.T) {
+	tests := []struct {
+		name  string
+		used  int64
+		limit int64
+		width int
+		want  string
+	}{
+		{"empty", 0, 100, 10, "[----------]   0.0%"},
+		{"half", 50, 100, 10, "[#####-----]  50.0%"},
+		{"full", 100, 100, 10, "[##########] 100.0%"},
+		{"over limit", 150, 100, 10, "[##########] 150.0%"},
+		{"no limit", 50, 0, 10, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := quotaBar(tt.used, tt.limit, tt.width)
+			if got != tt.want {
+				t.Errorf("quotaBar(%d, %d, %d) = %q, want %q", tt.used, tt.limit, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestFormatAttributesEmpty(t *testing.T) {
 	result := formatAttributes([]string{})
 	if result != "" {
@@ -111,3 +152,69 @@ func TestMailboxDeleteCmdStruct(t *testing.T) {
 		t.Errorf("Name = %q, want %q", cmd.Name, "OldFolder")
 	}
 }
+
+func TestBuildMailboxTree(t *testing.T) {
+	mailboxes := []imap.MailboxInfo{
+		{Name: "INBOX", Delimiter: "/"},
+		{Name: "Folders", Delimiter: "/", Attributes: []string{`\Noselect`}},
+		{Name: "Folders/Work", Delimiter: "/"},
+		{Name: "Folders/Work/Q3", Delimiter: "/"},
+		{Name: "Labels/Receipts", Delimiter: "/"},
+		{Name: "Labels/Travel", Delimiter: "/"},
+		{Name: "Labels", Delimiter: "/", Attributes: []string{`\Noselect`, `\HasChildren`}},
+		{Name: "Flat", Delimiter: ""},
+	}
+
+	roots := buildMailboxTree(mailboxes)
+
+	var labels []string
+	for _, r := range roots {
+		labels = append(labels, r.Label)
+	}
+	if !reflect.DeepEqual(labels, []string{"INBOX", "Folders", "Labels", "Flat"}) {
+		t.Fatalf("roots = %v", labels)
+	}
+
+	work := roots[1].Children
+	if len(work) != 1 || work[0].Label != "Work" || work[0].Name != "Folders/Work" {
+		t.Fatalf("Folders children = %+v", work)
+	}
+	if len(work[0].Children) != 1 || work[0].Children[0].Name != "Folders/Work/Q3" || work[0].Children[0].Label != "Q3" {
+		t.Errorf("Folders/Work children = %+v", work[0].Children)
+	}
+
+	// Labels is listed after its children; the server's entry replaces the
+	// placeholder without losing them
+	labelsNode := roots[2]
+	if len(labelsNode.Children) != 2 || labelsNode.Children[0].Label != "Receipts" || labelsNode.Children[1].Label != "Travel" {
+		t.Errorf("Labels children = %+v", labelsNode.Children)
+	}
+	if !reflect.DeepEqual(labelsNode.Attributes, []string{`\Noselect`, `\HasChildren`}) {
+		t.Errorf("Labels attributes = %v", labelsNode.Attributes)
+	}
+}
+
+func TestBuildMailboxTreeAddsMissingParents(t *testing.T) {
+	roots := buildMailboxTree([]imap.MailboxInfo{{Name: "Labels/Receipts", Delimiter: "/"}})
+	if len(roots) != 1 || roots[0].Name != "Labels" || !hasAttribute(roots[0].Attributes, `\Noselect`) {
+		t.Fatalf("roots = %+v", roots)
+	}
+	if len(roots[0].Children) != 1 || roots[0].Children[0].Name != "Labels/Receipts" {
+		t.Errorf("children = %+v", roots[0].Children)
+	}
+}
+
+func TestMissingUIDValidity(t *testing.T) {
+	listed := []imap.MailboxInfo{
+		{Name: "INBOX", UIDValidity: 7},
+		{Name: "Folders", Attributes: []string{`\Noselect`}},
+	}
+	if missingUIDValidity(listed) {
+		t.Error("missingUIDValidity() = true with every selectable mailbox known")
+	}
+
+	listed = append(listed, imap.MailboxInfo{Name: "Archive"})
+	if !missingUIDValidity(listed) {
+		t.Error("missingUIDValidity() = false with Archive unknown")
+	}
+}
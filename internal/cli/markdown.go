@@ -0,0 +1,340 @@
+package cli
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// htmlTokenRegex splits HTML into tags and comments; the text between
+// matches is character data.
+var htmlTokenRegex = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>`)
+
+var (
+	tagNameRegex = regexp.MustCompile(`^</?\s*([a-zA-Z][a-zA-Z0-9]*)`)
+	hrefRegex    = regexp.MustCompile(`(?i)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	srcRegex     = regexp.MustCompile(`(?i)\bsrc\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	altRegex     = regexp.MustCompile(`(?i)\balt\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	spaceRegex   = regexp.MustCompile(`\s+`)
+
+	markdownTrailingSpaceRegex = regexp.MustCompile(`(?m)[ \t]+$`)
+	markdownBlankLinesRegex    = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlAttr returns the unescaped value of the attribute matched by re.
+func htmlAttr(tag string, re *regexp.Regexp) string {
+	m := re.FindStringSubmatch(tag)
+	if m == nil {
+		return ""
+	}
+	return html.UnescapeString(m[1] + m[2] + m[3])
+}
+
+// markdownList is an open <ul> or <ol>.
+type markdownList struct {
+	ordered bool
+	next    int
+}
+
+// markdownWriter holds the state of an HTML to Markdown conversion.
+type markdownWriter struct {
+	b           bytes.Buffer
+	skip        int // depth inside <style>, <script>, <head> or <title>
+	pre         int // depth inside <pre>
+	lists       []markdownList
+	links       []int    // output offsets where open links start
+	hrefs       []string // targets of open links
+	quotes      []int    // output offsets where open blockquotes start
+	pendingMark string   // emphasis marker waiting for its first text
+}
+
+// htmlToMarkdown converts an HTML body to Markdown, keeping headings,
+// lists, links, emphasis, quotes and code. Unlike htmlToText it is meant
+// for tools that render or store Markdown.
+func htmlToMarkdown(htmlContent string) string {
+	w := &markdownWriter{}
+
+	pos := 0
+	for _, loc := range htmlTokenRegex.FindAllStringIndex(htmlContent, -1) {
+		w.text(htmlContent[pos:loc[0]])
+		w.tag(htmlContent[loc[0]:loc[1]])
+		pos = loc[1]
+	}
+	w.text(htmlContent[pos:])
+
+	out := w.b.String()
+	out = markdownTrailingSpaceRegex.ReplaceAllString(out, "")
+	out = markdownBlankLinesRegex.ReplaceAllString(out, "\n\n")
+	return strings.TrimSpace(out)
+}
+
+func (w *markdownWriter) text(s string) {
+	if w.skip > 0 || s == "" {
+		return
+	}
+	s = html.UnescapeString(s)
+	if w.pre > 0 {
+		w.b.WriteString(s)
+		return
+	}
+
+	s = spaceRegex.ReplaceAllString(s, " ")
+	// Collapse the space between inline elements and never start a line
+	// with one
+	if strings.HasPrefix(s, " ") && (w.b.Len() == 0 || w.endsWith(" ") || w.endsWith("\n")) {
+		s = s[1:]
+	}
+	if s == "" {
+		return
+	}
+
+	if w.pendingMark != "" {
+		// "<b> bold</b>" must become " **bold**", not "** bold**"
+		if strings.HasPrefix(s, " ") {
+			w.b.WriteString(" ")
+			s = s[1:]
+		}
+		w.b.WriteString(w.pendingMark)
+		w.pendingMark = ""
+	}
+	w.b.WriteString(s)
+}
+
+func (w *markdownWriter) endsWith(s string) bool {
+	return bytes.HasSuffix(w.b.Bytes(), []byte(s))
+}
+
+// block ends the current paragraph.
+func (w *markdownWriter) block() {
+	if w.b.Len() == 0 || w.endsWith("\n\n") {
+		return
+	}
+	if w.endsWith("\n") {
+		w.b.WriteString("\n")
+		return
+	}
+	w.b.WriteString("\n\n")
+}
+
+// line ends the current line.
+func (w *markdownWriter) line() {
+	if w.b.Len() > 0 && !w.endsWith("\n") {
+		w.b.WriteString("\n")
+	}
+}
+
+func (w *markdownWriter) openMark(mark string) {
+	if w.pendingMark != "" {
+		w.pendingMark += mark
+		return
+	}
+	w.pendingMark = mark
+}
+
+func (w *markdownWriter) closeMark(mark string) {
+	if w.pendingMark != "" {
+		// Nothing was written inside the element
+		w.pendingMark = strings.TrimSuffix(w.pendingMark, mark)
+		return
+	}
+	trimmed := len(bytes.TrimRight(w.b.Bytes(), " "))
+	spaced := trimmed < w.b.Len()
+	w.b.Truncate(trimmed)
+	w.b.WriteString(mark)
+	if spaced {
+		w.b.WriteString(" ")
+	}
+}
+
+func (w *markdownWriter) tag(tag string) {
+	if strings.HasPrefix(tag, "<!") {
+		return
+	}
+	m := tagNameRegex.FindStringSubmatch(tag)
+	if m == nil {
+		return
+	}
+	name := strings.ToLower(m[1])
+	closing := strings.HasPrefix(tag, "</")
+
+	switch name {
+	case "style", "script", "head", "title":
+		if closing {
+			if w.skip > 0 {
+				w.skip--
+			}
+		} else if !strings.HasSuffix(tag, "/>") {
+			w.skip++
+		}
+		return
+	}
+	if w.skip > 0 {
+		return
+	}
+
+	switch name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		w.block()
+		if !closing {
+			level, _ := strconv.Atoi(name[1:])
+			w.b.WriteString(strings.Repeat("#", level) + " ")
+		}
+	case "p", "div", "table", "section", "article", "header", "footer":
+		w.block()
+	case "tr":
+		w.line()
+	case "td", "th":
+		if !closing && w.b.Len() > 0 && !w.endsWith("\n") {
+			w.b.WriteString(" | ")
+		}
+	case "br":
+		w.line()
+	case "hr":
+		w.block()
+		w.b.WriteString("---\n\n")
+	case "strong", "b":
+		if closing {
+			w.closeMark("**")
+		} else {
+			w.openMark("**")
+		}
+	case "em", "i":
+		if closing {
+			w.closeMark("*")
+		} else {
+			w.openMark("*")
+		}
+	case "code":
+		if w.pre == 0 {
+			if closing {
+				w.closeMark("`")
+			} else {
+				w.openMark("`")
+			}
+		}
+	case "pre":
+		if closing {
+			if w.pre > 0 {
+				w.pre--
+			}
+			w.line()
+			w.b.WriteString("```\n\n")
+		} else {
+			w.block()
+			w.pre++
+			w.b.WriteString("```\n")
+		}
+	case "ul", "ol":
+		if closing {
+			if len(w.lists) > 0 {
+				w.lists = w.lists[:len(w.lists)-1]
+			}
+			if len(w.lists) == 0 {
+				w.block()
+			}
+		} else {
+			if len(w.lists) == 0 {
+				w.block()
+			}
+			w.lists = append(w.lists, markdownList{ordered: name == "ol", next: 1})
+		}
+	case "li":
+		if closing {
+			return
+		}
+		w.line()
+		depth := len(w.lists)
+		if depth == 0 {
+			w.b.WriteString("- ")
+			return
+		}
+		w.b.WriteString(strings.Repeat("  ", depth-1))
+		list := &w.lists[depth-1]
+		if list.ordered {
+			w.b.WriteString(strconv.Itoa(list.next) + ". ")
+			list.next++
+		} else {
+			w.b.WriteString("- ")
+		}
+	case "a":
+		if closing {
+			w.closeLink()
+			return
+		}
+		w.links = append(w.links, w.b.Len())
+		w.hrefs = append(w.hrefs, htmlAttr(tag, hrefRegex))
+	case "img":
+		if alt := strings.TrimSpace(htmlAttr(tag, altRegex)); alt != "" {
+			w.text("![" + alt + "](" + htmlAttr(tag, srcRegex) + ")")
+		}
+	case "blockquote":
+		if closing {
+			w.closeQuote()
+		} else {
+			w.block()
+			w.quotes = append(w.quotes, w.b.Len())
+		}
+	}
+}
+
+// closeLink rewrites the text written since the matching <a> as
+// [text](href). Links without a target keep their text, and links whose
+// text is the URL become <url>.
+func (w *markdownWriter) closeLink() {
+	if len(w.links) == 0 {
+		return
+	}
+	start, href := w.links[len(w.links)-1], w.hrefs[len(w.hrefs)-1]
+	w.links, w.hrefs = w.links[:len(w.links)-1], w.hrefs[:len(w.hrefs)-1]
+
+	if start > w.b.Len() {
+		start = w.b.Len()
+	}
+	text := string(w.b.Bytes()[start:])
+	label := strings.TrimSpace(text)
+	if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return
+	}
+
+	w.b.Truncate(start)
+	if strings.HasPrefix(text, " ") {
+		w.b.WriteString(" ")
+	}
+	switch label {
+	case "", href, strings.TrimPrefix(href, "mailto:"):
+		w.b.WriteString("<" + href + ">")
+	default:
+		w.b.WriteString("[" + label + "](" + href + ")")
+	}
+	if label != "" && strings.HasSuffix(text, " ") {
+		w.b.WriteString(" ")
+	}
+}
+
+// closeQuote prefixes every line written since the matching <blockquote>
+// with "> ".
+func (w *markdownWriter) closeQuote() {
+	if len(w.quotes) == 0 {
+		return
+	}
+	start := w.quotes[len(w.quotes)-1]
+	w.quotes = w.quotes[:len(w.quotes)-1]
+
+	if start > w.b.Len() {
+		start = w.b.Len()
+	}
+	quoted := strings.Trim(string(w.b.Bytes()[start:]), "\n")
+	if quoted == "" {
+		return
+	}
+	lines := strings.Split(quoted, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+
+	w.b.Truncate(start)
+	w.b.WriteString(strings.Join(lines, "\n"))
+	w.b.WriteString("\n\n")
+}
@@ -0,0 +1,82 @@
+package cli
+
+import "testing"
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "headings and paragraphs",
+			input: "<h1>Welcome</h1><p>First paragraph.</p><h2>Details</h2><p>Second.</p>",
+			want:  "# Welcome\n\nFirst paragraph.\n\n## Details\n\nSecond.",
+		},
+		{
+			name:  "emphasis keeps surrounding spaces outside markers",
+			input: "<p>This is<b> bold </b>and <em>italic</em> and <code>x := 1</code>.</p>",
+			want:  "This is **bold** and *italic* and `x := 1`.",
+		},
+		{
+			name:  "links",
+			input: `<p>Read <a href="https://example.com/a?x=1&amp;y=2">the docs</a>, or mail <a href="mailto:help@example.com">help@example.com</a>.</p>`,
+			want:  "Read [the docs](https://example.com/a?x=1&y=2), or mail <mailto:help@example.com>.",
+		},
+		{
+			name:  "nested lists",
+			input: "<ul><li>One</li><li>Two<ol><li>Alpha</li><li>Beta</li></ol></li></ul><p>After</p>",
+			want:  "- One\n- Two\n  1. Alpha\n  2. Beta\n\nAfter",
+		},
+		{
+			name:  "blockquote",
+			input: "<p>Reply</p><blockquote><p>Line one</p><p>Line two</p></blockquote>",
+			want:  "Reply\n\n> Line one\n>\n> Line two",
+		},
+		{
+			name:  "pre keeps whitespace",
+			input: "<pre>  indented\n    more</pre>",
+			want:  "```\n  indented\n    more\n```",
+		},
+		{
+			name:  "style, script and comments dropped",
+			input: "<html><head><title>T</title><style>p{color:red}</style></head><body><!-- hidden --><script>alert(1)</script><p>Visible &amp; kept</p></body></html>",
+			want:  "Visible & kept",
+		},
+		{
+			name:  "images with alt text",
+			input: `<p><img src="https://example.com/logo.png" alt="Logo"><img src="https://example.com/pixel.gif"></p>`,
+			want:  "![Logo](https://example.com/logo.png)",
+		},
+		{
+			name:  "javascript links keep only their text",
+			input: `<a href="javascript:void(0)">Click</a>`,
+			want:  "Click",
+		},
+		{
+			name:  "table cells",
+			input: "<table><tr><td>Item</td><td>Price</td></tr><tr><td>Book</td><td>$10</td></tr></table>",
+			want:  "Item | Price\nBook | $10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToMarkdown(tt.input); got != tt.want {
+				t.Errorf("htmlToMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownBody(t *testing.T) {
+	if got := markdownBody("plain *text*", "", false); got != "plain *text*" {
+		t.Errorf("markdownBody(text only) = %q", got)
+	}
+	if got := markdownBody("plain", "<p><b>rich</b></p>", false); got != "**rich**" {
+		t.Errorf("markdownBody(html) = %q, want HTML converted", got)
+	}
+	if got := markdownBody("", "<p>Reply</p><blockquote><p>Old</p></blockquote>", true); got != "Reply" {
+		t.Errorf("markdownBody(noQuotes) = %q, want quote stripped", got)
+	}
+}
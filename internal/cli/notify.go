@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// windowsToastScript shows a toast using the WinRT notification API. The
+// title and body are read from the environment so that email-derived text
+// is never interpolated into the script.
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$n = $t.GetElementsByTagName('text')
+$n.Item(0).AppendChild($t.CreateTextNode($env:PM_NOTIFY_TITLE)) > $null
+$n.Item(1).AppendChild($t.CreateTextNode($env:PM_NOTIFY_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('pm-cli').Show([Windows.UI.Notifications.ToastNotification]::new($t))`
+
+// notificationCommand returns the program, arguments and extra environment
+// used to show a desktop notification on goos. ok is false when the
+// platform has no supported notifier.
+func notificationCommand(goos, title, body string) (name string, args []string, env []string, ok bool) {
+	switch goos {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return "notify-send", []string{"--app-name=pm-cli", "--", title, body}, nil, true
+	case "darwin":
+		// Pass text as script arguments rather than embedding it in AppleScript
+		return "osascript", []string{
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, body,
+		}, nil, true
+	case "windows":
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", windowsToastScript},
+			[]string{"PM_NOTIFY_TITLE=" + title, "PM_NOTIFY_BODY=" + body}, true
+	}
+	return "", nil, nil, false
+}
+
+// sendDesktopNotification shows a native desktop notification. When no
+// notifier is available, or it fails, the notification is written to
+// stderr instead.
+func sendDesktopNotification(title, body string) {
+	name, args, env, ok := notificationCommand(runtime.GOOS, title, body)
+	if ok {
+		if path, err := exec.LookPath(name); err == nil {
+			cmd := exec.Command(path, args...)
+			cmd.Env = append(os.Environ(), env...)
+			if err := cmd.Run(); err == nil {
+				return
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: %s\n", title, body)
+}
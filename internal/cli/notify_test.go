@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNotificationCommand(t *testing.T) {
+	title := "New mail from Alice"
+	body := `Subject with "quotes" and $(danger)`
+
+	tests := []struct {
+		goos     string
+		wantName string
+		wantOK   bool
+	}{
+		{"linux", "notify-send", true},
+		{"darwin", "osascript", true},
+		{"windows", "powershell", true},
+		{"plan9", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			name, args, env, ok := notificationCommand(tt.goos, title, body)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if name != tt.wantName {
+				t.Errorf("name = %q, want %q", name, tt.wantName)
+			}
+			if !ok {
+				return
+			}
+
+			// Email-derived text must be passed verbatim as a separate
+			// argument or environment value, never spliced into a script.
+			all := append(append([]string{}, args...), env...)
+			found := false
+			for _, a := range all {
+				if a == body || a == "PM_NOTIFY_BODY="+body {
+					found = true
+				}
+				if a != body && strings.Contains(a, "$(danger)") && !strings.HasPrefix(a, "PM_NOTIFY_BODY=") {
+					t.Errorf("body interpolated into argument %q", a)
+				}
+			}
+			if !found {
+				t.Errorf("body not passed as a standalone value: args=%q env=%q", args, env)
+			}
+		})
+	}
+}
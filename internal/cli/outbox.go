@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/outbox"
+	"github.com/bscott/pm-cli/internal/safetext"
+	"github.com/bscott/pm-cli/internal/smtp"
+)
+
+// queueUnsent puts msg in the outbox after a send that failed because the
+// SMTP server could not be reached, prints where it went and reports
+// whether it was queued. Once queued it returns ErrQueued, since the
+// message was still not sent. Any other failure is returned as is; the
+// message may have been rejected, and sending it again later would fail
+// the same way.
+func queueUnsent(ctx *Context, msg *smtp.Message, sendErr error) (bool, error) {
+	if !errors.Is(sendErr, smtp.ErrConnect) {
+		return false, withForceHint(sendErr)
+	}
+	entry, err := outbox.Add(msg, sendErr)
+	if err != nil {
+		return false, fmt.Errorf("%w (queueing it in the outbox failed too: %v)", sendErr, err)
+	}
+
+	if ctx.Formatter.JSON {
+		if err := ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success": true,
+			"queued":  true,
+			"id":      entry.ID,
+			"message": "SMTP server unreachable; message queued in the outbox",
+			"error":   sendErr.Error(),
+			"to":      msg.To,
+			"subject": msg.Subject,
+		}); err != nil {
+			return true, err
+		}
+		return true, ErrQueued
+	}
+	fmt.Printf("%s %v\n", ctx.Formatter.WarningText("Not sent:"), sendErr)
+	fmt.Printf("Queued as %s. Run 'pm-cli mail outbox flush' once Bridge is running.\n", entry.ID)
+	return true, ErrQueued
+}
+
+// outboxSummary is the JSON form of a queued message in 'mail outbox
+// list', without the body and attachment content.
+func outboxSummary(e outbox.Entry) map[string]interface{} {
+	attachments := make([]string, len(e.Message.AttachmentData))
+	for i, att := range e.Message.AttachmentData {
+		attachments[i] = att.Filename
+	}
+	summary := map[string]interface{}{
+		"id":          e.ID,
+		"queued_at":   e.QueuedAt.Format(time.RFC3339),
+		"attempts":    e.Attempts,
+		"to":          e.Message.To,
+		"subject":     e.Message.Subject,
+		"attachments": attachments,
+	}
+	if len(e.Message.CC) > 0 {
+		summary["cc"] = e.Message.CC
+	}
+	if e.LastError != "" {
+		summary["last_error"] = e.LastError
+	}
+	return summary
+}
+
+func (c *OutboxListCmd) Run(ctx *Context) error {
+	entries, err := outbox.List()
+	if err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		messages := make([]map[string]interface{}, len(entries))
+		for i, e := range entries {
+			messages[i] = outboxSummary(e)
+		}
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"count":    len(entries),
+			"messages": messages,
+		})
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("Outbox is empty")
+		return nil
+	}
+
+	loc, _ := ctx.Config.Location()
+	tw := ctx.Formatter.NewTable("ID", "TO", "SUBJECT", "QUEUED", "ATTEMPTS", "LAST ERROR")
+	for _, e := range entries {
+		tw.AddRow(
+			e.ID,
+			truncate(safetext.SanitizeForTerminal(strings.Join(e.Message.To, ", ")), 30),
+			truncate(safetext.SanitizeForTerminal(e.Message.Subject), 40),
+			ctx.displayDate(e.QueuedAt.Format(time.RFC3339), e.QueuedAt.In(loc).Format("2006-01-02 15:04")),
+			strconv.Itoa(e.Attempts),
+			truncate(safetext.SanitizeForTerminal(e.LastError), 40),
+		)
+	}
+	tw.Flush()
+	return nil
+}
+
+func (c *OutboxFlushCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	entries, err := selectOutboxEntries(c.IDs)
+	if err != nil {
+		return err
+	}
+
+	var sent []string
+	var failures []string
+	var connErr error
+	if len(entries) > 0 {
+		password, err := ctx.Config.GetPassword()
+		if err != nil {
+			return err
+		}
+		smtpClient := smtp.NewClient(ctx.Config, password)
+
+		for _, e := range entries {
+			ctx.Formatter.Verbosef("Sending %s to %s...", e.ID, strings.Join(e.Message.To, ", "))
+			sendErr := smtpClient.Send(e.Message)
+			if sendErr == nil {
+				sent = append(sent, e.ID)
+				if err := outbox.Remove(e.ID); err != nil {
+					// Sent, but still queued: a later flush would send it twice
+					return fmt.Errorf("sent %s but could not remove it from the outbox: %w", e.ID, err)
+				}
+				continue
+			}
+
+			e.Attempts++
+			e.LastError = sendErr.Error()
+			if err := e.Save(); err != nil {
+				return err
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", e.ID, sendErr))
+			if errors.Is(sendErr, smtp.ErrConnect) {
+				// Bridge is still down; the rest would fail the same way
+				connErr = sendErr
+				break
+			}
+		}
+	}
+
+	remaining, err := outbox.List()
+	if err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		if sent == nil {
+			sent = []string{}
+		}
+		result := map[string]interface{}{
+			"success": len(failures) == 0,
+			"sent":    sent,
+			"pending": len(remaining),
+		}
+		if len(failures) > 0 {
+			result["errors"] = failures
+		}
+		return ctx.Formatter.PrintJSON(result)
+	}
+
+	for _, id := range sent {
+		fmt.Printf("Sent: %s\n", id)
+	}
+	fmt.Printf("%d sent, %d still queued.\n", len(sent), len(remaining))
+
+	if connErr != nil {
+		return fmt.Errorf("%d message(s) still queued: %w", len(remaining), connErr)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to send %d queued message(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func (c *OutboxDiscardCmd) Run(ctx *Context) error {
+	if len(c.IDs) == 0 && !c.All {
+		return fmt.Errorf("specify queued message IDs to discard, or --all")
+	}
+	if len(c.IDs) > 0 && c.All {
+		return fmt.Errorf("--all cannot be combined with message IDs")
+	}
+
+	entries, err := selectOutboxEntries(c.IDs)
+	if err != nil {
+		return err
+	}
+	discarded := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if err := outbox.Remove(e.ID); err != nil {
+			return err
+		}
+		discarded = append(discarded, e.ID)
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success":   true,
+			"message":   fmt.Sprintf("Discarded %d queued message(s)", len(discarded)),
+			"discarded": discarded,
+		})
+	}
+
+	ctx.Formatter.PrintSuccess(fmt.Sprintf("Discarded %d queued message(s)", len(discarded)))
+	return nil
+}
+
+// selectOutboxEntries returns the queued messages named by ids, in queue
+// order, or all of them when ids is empty. An unknown ID is an error so
+// that a typo does not quietly do nothing.
+func selectOutboxEntries(ids []string) ([]outbox.Entry, error) {
+	entries, err := outbox.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return entries, nil
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var selected []outbox.Entry
+	for _, e := range entries {
+		if wanted[e.ID] {
+			selected = append(selected, e)
+			delete(wanted, e.ID)
+		}
+	}
+	for _, id := range ids {
+		if wanted[id] {
+			return nil, fmt.Errorf("%w: %s", outbox.ErrNotFound, id)
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bscott/pm-cli/internal/config"
+	"github.com/bscott/pm-cli/internal/outbox"
+	"github.com/bscott/pm-cli/internal/output"
+	"github.com/bscott/pm-cli/internal/smtp"
+)
+
+func TestQueueUnsentAndDiscard(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	var buf bytes.Buffer
+	ctx := &Context{
+		Config:    config.DefaultConfig(),
+		Formatter: output.New(true, false, false, true),
+		Globals:   &Globals{},
+	}
+	ctx.Formatter.Writer = &buf
+
+	msg := &smtp.Message{To: []string{"you@example.com"}, Subject: "Hi", Body: "Hello"}
+
+	// Rejections are not queued; sending later would fail the same way
+	rejected := errors.New("550 mailbox unavailable")
+	if queued, err := queueUnsent(ctx, msg, rejected); queued || !errors.Is(err, rejected) {
+		t.Fatalf("queueUnsent(rejected) = %v, %v; want the send error back", queued, err)
+	}
+	if entries, _ := outbox.List(); len(entries) != 0 {
+		t.Fatalf("rejected message was queued: %+v", entries)
+	}
+
+	unreachable := fmt.Errorf("%w: connection refused", smtp.ErrConnect)
+	for i := 0; i < 2; i++ {
+		buf.Reset()
+		// Queued is still not sent, so it exits non-zero
+		if queued, err := queueUnsent(ctx, msg, unreachable); !queued || ExitCode(err) != ExitQueued {
+			t.Fatalf("queueUnsent(unreachable) = %v, %v; want queued with ErrQueued", queued, err)
+		}
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("queueUnsent output is not JSON: %v\n%s", err, buf.String())
+	}
+	if result["queued"] != true || result["id"] == "" {
+		t.Errorf("queueUnsent JSON = %v, want queued with an ID", result)
+	}
+
+	entries, err := outbox.List()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("List() = %d entries, %v; want 2", len(entries), err)
+	}
+
+	if err := (&OutboxDiscardCmd{}).Run(ctx); err == nil {
+		t.Error("expected discard without IDs or --all to fail")
+	}
+	if err := (&OutboxDiscardCmd{IDs: []string{"nope"}}).Run(ctx); ExitCode(err) != ExitNotFound {
+		t.Errorf("discard of an unknown ID = %v, want a not found error", err)
+	}
+	if err := (&OutboxDiscardCmd{IDs: []string{entries[0].ID}}).Run(ctx); err != nil {
+		t.Fatalf("discard %s error = %v", entries[0].ID, err)
+	}
+	if left, _ := outbox.List(); len(left) != 1 || left[0].ID != entries[1].ID {
+		t.Errorf("after discard the outbox holds %+v, want only %s", left, entries[1].ID)
+	}
+	if err := (&OutboxDiscardCmd{All: true}).Run(ctx); err != nil {
+		t.Fatalf("discard --all error = %v", err)
+	}
+	if left, _ := outbox.List(); len(left) != 0 {
+		t.Errorf("after discard --all the outbox holds %d messages", len(left))
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+const (
+	// previewFetchSize is how many bytes of the text part are fetched
+	previewFetchSize = 2048
+	// previewLength is the number of characters kept in Preview
+	previewLength = 200
+	// previewDisplayLength is the number of characters shown in the table
+	previewDisplayLength = 100
+)
+
+// addPreviews fills in the Preview of each message from a partial fetch of
+// its first text part.
+func addPreviews(client *imap.Client, mailbox string, messages []imap.MessageSummary) error {
+	uids := make([]uint32, len(messages))
+	for i, msg := range messages {
+		uids[i] = msg.UID
+	}
+
+	previews, err := client.Previews(mailbox, uids, previewFetchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range messages {
+		p, ok := previews[messages[i].UID]
+		if !ok {
+			continue
+		}
+		text := p.Text
+		if p.HTML {
+			// Drop a tag cut off by the partial fetch
+			if lt := strings.LastIndex(text, "<"); lt > strings.LastIndex(text, ">") {
+				text = text[:lt]
+			}
+			text = htmlToText(text)
+		}
+		messages[i].Preview = previewSnippet(text, previewLength)
+	}
+	return nil
+}
+
+// previewSnippet collapses whitespace to single spaces and shortens text to
+// max characters.
+func previewSnippet(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:max-3])) + "..."
+}
@@ -0,0 +1,26 @@
+package cli
+
+import "testing"
+
+func TestPreviewSnippet(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want string
+	}{
+		{"short", "Hello", 10, "Hello"},
+		{"collapses whitespace", "  Hello\r\n\r\n  there\tfriend ", 50, "Hello there friend"},
+		{"truncates", "The quick brown fox jumps", 12, "The quick..."},
+		{"counts runes", "héllo wörld", 8, "héllo..."},
+		{"empty", " \n ", 10, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := previewSnippet(tt.text, tt.max); got != tt.want {
+				t.Errorf("previewSnippet(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+			}
+		})
+	}
+}
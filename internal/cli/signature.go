@@ -0,0 +1,51 @@
+package cli
+
+import "strings"
+
+// signatureDelimiter is the "-- " line that separates a signature from the
+// body, which lets mail clients recognize and fold it.
+const signatureDelimiter = "-- "
+
+// signature returns the signature for an outgoing message: --no-signature
+// disables it, --signature replaces defaults.signature for this message.
+func (ctx *Context) signature(override string, disabled bool) string {
+	if disabled {
+		return ""
+	}
+	if override != "" {
+		return override
+	}
+	if ctx.Config != nil {
+		return ctx.Config.Defaults.Signature
+	}
+	return ""
+}
+
+// appendSignature adds the delimiter and signature after body. A body that
+// already ends with the same signature is returned unchanged, so it never
+// appears twice.
+func appendSignature(body, signature string) string {
+	signature = strings.Trim(signature, "\r\n")
+	if strings.TrimSpace(signature) == "" {
+		return body
+	}
+
+	block := signatureDelimiter + "\n" + signature
+	trimmed := strings.TrimRight(body, "\r\n")
+	if strings.HasSuffix(trimmed, "\n"+block) || trimmed == block {
+		return body
+	}
+	return trimmed + "\n" + block
+}
+
+// stripSignature removes everything from the last signature delimiter line
+// on. The trailing space is often lost in transit, so a bare "--" counts.
+func stripSignature(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if lines[i] == signatureDelimiter || lines[i] == "--" {
+			return strings.TrimRight(strings.Join(lines[:i], "\n"), " \t\n")
+		}
+	}
+	return body
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppendSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		want      string
+	}{
+		{"appends with delimiter", "Hello", "Jane\nAcme", "Hello\n-- \nJane\nAcme"},
+		{"trims trailing newlines", "Hello\n\n", "\nJane\n", "Hello\n-- \nJane"},
+		{"no signature", "Hello", "", "Hello"},
+		{"blank signature", "Hello", " \n ", "Hello"},
+		{"already signed", "Hello\n-- \nJane\n", "Jane", "Hello\n-- \nJane\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appendSignature(tt.body, tt.signature)
+			if got != tt.want {
+				t.Errorf("appendSignature(%q, %q) = %q, want %q", tt.body, tt.signature, got, tt.want)
+			}
+			if tt.signature != "" && strings.TrimSpace(tt.signature) != "" {
+				if n := strings.Count(got, "\n-- \n"); n != 1 {
+					t.Errorf("delimiter appears %d times in %q, want once", n, got)
+				}
+				if n := strings.Count(appendSignature(got, tt.signature), "Jane"); n != 1 {
+					t.Errorf("signature appears %d times after appending twice, want once", n)
+				}
+			}
+		})
+	}
+}
+
+func TestStripSignature(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"standard delimiter", "Hello\n\n-- \nJane\nAcme", "Hello"},
+		{"delimiter without space", "Hello\r\n--\r\nJane", "Hello"},
+		{"last delimiter wins", "a\n-- \nb\n-- \nsig", "a\n-- \nb"},
+		{"dashes inside text kept", "Hello -- world\n---\nmore", "Hello -- world\n---\nmore"},
+		{"no signature", "Hello", "Hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripSignature(tt.body); got != tt.want {
+				t.Errorf("stripSignature(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextSignature(t *testing.T) {
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Defaults.Signature = "Configured"
+
+	if got := ctx.signature("", false); got != "Configured" {
+		t.Errorf("signature() = %q, want defaults.signature", got)
+	}
+	if got := ctx.signature("Override", false); got != "Override" {
+		t.Errorf("signature(override) = %q, want %q", got, "Override")
+	}
+	if got := ctx.signature("", true); got != "" {
+		t.Errorf("signature(disabled) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/safetext"
+	"github.com/bscott/pm-cli/internal/snooze"
+)
+
+func (c *MailSnoozeCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	if c.Process {
+		if len(c.IDs) > 0 || c.Until != "" || c.In != "" {
+			return fmt.Errorf("--process cannot be combined with message IDs, --until or --in")
+		}
+		return c.process(ctx)
+	}
+
+	if len(c.IDs) == 0 {
+		return fmt.Errorf("no message IDs specified")
+	}
+	if c.Until == "" && c.In == "" {
+		return fmt.Errorf("specify when to wake the message with --until or --in")
+	}
+
+	loc, _ := ctx.Config.Location()
+	now := time.Now()
+	wakeAt, err := parseWakeTime(c.Until, c.In, now, loc)
+	if err != nil {
+		return err
+	}
+	if !wakeAt.After(now) {
+		return fmt.Errorf("wake time %s is in the past", wakeAt.In(loc).Format("2006-01-02 15:04"))
+	}
+
+	store, err := snooze.Load()
+	if err != nil {
+		return err
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	refs, err := client.MessageRefs(c.Mailbox, c.IDs)
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return fmt.Errorf("no matching messages in %s", c.Mailbox)
+	}
+	for _, ref := range refs {
+		if ref.MessageID == "" {
+			return fmt.Errorf("message uid:%d has no Message-ID and cannot be tracked while snoozed", ref.UID)
+		}
+	}
+
+	created, err := ensureMailbox(client, c.Folder)
+	if err != nil {
+		return err
+	}
+	if created {
+		ctx.Formatter.Verbosef("Created mailbox %s", c.Folder)
+	}
+
+	ctx.Formatter.Verbosef("Snoozing %d message(s) until %s...", len(refs), wakeAt.Format(time.RFC3339))
+
+	if err := client.MoveMessages(c.Mailbox, c.IDs, c.Folder); err != nil {
+		return err
+	}
+
+	snoozedAt := time.Now()
+	for _, ref := range refs {
+		store.Add(snooze.Entry{
+			MessageID: ref.MessageID,
+			Subject:   ref.Subject,
+			From:      ref.From,
+			Mailbox:   c.Mailbox,
+			Folder:    c.Folder,
+			WakeAt:    wakeAt.UTC(),
+			SnoozedAt: snoozedAt.UTC(),
+		})
+	}
+	if err := store.Save(); err != nil {
+		return fmt.Errorf("messages moved to %s but the snooze list could not be saved: %w", c.Folder, err)
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success": true,
+			"snoozed": refs,
+			"count":   len(refs),
+			"folder":  c.Folder,
+			"wake_at": wakeAt.UTC().Format(time.RFC3339),
+			"mailbox": c.Mailbox,
+		})
+	}
+
+	fmt.Printf("Snoozed %d message(s) until %s.\n", len(refs), wakeAt.In(loc).Format("2006-01-02 15:04"))
+	return nil
+}
+
+// process moves every snoozed message whose wake time has passed back to
+// the mailbox it was snoozed from.
+func (c *MailSnoozeCmd) process(ctx *Context) error {
+	store, err := snooze.Load()
+	if err != nil {
+		return err
+	}
+
+	due := store.Due(time.Now())
+	var woken, missing []snooze.Entry
+	var failures []string
+
+	if len(due) > 0 {
+		client, err := imap.NewClient(ctx.Config)
+		if err != nil {
+			return err
+		}
+
+		if err := client.Connect(); err != nil {
+			return err
+		}
+		defer client.Close()
+
+		for _, e := range due {
+			ids, err := client.FindByMessageID(e.Folder, e.MessageID)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", e.MessageID, err))
+				continue
+			}
+			if len(ids) == 0 {
+				// Moved or deleted by hand; nothing left to wake
+				missing = append(missing, e)
+				store.Remove(e.MessageID)
+				continue
+			}
+			if err := client.MoveMessages(e.Folder, ids, e.Mailbox); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", e.MessageID, err))
+				continue
+			}
+			woken = append(woken, e)
+			store.Remove(e.MessageID)
+		}
+
+		if err := store.Save(); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Formatter.JSON {
+		result := map[string]interface{}{
+			"success": len(failures) == 0,
+			"woken":   nonNilEntries(woken),
+			"missing": nonNilEntries(missing),
+			"pending": len(store.Entries),
+		}
+		if len(failures) > 0 {
+			result["errors"] = failures
+		}
+		return ctx.Formatter.PrintJSON(result)
+	}
+
+	for _, e := range woken {
+		fmt.Printf("Woke: %s (back in %s)\n", safetext.SanitizeForTerminal(e.Subject), e.Mailbox)
+	}
+	for _, e := range missing {
+		fmt.Printf("Missing: %s (no longer in %s, dropped from snooze list)\n", safetext.SanitizeForTerminal(e.Subject), e.Folder)
+	}
+	fmt.Printf("%d woken, %d still snoozed.\n", len(woken), len(store.Entries))
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to wake %d message(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func nonNilEntries(entries []snooze.Entry) []snooze.Entry {
+	if entries == nil {
+		return []snooze.Entry{}
+	}
+	return entries
+}
+
+// ensureMailbox creates name if it does not exist yet and reports whether
+// it had to be created.
+func ensureMailbox(client *imap.Client, name string) (bool, error) {
+	mailboxes, err := client.ListMailboxes()
+	if err != nil {
+		return false, err
+	}
+	for _, mb := range mailboxes {
+		if mb.Name == name {
+			return false, nil
+		}
+	}
+	if err := client.CreateMailbox(name); err != nil {
+		return false, err
+	}
+	return true, nil
+}
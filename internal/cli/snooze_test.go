@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestMailSnoozeCmdValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  MailSnoozeCmd
+	}{
+		{"no ids", MailSnoozeCmd{In: "3d"}},
+		{"no wake time", MailSnoozeCmd{IDs: []string{"1"}}},
+		{"process with ids", MailSnoozeCmd{IDs: []string{"1"}, Process: true}},
+		{"process with until", MailSnoozeCmd{Until: "2024-06-01", Process: true}},
+		{"wake time in past", MailSnoozeCmd{IDs: []string{"1"}, Until: "2000-01-01"}},
+		{"invalid duration", MailSnoozeCmd{IDs: []string{"1"}, In: "soon"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := NewContext(&Globals{})
+			ctx.Config.Bridge.Email = "user@example.com"
+
+			if err := tt.cmd.Run(ctx); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestMailSnoozeCmdRunWithoutConfig(t *testing.T) {
+	cmd := &MailSnoozeCmd{IDs: []string{"1"}, In: "3d"}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	if err := cmd.Run(ctx); err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/undo"
+)
+
+// spamMailbox is the folder Proton Bridge exposes for spam.
+const spamMailbox = "Spam"
+
+// The junk keywords registered by RFC 5788. Setting them on a move lets
+// server-side filters learn from the decision.
+const (
+	junkKeyword    = "$Junk"
+	notJunkKeyword = "$NotJunk"
+)
+
+func (c *MailSpamCmd) Run(ctx *Context) error {
+	return moveAsJunk(ctx, c.IDs, c.Query, c.Mailbox, true)
+}
+
+func (c *MailNotSpamCmd) Run(ctx *Context) error {
+	return moveAsJunk(ctx, c.IDs, c.Query, c.Mailbox, false)
+}
+
+// moveAsJunk tags messages with $Junk (or $NotJunk) before moving them to
+// Spam (or INBOX), so the keyword travels with the copy. The move is
+// recorded for 'mail undo'; the keywords are left in place.
+func moveAsJunk(ctx *Context, ids []string, query, mailbox string, junk bool) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	// Require either IDs or query
+	if len(ids) == 0 && query == "" {
+		return fmt.Errorf("provide message ID(s) or use --query to match messages")
+	}
+
+	destination, add, remove := spamMailbox, junkKeyword, notJunkKeyword
+	if !junk {
+		destination, add, remove = "INBOX", notJunkKeyword, junkKeyword
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// If query is provided, search for matching messages
+	if query != "" {
+		opts := parseQueryToSearchOptions(query)
+		searchIDs, err := client.SearchIDs(mailbox, opts)
+		if err != nil {
+			return fmt.Errorf("search failed: %w", err)
+		}
+		if len(searchIDs) == 0 {
+			if ctx.Formatter.JSON {
+				return ctx.Formatter.PrintJSON(map[string]interface{}{
+					"success":     true,
+					"moved":       []string{},
+					"destination": destination,
+					"message":     "No messages matched the query",
+				})
+			}
+			fmt.Println("No messages matched the query.")
+			return nil
+		}
+		ids = searchIDs
+		ctx.Formatter.Verbosef("Query matched %d message(s)", len(ids))
+	}
+
+	refs := messageRefsForUndo(ctx, client, mailbox, ids)
+
+	if err := client.SetKeyword(mailbox, ids, remove, false); err != nil {
+		return err
+	}
+	if err := client.SetKeyword(mailbox, ids, add, true); err != nil {
+		return err
+	}
+
+	destUIDs, err := client.MoveMessagesWithUIDs(mailbox, ids, destination)
+	if err != nil {
+		return err
+	}
+
+	recordAction(ctx, &undo.Action{
+		Command:     undo.CommandMove,
+		Mailbox:     mailbox,
+		Destination: destination,
+	}, refs, destUIDs)
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success":     true,
+			"moved":       ids,
+			"count":       len(ids),
+			"destination": destination,
+			"keyword":     add,
+		})
+	}
+
+	if junk {
+		fmt.Printf("%d message(s) marked as spam and moved to %s.\n", len(ids), destination)
+	} else {
+		fmt.Printf("%d message(s) marked as not spam and moved to %s.\n", len(ids), destination)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package cli
+
+import "testing"
+
+func TestMailSpamCmdRunWithoutConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(*Context) error
+	}{
+		{"spam", (&MailSpamCmd{IDs: []string{"1"}, Mailbox: "INBOX"}).Run},
+		{"not-spam", (&MailNotSpamCmd{IDs: []string{"1"}, Mailbox: "Spam"}).Run},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := NewContext(&Globals{})
+			ctx.Config.Bridge.Email = "" // No email configured
+
+			if err := tt.run(ctx); err == nil {
+				t.Error("expected error when email not configured")
+			}
+		})
+	}
+}
+
+func TestMailSpamCmdRequiresIDsOrQuery(t *testing.T) {
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "user@example.com"
+
+	if err := (&MailSpamCmd{Mailbox: "INBOX"}).Run(ctx); err == nil {
+		t.Error("expected error without IDs or --query")
+	}
+	if err := (&MailNotSpamCmd{Mailbox: "Spam"}).Run(ctx); err == nil {
+		t.Error("expected error without IDs or --query")
+	}
+}
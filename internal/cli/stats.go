@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/safetext"
+)
+
+// senderStat is the message count and total size for one sender address
+// or domain.
+type senderStat struct {
+	Name      string `json:"name"`
+	Count     int    `json:"count"`
+	TotalSize int64  `json:"total_size"`
+}
+
+func (c *MailStatsCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	if c.Top < 0 {
+		return fmt.Errorf("--top cannot be negative")
+	}
+	if c.Limit < 0 {
+		return fmt.Errorf("--limit cannot be negative")
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	limit := c.Limit
+	if limit == 0 {
+		limit = math.MaxInt32
+	}
+
+	ctx.Formatter.Verbosef("Fetching envelopes from %s...", c.Mailbox)
+	messages, err := client.ListMessages(c.Mailbox, limit, 0, false)
+	if err != nil {
+		return err
+	}
+
+	stats := aggregateSenders(messages, c.By == "domain")
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"mailbox":  c.Mailbox,
+			"by":       c.By,
+			"messages": len(messages),
+			"stats":    stats,
+		})
+	}
+
+	if len(stats) == 0 {
+		fmt.Println("No messages found.")
+		return nil
+	}
+
+	rows := stats
+	if c.Top > 0 && len(rows) > c.Top {
+		rows = rows[:c.Top]
+	}
+
+	header := "SENDER"
+	if c.By == "domain" {
+		header = "DOMAIN"
+	}
+	table := ctx.Formatter.NewTable(header, "COUNT", "TOTAL SIZE")
+	for _, s := range rows {
+		table.AddRow(
+			safetext.SanitizeForTerminal(s.Name),
+			fmt.Sprintf("%d", s.Count),
+			formatSize(s.TotalSize),
+		)
+	}
+	table.Flush()
+
+	if len(rows) < len(stats) {
+		fmt.Printf("\nShowing top %d of %d %ss across %d message(s).\n", len(rows), len(stats), c.By, len(messages))
+	}
+	return nil
+}
+
+// aggregateSenders groups messages by lowercased sender address, or by its
+// domain, and sorts the result by count, then total size, descending.
+func aggregateSenders(messages []imap.MessageSummary, byDomain bool) []senderStat {
+	index := make(map[string]int)
+	stats := []senderStat{}
+	for _, msg := range messages {
+		key := strings.ToLower(msg.FromAddress)
+		if byDomain {
+			if at := strings.LastIndex(key, "@"); at != -1 {
+				key = key[at+1:]
+			}
+		}
+		if key == "" {
+			key = "(unknown)"
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(stats)
+			index[key] = i
+			stats = append(stats, senderStat{Name: key})
+		}
+		stats[i].Count++
+		stats[i].TotalSize += msg.Size
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		if stats[i].TotalSize != stats[j].TotalSize {
+			return stats[i].TotalSize > stats[j].TotalSize
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+func TestAggregateSenders(t *testing.T) {
+	messages := []imap.MessageSummary{
+		{FromAddress: "news@shop.example.com", Size: 100},
+		{FromAddress: "News@Shop.example.com", Size: 200},
+		{FromAddress: "alice@example.com", Size: 5000},
+		{FromAddress: "bob@example.com", Size: 10},
+		{FromAddress: "deals@shop.example.com", Size: 50},
+		{FromAddress: "", Size: 1},
+	}
+
+	t.Run("by sender", func(t *testing.T) {
+		got := aggregateSenders(messages, false)
+		want := []senderStat{
+			{Name: "news@shop.example.com", Count: 2, TotalSize: 300},
+			{Name: "alice@example.com", Count: 1, TotalSize: 5000},
+			{Name: "deals@shop.example.com", Count: 1, TotalSize: 50},
+			{Name: "bob@example.com", Count: 1, TotalSize: 10},
+			{Name: "(unknown)", Count: 1, TotalSize: 1},
+		}
+		assertSenderStats(t, got, want)
+	})
+
+	t.Run("by domain", func(t *testing.T) {
+		got := aggregateSenders(messages, true)
+		want := []senderStat{
+			{Name: "shop.example.com", Count: 3, TotalSize: 350},
+			{Name: "example.com", Count: 2, TotalSize: 5010},
+			{Name: "(unknown)", Count: 1, TotalSize: 1},
+		}
+		assertSenderStats(t, got, want)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if got := aggregateSenders(nil, false); got == nil || len(got) != 0 {
+			t.Errorf("aggregateSenders(nil) = %v, want empty slice", got)
+		}
+	})
+}
+
+func assertSenderStats(t *testing.T, got, want []senderStat) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMailStatsCmdRunWithoutConfig(t *testing.T) {
+	cmd := &MailStatsCmd{Mailbox: "INBOX", Top: 20, By: "sender"}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	if err := cmd.Run(ctx); err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
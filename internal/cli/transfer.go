@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/config"
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+// transferredMessage is one message copied by mail transfer.
+type transferredMessage struct {
+	UID     uint32 `json:"uid"`
+	DestUID uint32 `json:"dest_uid,omitempty"`
+	Subject string `json:"subject"`
+}
+
+func (c *MailTransferCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+	if len(c.IDs) == 0 {
+		return fmt.Errorf("no message IDs specified")
+	}
+
+	destCfg, err := c.destinationConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	mailbox := c.Mailbox
+	if mailbox == "" {
+		mailbox = ctx.Config.Defaults.Mailbox
+	}
+	dest := c.Dest
+	if dest == "" {
+		dest = destCfg.Defaults.Mailbox
+	}
+	if strings.EqualFold(destCfg.Bridge.Email, ctx.Config.Bridge.Email) && dest == mailbox {
+		return fmt.Errorf("source and destination are both %s of %s - use 'pm-cli mail move' within one account", mailbox, destCfg.Bridge.Email)
+	}
+
+	source, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+	if err := source.Connect(); err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := imap.NewClient(destCfg)
+	if err != nil {
+		return err
+	}
+	if err := target.Connect(); err != nil {
+		return fmt.Errorf("destination account %s: %w", destCfg.Bridge.Email, err)
+	}
+	defer target.Close()
+
+	mailbox, ids, err := source.ResolveRefs(mailbox, c.IDs)
+	if err != nil {
+		return err
+	}
+	if ids, err = source.ExpandIDs(mailbox, ids); err != nil {
+		return err
+	}
+
+	// Peek, so copying does not mark the originals read
+	messages, err := source.PeekMessages(mailbox, ids)
+	if err != nil {
+		return err
+	}
+
+	ctx.Formatter.Verbosef("Copying %d message(s) to %s in %s...", len(messages), dest, destCfg.Bridge.Email)
+
+	transferred := make([]transferredMessage, 0, len(messages))
+	var copyErr error
+	for _, msg := range messages {
+		destUID, err := target.AppendMessage(dest, msg.RawBody, transferFlags(msg.Flags), messageDate(msg))
+		if err != nil {
+			copyErr = fmt.Errorf("uid:%d: %w", msg.UID, err)
+			break
+		}
+		transferred = append(transferred, transferredMessage{UID: msg.UID, DestUID: destUID, Subject: msg.Subject})
+	}
+
+	// Only what reached the destination is removed from the source
+	if c.Move && len(transferred) > 0 {
+		copied := make([]string, len(transferred))
+		for i, t := range transferred {
+			copied[i] = fmt.Sprintf("uid:%d", t.UID)
+		}
+		if err := source.DeleteMessages(mailbox, copied, true); err != nil {
+			return fmt.Errorf("copied %d message(s) but failed to delete them from %s: %w", len(transferred), mailbox, err)
+		}
+	}
+	if copyErr != nil {
+		return fmt.Errorf("transfer stopped after %d of %d message(s): %w", len(transferred), len(messages), copyErr)
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success":     true,
+			"mailbox":     mailbox,
+			"account":     destCfg.Bridge.Email,
+			"destination": dest,
+			"moved":       c.Move,
+			"count":       len(transferred),
+			"transferred": transferred,
+		})
+	}
+
+	verb := "Copied"
+	if c.Move {
+		verb = "Moved"
+	}
+	fmt.Printf("%s %d message(s) to %s in %s.\n", verb, len(transferred), dest, destCfg.Bridge.Email)
+	return nil
+}
+
+// destinationConfig loads the config of the destination account, with the
+// same --retries, --retry-delay and --timeout overrides as the source.
+func (c *MailTransferCmd) destinationConfig(ctx *Context) (*config.Config, error) {
+	destCfg, err := config.Load(c.ToConfig)
+	if err != nil {
+		return nil, err
+	}
+	if destCfg.Bridge.Email == "" {
+		return nil, fmt.Errorf("destination config %s has no bridge.email", c.ToConfig)
+	}
+
+	if g := ctx.Globals; g != nil {
+		if g.Retries != nil || g.RetryDelay != 0 {
+			destCfg.OverrideRetryPolicy(g.Retries, g.RetryDelay)
+		}
+		if g.Timeout > 0 {
+			destCfg.OverrideTimeout(g.Timeout)
+		}
+	}
+	destCfg.StartDeadline()
+	return destCfg, nil
+}
+
+// transferFlags returns the flags to keep on a copied message: everything
+// but \Recent, which only the server may set, and \Deleted, which would
+// get the copy expunged.
+func transferFlags(flags []string) []string {
+	kept := []string{}
+	for _, f := range flags {
+		switch strings.ToLower(f) {
+		case `\recent`, `\deleted`:
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// messageDate is the internal date to give a copied message: its Date
+// header, so it sorts where it did in the source. Zero when the header is
+// missing lets the server use the current time.
+func messageDate(msg *imap.Message) time.Time {
+	t, err := time.Parse(time.RFC3339, msg.DateISO)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
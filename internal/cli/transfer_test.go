@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+func TestTransferFlags(t *testing.T) {
+	got := transferFlags([]string{`\Seen`, `\Recent`, `\Flagged`, `\DELETED`, "$Label1"})
+	want := []string{`\Seen`, `\Flagged`, "$Label1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transferFlags() = %v, want %v", got, want)
+	}
+	if got := transferFlags(nil); got == nil || len(got) != 0 {
+		t.Errorf("transferFlags(nil) = %#v, want an empty list", got)
+	}
+}
+
+func TestMessageDate(t *testing.T) {
+	msg := &imap.Message{DateISO: "2024-03-05T09:30:00+01:00"}
+	want := time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC)
+	if got := messageDate(msg); !got.Equal(want) {
+		t.Errorf("messageDate() = %v, want %v", got, want)
+	}
+	if got := messageDate(&imap.Message{}); !got.IsZero() {
+		t.Errorf("messageDate() without a date = %v, want zero", got)
+	}
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/tui"
+	"github.com/bscott/pm-cli/internal/undo"
+)
+
+func (c *TuiCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+	if ctx.Formatter.JSON {
+		return fmt.Errorf("tui is interactive and has no JSON output")
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	mailbox := c.Mailbox
+	if mailbox == "" {
+		mailbox = ctx.Config.Defaults.Mailbox
+	}
+
+	return tui.Run(&tuiMailbox{ctx: ctx, client: client, mailbox: mailbox, limit: c.Limit})
+}
+
+// tuiMailbox carries out the TUI's actions the way the matching mail
+// commands do, including recording deletes and archives for 'mail undo'.
+type tuiMailbox struct {
+	ctx     *Context
+	client  *imap.Client
+	mailbox string
+	limit   int
+}
+
+func (m *tuiMailbox) Name() string { return m.mailbox }
+
+func (m *tuiMailbox) List() ([]imap.MessageSummary, error) {
+	return m.client.ListMessages(m.mailbox, m.limit, 0, false)
+}
+
+func (m *tuiMailbox) Search(query string) ([]imap.MessageSummary, error) {
+	opts := parseQueryToSearchOptions(query)
+	opts.Limit = m.limit
+	messages, _, err := m.client.Search(m.mailbox, opts)
+	return messages, err
+}
+
+func (m *tuiMailbox) Read(summary imap.MessageSummary) (string, error) {
+	msg, err := m.client.GetMessage(m.mailbox, fmt.Sprintf("uid:%d", summary.UID))
+	if err != nil {
+		return "", err
+	}
+	return messageText(m.ctx, msg), nil
+}
+
+func (m *tuiMailbox) SetStarred(summary imap.MessageSummary, starred bool) error {
+	return m.client.SetFlags(m.mailbox, fmt.Sprintf("uid:%d", summary.UID), false, false, starred, !starred)
+}
+
+func (m *tuiMailbox) Delete(summary imap.MessageSummary) error {
+	ids := []string{fmt.Sprintf("uid:%d", summary.UID)}
+	refs := messageRefsForUndo(m.ctx, m.client, m.mailbox, ids)
+	if err := m.client.DeleteMessages(m.mailbox, ids, false); err != nil {
+		return err
+	}
+	recordAction(m.ctx, &undo.Action{Command: undo.CommandDelete, Mailbox: m.mailbox}, refs, nil)
+	return nil
+}
+
+func (m *tuiMailbox) Archive(summary imap.MessageSummary) error {
+	ids := []string{fmt.Sprintf("uid:%d", summary.UID)}
+	destination := m.ctx.Config.ArchiveMailbox()
+	refs := messageRefsForUndo(m.ctx, m.client, m.mailbox, ids)
+	destUIDs, err := m.client.MoveMessagesWithUIDs(m.mailbox, ids, destination)
+	if err != nil {
+		return err
+	}
+	recordAction(m.ctx, &undo.Action{Command: undo.CommandMove, Mailbox: m.mailbox, Destination: destination}, refs, destUIDs)
+	return nil
+}
+
+// messageText renders a message for the reading pane: the main headers,
+// then the plain text body, or the HTML body converted to text. The TUI
+// sanitizes and wraps it.
+func messageText(ctx *Context, msg *imap.Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From:    %s\n", msg.From)
+	fmt.Fprintf(&b, "To:      %s\n", strings.Join(msg.To, ", "))
+	if len(msg.CC) > 0 {
+		fmt.Fprintf(&b, "CC:      %s\n", strings.Join(msg.CC, ", "))
+	}
+	fmt.Fprintf(&b, "Date:    %s\n", readDate(ctx, msg.DateISO, msg.Date))
+	fmt.Fprintf(&b, "Subject: %s\n\n", msg.Subject)
+
+	textBody, htmlBody := parseMessageBody(msg.RawBody)
+	switch {
+	case textBody != "":
+		b.WriteString(textBody)
+	case htmlBody != "":
+		b.WriteString(htmlToText(htmlBody))
+	default:
+		b.WriteString("[No body content]")
+	}
+	return b.String()
+}
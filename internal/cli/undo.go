@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/undo"
+)
+
+// trashMailbox is where Proton Bridge puts messages deleted without
+// --permanent once the source mailbox is expunged.
+const trashMailbox = "Trash"
+
+// messageRefsForUndo fetches the UIDs and Message-IDs of the messages about
+// to be changed. A failure only costs the ability to undo, so it is reported
+// as a warning.
+func messageRefsForUndo(ctx *Context, client *imap.Client, mailbox string, ids []string) []imap.MessageRef {
+	refs, err := client.MessageRefs(mailbox, ids)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot record action for undo: %v\n", err)
+		return nil
+	}
+	return refs
+}
+
+// recordAction saves action as the one 'mail undo' reverses. destUIDs maps
+// source UIDs to destination UIDs for moves.
+func recordAction(ctx *Context, action *undo.Action, refs []imap.MessageRef, destUIDs map[uint32]uint32) {
+	if refs == nil {
+		return
+	}
+
+	action.At = time.Now().UTC()
+	action.Messages = make([]undo.Message, len(refs))
+	for i, ref := range refs {
+		action.Messages[i] = undo.Message{
+			UID:       ref.UID,
+			DestUID:   destUIDs[ref.UID],
+			MessageID: ref.MessageID,
+			Subject:   ref.Subject,
+		}
+	}
+
+	if err := undo.Save(action); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot record action for undo: %v\n", err)
+		return
+	}
+	ctx.Formatter.Verbosef("Recorded %s of %d message(s) for undo", action.Command, len(refs))
+}
+
+func (c *MailUndoCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	action, err := undo.Load()
+	if err != nil {
+		return err
+	}
+	if action == nil {
+		return fmt.Errorf("nothing to undo")
+	}
+	if err := checkUndoable(action); err != nil {
+		return err
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var restored, missing int
+	switch action.Command {
+	case undo.CommandMove:
+		restored, missing, err = undoMove(client, action)
+	case undo.CommandDelete:
+		restored, missing, err = undoDelete(client, action)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := undo.Clear(); err != nil {
+		return err
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(map[string]interface{}{
+			"success":  true,
+			"command":  action.Command,
+			"mailbox":  action.Mailbox,
+			"restored": restored,
+			"missing":  missing,
+		})
+	}
+
+	fmt.Printf("Undid %s: %d message(s) restored to %s.\n", action.Command, restored, action.Mailbox)
+	if missing > 0 {
+		fmt.Printf("%d message(s) could not be found and were not restored.\n", missing)
+	}
+	return nil
+}
+
+// checkUndoable rejects actions that cannot be reversed.
+func checkUndoable(action *undo.Action) error {
+	switch action.Command {
+	case undo.CommandMove:
+		return nil
+	case undo.CommandDelete:
+		if action.Permanent {
+			return fmt.Errorf("last action permanently deleted %d message(s) from %s and cannot be undone", len(action.Messages), action.Mailbox)
+		}
+		return nil
+	default:
+		return fmt.Errorf("cannot undo unknown action %q", action.Command)
+	}
+}
+
+// undoMove moves the messages of a move back to their source mailbox. A
+// message is located by its destination UID when the server reported one,
+// and by Message-ID otherwise.
+func undoMove(client *imap.Client, action *undo.Action) (int, int, error) {
+	var ids []string
+	missing := 0
+	for _, m := range action.Messages {
+		if m.DestUID != 0 {
+			ids = append(ids, fmt.Sprintf("uid:%d", m.DestUID))
+			continue
+		}
+		found, err := findMessage(client, action.Destination, m.MessageID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(found) == 0 {
+			missing++
+			continue
+		}
+		ids = append(ids, found...)
+	}
+
+	if len(ids) > 0 {
+		if err := client.MoveMessages(action.Destination, ids, action.Mailbox); err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(ids), missing, nil
+}
+
+// undoDelete reverses a delete to trash. Messages still in the source
+// mailbox get their \Deleted flag cleared; messages that were already
+// expunged into Trash are moved back.
+func undoDelete(client *imap.Client, action *undo.Action) (int, int, error) {
+	ids := make([]string, len(action.Messages))
+	for i, m := range action.Messages {
+		ids[i] = fmt.Sprintf("uid:%d", m.UID)
+	}
+	if len(ids) > 0 {
+		if err := client.UndeleteMessages(action.Mailbox, ids); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	restored := len(ids)
+	missing := 0
+	var fromTrash []string
+	for _, m := range action.Messages {
+		inSource, err := findMessage(client, action.Mailbox, m.MessageID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(inSource) > 0 || m.MessageID == "" {
+			continue
+		}
+		inTrash, err := findMessage(client, trashMailbox, m.MessageID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(inTrash) == 0 {
+			restored--
+			missing++
+			continue
+		}
+		fromTrash = append(fromTrash, inTrash...)
+	}
+
+	if len(fromTrash) > 0 {
+		if err := client.MoveMessages(trashMailbox, fromTrash, action.Mailbox); err != nil {
+			return 0, 0, err
+		}
+	}
+	return restored, missing, nil
+}
+
+// findMessage looks up a message by Message-ID; messages without one cannot
+// be found this way.
+func findMessage(client *imap.Client, mailbox, messageID string) ([]string, error) {
+	if messageID == "" {
+		return nil, nil
+	}
+	return client.FindByMessageID(mailbox, messageID)
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bscott/pm-cli/internal/undo"
+)
+
+func TestCheckUndoable(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  undo.Action
+		wantErr string
+	}{
+		{"move", undo.Action{Command: undo.CommandMove, Mailbox: "INBOX", Destination: "Archive"}, ""},
+		{"trash delete", undo.Action{Command: undo.CommandDelete, Mailbox: "INBOX"}, ""},
+		{"permanent delete", undo.Action{Command: undo.CommandDelete, Mailbox: "INBOX", Permanent: true}, "cannot be undone"},
+		{"unknown", undo.Action{Command: "flag"}, "unknown action"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUndoable(&tt.action)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkUndoable() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkUndoable() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMailUndoCmdRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "user@example.com"
+
+	cmd := &MailUndoCmd{}
+	if err := cmd.Run(ctx); err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+		t.Errorf("Run() with no recorded action error = %v", err)
+	}
+
+	if err := undo.Save(&undo.Action{
+		Command:   undo.CommandDelete,
+		Mailbox:   "INBOX",
+		Permanent: true,
+		Messages:  []undo.Message{{UID: 1}},
+	}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := cmd.Run(ctx); err == nil || !strings.Contains(err.Error(), "cannot be undone") {
+		t.Errorf("Run() after permanent delete error = %v", err)
+	}
+}
+
+func TestMailUndoCmdRunWithoutConfig(t *testing.T) {
+	cmd := &MailUndoCmd{}
+
+	ctx, _ := NewContext(&Globals{})
+	ctx.Config.Bridge.Email = "" // No email configured
+
+	if err := cmd.Run(ctx); err == nil {
+		t.Error("expected error when email not configured")
+	}
+}
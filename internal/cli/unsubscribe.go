@@ -0,0 +1,303 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/safetext"
+	"github.com/bscott/pm-cli/internal/smtp"
+)
+
+// Unsubscribe methods, as accepted by --via and reported as the JSON action.
+const (
+	unsubscribePost = "post"
+	unsubscribeMail = "mail"
+	unsubscribeURL  = "url"
+)
+
+// oneClickBody is the List-Unsubscribe-Post value and POST body that
+// RFC 8058 defines for one-click unsubscribe.
+const oneClickBody = "List-Unsubscribe=One-Click"
+
+// unsubscribeTimeout bounds the one-click POST.
+const unsubscribeTimeout = 30 * time.Second
+
+// openURL opens a link in the desktop browser. A variable so tests can
+// replace it.
+var openURL = func(link string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	return cmd.Start()
+}
+
+// unsubscribeOptions are the ways a message offers to unsubscribe, from its
+// List-Unsubscribe (RFC 2369) and List-Unsubscribe-Post (RFC 8058) headers.
+type unsubscribeOptions struct {
+	MailTo   []string `json:"mailto"`
+	URLs     []string `json:"urls"`
+	OneClick bool     `json:"one_click"`
+}
+
+var listUnsubscribeURI = regexp.MustCompile(`<([^>]*)>`)
+
+// parseListUnsubscribe collects the mailto: and http(s) URIs of a
+// List-Unsubscribe header. Other schemes are ignored. One-click applies
+// when the post header asks for it and there is an https URL to POST to.
+func parseListUnsubscribe(header, post string) unsubscribeOptions {
+	opts := unsubscribeOptions{MailTo: []string{}, URLs: []string{}}
+	for _, m := range listUnsubscribeURI.FindAllStringSubmatch(header, -1) {
+		// Folded headers can leave whitespace inside the brackets
+		uri := strings.Join(strings.Fields(m[1]), "")
+		u, err := url.Parse(uri)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "mailto":
+			opts.MailTo = append(opts.MailTo, uri)
+		case "http", "https":
+			if u.Host != "" {
+				opts.URLs = append(opts.URLs, uri)
+			}
+		}
+	}
+	opts.OneClick = strings.EqualFold(strings.TrimSpace(post), oneClickBody) && opts.httpsURL() != ""
+	return opts
+}
+
+// httpsURL returns the first https URL, which is the only kind RFC 8058
+// allows for one-click.
+func (o unsubscribeOptions) httpsURL() string {
+	for _, uri := range o.URLs {
+		if strings.HasPrefix(strings.ToLower(uri), "https:") {
+			return uri
+		}
+	}
+	return ""
+}
+
+// chooseUnsubscribe picks the method and target for via. Auto prefers
+// one-click, which needs no further action from the user, then mailto,
+// then a link to open by hand.
+func chooseUnsubscribe(opts unsubscribeOptions, via string) (method, target string, err error) {
+	switch via {
+	case unsubscribePost:
+		if !opts.OneClick {
+			return "", "", fmt.Errorf("message does not offer one-click unsubscribe (List-Unsubscribe-Post with an https URL)")
+		}
+		return unsubscribePost, opts.httpsURL(), nil
+	case unsubscribeMail:
+		if len(opts.MailTo) == 0 {
+			return "", "", fmt.Errorf("message has no mailto: unsubscribe address")
+		}
+		return unsubscribeMail, opts.MailTo[0], nil
+	case unsubscribeURL:
+		if len(opts.URLs) == 0 {
+			return "", "", fmt.Errorf("message has no unsubscribe link")
+		}
+		if link := opts.httpsURL(); link != "" {
+			return unsubscribeURL, link, nil
+		}
+		return unsubscribeURL, opts.URLs[0], nil
+	}
+
+	switch {
+	case opts.OneClick:
+		return unsubscribePost, opts.httpsURL(), nil
+	case len(opts.MailTo) > 0:
+		return unsubscribeMail, opts.MailTo[0], nil
+	case len(opts.URLs) > 0:
+		return chooseUnsubscribe(opts, unsubscribeURL)
+	}
+	return "", "", fmt.Errorf("message has no List-Unsubscribe header")
+}
+
+// parseMailto turns a mailto: URI into the unsubscribe message it asks
+// for. The subject and body default to "unsubscribe", which list servers
+// accept when the URI does not say otherwise.
+func parseMailto(uri string) (to []string, subject, body string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid unsubscribe address %q: %w", uri, err)
+	}
+	addrs, err := url.PathUnescape(u.Opaque)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid unsubscribe address %q: %w", uri, err)
+	}
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	if len(to) == 0 {
+		return nil, "", "", fmt.Errorf("unsubscribe address %q has no recipient", uri)
+	}
+	if err := smtp.ValidateAddresses(to); err != nil {
+		return nil, "", "", err
+	}
+
+	query := u.Query()
+	subject = query.Get("subject")
+	if subject == "" {
+		subject = "unsubscribe"
+	}
+	body = query.Get("body")
+	if body == "" {
+		body = "unsubscribe"
+	}
+	return to, subject, body, nil
+}
+
+// postOneClick sends the RFC 8058 one-click request. Redirects are not
+// followed: they would turn the POST into a GET, and the sender has
+// already got the request by then.
+func postOneClick(client *http.Client, target string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(oneClickBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to build unsubscribe request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "pm-cli/"+Version)
+
+	noRedirects := *client
+	noRedirects.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := noRedirects.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unsubscribe request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return resp.Status, fmt.Errorf("unsubscribe request returned %s", resp.Status)
+	}
+	return resp.Status, nil
+}
+
+func (c *MailUnsubscribeCmd) Run(ctx *Context) error {
+	if ctx.Config.Bridge.Email == "" {
+		return ErrNotConfigured
+	}
+
+	mailbox := c.Mailbox
+	if mailbox == "" {
+		mailbox = ctx.Config.Defaults.Mailbox
+	}
+
+	client, err := imap.NewClient(ctx.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	mailbox, ids, err := client.ResolveRefs(mailbox, []string{c.ID})
+	if err != nil {
+		return err
+	}
+	messages, err := client.GetHeaders(mailbox, ids, []string{"List-Unsubscribe", "List-Unsubscribe-Post"})
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return notFoundf("message %s not found in %s", c.ID, mailbox)
+	}
+	msg := messages[0]
+
+	headers := headerFieldValues(msg.RawBody, []string{"List-Unsubscribe", "List-Unsubscribe-Post"})
+	opts := parseListUnsubscribe(headers["List-Unsubscribe"], headers["List-Unsubscribe-Post"])
+	method, target, err := chooseUnsubscribe(opts, c.Via)
+	if err != nil {
+		return err
+	}
+
+	result := map[string]interface{}{
+		"success": true,
+		"uid":     msg.UID,
+		"subject": msg.Subject,
+		"action":  method,
+		"target":  target,
+		"options": opts,
+	}
+	if c.DryRun {
+		result["dry_run"] = true
+		if ctx.Formatter.JSON {
+			return ctx.Formatter.PrintJSON(result)
+		}
+		fmt.Printf("Would unsubscribe from %s via %s: %s\n",
+			safetext.SanitizeForTerminal(msg.Subject), method, safetext.SanitizeForTerminal(target))
+		return nil
+	}
+
+	var done string
+	switch method {
+	case unsubscribePost:
+		ctx.Formatter.Verbosef("Sending one-click unsubscribe request to %s...", target)
+		status, err := postOneClick(&http.Client{Timeout: unsubscribeTimeout}, target)
+		if err != nil {
+			return err
+		}
+		result["status"] = status
+		done = "Unsubscribed with a one-click request (" + status + ")"
+
+	case unsubscribeMail:
+		to, subject, body, err := parseMailto(target)
+		if err != nil {
+			return err
+		}
+		password, err := ctx.Config.GetPassword()
+		if err != nil {
+			return err
+		}
+		ctx.Formatter.Verbosef("Sending unsubscribe email to %s...", strings.Join(to, ", "))
+		if err := smtp.NewClient(ctx.Config, password).Send(&smtp.Message{
+			From:    ctx.Config.Bridge.Email,
+			To:      to,
+			Subject: subject,
+			Body:    body,
+		}); err != nil {
+			return err
+		}
+		result["to"] = to
+		done = "Sent unsubscribe email to " + strings.Join(to, ", ")
+
+	case unsubscribeURL:
+		// A plain link needs a person to confirm on the page
+		opened := false
+		if c.Open {
+			if err := openURL(target); err != nil {
+				return fmt.Errorf("failed to open browser: %w", err)
+			}
+			opened = true
+		}
+		result["opened"] = opened
+		done = "Unsubscribe link: " + target
+		if opened {
+			done += " (opened in browser)"
+		}
+	}
+
+	if ctx.Formatter.JSON {
+		return ctx.Formatter.PrintJSON(result)
+	}
+	fmt.Println(safetext.SanitizeForTerminal(done))
+	return nil
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseListUnsubscribe(t *testing.T) {
+	opts := parseListUnsubscribe(
+		"<mailto:leave@lists.example.com?subject=unsub%20me>, <https://lists.example.com/u?\r\n id=42>, <javascript:alert(1)>, <http://plain.example.com/u>",
+		" List-Unsubscribe=One-Click ",
+	)
+	want := unsubscribeOptions{
+		MailTo:   []string{"mailto:leave@lists.example.com?subject=unsub%20me"},
+		URLs:     []string{"https://lists.example.com/u?id=42", "http://plain.example.com/u"},
+		OneClick: true,
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("parseListUnsubscribe() = %+v, want %+v", opts, want)
+	}
+
+	// One-click needs an https URL to POST to
+	if opts := parseListUnsubscribe("<http://plain.example.com/u>", oneClickBody); opts.OneClick {
+		t.Error("one-click must not be offered for a plain http URL")
+	}
+}
+
+func TestChooseUnsubscribe(t *testing.T) {
+	full := unsubscribeOptions{
+		MailTo:   []string{"mailto:leave@example.com"},
+		URLs:     []string{"http://example.com/u", "https://example.com/u"},
+		OneClick: true,
+	}
+	tests := []struct {
+		name       string
+		opts       unsubscribeOptions
+		via        string
+		wantMethod string
+		wantTarget string
+		wantErr    bool
+	}{
+		{"auto prefers one-click", full, "auto", unsubscribePost, "https://example.com/u", false},
+		{"auto falls back to mailto", unsubscribeOptions{MailTo: full.MailTo, URLs: full.URLs}, "auto", unsubscribeMail, "mailto:leave@example.com", false},
+		{"auto falls back to the link", unsubscribeOptions{URLs: full.URLs}, "auto", unsubscribeURL, "https://example.com/u", false},
+		{"forced mail", full, "mail", unsubscribeMail, "mailto:leave@example.com", false},
+		{"post without one-click", unsubscribeOptions{URLs: full.URLs}, "post", "", "", true},
+		{"no header", unsubscribeOptions{}, "auto", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, target, err := chooseUnsubscribe(tt.opts, tt.via)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("chooseUnsubscribe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if method != tt.wantMethod || target != tt.wantTarget {
+				t.Errorf("chooseUnsubscribe() = %s %s, want %s %s", method, target, tt.wantMethod, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestParseMailto(t *testing.T) {
+	to, subject, body, err := parseMailto("mailto:leave%2Bnews@example.com?subject=Remove%20me")
+	if err != nil {
+		t.Fatalf("parseMailto() error = %v", err)
+	}
+	if !reflect.DeepEqual(to, []string{"leave+news@example.com"}) || subject != "Remove me" || body != "unsubscribe" {
+		t.Errorf("parseMailto() = %v %q %q", to, subject, body)
+	}
+
+	if _, _, _, err := parseMailto("mailto:?subject=x"); err == nil {
+		t.Error("expected an error for a mailto: without a recipient")
+	}
+}
+
+func TestPostOneClick(t *testing.T) {
+	var gotBody, gotType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/done", http.StatusFound)
+			return
+		}
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotBody, gotType = string(body), r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	status, err := postOneClick(server.Client(), server.URL+"/u")
+	if err != nil || status != "200 OK" {
+		t.Fatalf("postOneClick() = %q, %v", status, err)
+	}
+	if gotBody != oneClickBody || gotType != "application/x-www-form-urlencoded" {
+		t.Errorf("request body %q with type %q, want the RFC 8058 form", gotBody, gotType)
+	}
+
+	// The redirect is reported, not followed as a GET
+	gotBody = ""
+	if status, err := postOneClick(server.Client(), server.URL+"/redirect"); err != nil || status != "302 Found" || gotBody != "" {
+		t.Errorf("postOneClick(redirect) = %q, %v; want 302 without following it", status, err)
+	}
+}
@@ -3,13 +3,17 @@ package cli
 import (
 	"fmt"
 	"runtime"
+	"runtime/debug"
 )
 
 func (c *VersionCmd) Run(ctx *Context) error {
+	commit, buildDate := buildMetadata()
 	if ctx.Formatter.JSON {
 		return ctx.Formatter.PrintJSON(map[string]interface{}{
 			"name":       "pm-cli",
 			"version":    Version,
+			"commit":     commit,
+			"build_date": buildDate,
 			"go_version": runtime.Version(),
 			"os":         runtime.GOOS,
 			"arch":       runtime.GOARCH,
@@ -17,7 +21,33 @@ func (c *VersionCmd) Run(ctx *Context) error {
 	}
 
 	fmt.Printf("pm-cli version %s\n", Version)
+	fmt.Printf("Commit: %s\n", commit)
+	fmt.Printf("Built: %s\n", buildDate)
 	fmt.Printf("Go version: %s\n", runtime.Version())
 	fmt.Printf("OS/Arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
 	return nil
 }
+
+// buildMetadata returns Commit and BuildDate. When -ldflags did not set
+// them, it falls back to the VCS revision and commit time that go build
+// records when building from a git checkout, and then to "unknown".
+func buildMetadata() (commit, buildDate string) {
+	commit, buildDate = Commit, BuildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && commit == "":
+				commit = setting.Value
+			case setting.Key == "vcs.time" && buildDate == "":
+				buildDate = setting.Value
+			}
+		}
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	if buildDate == "" {
+		buildDate = "unknown"
+	}
+	return commit, buildDate
+}
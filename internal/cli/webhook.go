@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// webhookSignatureHeader carries the hex HMAC-SHA256 of the request body
+// when --webhook-secret is set.
+const webhookSignatureHeader = "X-PM-CLI-Signature"
+
+// webhookAttempts is the number of delivery attempts per message.
+const webhookAttempts = 4
+
+// webhookRetryDelay is the delay before the first retry; it doubles after
+// each failed attempt. A variable so tests can shorten it.
+var webhookRetryDelay = time.Second
+
+// webhookQueueSize is how many payloads may wait for delivery. Further ones
+// are dropped, so a dead endpoint cannot make watch hold on to them all.
+const webhookQueueSize = 100
+
+// webhookDrainTimeout is how long watch waits for queued payloads to go
+// out when it stops.
+const webhookDrainTimeout = 10 * time.Second
+
+// webhookPayload is the JSON body POSTed for each new message.
+type webhookPayload struct {
+	UID     uint32 `json:"uid"`
+	From    string `json:"from"`
+	Subject string `json:"subject"`
+	Date    string `json:"date"`
+	Mailbox string `json:"mailbox"`
+}
+
+// validateWebhookURL checks that rawURL is an absolute http(s) URL.
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: must be an http or https URL", rawURL)
+	}
+	return nil
+}
+
+// signWebhookBody returns the hex HMAC-SHA256 of body keyed with secret,
+// prefixed with "sha256=".
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook POSTs payload to target, retrying with exponential
+// backoff on network errors and non-2xx responses.
+func deliverWebhook(client *http.Client, target, secret string, payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	delay := webhookRetryDelay
+	var lastErr error
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to build webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "pm-cli/"+Version)
+		if secret != "" {
+			req.Header.Set(webhookSignatureHeader, signWebhookBody(secret, body))
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("webhook returned %s", resp.Status)
+	}
+
+	return fmt.Errorf("webhook delivery failed after %d attempts: %w", webhookAttempts, lastErr)
+}
+
+// webhookSender delivers payloads in the background, one at a time and in
+// the order they were queued, so a slow or dead endpoint never holds up
+// polling.
+type webhookSender struct {
+	client *http.Client
+	target string
+	secret string
+	queue  chan webhookPayload
+	done   chan struct{}
+}
+
+func newWebhookSender(client *http.Client, target, secret string) *webhookSender {
+	s := &webhookSender{
+		client: client,
+		target: target,
+		secret: secret,
+		queue:  make(chan webhookPayload, webhookQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *webhookSender) run() {
+	defer close(s.done)
+	for payload := range s.queue {
+		if err := deliverWebhook(s.client, s.target, s.secret, payload); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// Send queues payload for delivery without waiting for it. When the queue
+// is full the payload is dropped with a warning.
+func (s *webhookSender) Send(payload webhookPayload) {
+	select {
+	case s.queue <- payload:
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: webhook queue full, dropped the notification for UID %d in %s\n", payload.UID, payload.Mailbox)
+	}
+}
+
+// Close stops taking payloads and waits up to timeout for the queued ones
+// to be delivered.
+func (s *webhookSender) Close(timeout time.Duration) {
+	close(s.queue)
+	select {
+	case <-s.done:
+	case <-time.After(timeout):
+		fmt.Fprintf(os.Stderr, "Warning: stopped with webhook notifications still undelivered\n")
+	}
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://hooks.example.com/mail", false},
+		{"http://localhost:8080/hook", false},
+		{"ftp://example.com", true},
+		{"/relative/path", true},
+		{"https://", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := validateWebhookURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateWebhookURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeliverWebhookSignsAndRetries(t *testing.T) {
+	oldDelay := webhookRetryDelay
+	webhookRetryDelay = time.Millisecond
+	defer func() { webhookRetryDelay = oldDelay }()
+
+	var calls int32
+	var gotPayload webhookPayload
+	var gotSignature, wantSignature string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &gotPayload)
+		gotSignature = r.Header.Get(webhookSignatureHeader)
+		wantSignature = signWebhookBody("s3cret", body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	payload := webhookPayload{UID: 42, From: "alice@example.com", Subject: "Hi", Date: "2024-01-15 10:30", Mailbox: "INBOX"}
+	if err := deliverWebhook(server.Client(), server.URL, "s3cret", payload); err != nil {
+		t.Fatalf("deliverWebhook() error = %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+	if gotPayload != payload {
+		t.Errorf("payload = %+v, want %+v", gotPayload, payload)
+	}
+	if gotSignature == "" || gotSignature != wantSignature {
+		t.Errorf("signature = %q, want %q", gotSignature, wantSignature)
+	}
+}
+
+func TestDeliverWebhookGivesUp(t *testing.T) {
+	oldDelay := webhookRetryDelay
+	webhookRetryDelay = time.Millisecond
+	defer func() { webhookRetryDelay = oldDelay }()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get(webhookSignatureHeader) != "" {
+			t.Error("signature header should be absent without a secret")
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := deliverWebhook(server.Client(), server.URL, "", webhookPayload{UID: 1})
+	if err == nil {
+		t.Fatal("expected error after repeated failures")
+	}
+	if calls != webhookAttempts {
+		t.Errorf("calls = %d, want %d", calls, webhookAttempts)
+	}
+}
+
+func TestWebhookSenderDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := newWebhookSender(server.Client(), server.URL, "")
+	start := time.Now()
+	for uid := uint32(1); uid <= 3; uid++ {
+		sender.Send(webhookPayload{UID: uid, Mailbox: "INBOX"})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send() waited %v for a stalled endpoint", elapsed)
+	}
+
+	close(release)
+	sender.Close(5 * time.Second)
+	if calls != 3 {
+		t.Errorf("calls = %d after Close, want all 3 payloads delivered", calls)
+	}
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BackupFiles lists the files bundled by config backup. The Bridge password
+// (keyring entry or credentials.enc) is never included.
+var BackupFiles = []string{"config.yaml", "contacts.json", "idempotency.json"}
+
+// maxBackupEntrySize caps each file read from an archive during restore.
+const maxBackupEntrySize = 10 << 20
+
+// backupPaths maps each archive entry name to its location on disk. A
+// non-empty configPath overrides the location of config.yaml.
+func backupPaths(configPath string) (map[string]string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string, len(BackupFiles))
+	for _, name := range BackupFiles {
+		paths[name] = filepath.Join(dir, name)
+	}
+	if configPath != "" {
+		paths["config.yaml"] = configPath
+	}
+	return paths, nil
+}
+
+// WriteBackup writes a tar archive of the files in BackupFiles that exist
+// and returns the names that were included.
+func WriteBackup(w io.Writer, configPath string) ([]string, error) {
+	paths, err := backupPaths(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tw := tar.NewWriter(w)
+	var included []string
+
+	for _, name := range BackupFiles {
+		data, err := os.ReadFile(paths[name])
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, fmt.Errorf("failed to write archive: %w", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to write archive: %w", err)
+		}
+		included = append(included, name)
+	}
+
+	if len(included) == 0 {
+		return nil, errors.New("nothing to back up - no configuration files found")
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return included, nil
+}
+
+// ReadBackup reads a backup archive into memory. Entries that are not in
+// BackupFiles are rejected so an archive cannot write outside the config
+// directory.
+func ReadBackup(r io.Reader) (map[string][]byte, error) {
+	allowed := make(map[string]bool, len(BackupFiles))
+	for _, name := range BackupFiles {
+		allowed[name] = true
+	}
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !allowed[hdr.Name] {
+			return nil, fmt.Errorf("unexpected entry in backup: %s", hdr.Name)
+		}
+		if hdr.Size > maxBackupEntrySize {
+			return nil, fmt.Errorf("backup entry %s is too large", hdr.Name)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, maxBackupEntrySize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", hdr.Name, err)
+		}
+		if hdr.Name == "config.yaml" {
+			if err := yaml.Unmarshal(data, DefaultConfig()); err != nil {
+				return nil, fmt.Errorf("config.yaml in backup is invalid: %w", err)
+			}
+		}
+		files[hdr.Name] = data
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("backup archive is empty")
+	}
+	return files, nil
+}
+
+// RestoreBackup writes files read by ReadBackup back to the config
+// directory and returns the names written, in BackupFiles order.
+func RestoreBackup(files map[string][]byte, configPath string) ([]string, error) {
+	paths, err := backupPaths(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var restored []string
+	for _, name := range BackupFiles {
+		data, ok := files[name]
+		if !ok {
+			continue
+		}
+
+		path := paths[name]
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return restored, fmt.Errorf("failed to create config directory: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return restored, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		restored = append(restored, name)
+	}
+	return restored, nil
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	dir, err := ConfigDir()
+	if err != nil {
+		t.Fatalf("ConfigDir() error = %v", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Bridge.Email = "user@proton.me"
+	if err := cfg.Save(""); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	contactsData := []byte(`{"contacts":[]}`)
+	if err := os.WriteFile(filepath.Join(dir, "contacts.json"), contactsData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "credentials.enc"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	included, err := WriteBackup(&buf, "")
+	if err != nil {
+		t.Fatalf("WriteBackup() error = %v", err)
+	}
+	if len(included) != 2 || included[0] != "config.yaml" || included[1] != "contacts.json" {
+		t.Errorf("WriteBackup() included = %v, want [config.yaml contacts.json]", included)
+	}
+
+	files, err := ReadBackup(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadBackup() error = %v", err)
+	}
+	if _, ok := files["credentials.enc"]; ok {
+		t.Error("backup must not include credentials.enc")
+	}
+
+	// Restore into a fresh config dir
+	restoreDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", restoreDir)
+	t.Setenv("HOME", restoreDir)
+
+	restored, err := RestoreBackup(files, "")
+	if err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+	if len(restored) != 2 {
+		t.Errorf("RestoreBackup() restored = %v, want 2 files", restored)
+	}
+
+	loaded, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() after restore error = %v", err)
+	}
+	if loaded.Bridge.Email != "user@proton.me" {
+		t.Errorf("restored email = %q, want %q", loaded.Bridge.Email, "user@proton.me")
+	}
+
+	newDir, _ := ConfigDir()
+	got, err := os.ReadFile(filepath.Join(newDir, "contacts.json"))
+	if err != nil || !bytes.Equal(got, contactsData) {
+		t.Errorf("restored contacts.json = %q, %v", got, err)
+	}
+}
+
+func TestWriteBackupNothingToBackUp(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	var buf bytes.Buffer
+	if _, err := WriteBackup(&buf, ""); err == nil {
+		t.Error("WriteBackup() with no files should return an error")
+	}
+}
+
+func TestReadBackupRejectsUnexpectedEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		content string
+	}{
+		{"path traversal", "../../.bashrc", "evil"},
+		{"unknown file", "credentials.enc", "secret"},
+		{"invalid config", "config.yaml", "bridge: [unclosed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			if err := tw.WriteHeader(&tar.Header{Name: tt.entry, Mode: 0600, Size: int64(len(tt.content))}); err != nil {
+				t.Fatal(err)
+			}
+			tw.Write([]byte(tt.content))
+			tw.Close()
+
+			if _, err := ReadBackup(&buf); err == nil {
+				t.Errorf("ReadBackup() should reject entry %q", tt.entry)
+			}
+		})
+	}
+}
@@ -1,13 +1,20 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
+	_ "time/tzdata" // IANA zones for defaults.timezone on systems without zoneinfo
 
+	"github.com/bscott/pm-cli/internal/retry"
 	"github.com/zalando/go-keyring"
 	"gopkg.in/yaml.v3"
 )
@@ -27,19 +34,71 @@ type BridgeConfig struct {
 	SMTPHost string `yaml:"smtp_host"`
 	SMTPPort int    `yaml:"smtp_port"`
 	Email    string `yaml:"email"`
+	// CredentialStore selects where the Bridge password is kept:
+	// "keyring" (default) or "file" (encrypted with PM_CLI_SECRET).
+	CredentialStore string `yaml:"credential_store,omitempty"`
+	// TLSVerify checks the server certificate. Unset means off for
+	// loopback hosts, where Bridge uses a self-signed certificate, and on
+	// for anything else
+	TLSVerify *bool `yaml:"tls_verify,omitempty"`
+	// CACert is a PEM file trusted instead of the system roots, e.g.
+	// Bridge's exported certificate; setting it turns verification on
+	CACert string `yaml:"ca_cert,omitempty"`
+	// IMAPSecurity and SMTPSecurity select how each connection is
+	// secured: "starttls" (default, as Bridge does), "tls" for implicit
+	// TLS, or "none" for a plain connection to a loopback host
+	IMAPSecurity string `yaml:"imap_security,omitempty"`
+	SMTPSecurity string `yaml:"smtp_security,omitempty"`
 }
 
 type DefaultsConfig struct {
 	Mailbox string `yaml:"mailbox"`
 	Limit   int    `yaml:"limit"`
 	Format  string `yaml:"format"`
+	// DateStyle is "absolute" (default) or "relative" for text output
+	DateStyle string `yaml:"date_style,omitempty"`
+	// Timezone is an IANA zone name used to display dates; empty means local
+	Timezone string `yaml:"timezone,omitempty"`
+	// Signature is appended to sent mail and replies after a "-- " line
+	Signature string `yaml:"signature,omitempty"`
+	// MaxAttachmentSize limits each outgoing attachment, e.g. "25M"; empty
+	// means 25M and "0" disables the check
+	MaxAttachmentSize string `yaml:"max_attachment_size,omitempty"`
+	// ArchiveMailbox and TrashMailbox are the targets of 'mail archive'
+	// and 'mail trash'; empty means Proton's Archive and Trash
+	ArchiveMailbox string `yaml:"archive_mailbox,omitempty"`
+	TrashMailbox   string `yaml:"trash_mailbox,omitempty"`
+	// Retries is how often a Bridge connection or fetch that failed for a
+	// transient reason is retried; unset means 2 and 0 disables retrying
+	Retries *int `yaml:"retries,omitempty"`
+	// RetryDelay is the wait before the first retry, e.g. "500ms"; it
+	// doubles after each attempt. Empty means 1s
+	RetryDelay string `yaml:"retry_delay,omitempty"`
+	// Timeout limits how long a command may spend talking to Bridge, e.g.
+	// "30s"; it is also the connect timeout. Empty means no limit
+	Timeout string `yaml:"timeout,omitempty"`
 }
 
 type Config struct {
 	Bridge   BridgeConfig   `yaml:"bridge"`
 	Defaults DefaultsConfig `yaml:"defaults"`
+
+	// retryOverride holds --retries/--retry-delay and timeoutOverride
+	// --timeout; they are never saved
+	retryOverride   *retry.Policy
+	timeoutOverride *time.Duration
+	// deadline is when the running command times out; zero means never
+	deadline time.Time
 }
 
+const (
+	DefaultRetries    = 2
+	DefaultRetryDelay = time.Second
+	// DefaultConnectTimeout bounds establishing a Bridge connection when
+	// no timeout is configured.
+	DefaultConnectTimeout = 5 * time.Second
+)
+
 func DefaultConfig() *Config {
 	return &Config{
 		Bridge: BridgeConfig{
@@ -49,13 +108,236 @@ func DefaultConfig() *Config {
 			SMTPPort: DefaultSMTPPort,
 		},
 		Defaults: DefaultsConfig{
-			Mailbox: "INBOX",
-			Limit:   20,
-			Format:  "text",
+			Mailbox:   "INBOX",
+			Limit:     20,
+			Format:    "text",
+			DateStyle: "absolute",
 		},
 	}
 }
 
+// ArchiveMailbox returns defaults.archive_mailbox, or "Archive" when unset.
+func (c *Config) ArchiveMailbox() string {
+	if c.Defaults.ArchiveMailbox == "" {
+		return "Archive"
+	}
+	return c.Defaults.ArchiveMailbox
+}
+
+// TrashMailbox returns defaults.trash_mailbox, or "Trash" when unset.
+func (c *Config) TrashMailbox() string {
+	if c.Defaults.TrashMailbox == "" {
+		return "Trash"
+	}
+	return c.Defaults.TrashMailbox
+}
+
+// RetryPolicy returns how transient Bridge failures are retried: the
+// command-line override if one is set, otherwise defaults.retries and
+// defaults.retry_delay. An invalid retry_delay falls back to 1s; config
+// set rejects one.
+func (c *Config) RetryPolicy() retry.Policy {
+	if c.retryOverride != nil {
+		return *c.retryOverride
+	}
+	policy := retry.Policy{Retries: DefaultRetries, Delay: DefaultRetryDelay}
+	if c.Defaults.Retries != nil && *c.Defaults.Retries >= 0 {
+		policy.Retries = *c.Defaults.Retries
+	}
+	if d, err := time.ParseDuration(c.Defaults.RetryDelay); err == nil && d >= 0 {
+		policy.Delay = d
+	}
+	return policy
+}
+
+// OverrideRetryPolicy replaces parts of the retry policy for this process
+// only. A nil retries or a zero delay keeps the configured value.
+func (c *Config) OverrideRetryPolicy(retries *int, delay time.Duration) {
+	policy := c.RetryPolicy()
+	if retries != nil {
+		policy.Retries = *retries
+	}
+	if delay > 0 {
+		policy.Delay = delay
+	}
+	c.retryOverride = &policy
+}
+
+// IsLoopbackHost reports whether host is a loopback address. Accepts the
+// literal "localhost" (case-insensitive) or any IP that parses as loopback.
+// Does not resolve DNS; DNS is itself untrusted and we want a hard
+// guarantee that we are speaking to a local process (Proton Bridge).
+func IsLoopbackHost(host string) bool {
+	h := strings.ToLower(strings.TrimSpace(host))
+	if h == "" {
+		return false
+	}
+	if h == "localhost" {
+		return true
+	}
+	if ip := net.ParseIP(h); ip != nil {
+		return ip.IsLoopback()
+	}
+	return false
+}
+
+const (
+	// SecuritySTARTTLS connects in plain text and upgrades with STARTTLS.
+	SecuritySTARTTLS = "starttls"
+	// SecurityTLS speaks TLS from the start (implicit TLS).
+	SecurityTLS = "tls"
+	// SecurityNone never encrypts the connection.
+	SecurityNone = "none"
+)
+
+// ValidSecurity reports whether s is a bridge.imap_security or
+// bridge.smtp_security value.
+func ValidSecurity(s string) bool {
+	return s == SecuritySTARTTLS || s == SecurityTLS || s == SecurityNone
+}
+
+// IMAPSecurity returns bridge.imap_security, or starttls when unset.
+func (c *Config) IMAPSecurity() string {
+	if c.Bridge.IMAPSecurity == "" {
+		return SecuritySTARTTLS
+	}
+	return c.Bridge.IMAPSecurity
+}
+
+// SMTPSecurity returns bridge.smtp_security, or starttls when unset.
+func (c *Config) SMTPSecurity() string {
+	if c.Bridge.SMTPSecurity == "" {
+		return SecuritySTARTTLS
+	}
+	return c.Bridge.SMTPSecurity
+}
+
+// CheckSecurity rejects an unknown security setting, and "none" for a host
+// that is not a loopback address: the password would cross the network in
+// the clear.
+func CheckSecurity(security, host string) error {
+	if !ValidSecurity(security) {
+		return fmt.Errorf("invalid connection security %q - use starttls, tls or none", security)
+	}
+	if security == SecurityNone && !IsLoopbackHost(host) {
+		return fmt.Errorf("refusing to connect: %q is not a loopback address and its security is none - use starttls or tls", host)
+	}
+	return nil
+}
+
+// TLSVerify reports whether the certificate of host is verified:
+// bridge.tls_verify when set, otherwise on when bridge.ca_cert is set or
+// host is not a loopback address.
+func (c *Config) TLSVerify(host string) bool {
+	if c.Bridge.TLSVerify != nil {
+		return *c.Bridge.TLSVerify
+	}
+	return c.Bridge.CACert != "" || !IsLoopbackHost(host)
+}
+
+// TLSConfig returns the TLS settings for connecting to host. Without
+// verification, only a loopback host is accepted: skipping verification
+// is safe against a locally-running Bridge, but sending the password
+// anywhere else could hand it to whoever sits in between.
+func (c *Config) TLSConfig(host string) (*tls.Config, error) {
+	if !c.TLSVerify(host) {
+		if !IsLoopbackHost(host) {
+			return nil, fmt.Errorf("refusing to connect: %q is not a loopback address and TLS verification is off - set bridge.tls_verify to true", host)
+		}
+		return &tls.Config{InsecureSkipVerify: true, ServerName: host}, nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: host}
+	if c.Bridge.CACert != "" {
+		pool, err := loadCACert(c.Bridge.CACert)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// loadCACert reads the PEM certificates in path into a pool.
+func loadCACert(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bridge.ca_cert: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("bridge.ca_cert %s contains no PEM certificates", path)
+	}
+	return pool, nil
+}
+
+// CheckCACert reports whether path holds at least one PEM certificate.
+func CheckCACert(path string) error {
+	_, err := loadCACert(path)
+	return err
+}
+
+// Timeout returns --timeout if given, otherwise defaults.timeout. Zero
+// means commands are not limited. An invalid timeout counts as unset;
+// config set rejects one.
+func (c *Config) Timeout() time.Duration {
+	if c.timeoutOverride != nil {
+		return *c.timeoutOverride
+	}
+	if d, err := time.ParseDuration(c.Defaults.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
+
+// OverrideTimeout replaces the timeout for this process only.
+func (c *Config) OverrideTimeout(d time.Duration) {
+	c.timeoutOverride = &d
+}
+
+// ConnectTimeout returns how long dialing Bridge may take: the timeout
+// when one is set, otherwise DefaultConnectTimeout. It never runs past the
+// deadline.
+func (c *Config) ConnectTimeout() time.Duration {
+	timeout := DefaultConnectTimeout
+	if t := c.Timeout(); t > 0 {
+		timeout = t
+	}
+	if !c.deadline.IsZero() {
+		if left := time.Until(c.deadline); left < timeout {
+			timeout = left
+		}
+	}
+	return timeout
+}
+
+// StartDeadline starts the timeout clock: operations against Bridge fail
+// once Timeout has passed from now. Without a timeout it does nothing.
+func (c *Config) StartDeadline() {
+	if t := c.Timeout(); t > 0 {
+		c.deadline = time.Now().Add(t)
+	}
+}
+
+// Deadline returns when the running command times out, or the zero time
+// if it does not.
+func (c *Config) Deadline() time.Time {
+	return c.deadline
+}
+
+// Location returns the zone dates are displayed in: defaults.timezone when
+// set, otherwise local time.
+func (c *Config) Location() (*time.Location, error) {
+	if c.Defaults.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.Defaults.Timezone)
+	if err != nil {
+		return time.Local, fmt.Errorf("invalid timezone %q: %w", c.Defaults.Timezone, err)
+	}
+	return loc, nil
+}
+
 func ConfigDir() (string, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
@@ -127,6 +409,9 @@ func (c *Config) SetPassword(password string) error {
 	if c.Bridge.Email == "" {
 		return errors.New("email must be set before storing password")
 	}
+	if c.UsesFileCredentials() {
+		return setFilePassword(c.Bridge.Email, password)
+	}
 	return keyring.Set(AppName, c.Bridge.Email, password)
 }
 
@@ -134,6 +419,9 @@ func (c *Config) GetPassword() (string, error) {
 	if c.Bridge.Email == "" {
 		return "", errors.New("email not configured")
 	}
+	if c.UsesFileCredentials() {
+		return getFilePassword(c.Bridge.Email)
+	}
 	password, err := keyring.Get(AppName, c.Bridge.Email)
 	if err != nil {
 		if errors.Is(err, keyring.ErrNotFound) {
@@ -148,6 +436,71 @@ func DeletePassword(email string) error {
 	return keyring.Delete(AppName, email)
 }
 
+// UsesFileCredentials reports whether the password is kept in the encrypted
+// credentials file rather than the OS keyring.
+func (c *Config) UsesFileCredentials() bool {
+	return c.Bridge.CredentialStore == CredentialStoreFile
+}
+
+// CredentialStoreName returns the configured credential store, defaulting
+// to the keyring.
+func (c *Config) CredentialStoreName() string {
+	if c.UsesFileCredentials() {
+		return CredentialStoreFile
+	}
+	return CredentialStoreKeyring
+}
+
+// keyringProbeUser is the account name used by ProbeKeyring. It cannot
+// collide with a real entry, which is keyed by email address.
+const keyringProbeUser = "doctor-probe"
+
+// KeyringBackend describes the OS credential store go-keyring uses on this
+// platform.
+func KeyringBackend() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS Keychain"
+	case "windows":
+		return "Windows Credential Manager"
+	default:
+		return "Secret Service (D-Bus)"
+	}
+}
+
+// KeyringHint returns a remediation hint for when the keyring is unusable.
+func KeyringHint() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "unlock the login keychain in Keychain Access"
+	case "windows":
+		return "check that Credential Manager is available for this user"
+	default:
+		return "install gnome-keyring or another libsecret provider and run inside a D-Bus session (e.g. dbus-run-session)"
+	}
+}
+
+// ProbeKeyring writes, reads back and deletes a throwaway secret to verify
+// that the keyring is available and writable.
+func ProbeKeyring() error {
+	value := fmt.Sprintf("probe-%d", time.Now().UnixNano())
+
+	if err := keyring.Set(AppName, keyringProbeUser, value); err != nil {
+		return fmt.Errorf("cannot write to keyring: %w", err)
+	}
+	defer keyring.Delete(AppName, keyringProbeUser)
+
+	got, err := keyring.Get(AppName, keyringProbeUser)
+	if err != nil {
+		return fmt.Errorf("cannot read from keyring: %w", err)
+	}
+	if got != value {
+		return errors.New("keyring returned a different value than was stored")
+	}
+
+	return nil
+}
+
 func Exists() bool {
 	path, err := ConfigPath()
 	if err != nil {
@@ -218,41 +571,79 @@ func (s *idempotencyStore) save() error {
 	return os.WriteFile(path, data, 0600)
 }
 
-// CheckIdempotencyKey returns true if the key was already used (within TTL)
-func CheckIdempotencyKey(key string) (bool, error) {
-	if key == "" {
-		return false, nil
+// withIdempotencyLock runs fn while holding an exclusive advisory lock on
+// the idempotency store, so concurrent pm-cli processes cannot interleave
+// their read-modify-write cycles.
+func withIdempotencyLock(fn func() error) error {
+	path, err := idempotencyPath()
+	if err != nil {
+		return err
 	}
 
-	store, err := loadIdempotencyStore()
-	if err != nil {
-		return false, err
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	ts, exists := store.Keys[key]
-	if !exists {
-		return false, nil
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open idempotency lock: %w", err)
 	}
+	defer f.Close()
 
-	// Check if expired
-	if time.Now().Unix()-ts > int64(idempotencyTTL.Seconds()) {
-		return false, nil
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("failed to lock idempotency store: %w", err)
 	}
+	defer unlockFile(f)
 
-	return true, nil
+	return fn()
 }
 
-// RecordIdempotencyKey marks a key as used
-func RecordIdempotencyKey(key string) error {
+// ClaimIdempotencyKey atomically checks and records key. It returns true if
+// the key was already used within the TTL; otherwise the key is recorded and
+// the caller owns the send. Call ReleaseIdempotencyKey if the send fails so
+// a retry with the same key is not treated as a duplicate.
+func ClaimIdempotencyKey(key string) (bool, error) {
 	if key == "" {
-		return nil
+		return false, nil
 	}
 
-	store, err := loadIdempotencyStore()
+	used := false
+	err := withIdempotencyLock(func() error {
+		store, err := loadIdempotencyStore()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().Unix()
+		if ts, exists := store.Keys[key]; exists && now-ts <= int64(idempotencyTTL.Seconds()) {
+			used = true
+			return nil
+		}
+
+		store.Keys[key] = now
+		return store.save()
+	})
 	if err != nil {
-		return err
+		return false, err
+	}
+	return used, nil
+}
+
+// ReleaseIdempotencyKey forgets a key claimed by ClaimIdempotencyKey.
+func ReleaseIdempotencyKey(key string) error {
+	if key == "" {
+		return nil
 	}
 
-	store.Keys[key] = time.Now().Unix()
-	return store.save()
+	return withIdempotencyLock(func() error {
+		store, err := loadIdempotencyStore()
+		if err != nil {
+			return err
+		}
+		if _, exists := store.Keys[key]; !exists {
+			return nil
+		}
+		delete(store.Keys, key)
+		return store.save()
+	})
 }
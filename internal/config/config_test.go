@@ -1,9 +1,23 @@
 package config
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/zalando/go-keyring"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -236,6 +250,181 @@ func TestDefaultsConfigStruct(t *testing.T) {
 	}
 }
 
+func TestRetryPolicy(t *testing.T) {
+	cfg := DefaultConfig()
+	if p := cfg.RetryPolicy(); p.Retries != DefaultRetries || p.Delay != DefaultRetryDelay {
+		t.Errorf("default RetryPolicy() = %+v", p)
+	}
+
+	retries := 0
+	cfg.Defaults.Retries = &retries
+	cfg.Defaults.RetryDelay = "250ms"
+	if p := cfg.RetryPolicy(); p.Retries != 0 || p.Delay != 250*time.Millisecond {
+		t.Errorf("configured RetryPolicy() = %+v, want 0 retries after 250ms", p)
+	}
+
+	five := 5
+	cfg.OverrideRetryPolicy(&five, 0)
+	if p := cfg.RetryPolicy(); p.Retries != 5 || p.Delay != 250*time.Millisecond {
+		t.Errorf("overridden RetryPolicy() = %+v, want 5 retries after 250ms", p)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Timeout() != 0 || cfg.ConnectTimeout() != DefaultConnectTimeout {
+		t.Errorf("default Timeout() = %v, ConnectTimeout() = %v", cfg.Timeout(), cfg.ConnectTimeout())
+	}
+	cfg.StartDeadline()
+	if !cfg.Deadline().IsZero() {
+		t.Error("expected no deadline without a timeout")
+	}
+
+	cfg.Defaults.Timeout = "30s"
+	if cfg.Timeout() != 30*time.Second || cfg.ConnectTimeout() != 30*time.Second {
+		t.Errorf("Timeout() = %v, ConnectTimeout() = %v, want 30s", cfg.Timeout(), cfg.ConnectTimeout())
+	}
+
+	cfg.OverrideTimeout(2 * time.Second)
+	start := time.Now()
+	cfg.StartDeadline()
+	if d := cfg.Deadline().Sub(start); d < 2*time.Second || d > 3*time.Second {
+		t.Errorf("deadline is %v after start, want 2s", d)
+	}
+	if ct := cfg.ConnectTimeout(); ct > 2*time.Second {
+		t.Errorf("ConnectTimeout() = %v, must not run past the deadline", ct)
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"localhost", true},
+		{"LOCALHOST", true},
+		{"127.0.0.1", true},
+		{"127.1.2.3", true},
+		{"::1", true},
+		{"  127.0.0.1  ", true},
+		{"imap.example.com", false},
+		{"10.0.0.1", false},
+		{"0.0.0.0", false},
+		{"", false},
+		{"localhost.evil.com", false},
+	}
+	for _, tc := range tests {
+		got := IsLoopbackHost(tc.host)
+		if got != tc.want {
+			t.Errorf("IsLoopbackHost(%q) = %v, want %v", tc.host, got, tc.want)
+		}
+	}
+}
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 as PEM.
+func writeTestCert(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bridge.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	return path
+}
+
+func TestTLSConfig(t *testing.T) {
+	on, off := true, false
+
+	t.Run("loopback skips verification by default", func(t *testing.T) {
+		tlsConfig, err := DefaultConfig().TLSConfig("127.0.0.1")
+		if err != nil || !tlsConfig.InsecureSkipVerify {
+			t.Fatalf("TLSConfig() = %+v, %v; want verification skipped", tlsConfig, err)
+		}
+	})
+
+	t.Run("remote host verifies by default", func(t *testing.T) {
+		tlsConfig, err := DefaultConfig().TLSConfig("imap.example.com")
+		if err != nil || tlsConfig.InsecureSkipVerify || tlsConfig.ServerName != "imap.example.com" {
+			t.Fatalf("TLSConfig() = %+v, %v; want verification on", tlsConfig, err)
+		}
+	})
+
+	t.Run("remote host without verification is refused", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Bridge.TLSVerify = &off
+		if _, err := cfg.TLSConfig("imap.example.com"); err == nil || !strings.Contains(err.Error(), "loopback") {
+			t.Fatalf("TLSConfig() error = %v, want a loopback refusal", err)
+		}
+	})
+
+	t.Run("tls_verify on loopback", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Bridge.TLSVerify = &on
+		tlsConfig, err := cfg.TLSConfig("127.0.0.1")
+		if err != nil || tlsConfig.InsecureSkipVerify {
+			t.Fatalf("TLSConfig() = %+v, %v; want verification on", tlsConfig, err)
+		}
+	})
+
+	t.Run("ca_cert pins the certificate", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Bridge.CACert = writeTestCert(t)
+		tlsConfig, err := cfg.TLSConfig("127.0.0.1")
+		if err != nil || tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs == nil {
+			t.Fatalf("TLSConfig() = %+v, %v; want verification against ca_cert", tlsConfig, err)
+		}
+	})
+
+	t.Run("unreadable ca_cert", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Bridge.CACert = filepath.Join(t.TempDir(), "missing.pem")
+		if _, err := cfg.TLSConfig("127.0.0.1"); err == nil {
+			t.Fatal("expected an error for a missing ca_cert")
+		}
+	})
+}
+
+func TestCheckSecurity(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.IMAPSecurity() != SecuritySTARTTLS || cfg.SMTPSecurity() != SecuritySTARTTLS {
+		t.Errorf("default security = %q/%q, want starttls", cfg.IMAPSecurity(), cfg.SMTPSecurity())
+	}
+
+	tests := []struct {
+		security, host string
+		wantErr        bool
+	}{
+		{SecuritySTARTTLS, "imap.example.com", false},
+		{SecurityTLS, "imap.example.com", false},
+		{SecurityNone, "127.0.0.1", false},
+		{SecurityNone, "localhost", false},
+		{SecurityNone, "imap.example.com", true},
+		{"ssl", "127.0.0.1", true},
+	}
+	for _, tt := range tests {
+		err := CheckSecurity(tt.security, tt.host)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckSecurity(%q, %q) error = %v, wantErr %v", tt.security, tt.host, err, tt.wantErr)
+		}
+	}
+}
+
 func TestSetPasswordWithoutEmail(t *testing.T) {
 	cfg := DefaultConfig()
 	// Email is empty by default
@@ -256,6 +445,82 @@ func TestGetPasswordWithoutEmail(t *testing.T) {
 	}
 }
 
+func TestProbeKeyring(t *testing.T) {
+	keyring.MockInit()
+	if err := ProbeKeyring(); err != nil {
+		t.Errorf("ProbeKeyring() with working keyring error = %v", err)
+	}
+	if _, err := keyring.Get(AppName, keyringProbeUser); !errors.Is(err, keyring.ErrNotFound) {
+		t.Errorf("probe entry should be deleted, Get() error = %v", err)
+	}
+
+	keyring.MockInitWithError(errors.New("no D-Bus session"))
+	if err := ProbeKeyring(); err == nil {
+		t.Error("ProbeKeyring() with broken keyring should return an error")
+	}
+
+	if KeyringBackend() == "" {
+		t.Error("KeyringBackend() should not be empty")
+	}
+	if KeyringHint() == "" {
+		t.Error("KeyringHint() should not be empty")
+	}
+}
+
+func TestFileCredentialStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv(SecretEnvVar, "correct horse")
+
+	cfg := DefaultConfig()
+	cfg.Bridge.Email = "user@proton.me"
+	cfg.Bridge.CredentialStore = CredentialStoreFile
+
+	if err := cfg.SetPassword("bridge-secret"); err != nil {
+		t.Fatalf("SetPassword() error = %v", err)
+	}
+
+	got, err := cfg.GetPassword()
+	if err != nil {
+		t.Fatalf("GetPassword() error = %v", err)
+	}
+	if got != "bridge-secret" {
+		t.Errorf("GetPassword() = %q, want %q", got, "bridge-secret")
+	}
+
+	path, _ := credentialsPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("credentials file not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("credentials file mode = %v, want 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "bridge-secret") {
+		t.Error("credentials file contains the plaintext password")
+	}
+
+	t.Setenv(SecretEnvVar, "wrong passphrase")
+	if _, err := cfg.GetPassword(); err == nil {
+		t.Error("GetPassword() with wrong secret should fail")
+	}
+
+	t.Setenv(SecretEnvVar, "")
+	if _, err := cfg.GetPassword(); err == nil || !strings.Contains(err.Error(), SecretEnvVar) {
+		t.Errorf("GetPassword() without secret error = %v, want mention of %s", err, SecretEnvVar)
+	}
+
+	other := DefaultConfig()
+	other.Bridge.Email = "other@proton.me"
+	other.Bridge.CredentialStore = CredentialStoreFile
+	t.Setenv(SecretEnvVar, "correct horse")
+	if _, err := other.GetPassword(); err == nil {
+		t.Error("GetPassword() for unknown account should fail")
+	}
+}
+
 func TestLoadWithDefaultPath(t *testing.T) {
 	// Test that Load with empty path uses default ConfigPath.
 	// This will likely fail since the user may not have a config,
@@ -350,3 +615,65 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestClaimIdempotencyKeyConcurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	const workers = 8
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		notSeen int
+	)
+
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			used, err := ClaimIdempotencyKey("same-key")
+			if err != nil {
+				t.Errorf("ClaimIdempotencyKey() error = %v", err)
+				return
+			}
+			if !used {
+				mu.Lock()
+				notSeen++
+				mu.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if notSeen != 1 {
+		t.Errorf("%d claims saw the key as unused, want exactly 1", notSeen)
+	}
+}
+
+func TestReleaseIdempotencyKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	if used, err := ClaimIdempotencyKey("retry-me"); err != nil || used {
+		t.Fatalf("first ClaimIdempotencyKey() = %v, %v; want false, nil", used, err)
+	}
+	if used, _ := ClaimIdempotencyKey("retry-me"); !used {
+		t.Error("second ClaimIdempotencyKey() should report the key as used")
+	}
+
+	if err := ReleaseIdempotencyKey("retry-me"); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey() error = %v", err)
+	}
+	if used, _ := ClaimIdempotencyKey("retry-me"); used {
+		t.Error("ClaimIdempotencyKey() after release should succeed")
+	}
+
+	if used, err := ClaimIdempotencyKey(""); err != nil || used {
+		t.Errorf("ClaimIdempotencyKey(\"\") = %v, %v; want false, nil", used, err)
+	}
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// CredentialStoreKeyring stores the Bridge password in the OS keyring.
+	CredentialStoreKeyring = "keyring"
+	// CredentialStoreFile stores the Bridge password in an encrypted file.
+	CredentialStoreFile = "file"
+
+	// SecretEnvVar holds the passphrase for the file credential store.
+	SecretEnvVar = "PM_CLI_SECRET"
+
+	credentialsFileName = "credentials.enc"
+	pbkdf2Iterations    = 600000
+	saltSize            = 16
+	keySize             = 32
+)
+
+// credentialsFile is the on-disk layout of the encrypted credential store.
+// Each entry is base64(nonce || AES-256-GCM ciphertext) keyed by email.
+type credentialsFile struct {
+	Version int               `json:"version"`
+	Salt    string            `json:"salt"`
+	Entries map[string]string `json:"entries"`
+}
+
+func credentialsPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, credentialsFileName), nil
+}
+
+// credentialSecret returns the passphrase for the file store.
+func credentialSecret() (string, error) {
+	secret := os.Getenv(SecretEnvVar)
+	if secret == "" {
+		return "", fmt.Errorf("%s must be set to use the file credential store", SecretEnvVar)
+	}
+	return secret, nil
+}
+
+func loadCredentialsFile() (*credentialsFile, string, error) {
+	path, err := credentialsPath()
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			salt := make([]byte, saltSize)
+			if _, err := rand.Read(salt); err != nil {
+				return nil, "", fmt.Errorf("failed to generate salt: %w", err)
+			}
+			return &credentialsFile{
+				Version: 1,
+				Salt:    base64.StdEncoding.EncodeToString(salt),
+				Entries: make(map[string]string),
+			}, path, nil
+		}
+		return nil, "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var creds credentialsFile
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, "", fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	if creds.Entries == nil {
+		creds.Entries = make(map[string]string)
+	}
+	return &creds, path, nil
+}
+
+func (f *credentialsFile) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+// gcm derives the AES-256-GCM cipher for secret and the file's salt.
+func (f *credentialsFile) gcm(secret string) (cipher.AEAD, error) {
+	salt, err := base64.StdEncoding.DecodeString(f.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt in credentials file: %w", err)
+	}
+
+	key, err := pbkdf2.Key(sha256.New, secret, salt, pbkdf2Iterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func setFilePassword(email, password string) error {
+	secret, err := credentialSecret()
+	if err != nil {
+		return err
+	}
+
+	creds, path, err := loadCredentialsFile()
+	if err != nil {
+		return err
+	}
+
+	aead, err := creds.gcm(secret)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// Bind the ciphertext to the account so entries cannot be swapped
+	sealed := aead.Seal(nonce, nonce, []byte(password), []byte(email))
+	creds.Entries[email] = base64.StdEncoding.EncodeToString(sealed)
+
+	return creds.save(path)
+}
+
+func getFilePassword(email string) (string, error) {
+	secret, err := credentialSecret()
+	if err != nil {
+		return "", err
+	}
+
+	creds, _, err := loadCredentialsFile()
+	if err != nil {
+		return "", err
+	}
+
+	encoded, ok := creds.Entries[email]
+	if !ok {
+		return "", fmt.Errorf("password not found in credentials file - run 'pm-cli config init' to set it")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("corrupt entry in credentials file: %w", err)
+	}
+
+	aead, err := creds.gcm(secret)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("corrupt entry in credentials file")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(email))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt password - check %s", SecretEnvVar)
+	}
+	return string(plaintext), nil
+}
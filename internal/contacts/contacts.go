@@ -2,6 +2,7 @@ package contacts
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +11,10 @@ import (
 	"time"
 )
 
+// ErrExists matches the error of adding an address that is already in the
+// address book.
+var ErrExists = errors.New("already exists")
+
 // Contact represents a single address book entry.
 type Contact struct {
 	Email   string    `json:"email"`
@@ -144,6 +149,15 @@ func (s *Store) Search(query string) []Contact {
 
 // Add creates a new contact. Returns error if email already exists.
 func (s *Store) Add(email, name string) error {
+	if err := s.add(email, name); err != nil {
+		return err
+	}
+	return s.Save()
+}
+
+// add appends a contact without saving. Returns error if email is empty
+// or already exists.
+func (s *Store) add(email, name string) error {
 	email = strings.TrimSpace(strings.ToLower(email))
 	name = strings.TrimSpace(name)
 
@@ -154,7 +168,7 @@ func (s *Store) Add(email, name string) error {
 	// Check for duplicates
 	for _, c := range s.Contacts {
 		if strings.EqualFold(c.Email, email) {
-			return fmt.Errorf("contact with email %s already exists", email)
+			return fmt.Errorf("contact with email %s %w", email, ErrExists)
 		}
 	}
 
@@ -166,7 +180,57 @@ func (s *Store) Add(email, name string) error {
 		Updated: now,
 	})
 
-	return s.Save()
+	return nil
+}
+
+// ImportResult is what Import did with the addresses on the cards.
+type ImportResult struct {
+	Added int
+	// Skipped counts addresses already in the address book
+	Skipped int
+	// Invalid lists the addresses that could not be added for another
+	// reason
+	Invalid []ImportError
+}
+
+// ImportError is an address from a card that Import could not add.
+type ImportError struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+	Error string `json:"error"`
+}
+
+// Import adds every email of every card as a separate contact, skipping
+// addresses that already exist. A card name equal to the address (as
+// written by WriteVCards for unnamed contacts) is treated as no name.
+// The store is saved once at the end.
+func (s *Store) Import(cards []VCard) (ImportResult, error) {
+	var result ImportResult
+	for _, card := range cards {
+		for _, email := range card.Emails {
+			name := card.Name
+			if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(email)) {
+				name = ""
+			}
+			if err := s.add(email, name); err != nil {
+				if errors.Is(err, ErrExists) {
+					result.Skipped++
+				} else {
+					result.Invalid = append(result.Invalid, ImportError{Name: card.Name, Email: email, Error: err.Error()})
+				}
+				continue
+			}
+			result.Added++
+		}
+	}
+
+	if result.Added > 0 {
+		if err := s.Save(); err != nil {
+			return ImportResult{}, err
+		}
+	}
+
+	return result, nil
 }
 
 // Remove deletes a contact by email. Returns error if not found.
@@ -212,6 +276,45 @@ func (s *Store) Update(email, name string) error {
 	return fmt.Errorf("contact with email %s not found", email)
 }
 
+// Resolve expands a recipient that is not an email address into the email
+// of the single contact whose name or email matches it. An exact
+// (case-insensitive) name match wins over substring matches. Values that
+// already contain "@" are returned unchanged.
+func (s *Store) Resolve(recipient string) (string, error) {
+	recipient = strings.TrimSpace(recipient)
+	if strings.Contains(recipient, "@") {
+		return recipient, nil
+	}
+
+	matches := s.Search(recipient)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no contact matches %q", recipient)
+	}
+	if len(matches) == 1 {
+		return matches[0].Email, nil
+	}
+
+	var exact []Contact
+	for _, c := range matches {
+		if strings.EqualFold(c.Name, recipient) {
+			exact = append(exact, c)
+		}
+	}
+	if len(exact) == 1 {
+		return exact[0].Email, nil
+	}
+
+	candidates := make([]string, len(matches))
+	for i, c := range matches {
+		if c.Name != "" {
+			candidates[i] = fmt.Sprintf("%s <%s>", c.Name, c.Email)
+		} else {
+			candidates[i] = c.Email
+		}
+	}
+	return "", fmt.Errorf("%q matches multiple contacts: %s", recipient, strings.Join(candidates, ", "))
+}
+
 // Count returns the number of contacts.
 func (s *Store) Count() int {
 	return len(s.Contacts)
@@ -0,0 +1,57 @@
+package contacts
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoreResolve(t *testing.T) {
+	store := &Store{path: filepath.Join(t.TempDir(), "contacts.json")}
+	for _, c := range []struct{ email, name string }{
+		{"jane@example.com", "Jane Doe"},
+		{"janet@example.com", "Janet Smith"},
+		{"bob@example.com", "Bob"},
+		{"bobby@example.com", "Bobby Tables"},
+	} {
+		if err := store.Add(c.email, c.name); err != nil {
+			t.Fatalf("Add(%q) error = %v", c.email, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		wantErr   bool
+		errSubstr string
+	}{
+		{"email passes through", "someone@else.com", "someone@else.com", false, ""},
+		{"unique substring", "doe", "jane@example.com", false, ""},
+		{"unique email substring", "bobby", "bobby@example.com", false, ""},
+		{"exact name beats substring", "bob", "bob@example.com", false, ""},
+		{"ambiguous", "jan", "", true, "jane@example.com"},
+		{"no match", "nobody", "", true, "no contact matches"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Resolve(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Resolve(%q) = %q, want error", tt.input, got)
+				}
+				if !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Errorf("Resolve(%q) error = %q, want it to contain %q", tt.input, err, tt.errSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package contacts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// VCard holds the fields pm-cli uses from a vCard entry.
+type VCard struct {
+	Name   string
+	Emails []string
+}
+
+// ParseVCards reads vCard 3.0/4.0 entries from r. Only FN, N and EMAIL
+// properties are interpreted; everything else is ignored.
+func ParseVCards(r io.Reader) ([]VCard, error) {
+	lines, err := unfoldVCardLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []VCard
+	var current *VCard
+	var structuredName string
+
+	for _, line := range lines {
+		name, value, ok := splitVCardProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "BEGIN":
+			if strings.EqualFold(value, "VCARD") {
+				current = &VCard{}
+				structuredName = ""
+			}
+		case "END":
+			if strings.EqualFold(value, "VCARD") && current != nil {
+				if current.Name == "" {
+					current.Name = structuredName
+				}
+				cards = append(cards, *current)
+				current = nil
+			}
+		case "FN":
+			if current != nil {
+				current.Name = unescapeVCardValue(value)
+			}
+		case "N":
+			if current != nil {
+				structuredName = formatStructuredName(value)
+			}
+		case "EMAIL":
+			if current != nil {
+				email := strings.TrimSpace(unescapeVCardValue(value))
+				email = strings.TrimPrefix(strings.TrimPrefix(email, "mailto:"), "MAILTO:")
+				if email != "" {
+					current.Emails = append(current.Emails, email)
+				}
+			}
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("unterminated vCard (missing END:VCARD)")
+	}
+
+	return cards, nil
+}
+
+// unfoldVCardLines joins folded continuation lines (RFC 6350 section 3.2).
+func unfoldVCardLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vCard: %w", err)
+	}
+
+	return lines, nil
+}
+
+// splitVCardProperty splits a content line into its upper-cased property
+// name (without group or parameters) and value.
+func splitVCardProperty(line string) (name, value string, ok bool) {
+	idx := strings.Index(line, ":")
+	if idx <= 0 {
+		return "", "", false
+	}
+
+	name = line[:idx]
+	value = line[idx+1:]
+
+	if semi := strings.Index(name, ";"); semi != -1 {
+		name = name[:semi]
+	}
+	if dot := strings.LastIndex(name, "."); dot != -1 {
+		name = name[dot+1:]
+	}
+
+	return strings.ToUpper(strings.TrimSpace(name)), value, true
+}
+
+// formatStructuredName turns an N value (Family;Given;Additional;Prefix;Suffix)
+// into a display name.
+func formatStructuredName(value string) string {
+	parts := strings.Split(value, ";")
+	var names []string
+	for _, idx := range []int{3, 1, 2, 0, 4} {
+		if idx < len(parts) {
+			if p := strings.TrimSpace(unescapeVCardValue(parts[idx])); p != "" {
+				names = append(names, p)
+			}
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// WriteVCards serializes contacts as vCard 3.0 entries. Contacts without
+// a name use their email address as FN, which is a required property.
+func WriteVCards(w io.Writer, contacts []Contact) error {
+	for _, c := range contacts {
+		name := c.Name
+		if name == "" {
+			name = c.Email
+		}
+
+		card := "BEGIN:VCARD\r\n" +
+			"VERSION:3.0\r\n" +
+			"FN:" + escapeVCardValue(name) + "\r\n" +
+			"EMAIL;TYPE=INTERNET:" + c.Email + "\r\n" +
+			"END:VCARD\r\n"
+
+		if _, err := io.WriteString(w, card); err != nil {
+			return fmt.Errorf("failed to write vCard: %w", err)
+		}
+	}
+	return nil
+}
+
+func escapeVCardValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+func unescapeVCardValue(value string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return replacer.Replace(value)
+}
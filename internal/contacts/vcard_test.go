@@ -0,0 +1,138 @@
+package contacts
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleVCards = "BEGIN:VCARD\r\n" +
+	"VERSION:3.0\r\n" +
+	"FN:Alice Example\r\n" +
+	"N:Example;Alice;;;\r\n" +
+	"EMAIL;TYPE=INTERNET,HOME:alice@example.com\r\n" +
+	"item1.EMAIL;TYPE=WORK:alice@work.example\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"N:Builder;Bob;;Mr.;\r\n" +
+	"EMAIL:bob@example.com\r\n" +
+	"NOTE:folded line that\r\n" +
+	" continues here\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"FN:Carol\\, PhD\r\n" +
+	"END:VCARD\r\n"
+
+func TestParseVCards(t *testing.T) {
+	cards, err := ParseVCards(strings.NewReader(sampleVCards))
+	if err != nil {
+		t.Fatalf("ParseVCards() error = %v", err)
+	}
+
+	if len(cards) != 3 {
+		t.Fatalf("got %d cards, want 3", len(cards))
+	}
+
+	if cards[0].Name != "Alice Example" {
+		t.Errorf("cards[0].Name = %q, want %q", cards[0].Name, "Alice Example")
+	}
+	if len(cards[0].Emails) != 2 || cards[0].Emails[1] != "alice@work.example" {
+		t.Errorf("cards[0].Emails = %v, want two addresses", cards[0].Emails)
+	}
+
+	// No FN: falls back to the structured N property
+	if cards[1].Name != "Mr. Bob Builder" {
+		t.Errorf("cards[1].Name = %q, want %q", cards[1].Name, "Mr. Bob Builder")
+	}
+
+	if cards[2].Name != "Carol, PhD" {
+		t.Errorf("cards[2].Name = %q, want %q", cards[2].Name, "Carol, PhD")
+	}
+	if len(cards[2].Emails) != 0 {
+		t.Errorf("cards[2].Emails = %v, want none", cards[2].Emails)
+	}
+}
+
+func TestParseVCardsUnterminated(t *testing.T) {
+	_, err := ParseVCards(strings.NewReader("BEGIN:VCARD\nFN:Nobody\n"))
+	if err == nil {
+		t.Error("expected error for unterminated vCard")
+	}
+}
+
+func TestStoreImport(t *testing.T) {
+	store := &Store{path: filepath.Join(t.TempDir(), "contacts.json")}
+	if err := store.Add("alice@example.com", "Alice"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	cards, err := ParseVCards(strings.NewReader(sampleVCards))
+	if err != nil {
+		t.Fatalf("ParseVCards() error = %v", err)
+	}
+
+	// A card with a blank EMAIL is reported, not counted as a duplicate
+	cards = append(cards, VCard{Name: "Blank", Emails: []string{" "}})
+
+	result, err := store.Import(cards)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Added != 2 || result.Skipped != 1 {
+		t.Errorf("Import() = (%d added, %d skipped), want (2, 1)", result.Added, result.Skipped)
+	}
+	if len(result.Invalid) != 1 || result.Invalid[0].Name != "Blank" {
+		t.Errorf("Import() invalid = %+v, want the blank card", result.Invalid)
+	}
+	if store.Count() != 3 {
+		t.Errorf("Count() = %d, want 3", store.Count())
+	}
+	if c := store.Get("alice@work.example"); c == nil || c.Name != "Alice Example" {
+		t.Errorf("Get(alice@work.example) = %+v, want name %q", c, "Alice Example")
+	}
+}
+
+func TestVCardRoundTrip(t *testing.T) {
+	original := &Store{path: filepath.Join(t.TempDir(), "contacts.json")}
+	for _, c := range []struct{ email, name string }{
+		{"alice@example.com", "Alice Example"},
+		{"bob@example.com", "Builder, Bob; Jr."},
+		{"nameless@example.com", ""},
+	} {
+		if err := original.Add(c.email, c.name); err != nil {
+			t.Fatalf("Add(%q) error = %v", c.email, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteVCards(&buf, original.List()); err != nil {
+		t.Fatalf("WriteVCards() error = %v", err)
+	}
+
+	cards, err := ParseVCards(&buf)
+	if err != nil {
+		t.Fatalf("ParseVCards() error = %v", err)
+	}
+
+	imported := &Store{path: filepath.Join(t.TempDir(), "contacts.json")}
+	if _, err := imported.Import(cards); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if imported.Count() != original.Count() {
+		t.Fatalf("Count() = %d, want %d", imported.Count(), original.Count())
+	}
+	for _, want := range original.Contacts {
+		got := imported.Get(want.Email)
+		if got == nil {
+			t.Errorf("contact %s missing after round trip", want.Email)
+			continue
+		}
+		if got.Name != want.Name {
+			t.Errorf("contact %s name = %q, want %q", want.Email, got.Name, want.Name)
+		}
+	}
+}
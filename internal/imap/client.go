@@ -2,40 +2,35 @@ package imap
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bscott/pm-cli/internal/config"
-	"github.com/bscott/pm-cli/internal/safetext"
+	"github.com/bscott/pm-cli/internal/retry"
 	"github.com/emersion/go-imap/v2"
 	"github.com/emersion/go-imap/v2/imapclient"
+	"github.com/emersion/go-message/charset"
 )
 
-// isLoopbackHost reports whether host is a loopback address. Accepts the
-// literal "localhost" (case-insensitive) or any IP that parses as loopback.
-// Does not resolve DNS; DNS is itself untrusted and we want a hard
-// guarantee that we are speaking to a local process (Proton Bridge).
-func isLoopbackHost(host string) bool {
-	h := strings.ToLower(strings.TrimSpace(host))
-	if h == "" {
-		return false
-	}
-	if h == "localhost" {
-		return true
-	}
-	if ip := net.ParseIP(h); ip != nil {
-		return ip.IsLoopback()
-	}
-	return false
-}
-
 type Client struct {
-	client *imapclient.Client
-	config *config.Config
+	client    *imapclient.Client
+	config    *config.Config
+	password  string // kept to reconnect after a dropped connection
+	tlsConfig *tls.Config
+
+	// mu guards client against timer, which closes the connection when
+	// the configured deadline passes
+	mu    sync.Mutex
+	timer *time.Timer
 }
 
 type messageSelector struct {
@@ -88,43 +83,85 @@ func selectorToNumSet(selector messageSelector) imap.NumSet {
 	return imap.SeqSetNum(selector.seq)
 }
 
+// buildNumSetFromIDs merges ids into one set. Each ID is a sequence number,
+// uid:<uid>, or a range or comma group of either (see IsIDSpec); sequence
+// numbers and UIDs cannot be mixed.
 func buildNumSetFromIDs(ids []string) (imap.NumSet, error) {
 	if len(ids) == 0 {
 		return nil, fmt.Errorf("no message IDs provided")
 	}
 
-	firstSelector, err := parseMessageSelector(ids[0])
-	if err != nil {
-		return nil, err
-	}
-
-	if firstSelector.kind == selectorKindUID {
-		set := imap.UIDSetNum(firstSelector.uid)
-		for _, id := range ids[1:] {
-			selector, err := parseMessageSelector(id)
+	var seqSet imap.SeqSet
+	var uidSet imap.UIDSet
+	for _, id := range ids {
+		value := strings.TrimSpace(id)
+		isUID := strings.HasPrefix(strings.ToLower(value), "uid:")
+		switch {
+		case IsIDSpec(value) && isUID:
+			set, err := parseUIDSpec(value[len("uid:"):])
 			if err != nil {
 				return nil, err
 			}
-			if selector.kind != selectorKindUID {
-				return nil, fmt.Errorf("cannot mix sequence numbers and UID selectors in one command")
+			uidSet.AddSet(set)
+		case IsIDSpec(value):
+			set, err := parseIDSpec(value)
+			if err != nil {
+				return nil, err
+			}
+			seqSet.AddSet(set)
+		default:
+			selector, err := parseMessageSelector(value)
+			if err != nil {
+				return nil, err
+			}
+			if selector.kind == selectorKindUID {
+				uidSet.AddNum(selector.uid)
+			} else {
+				seqSet.AddNum(selector.seq)
 			}
-			set.AddNum(selector.uid)
-		}
-		return set, nil
-	}
-
-	set := imap.SeqSetNum(firstSelector.seq)
-	for _, id := range ids[1:] {
-		selector, err := parseMessageSelector(id)
-		if err != nil {
-			return nil, err
 		}
-		if selector.kind != selectorKindSeq {
+		if len(seqSet) > 0 && len(uidSet) > 0 {
 			return nil, fmt.Errorf("cannot mix sequence numbers and UID selectors in one command")
 		}
-		set.AddNum(selector.seq)
 	}
-	return set, nil
+
+	if len(uidSet) > 0 {
+		return uidSet, nil
+	}
+	return seqSet, nil
+}
+
+// location returns the configured display zone, falling back to local time
+// when defaults.timezone is unset or invalid.
+func (c *Client) location() *time.Location {
+	loc, _ := c.config.Location()
+	return loc
+}
+
+// formatDate renders t in loc using layout for display, and as UTC RFC 3339
+// for the date_iso field.
+func formatDate(t time.Time, loc *time.Location, layout string) (date, iso string) {
+	return t.In(loc).Format(layout), t.UTC().Format(time.RFC3339)
+}
+
+var (
+	// ErrConnect is returned by Connect when the server cannot be reached.
+	ErrConnect = errors.New("failed to connect to IMAP server")
+	// ErrMessageNotFound is returned when an ID matches no message.
+	ErrMessageNotFound = errors.New("message not found")
+	// ErrMailboxEmpty is returned when a message is requested from an
+	// empty mailbox.
+	ErrMailboxEmpty = errors.New("mailbox is empty")
+)
+
+// IsNotFound reports whether err means the requested message or mailbox
+// does not exist.
+func IsNotFound(err error) bool {
+	if errors.Is(err, ErrMessageNotFound) || errors.Is(err, ErrMailboxEmpty) {
+		return true
+	}
+	var imapErr *imap.Error
+	return errors.As(err, &imapErr) && imapErr.Code == imap.ResponseCodeNonExistent
 }
 
 func NewClient(cfg *config.Config) (*Client, error) {
@@ -134,46 +171,163 @@ func NewClient(cfg *config.Config) (*Client, error) {
 }
 
 func (c *Client) Connect() error {
-	// TLS skip-verify below is only safe against a locally-running Proton
-	// Bridge. Refuse to send the Bridge password to anything that is not a
-	// loopback address, in case the user's config was tampered with or
-	// redirected via --config.
-	if !isLoopbackHost(c.config.Bridge.IMAPHost) {
-		return fmt.Errorf("refusing to connect: IMAP host %q is not a loopback address (Proton Bridge runs on localhost; InsecureSkipVerify is unsafe for remote hosts)", c.config.Bridge.IMAPHost)
+	// Checked before the password is read, so a config that was tampered
+	// with or redirected via --config cannot leak it to a remote host
+	if err := config.CheckSecurity(c.config.IMAPSecurity(), c.config.Bridge.IMAPHost); err != nil {
+		return err
 	}
+	tlsConfig, err := c.config.TLSConfig(c.config.Bridge.IMAPHost)
+	if err != nil {
+		return err
+	}
+	c.tlsConfig = tlsConfig
 
 	password, err := c.config.GetPassword()
 	if err != nil {
 		return fmt.Errorf("failed to get password: %w", err)
 	}
 
-	addr := net.JoinHostPort(c.config.Bridge.IMAPHost, strconv.Itoa(c.config.Bridge.IMAPPort))
+	c.password = password
 
-	// TLS config for STARTTLS - skip verification for Proton Bridge self-signed certs
-	options := &imapclient.Options{
-		TLSConfig: &tls.Config{
-			InsecureSkipVerify: true,
-			ServerName:         c.config.Bridge.IMAPHost,
-		},
+	if deadline := c.config.Deadline(); !deadline.IsZero() && c.timer == nil {
+		// Closing the connection fails whatever command is waiting on it
+		c.timer = time.AfterFunc(time.Until(deadline), c.expire)
 	}
 
-	// Connect with STARTTLS
-	client, err := imapclient.DialStartTLS(addr, options)
+	// Bridge drops connections now and then; retry those, not bad logins
+	return retry.Do(c.config.RetryPolicy(), c.isTransient, c.dial)
+}
+
+// expire closes the connection once the deadline has passed.
+func (c *Client) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client != nil {
+		c.client.Close()
+	}
+}
+
+// setClient replaces the connection, closing the old one.
+func (c *Client) setClient(client *imapclient.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client != nil {
+		c.client.Close()
+	}
+	c.client = client
+}
+
+// dial makes one attempt to connect and log in. The dial, the TLS or
+// STARTTLS handshake and the login all fail once the deadline passes.
+func (c *Client) dial() error {
+	host := c.config.Bridge.IMAPHost
+	addr := net.JoinHostPort(host, strconv.Itoa(c.config.Bridge.IMAPPort))
+
+	deadline := c.config.Deadline()
+	dialer := &net.Dialer{Timeout: c.config.ConnectTimeout(), Deadline: deadline}
+	conn, err := dialer.Dial("tcp", addr)
 	if err != nil {
-		return fmt.Errorf("failed to connect to IMAP server: %w", err)
+		return fmt.Errorf("%w: %w", ErrConnect, err)
+	}
+	if !deadline.IsZero() {
+		// c.timer only reaches the connection once setClient has run;
+		// until then, closing the socket fails whatever step is waiting
+		stop := time.AfterFunc(time.Until(deadline), func() { conn.Close() })
+		defer stop.Stop()
+	}
+
+	tlsConfig := c.tlsConfig.Clone()
+	if tlsConfig == nil {
+		tlsConfig = new(tls.Config)
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+	options := &imapclient.Options{
+		TLSConfig:   tlsConfig,
+		WordDecoder: wordDecoder,
+	}
+
+	var client *imapclient.Client
+	switch c.config.IMAPSecurity() {
+	case config.SecurityTLS:
+		if tlsConfig.NextProtos == nil {
+			tlsConfig.NextProtos = []string{"imap"}
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return fmt.Errorf("%w: %w", ErrConnect, err)
+		}
+		client = imapclient.New(tlsConn, options)
+	case config.SecurityNone:
+		client = imapclient.New(conn, options)
+	default:
+		// NewStartTLS closes conn when it fails
+		client, err = imapclient.NewStartTLS(conn, options)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrConnect, err)
+		}
 	}
 
 	// Login
-	if err := client.Login(c.config.Bridge.Email, password).Wait(); err != nil {
+	if err := client.Login(c.config.Bridge.Email, c.password).Wait(); err != nil {
 		client.Close()
 		return fmt.Errorf("IMAP login failed: %w", err)
 	}
 
-	c.client = client
+	c.setClient(client)
 	return nil
 }
 
+// isTransient reports whether err may go away on a retry: a network
+// failure or a server response marked [UNAVAILABLE]. Other server
+// responses, including authentication failures, are final.
+func isTransient(err error) bool {
+	var imapErr *imap.Error
+	if errors.As(err, &imapErr) {
+		return imapErr.Code == imap.ResponseCodeUnavailable
+	}
+	return retry.IsNetworkError(err)
+}
+
+// isTransient is the package isTransient, except that nothing is retried
+// once the deadline has passed.
+func (c *Client) isTransient(err error) bool {
+	if deadline := c.config.Deadline(); !deadline.IsZero() && !time.Now().Before(deadline) {
+		return false
+	}
+	return isTransient(err)
+}
+
+// withReconnect runs a read-only operation, reconnecting and running it
+// again when it fails for a transient reason. Operations that change the
+// mailbox must not use it, since a dropped response does not mean the
+// server did not act.
+func (c *Client) withReconnect(op func() error) error {
+	policy := c.config.RetryPolicy()
+	if c.password == "" {
+		// Not connected through Connect, so there is no way to reconnect
+		policy.Retries = 0
+	}
+
+	attempt := 0
+	return retry.Do(policy, c.isTransient, func() error {
+		attempt++
+		if attempt > 1 {
+			c.setClient(nil)
+			if err := c.dial(); err != nil {
+				return err
+			}
+		}
+		return op()
+	})
+}
+
 func (c *Client) Close() error {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
 	if c.client != nil {
 		if err := c.client.Logout().Wait(); err != nil {
 			// Ignore logout errors, just close
@@ -188,7 +342,13 @@ func (c *Client) ListMailboxes() ([]MailboxInfo, error) {
 		return nil, fmt.Errorf("not connected")
 	}
 
-	listCmd := c.client.List("", "*", nil)
+	// With LIST-STATUS the UIDVALIDITY of every mailbox comes back in the
+	// same round-trip; otherwise callers that need it issue STATUS
+	var options *imap.ListOptions
+	if c.client.Caps().Has(imap.CapListStatus) {
+		options = &imap.ListOptions{ReturnStatus: &imap.StatusOptions{UIDValidity: true}}
+	}
+	listCmd := c.client.List("", "*", options)
 	mailboxes, err := listCmd.Collect()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list mailboxes: %w", err)
@@ -204,6 +364,9 @@ func (c *Client) ListMailboxes() ([]MailboxInfo, error) {
 		for _, attr := range mb.Attrs {
 			info.Attributes = append(info.Attributes, string(attr))
 		}
+		if mb.Status != nil {
+			info.UIDValidity = mb.Status.UIDValidity
+		}
 		result = append(result, info)
 	}
 
@@ -215,20 +378,103 @@ func (c *Client) SelectMailbox(name string) (*MailboxStatus, error) {
 		return nil, fmt.Errorf("not connected")
 	}
 
+	name, err := NormalizeMailboxName(name)
+	if err != nil {
+		return nil, err
+	}
+
 	selected, err := c.client.Select(name, nil).Wait()
 	if err != nil {
 		return nil, fmt.Errorf("failed to select mailbox %s: %w", name, err)
 	}
 
 	return &MailboxStatus{
-		Name:     name,
-		Messages: selected.NumMessages,
-		Recent:   0, // Not available in v2 select response
-		Unseen:   0, // Not available in v2 select response
+		Name:        name,
+		Messages:    selected.NumMessages,
+		Recent:      0, // Not available in v2 select response
+		Unseen:      0, // Not available in v2 select response
+		UIDValidity: selected.UIDValidity,
 	}, nil
 }
 
+// Status returns message and unseen counts and the UIDVALIDITY of a
+// mailbox without selecting it.
+func (c *Client) Status(name string) (*MailboxStatus, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	name, err := NormalizeMailboxName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.client.Status(name, &imap.StatusOptions{
+		NumMessages: true,
+		NumUnseen:   true,
+		UIDValidity: true,
+	}).Wait()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of mailbox %s: %w", name, err)
+	}
+
+	status := &MailboxStatus{Name: name, UIDValidity: data.UIDValidity}
+	if data.NumMessages != nil {
+		status.Messages = *data.NumMessages
+	}
+	if data.NumUnseen != nil {
+		status.Unseen = *data.NumUnseen
+	}
+	return status, nil
+}
+
+// MailboxState returns the identifiers that change whenever messages are
+// added, expunged or (with CONDSTORE) have their flags modified.
+func (c *Client) MailboxState(name string) (*MailboxState, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	name, err := NormalizeMailboxName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.client.Status(name, &imap.StatusOptions{
+		NumMessages:   true,
+		NumUnseen:     true,
+		UIDNext:       true,
+		UIDValidity:   true,
+		HighestModSeq: c.client.Caps().Has(imap.CapCondStore),
+	}).Wait()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of mailbox %s: %w", name, err)
+	}
+
+	state := &MailboxState{
+		UIDValidity:   data.UIDValidity,
+		UIDNext:       uint32(data.UIDNext),
+		HighestModSeq: data.HighestModSeq,
+	}
+	if data.NumMessages != nil {
+		state.Messages = *data.NumMessages
+	}
+	if data.NumUnseen != nil {
+		state.Unseen = *data.NumUnseen
+	}
+	return state, nil
+}
+
 func (c *Client) ListMessages(mailbox string, limit, offset int, unreadOnly bool) ([]MessageSummary, error) {
+	var messages []MessageSummary
+	err := c.withReconnect(func() (err error) {
+		messages, err = c.listMessages(mailbox, limit, offset, unreadOnly)
+		return err
+	})
+	return messages, err
+}
+
+func (c *Client) listMessages(mailbox string, limit, offset int, unreadOnly bool) ([]MessageSummary, error) {
 	status, err := c.SelectMailbox(mailbox)
 	if err != nil {
 		return nil, err
@@ -251,19 +497,104 @@ func (c *Client) ListMessages(mailbox string, limit, offset int, unreadOnly bool
 		start = 1
 	}
 
-	// Build sequence set for range
 	var seqSet imap.SeqSet
 	seqSet.AddRange(uint32(start), uint32(end))
+	return c.fetchSummaries(mailbox, status.UIDValidity, seqSet, unreadOnly)
+}
 
+// ListMessagesAfter returns every message in mailbox with a UID above
+// lastUID, newest first, however many arrived since.
+func (c *Client) ListMessagesAfter(mailbox string, lastUID uint32) ([]MessageSummary, error) {
+	var messages []MessageSummary
+	err := c.withReconnect(func() error {
+		status, err := c.SelectMailbox(mailbox)
+		if err != nil {
+			return err
+		}
+		if status.Messages == 0 {
+			messages = []MessageSummary{}
+			return nil
+		}
+
+		var uidSet imap.UIDSet
+		uidSet.AddRange(imap.UID(lastUID+1), 0) // lastUID+1:*
+		fetched, err := c.fetchSummaries(mailbox, status.UIDValidity, uidSet, false)
+		if err != nil {
+			return err
+		}
+
+		// "n:*" still matches the newest message when n is past it
+		messages = fetched[:0]
+		for _, msg := range fetched {
+			if msg.UID > lastUID {
+				messages = append(messages, msg)
+			}
+		}
+		return nil
+	})
+	return messages, err
+}
+
+// ListMessagesChunked lists every message in mailbox except the offset
+// most recent ones, newest first, in FETCHes of at most chunk messages.
+// Each batch goes to fn as soon as it arrives, so a large mailbox neither
+// needs one huge sequence set nor has to be held in memory at once.
+func (c *Client) ListMessagesChunked(mailbox string, offset, chunk int, unreadOnly bool, fn func([]MessageSummary) error) error {
+	if chunk <= 0 {
+		return fmt.Errorf("invalid chunk size %d", chunk)
+	}
+
+	var status *MailboxStatus
+	err := c.withReconnect(func() (err error) {
+		status, err = c.SelectMailbox(mailbox)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// The range is fixed from the first SELECT, so mail arriving during
+	// the listing does not shift later batches
+	for end := int(status.Messages) - offset; end >= 1; end -= chunk {
+		start := end - chunk + 1
+		if start < 1 {
+			start = 1
+		}
+
+		var batch []MessageSummary
+		err := c.withReconnect(func() (err error) {
+			// Selected again in case the connection was replaced
+			if _, err := c.SelectMailbox(mailbox); err != nil {
+				return err
+			}
+			var seqSet imap.SeqSet
+			seqSet.AddRange(uint32(start), uint32(end))
+			batch, err = c.fetchSummaries(mailbox, status.UIDValidity, seqSet, unreadOnly)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchSummaries fetches the summaries of the messages in numSet (sequence
+// numbers or UIDs) of the selected mailbox, newest first.
+func (c *Client) fetchSummaries(mailbox string, uidValidity uint32, numSet imap.NumSet, unreadOnly bool) ([]MessageSummary, error) {
 	// Fetch options
 	fetchOptions := &imap.FetchOptions{
 		UID:          true,
 		Flags:        true,
 		Envelope:     true,
 		InternalDate: true,
+		RFC822Size:   true,
 	}
 
-	fetchCmd := c.client.Fetch(seqSet, fetchOptions)
+	fetchCmd := c.client.Fetch(numSet, fetchOptions)
 	defer fetchCmd.Close()
 
 	var messages []MessageSummary
@@ -279,6 +610,7 @@ func (c *Client) ListMessages(mailbox string, limit, offset int, unreadOnly bool
 		var uid imap.UID
 		var date string
 		var dateISO string
+		var size int64
 
 		for {
 			item := msg.Next()
@@ -294,8 +626,9 @@ func (c *Client) ListMessages(mailbox string, limit, offset int, unreadOnly bool
 			case imapclient.FetchItemDataEnvelope:
 				envelope = data.Envelope
 			case imapclient.FetchItemDataInternalDate:
-				date = data.Time.Format("2006-01-02 15:04")
-				dateISO = data.Time.Format(time.RFC3339)
+				date, dateISO = formatDate(data.Time, c.location(), "2006-01-02 15:04")
+			case imapclient.FetchItemDataRFC822Size:
+				size = data.Size
 			}
 		}
 
@@ -306,13 +639,15 @@ func (c *Client) ListMessages(mailbox string, limit, offset int, unreadOnly bool
 		// Check if unread only
 		seen := false
 		flagged := false
-		for _, f := range flags {
+		flagNames := make([]string, len(flags))
+		for i, f := range flags {
 			if f == imap.FlagSeen {
 				seen = true
 			}
 			if f == imap.FlagFlagged {
 				flagged = true
 			}
+			flagNames[i] = string(f)
 		}
 
 		if unreadOnly && seen {
@@ -320,74 +655,380 @@ func (c *Client) ListMessages(mailbox string, limit, offset int, unreadOnly bool
 		}
 
 		from := ""
+		fromAddress := ""
 		if len(envelope.From) > 0 {
 			addr := envelope.From[0]
-			if addr.Name != "" {
-				from = addr.Name
+			fromAddress = addr.Addr()
+			if name := decodeWords(addr.Name); name != "" {
+				from = name
 			} else {
-				from = addr.Addr()
+				from = fromAddress
 			}
 		}
 
 		summary := MessageSummary{
-			UID:     uint32(uid),
-			SeqNum:  msg.SeqNum,
-			From:    from,
-			Subject: envelope.Subject,
-			Date:    date,
-			DateISO: dateISO,
-			Seen:    seen,
-			Flagged: flagged,
+			UID:         uint32(uid),
+			SeqNum:      msg.SeqNum,
+			Ref:         EncodeRef(mailbox, uidValidity, uint32(uid)),
+			From:        from,
+			FromAddress: fromAddress,
+			MessageID:   envelope.MessageID,
+			Subject:     decodeWords(envelope.Subject),
+			Date:        date,
+			DateISO:     dateISO,
+			Seen:        seen,
+			Flagged:     flagged,
+			Status:      MessageStatus(flagNames),
+			Size:        size,
 		}
 
 		messages = append(messages, summary)
 	}
 
-	if err := fetchCmd.Close(); err != nil {
-		return nil, fmt.Errorf("fetch failed: %w", err)
-	}
+	if err := fetchCmd.Close(); err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+
+	// Reverse to show newest first
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, nil
+}
+
+// ListRecipients returns the To and Cc addresses from the envelopes of the
+// most recent limit messages in mailbox (all messages if limit <= 0),
+// newest message first.
+func (c *Client) ListRecipients(mailbox string, limit int) ([]Recipient, error) {
+	status, err := c.SelectMailbox(mailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.Messages == 0 {
+		return []Recipient{}, nil
+	}
+
+	end := int(status.Messages)
+	start := 1
+	if limit > 0 && end-limit+1 > start {
+		start = end - limit + 1
+	}
+
+	var seqSet imap.SeqSet
+	seqSet.AddRange(uint32(start), uint32(end))
+
+	messages, err := c.client.Fetch(seqSet, &imap.FetchOptions{Envelope: true}).Collect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch envelopes: %w", err)
+	}
+
+	var recipients []Recipient
+	for i := len(messages) - 1; i >= 0; i-- {
+		envelope := messages[i].Envelope
+		if envelope == nil {
+			continue
+		}
+		for _, addr := range append(append([]imap.Address{}, envelope.To...), envelope.Cc...) {
+			if addr.IsGroupStart() || addr.IsGroupEnd() || addr.Addr() == "" {
+				continue
+			}
+			recipients = append(recipients, Recipient{Name: decodeWords(addr.Name), Email: addr.Addr()})
+		}
+	}
+
+	return recipients, nil
+}
+
+// InternalDates returns the INTERNALDATE (arrival time) of every message in
+// mailbox that arrived on or after the day of since. Only the dates are
+// fetched; the matches come from one SEARCH SINCE, which compares dates
+// without the time of day.
+func (c *Client) InternalDates(mailbox string, since time.Time) ([]time.Time, error) {
+	if _, err := c.SelectMailbox(mailbox); err != nil {
+		return nil, err
+	}
+
+	data, err := c.client.UIDSearch(&imap.SearchCriteria{Since: since}, nil).Wait()
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	uids := data.AllUIDs()
+	if len(uids) == 0 {
+		return []time.Time{}, nil
+	}
+
+	messages, err := c.client.Fetch(imap.UIDSetNum(uids...), &imap.FetchOptions{InternalDate: true}).Collect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dates: %w", err)
+	}
+
+	dates := make([]time.Time, 0, len(messages))
+	for _, msg := range messages {
+		if !msg.InternalDate.IsZero() {
+			dates = append(dates, msg.InternalDate)
+		}
+	}
+	return dates, nil
+}
+
+// MessageRefs fetches the Message-ID, subject and sender of the given
+// messages, so they can be found again after a move changes their UID.
+func (c *Client) MessageRefs(mailbox string, ids []string) ([]MessageRef, error) {
+	if _, err := c.SelectMailbox(mailbox); err != nil {
+		return nil, err
+	}
+
+	numSet, err := buildNumSetFromIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := c.client.Fetch(numSet, &imap.FetchOptions{UID: true, Envelope: true}).Collect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch envelopes: %w", err)
+	}
+
+	refs := make([]MessageRef, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Envelope == nil {
+			continue
+		}
+		ref := MessageRef{
+			UID:       uint32(msg.UID),
+			MessageID: msg.Envelope.MessageID,
+			Subject:   decodeWords(msg.Envelope.Subject),
+		}
+		if len(msg.Envelope.From) > 0 {
+			ref.From = formatAddress(msg.Envelope.From[0])
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// FindByMessageID returns uid:<uid> selectors for the messages in mailbox
+// whose Message-ID header matches messageID.
+func (c *Client) FindByMessageID(mailbox, messageID string) ([]string, error) {
+	if _, err := c.SelectMailbox(mailbox); err != nil {
+		return nil, err
+	}
+
+	criteria := &imap.SearchCriteria{
+		Header: []imap.SearchCriteriaHeaderField{{Key: "Message-ID", Value: messageID}},
+	}
+	data, err := c.client.UIDSearch(criteria, nil).Wait()
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	uids := data.AllUIDs()
+	ids := make([]string, len(uids))
+	for i, uid := range uids {
+		ids[i] = fmt.Sprintf("uid:%d", uid)
+	}
+	return ids, nil
+}
+
+func (c *Client) GetMessage(mailbox string, id string) (*Message, error) {
+	return c.getMessage(mailbox, id, false)
+}
+
+// PeekMessage is GetMessage using BODY.PEEK[], so the server leaves the
+// message's \Seen flag unchanged.
+func (c *Client) PeekMessage(mailbox string, id string) (*Message, error) {
+	return c.getMessage(mailbox, id, true)
+}
+
+// fullBodySection requests the whole message. Without peek the server sets
+// \Seen as a side effect of the fetch.
+func fullBodySection(peek bool) []*imap.FetchItemBodySection {
+	return []*imap.FetchItemBodySection{{Peek: peek}}
+}
+
+func (c *Client) getMessage(mailbox string, id string, peek bool) (*Message, error) {
+	mailbox, ids, err := c.ResolveRefs(mailbox, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	id = ids[0]
+
+	var msg *Message
+	err = c.withReconnect(func() (err error) {
+		msg, err = c.fetchMessage(mailbox, id, peek)
+		return err
+	})
+	return msg, err
+}
+
+func (c *Client) fetchMessage(mailbox string, id string, peek bool) (*Message, error) {
+	status, err := c.SelectMailbox(mailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.Messages == 0 {
+		return nil, ErrMailboxEmpty
+	}
+
+	selector, err := parseMessageSelector(id)
+	if err != nil {
+		return nil, err
+	}
+
+	numSet := selectorToNumSet(selector)
+
+	fetchOptions := &imap.FetchOptions{
+		UID:          true,
+		Flags:        true,
+		Envelope:     true,
+		InternalDate: true,
+		BodySection:  fullBodySection(peek),
+	}
+
+	fetchCmd := c.client.Fetch(numSet, fetchOptions)
+	defer fetchCmd.Close()
+
+	msg := fetchCmd.Next()
+	if msg == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+	}
+
+	result := collectMessage(msg, c.location())
+	result.Ref = EncodeRef(mailbox, status.UIDValidity, result.UID)
+
+	if err := fetchCmd.Close(); err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetMessages fetches several messages with a single FETCH command. Results
+// are returned in the order the IDs were given; IDs that do not match a
+// message are reported as an error.
+func (c *Client) GetMessages(mailbox string, ids []string) ([]*Message, error) {
+	return c.getMessages(mailbox, ids, false)
+}
+
+// PeekMessages is GetMessages without setting \Seen.
+func (c *Client) PeekMessages(mailbox string, ids []string) ([]*Message, error) {
+	return c.getMessages(mailbox, ids, true)
+}
+
+// GetHeaders fetches the envelope and flags of several messages along with
+// only the named header fields, which end up in RawBody as a header block.
+// The body is not downloaded and \Seen is left unchanged.
+func (c *Client) GetHeaders(mailbox string, ids []string, fields []string) ([]*Message, error) {
+	var messages []*Message
+	err := c.withReconnect(func() (err error) {
+		messages, err = c.fetchMessages(mailbox, ids, headerFieldsSection(fields))
+		return err
+	})
+	return messages, err
+}
+
+// GetFlags fetches the envelope and flags of several messages and no body
+// data, so \Seen is left unchanged.
+func (c *Client) GetFlags(mailbox string, ids []string) ([]*Message, error) {
+	var messages []*Message
+	err := c.withReconnect(func() (err error) {
+		messages, err = c.fetchMessages(mailbox, ids, nil)
+		return err
+	})
+	return messages, err
+}
+
+// headerFieldsSection requests BODY.PEEK[HEADER.FIELDS (fields)].
+func headerFieldsSection(fields []string) []*imap.FetchItemBodySection {
+	return []*imap.FetchItemBodySection{{
+		Specifier:    imap.PartSpecifierHeader,
+		HeaderFields: fields,
+		Peek:         true,
+	}}
+}
 
-	// Reverse to show newest first
-	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
-		messages[i], messages[j] = messages[j], messages[i]
+func (c *Client) getMessages(mailbox string, ids []string, peek bool) ([]*Message, error) {
+	mailbox, ids, err := c.ResolveRefs(mailbox, ids)
+	if err != nil {
+		return nil, err
 	}
 
-	return messages, nil
+	var messages []*Message
+	err = c.withReconnect(func() (err error) {
+		messages, err = c.fetchMessages(mailbox, ids, fullBodySection(peek))
+		return err
+	})
+	return messages, err
 }
 
-func (c *Client) GetMessage(mailbox string, id string) (*Message, error) {
+func (c *Client) fetchMessages(mailbox string, ids []string, section []*imap.FetchItemBodySection) ([]*Message, error) {
 	status, err := c.SelectMailbox(mailbox)
 	if err != nil {
 		return nil, err
 	}
 
 	if status.Messages == 0 {
-		return nil, fmt.Errorf("mailbox is empty")
+		return nil, ErrMailboxEmpty
 	}
 
-	selector, err := parseMessageSelector(id)
+	numSet, err := buildNumSetFromIDs(ids)
 	if err != nil {
 		return nil, err
 	}
 
-	numSet := selectorToNumSet(selector)
-
 	fetchOptions := &imap.FetchOptions{
 		UID:          true,
 		Flags:        true,
 		Envelope:     true,
 		InternalDate: true,
-		BodySection:  []*imap.FetchItemBodySection{{}}, // Fetch full body
+		BodySection:  section,
 	}
 
 	fetchCmd := c.client.Fetch(numSet, fetchOptions)
 	defer fetchCmd.Close()
 
-	msg := fetchCmd.Next()
-	if msg == nil {
-		return nil, fmt.Errorf("message not found: %s", id)
+	bySeq := make(map[uint32]*Message)
+	byUID := make(map[uint32]*Message)
+	for {
+		msg := fetchCmd.Next()
+		if msg == nil {
+			break
+		}
+		result := collectMessage(msg, c.location())
+		result.Ref = EncodeRef(mailbox, status.UIDValidity, result.UID)
+		bySeq[result.SeqNum] = result
+		byUID[result.UID] = result
+	}
+
+	if err := fetchCmd.Close(); err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+
+	messages := make([]*Message, 0, len(ids))
+	for _, id := range ids {
+		selector, err := parseMessageSelector(id)
+		if err != nil {
+			return nil, err
+		}
+		var result *Message
+		if selector.kind == selectorKindUID {
+			result = byUID[uint32(selector.uid)]
+		} else {
+			result = bySeq[selector.seq]
+		}
+		if result == nil {
+			return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+		}
+		messages = append(messages, result)
 	}
 
+	return messages, nil
+}
+
+// collectMessage drains the fetch items of a single message into a Message.
+func collectMessage(msg *imapclient.FetchMessageData, loc *time.Location) *Message {
 	result := &Message{
 		SeqNum: msg.SeqNum,
 	}
@@ -407,9 +1048,8 @@ func (c *Client) GetMessage(mailbox string, id string) (*Message, error) {
 				result.Flags[i] = string(f)
 			}
 		case imapclient.FetchItemDataEnvelope:
-			result.Subject = data.Envelope.Subject
-			result.Date = data.Envelope.Date.Format("2006-01-02 15:04:05")
-			result.DateISO = data.Envelope.Date.Format(time.RFC3339)
+			result.Subject = decodeWords(data.Envelope.Subject)
+			result.Date, result.DateISO = formatDate(data.Envelope.Date, loc, "2006-01-02 15:04:05")
 			if len(data.Envelope.From) > 0 {
 				addr := data.Envelope.From[0]
 				result.From = formatAddress(addr)
@@ -420,6 +1060,9 @@ func (c *Client) GetMessage(mailbox string, id string) (*Message, error) {
 			for _, addr := range data.Envelope.Cc {
 				result.CC = append(result.CC, formatAddress(addr))
 			}
+			for _, addr := range data.Envelope.Bcc {
+				result.Bcc = append(result.Bcc, formatAddress(addr))
+			}
 			result.MessageID = data.Envelope.MessageID
 			if len(data.Envelope.InReplyTo) > 0 {
 				result.InReplyTo = data.Envelope.InReplyTo[0]
@@ -432,11 +1075,7 @@ func (c *Client) GetMessage(mailbox string, id string) (*Message, error) {
 		}
 	}
 
-	if err := fetchCmd.Close(); err != nil {
-		return nil, fmt.Errorf("fetch failed: %w", err)
-	}
-
-	return result, nil
+	return result
 }
 
 func (c *Client) DeleteMessages(mailbox string, ids []string, permanent bool) error {
@@ -486,6 +1125,9 @@ func (c *Client) CopyMessages(mailbox string, ids []string, destMailbox string)
 	if err != nil {
 		return err
 	}
+	if destMailbox, err = NormalizeMailboxName(destMailbox); err != nil {
+		return err
+	}
 
 	// Copy to destination (does not delete from source)
 	copyCmd := c.client.Copy(numSet, destMailbox)
@@ -497,20 +1139,32 @@ func (c *Client) CopyMessages(mailbox string, ids []string, destMailbox string)
 }
 
 func (c *Client) MoveMessages(mailbox string, ids []string, destMailbox string) error {
+	_, err := c.MoveMessagesWithUIDs(mailbox, ids, destMailbox)
+	return err
+}
+
+// MoveMessagesWithUIDs moves messages like MoveMessages and returns a map
+// from source UID to the UID the message got in destMailbox. The map is
+// empty when the server does not report COPYUID (UIDPLUS).
+func (c *Client) MoveMessagesWithUIDs(mailbox string, ids []string, destMailbox string) (map[uint32]uint32, error) {
 	_, err := c.SelectMailbox(mailbox)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	numSet, err := buildNumSetFromIDs(ids)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if destMailbox, err = NormalizeMailboxName(destMailbox); err != nil {
+		return nil, err
 	}
 
 	// Copy to destination
 	copyCmd := c.client.Copy(numSet, destMailbox)
-	if _, err := copyCmd.Wait(); err != nil {
-		return fmt.Errorf("failed to copy messages to %s: %w", destMailbox, err)
+	copyData, err := copyCmd.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy messages to %s: %w", destMailbox, err)
 	}
 
 	// Delete from source
@@ -519,11 +1173,55 @@ func (c *Client) MoveMessages(mailbox string, ids []string, destMailbox string)
 		Flags: []imap.Flag{imap.FlagDeleted},
 	}, nil)
 	if err := storeCmd.Close(); err != nil {
-		return fmt.Errorf("failed to delete from source: %w", err)
+		return nil, fmt.Errorf("failed to delete from source: %w", err)
 	}
 
 	if err := c.client.Expunge().Close(); err != nil {
-		return fmt.Errorf("failed to expunge: %w", err)
+		return nil, fmt.Errorf("failed to expunge: %w", err)
+	}
+
+	return copyUIDMap(copyData), nil
+}
+
+// copyUIDMap pairs the source and destination UIDs of a COPYUID response,
+// which the server lists in matching order.
+func copyUIDMap(data *imap.CopyData) map[uint32]uint32 {
+	result := make(map[uint32]uint32)
+	if data == nil {
+		return result
+	}
+	src, ok := data.SourceUIDs.Nums()
+	if !ok {
+		return result
+	}
+	dest, ok := data.DestUIDs.Nums()
+	if !ok || len(src) != len(dest) {
+		return result
+	}
+	for i := range src {
+		result[uint32(src[i])] = uint32(dest[i])
+	}
+	return result
+}
+
+// UndeleteMessages clears the \Deleted flag set by a non-permanent delete.
+func (c *Client) UndeleteMessages(mailbox string, ids []string) error {
+	_, err := c.SelectMailbox(mailbox)
+	if err != nil {
+		return err
+	}
+
+	numSet, err := buildNumSetFromIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	storeCmd := c.client.Store(numSet, &imap.StoreFlags{
+		Op:    imap.StoreFlagsDel,
+		Flags: []imap.Flag{imap.FlagDeleted},
+	}, nil)
+	if err := storeCmd.Close(); err != nil {
+		return fmt.Errorf("failed to clear deleted flag: %w", err)
 	}
 
 	return nil
@@ -587,32 +1285,96 @@ func (c *Client) SetFlagsMultiple(mailbox string, ids []string, read, unread, st
 	return nil
 }
 
-func (c *Client) Search(mailbox string, opts SearchOptions) ([]MessageSummary, error) {
+// MarkAnswered adds the \Answered flag to messages that have been replied to.
+func (c *Client) MarkAnswered(mailbox string, ids []string) error {
 	_, err := c.SelectMailbox(mailbox)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	numSet, err := buildNumSetFromIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	storeCmd := c.client.Store(numSet, &imap.StoreFlags{
+		Op:     imap.StoreFlagsAdd,
+		Silent: true,
+		Flags:  []imap.Flag{imap.FlagAnswered},
+	}, nil)
+	if err := storeCmd.Close(); err != nil {
+		return fmt.Errorf("failed to mark as answered: %w", err)
+	}
+	return nil
+}
+
+// SetKeyword adds or removes a keyword such as $Junk on messages.
+func (c *Client) SetKeyword(mailbox string, ids []string, keyword string, add bool) error {
+	if !isFlagKeyword(keyword) {
+		return fmt.Errorf("invalid keyword %q", keyword)
+	}
+
+	_, err := c.SelectMailbox(mailbox)
+	if err != nil {
+		return err
+	}
+
+	numSet, err := buildNumSetFromIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	op := imap.StoreFlagsDel
+	if add {
+		op = imap.StoreFlagsAdd
+	}
+	storeCmd := c.client.Store(numSet, &imap.StoreFlags{
+		Op:     op,
+		Silent: true,
+		Flags:  []imap.Flag{imap.Flag(keyword)},
+	}, nil)
+	if err := storeCmd.Close(); err != nil {
+		return fmt.Errorf("failed to set keyword %s: %w", keyword, err)
+	}
+	return nil
+}
+
+// Search returns the matches within the window set by opts.Limit and
+// opts.Offset, newest first, along with the total number of matches.
+func (c *Client) Search(mailbox string, opts SearchOptions) ([]MessageSummary, int, error) {
+	status, err := c.SelectMailbox(mailbox)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Build search criteria based on options
-	criteria := c.buildSearchCriteria(opts)
+	criteria, err := c.searchCriteria(opts)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	searchCmd := c.client.Search(criteria, nil)
 	searchData, err := searchCmd.Wait()
 	if err != nil {
-		return nil, fmt.Errorf("search failed: %w", err)
+		return nil, 0, fmt.Errorf("search failed: %w", err)
 	}
 
-	if len(searchData.AllSeqNums()) == 0 {
-		return []MessageSummary{}, nil
+	allNums := searchData.AllSeqNums()
+	total := len(allNums)
+	window := searchWindow(allNums, opts.Limit, opts.Offset)
+	if len(window) == 0 {
+		return []MessageSummary{}, total, nil
 	}
 
-	// Fetch the matching messages
-	seqSet := imap.SeqSetNum(searchData.AllSeqNums()...)
+	// Fetch only the requested window of matches
+	seqSet := imap.SeqSetNum(window...)
 
 	fetchOptions := &imap.FetchOptions{
-		UID:      true,
-		Flags:    true,
-		Envelope: true,
+		UID:          true,
+		Flags:        true,
+		Envelope:     true,
+		InternalDate: true,
+		RFC822Size:   true,
 	}
 
 	fetchCmd := c.client.Fetch(seqSet, fetchOptions)
@@ -628,6 +1390,8 @@ func (c *Client) Search(mailbox string, opts SearchOptions) ([]MessageSummary, e
 		var envelope *imap.Envelope
 		var flags []imap.Flag
 		var uid imap.UID
+		var internalDate time.Time
+		var size int64
 
 		for {
 			item := msg.Next()
@@ -642,6 +1406,10 @@ func (c *Client) Search(mailbox string, opts SearchOptions) ([]MessageSummary, e
 				flags = data.Flags
 			case imapclient.FetchItemDataEnvelope:
 				envelope = data.Envelope
+			case imapclient.FetchItemDataInternalDate:
+				internalDate = data.Time
+			case imapclient.FetchItemDataRFC822Size:
+				size = data.Size
 			}
 		}
 
@@ -651,40 +1419,88 @@ func (c *Client) Search(mailbox string, opts SearchOptions) ([]MessageSummary, e
 
 		seen := false
 		flagged := false
-		for _, f := range flags {
+		flagNames := make([]string, len(flags))
+		for i, f := range flags {
 			if f == imap.FlagSeen {
 				seen = true
 			}
 			if f == imap.FlagFlagged {
 				flagged = true
 			}
+			flagNames[i] = string(f)
 		}
 
-		fromStr := ""
+		fromStr := noSender
+		fromAddress := ""
 		if len(envelope.From) > 0 {
 			addr := envelope.From[0]
-			if addr.Name != "" {
-				fromStr = addr.Name
-			} else {
-				fromStr = addr.Addr()
+			fromAddress = addr.Addr()
+			if name := decodeWords(addr.Name); name != "" {
+				fromStr = name
+			} else if fromAddress != "" {
+				fromStr = fromAddress
 			}
 		}
 
+		// A message without a Date header has a zero envelope date; the
+		// time the server received it is the next best thing.
+		sent := envelope.Date
+		if sent.IsZero() {
+			sent = internalDate
+		}
+		date, dateISO := formatDate(sent, c.location(), "2006-01-02 15:04")
+
 		summary := MessageSummary{
-			UID:     uint32(uid),
-			SeqNum:  msg.SeqNum,
-			From:    fromStr,
-			Subject: envelope.Subject,
-			Date:    envelope.Date.Format("2006-01-02 15:04"),
-			DateISO: envelope.Date.Format(time.RFC3339),
-			Seen:    seen,
-			Flagged: flagged,
+			UID:         uint32(uid),
+			SeqNum:      msg.SeqNum,
+			Ref:         EncodeRef(mailbox, status.UIDValidity, uint32(uid)),
+			From:        fromStr,
+			FromAddress: fromAddress,
+			MessageID:   envelope.MessageID,
+			Subject:     decodeWords(envelope.Subject),
+			Date:        date,
+			DateISO:     dateISO,
+			Seen:        seen,
+			Flagged:     flagged,
+			Status:      MessageStatus(flagNames),
+			Size:        size,
 		}
 
 		messages = append(messages, summary)
 	}
 
-	return messages, fetchCmd.Close()
+	if err := fetchCmd.Close(); err != nil {
+		return nil, 0, fmt.Errorf("fetch failed: %w", err)
+	}
+
+	// Newest first, matching ListMessages
+	sort.Slice(messages, func(i, j int) bool { return messages[i].SeqNum > messages[j].SeqNum })
+
+	return messages, total, nil
+}
+
+// noSender is shown in place of the sender of a message whose envelope
+// has no From address.
+const noSender = "(no sender)"
+
+// searchWindow picks the sequence numbers to fetch: skip the offset newest
+// matches, then take up to limit (all when limit <= 0).
+func searchWindow(nums []uint32, limit, offset int) []uint32 {
+	sorted := append([]uint32(nil), nums...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	if offset < 0 {
+		offset = 0
+	}
+	end := len(sorted) - offset
+	if end <= 0 {
+		return nil
+	}
+	start := 0
+	if limit > 0 && end-limit > 0 {
+		start = end - limit
+	}
+	return sorted[start:end]
 }
 
 // SearchIDs returns sequence numbers of messages matching the search criteria.
@@ -696,7 +1512,10 @@ func (c *Client) SearchIDs(mailbox string, opts SearchOptions) ([]string, error)
 	}
 
 	// Build search criteria
-	criteria := c.buildSearchCriteria(opts)
+	criteria, err := c.searchCriteria(opts)
+	if err != nil {
+		return nil, err
+	}
 
 	searchCmd := c.client.Search(criteria, nil)
 	searchData, err := searchCmd.Wait()
@@ -713,6 +1532,19 @@ func (c *Client) SearchIDs(mailbox string, opts SearchOptions) ([]string, error)
 	return ids, nil
 }
 
+// searchCriteria returns the parsed raw search when opts.Raw is set and the
+// criteria built from the structured filters otherwise.
+func (c *Client) searchCriteria(opts SearchOptions) (*imap.SearchCriteria, error) {
+	if opts.Raw != "" {
+		criteria, err := parseRawSearch(opts.Raw, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("invalid raw search: %w", err)
+		}
+		return criteria, nil
+	}
+	return c.buildSearchCriteria(opts), nil
+}
+
 // buildSearchCriteria constructs IMAP search criteria from SearchOptions
 func (c *Client) buildSearchCriteria(opts SearchOptions) *imap.SearchCriteria {
 	// For OR logic, we need to build individual criteria and combine them
@@ -732,19 +1564,7 @@ func (c *Client) buildSearchCriteria(opts SearchOptions) *imap.SearchCriteria {
 	}
 
 	// Header filters
-	if opts.From != "" {
-		criteria.Header = append(criteria.Header, imap.SearchCriteriaHeaderField{
-			Key:   "From",
-			Value: opts.From,
-		})
-	}
-
-	if opts.To != "" {
-		criteria.Header = append(criteria.Header, imap.SearchCriteriaHeaderField{
-			Key:   "To",
-			Value: opts.To,
-		})
-	}
+	criteria.Header = append(criteria.Header, addressFilters(opts)...)
 
 	if opts.Subject != "" {
 		criteria.Header = append(criteria.Header, imap.SearchCriteriaHeaderField{
@@ -784,6 +1604,16 @@ func (c *Client) buildSearchCriteria(opts SearchOptions) *imap.SearchCriteria {
 		})
 	}
 
+	// Flag filters
+	for _, f := range flagFilters(opts) {
+		criteria.Flag = append(criteria.Flag, f.Flag...)
+		criteria.NotFlag = append(criteria.NotFlag, f.NotFlag...)
+	}
+
+	// Negated filters: each excluded term is its own NOT alongside the
+	// positive filters
+	criteria.Not = append(criteria.Not, negatedFilters(opts)...)
+
 	// Negation: wrap the entire criteria in a NOT
 	if opts.Negate {
 		return &imap.SearchCriteria{
@@ -811,15 +1641,9 @@ func (c *Client) buildOrSearchCriteria(opts SearchOptions) *imap.SearchCriteria
 		})
 	}
 
-	if opts.From != "" {
-		orCriteria = append(orCriteria, imap.SearchCriteria{
-			Header: []imap.SearchCriteriaHeaderField{{Key: "From", Value: opts.From}},
-		})
-	}
-
-	if opts.To != "" {
+	for _, h := range addressFilters(opts) {
 		orCriteria = append(orCriteria, imap.SearchCriteria{
-			Header: []imap.SearchCriteriaHeaderField{{Key: "To", Value: opts.To}},
+			Header: []imap.SearchCriteriaHeaderField{h},
 		})
 	}
 
@@ -841,12 +1665,14 @@ func (c *Client) buildOrSearchCriteria(opts SearchOptions) *imap.SearchCriteria
 		}
 	}
 
-	if opts.LargerThan > 0 {
-		orCriteria = append(orCriteria, imap.SearchCriteria{Larger: opts.LargerThan})
-	}
-
-	if opts.SmallerThan > 0 {
-		orCriteria = append(orCriteria, imap.SearchCriteria{Smaller: opts.SmallerThan})
+	// A size bound on each side describes one "between" range, so the
+	// pair is a single operand rather than "larger OR smaller", which
+	// would match everything
+	if opts.LargerThan > 0 || opts.SmallerThan > 0 {
+		orCriteria = append(orCriteria, imap.SearchCriteria{
+			Larger:  opts.LargerThan,
+			Smaller: opts.SmallerThan,
+		})
 	}
 
 	if opts.HasAttachments {
@@ -855,6 +1681,12 @@ func (c *Client) buildOrSearchCriteria(opts SearchOptions) *imap.SearchCriteria
 		})
 	}
 
+	orCriteria = append(orCriteria, flagFilters(opts)...)
+
+	for _, n := range negatedFilters(opts) {
+		orCriteria = append(orCriteria, imap.SearchCriteria{Not: []imap.SearchCriteria{n}})
+	}
+
 	// If no criteria, return empty criteria (matches all)
 	if len(orCriteria) == 0 {
 		return &imap.SearchCriteria{}
@@ -884,6 +1716,80 @@ func (c *Client) buildOrSearchCriteria(opts SearchOptions) *imap.SearchCriteria
 	return &result
 }
 
+// addressFilters returns a HEADER criterion for each address filter set in
+// opts. IMAP header search is a case-insensitive substring match, so
+// "@example.com" already matches every sender at that domain; leading and
+// trailing "*" wildcards are accepted and stripped.
+func addressFilters(opts SearchOptions) []imap.SearchCriteriaHeaderField {
+	filters := []struct{ key, value string }{
+		{"From", opts.From},
+		{"To", opts.To},
+		{"Cc", opts.Cc},
+		{"Bcc", opts.Bcc},
+	}
+
+	var result []imap.SearchCriteriaHeaderField
+	for _, f := range filters {
+		value := strings.Trim(f.value, "*")
+		if value == "" {
+			continue
+		}
+		result = append(result, imap.SearchCriteriaHeaderField{Key: f.key, Value: value})
+	}
+	return result
+}
+
+// negatedFilters returns the criteria that matching messages must not
+// satisfy, one per negated filter set in opts.
+func negatedFilters(opts SearchOptions) []imap.SearchCriteria {
+	var result []imap.SearchCriteria
+	headers := []struct{ key, value string }{
+		{"From", opts.NotFrom},
+		{"To", opts.NotTo},
+		{"Subject", opts.NotSubject},
+	}
+	for _, h := range headers {
+		value := strings.Trim(h.value, "*")
+		if value == "" {
+			continue
+		}
+		result = append(result, imap.SearchCriteria{
+			Header: []imap.SearchCriteriaHeaderField{{Key: h.key, Value: value}},
+		})
+	}
+	if opts.NotBody != "" {
+		result = append(result, imap.SearchCriteria{Body: []string{opts.NotBody}})
+	}
+	return result
+}
+
+// flagFilters returns one criterion per flag filter set in opts: the flag
+// when the filter is true, NOT the flag when it is false.
+func flagFilters(opts SearchOptions) []imap.SearchCriteria {
+	filters := []struct {
+		want *bool
+		flag imap.Flag
+	}{
+		{opts.Flagged, imap.FlagFlagged},
+		{opts.Seen, imap.FlagSeen},
+		{opts.Answered, imap.FlagAnswered},
+		{opts.Draft, imap.FlagDraft},
+	}
+
+	var result []imap.SearchCriteria
+	for _, f := range filters {
+		if f.want == nil {
+			continue
+		}
+		if *f.want {
+			result = append(result, imap.SearchCriteria{Flag: []imap.Flag{f.flag}})
+		} else {
+			result = append(result, imap.SearchCriteria{NotFlag: []imap.Flag{f.flag}})
+		}
+	}
+	return result
+}
+
 // parseDate parses a date string in YYYY-MM-DD format
 func parseDate(s string) (time.Time, error) {
 	return time.Parse("2006-01-02", s)
@@ -894,6 +1800,14 @@ func (c *Client) CreateMailbox(name string) error {
 		return fmt.Errorf("not connected")
 	}
 
+	name, err := NormalizeMailboxName(name)
+	if err != nil {
+		return err
+	}
+	if err := validateNewMailboxName(name); err != nil {
+		return err
+	}
+
 	if err := c.client.Create(name, nil).Wait(); err != nil {
 		return fmt.Errorf("failed to create mailbox %s: %w", name, err)
 	}
@@ -906,6 +1820,11 @@ func (c *Client) DeleteMailbox(name string) error {
 		return fmt.Errorf("not connected")
 	}
 
+	name, err := NormalizeMailboxName(name)
+	if err != nil {
+		return err
+	}
+
 	if err := c.client.Delete(name).Wait(); err != nil {
 		return fmt.Errorf("failed to delete mailbox %s: %w", name, err)
 	}
@@ -913,13 +1832,73 @@ func (c *Client) DeleteMailbox(name string) error {
 	return nil
 }
 
+// ErrQuotaNotSupported is returned by Quota when the server does not
+// advertise the QUOTA capability.
+var ErrQuotaNotSupported = errors.New("server does not support QUOTA")
+
+// Quota returns the storage and message usage for the quota root that
+// contains INBOX.
+func (c *Client) Quota() (*QuotaInfo, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	if !c.client.Caps().Has(imap.CapQuota) {
+		return nil, ErrQuotaNotSupported
+	}
+
+	roots, err := c.client.GetQuotaRoot("INBOX").Wait()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota: %w", err)
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("server returned no quota roots for INBOX")
+	}
+
+	return quotaInfoFromData(roots[0]), nil
+}
+
+// quotaInfoFromData converts a QUOTA response into a QuotaInfo.
+// IMAP reports STORAGE in units of 1024 octets.
+func quotaInfoFromData(data imapclient.QuotaData) *QuotaInfo {
+	info := &QuotaInfo{Root: data.Root}
+	if res, ok := data.Resources[imap.QuotaResourceStorage]; ok {
+		info.StorageUsed = res.Usage * 1024
+		info.StorageLimit = res.Limit * 1024
+	}
+	if res, ok := data.Resources[imap.QuotaResourceMessage]; ok {
+		info.MessagesUsed = res.Usage
+		info.MessagesLimit = res.Limit
+	}
+	return info
+}
+
 func formatAddress(addr imap.Address) string {
-	if addr.Name != "" {
-		return fmt.Sprintf("%s <%s>", addr.Name, addr.Addr())
+	if name := decodeWords(addr.Name); name != "" {
+		return fmt.Sprintf("%s <%s>", name, addr.Addr())
 	}
 	return addr.Addr()
 }
 
+// wordDecoder decodes RFC 2047 encoded words in every charset go-message
+// supports; mime.WordDecoder on its own only knows UTF-8 and Latin-1.
+var wordDecoder = &mime.WordDecoder{CharsetReader: charset.Reader}
+
+// decodeWords returns an envelope subject or display name with any encoded
+// words (=?charset?Q?...?=) decoded. imapclient decodes them too, but leaves
+// the raw text when decoding fails, and some servers send names it does not
+// try. Text that still cannot be decoded is returned unchanged.
+func decodeWords(s string) string {
+	if !strings.Contains(s, "=?") {
+		return s
+	}
+	decoded, err := wordDecoder.DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
 func readAll(r imap.LiteralReader) ([]byte, error) {
 	return io.ReadAll(r)
 }
@@ -948,7 +1927,7 @@ func (c *Client) GetAttachments(mailbox, id string) ([]Attachment, error) {
 
 	msg := fetchCmd.Next()
 	if msg == nil {
-		return nil, fmt.Errorf("message not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, id)
 	}
 
 	var attachments []Attachment
@@ -1007,8 +1986,10 @@ func extractAttachments(bs imap.BodyStructure, index *int, partNum string) []Att
 				Index:       *index,
 				Filename:    filename,
 				ContentType: fmt.Sprintf("%s/%s", s.Type, s.Subtype),
+				Encoding:    strings.ToLower(s.Encoding),
 				Size:        int64(s.Size),
 			}
+			att.GuessedType = guessAttachmentType(att.ContentType, filename)
 			if att.Filename == "" {
 				att.Filename = fmt.Sprintf("attachment_%d", *index)
 			}
@@ -1029,6 +2010,25 @@ func extractAttachments(bs imap.BodyStructure, index *int, partNum string) []Att
 	return attachments
 }
 
+// guessAttachmentType returns the media type implied by filename's
+// extension when contentType is application/octet-stream, which some
+// senders use for every attachment. It returns "" when the declared type
+// is specific or the extension is unknown.
+func guessAttachmentType(contentType, filename string) string {
+	if !strings.EqualFold(contentType, "application/octet-stream") {
+		return ""
+	}
+	guessed := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))
+	if guessed == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(guessed)
+	if err != nil || mediaType == "application/octet-stream" {
+		return ""
+	}
+	return mediaType
+}
+
 // DownloadAttachment downloads a specific attachment by index
 func (c *Client) DownloadAttachment(mailbox, id string, index int) ([]byte, string, error) {
 	_, err := c.SelectMailbox(mailbox)
@@ -1053,7 +2053,7 @@ func (c *Client) DownloadAttachment(mailbox, id string, index int) ([]byte, stri
 	msg := fetchCmd.Next()
 	if msg == nil {
 		fetchCmd.Close()
-		return nil, "", fmt.Errorf("message not found: %s", id)
+		return nil, "", fmt.Errorf("%w: %s", ErrMessageNotFound, id)
 	}
 
 	var bodyStruct imap.BodyStructure
@@ -1092,7 +2092,7 @@ func (c *Client) DownloadAttachment(mailbox, id string, index int) ([]byte, stri
 
 	msg2 := fetchCmd2.Next()
 	if msg2 == nil {
-		return nil, "", fmt.Errorf("message not found: %s", id)
+		return nil, "", fmt.Errorf("%w: %s", ErrMessageNotFound, id)
 	}
 
 	var data []byte
@@ -1290,111 +2290,74 @@ func (c *Client) GetMessageLabels(messageID string) ([]string, error) {
 	return labels, nil
 }
 
-// Draft represents an email draft
-type Draft struct {
-	To      []string
-	CC      []string
-	Subject string
-	Body    string
+// AppendDraft stores a complete RFC822 message in mailbox with the \Draft
+// flag and returns its UID (0 when the server does not report it).
+func (c *Client) AppendDraft(mailbox string, message []byte) (uint32, error) {
+	return c.AppendMessage(mailbox, message, []string{string(imap.FlagDraft), string(imap.FlagSeen)}, time.Time{})
 }
 
-// CreateDraft creates a new draft in the Drafts folder
-func (c *Client) CreateDraft(draft *Draft) (uint32, error) {
+// AppendMessage stores a complete RFC822 message in mailbox with the given
+// flags and internal date (the server's clock when date is zero), and
+// returns its UID (0 when the server does not report it).
+func (c *Client) AppendMessage(mailbox string, message []byte, flags []string, date time.Time) (uint32, error) {
 	if c.client == nil {
 		return 0, fmt.Errorf("not connected")
 	}
 
-	// Build the RFC822 message
-	message := buildDraftMessage(draft, c.config.Bridge.Email)
+	mailbox, err := NormalizeMailboxName(mailbox)
+	if err != nil {
+		return 0, err
+	}
 
-	// Append to Drafts folder with \Draft flag
-	flags := []imap.Flag{imap.FlagDraft, imap.FlagSeen}
-	appendCmd := c.client.Append("Drafts", int64(len(message)), &imap.AppendOptions{
-		Flags: flags,
+	imapFlags := make([]imap.Flag, len(flags))
+	for i, f := range flags {
+		imapFlags[i] = imap.Flag(f)
+	}
+	appendCmd := c.client.Append(mailbox, int64(len(message)), &imap.AppendOptions{
+		Flags: imapFlags,
+		Time:  date,
 	})
 
-	if _, err := appendCmd.Write([]byte(message)); err != nil {
-		return 0, fmt.Errorf("failed to write draft: %w", err)
+	if _, err := appendCmd.Write(message); err != nil {
+		return 0, fmt.Errorf("failed to write message to %s: %w", mailbox, err)
+	}
+	if err := appendCmd.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write message to %s: %w", mailbox, err)
 	}
 
 	data, err := appendCmd.Wait()
 	if err != nil {
-		return 0, fmt.Errorf("failed to create draft: %w", err)
-	}
-
-	// Return the UID of the created message
-	if data.UID > 0 {
-		return uint32(data.UID), nil
+		return 0, fmt.Errorf("failed to append message to %s: %w", mailbox, err)
 	}
-
-	return 0, nil
+	return uint32(data.UID), nil
 }
 
-// UpdateDraft updates an existing draft (deletes old, creates new)
-func (c *Client) UpdateDraft(id string, draft *Draft) (uint32, error) {
-	// Delete the old draft
-	if err := c.DeleteMessages("Drafts", []string{id}, true); err != nil {
+// ReplaceDraft replaces an existing draft in mailbox with a complete
+// RFC822 message.
+func (c *Client) ReplaceDraft(mailbox, id string, message []byte) (uint32, error) {
+	if err := c.DeleteMessages(mailbox, []string{id}, true); err != nil {
 		return 0, fmt.Errorf("failed to delete old draft: %w", err)
 	}
-
-	// Create the new draft
-	return c.CreateDraft(draft)
-}
-
-// ListDrafts returns drafts from the Drafts folder
-func (c *Client) ListDrafts(limit int) ([]MessageSummary, error) {
-	return c.ListMessages("Drafts", limit, 0, false)
+	return c.AppendDraft(mailbox, message)
 }
 
-// GetDraft retrieves a specific draft
-func (c *Client) GetDraft(id string) (*Message, error) {
-	return c.GetMessage("Drafts", id)
-}
+// DraftsMailbox is Proton's drafts folder.
+const DraftsMailbox = "Drafts"
 
-// DeleteDraft deletes a draft (permanently)
-func (c *Client) DeleteDraft(ids []string) error {
-	return c.DeleteMessages("Drafts", ids, true)
+// ListDrafts returns drafts from mailbox, newest first, skipping the
+// offset most recent ones.
+func (c *Client) ListDrafts(mailbox string, limit, offset int) ([]MessageSummary, error) {
+	return c.ListMessages(mailbox, limit, offset, false)
 }
 
-// sanitizeAddressList strips CR/LF from each address and joins with ", ".
-func sanitizeAddressList(addrs []string) string {
-	clean := make([]string, len(addrs))
-	for i, a := range addrs {
-		clean[i] = safetext.SanitizeHeaderValue(a)
-	}
-	return strings.Join(clean, ", ")
+// GetDraft retrieves a specific draft from mailbox
+func (c *Client) GetDraft(mailbox, id string) (*Message, error) {
+	return c.GetMessage(mailbox, id)
 }
 
-// buildDraftMessage creates an RFC822 message from a Draft
-func buildDraftMessage(draft *Draft, from string) string {
-	var sb strings.Builder
-
-	// Headers — strip CR/LF from every value to prevent header injection
-	// if draft data was ever sourced from untrusted input (e.g. a forward
-	// template pulling Subject/From from a received email).
-	sb.WriteString(fmt.Sprintf("From: %s\r\n", safetext.SanitizeHeaderValue(from)))
-
-	if len(draft.To) > 0 {
-		sb.WriteString(fmt.Sprintf("To: %s\r\n", sanitizeAddressList(draft.To)))
-	}
-
-	if len(draft.CC) > 0 {
-		sb.WriteString(fmt.Sprintf("Cc: %s\r\n", sanitizeAddressList(draft.CC)))
-	}
-
-	if draft.Subject != "" {
-		sb.WriteString(fmt.Sprintf("Subject: %s\r\n", safetext.SanitizeHeaderValue(draft.Subject)))
-	}
-
-	sb.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
-	sb.WriteString("MIME-Version: 1.0\r\n")
-	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
-	sb.WriteString("\r\n")
-
-	// Body
-	sb.WriteString(draft.Body)
-
-	return sb.String()
+// DeleteDraft deletes drafts from mailbox (permanently)
+func (c *Client) DeleteDraft(mailbox string, ids []string) error {
+	return c.DeleteMessages(mailbox, ids, true)
 }
 
 // ThreadMessage is a message with body text for thread display
@@ -1411,10 +2374,11 @@ type ThreadMessage struct {
 	Seen      bool   `json:"seen"`
 }
 
-// GetThread retrieves all messages in a conversation thread
-func (c *Client) GetThread(mailbox, id string) ([]ThreadMessage, error) {
+// GetThread retrieves all messages in a conversation thread. With peek the
+// messages are fetched with BODY.PEEK[] and keep their \Seen state.
+func (c *Client) GetThread(mailbox, id string, peek bool) ([]ThreadMessage, error) {
 	// First get the target message
-	msg, err := c.GetMessage(mailbox, id)
+	msg, err := c.getMessage(mailbox, id, peek)
 	if err != nil {
 		return nil, err
 	}
@@ -1423,7 +2387,7 @@ func (c *Client) GetThread(mailbox, id string) ([]ThreadMessage, error) {
 	baseSubject := stripSubjectPrefixes(msg.Subject)
 
 	// Search by subject (IMAP's primary threading mechanism)
-	results, err := c.Search(mailbox, SearchOptions{
+	results, _, err := c.Search(mailbox, SearchOptions{
 		Subject: baseSubject,
 	})
 	if err != nil {
@@ -1441,7 +2405,7 @@ func (c *Client) GetThread(mailbox, id string) ([]ThreadMessage, error) {
 		seenUIDs[summary.UID] = true
 
 		// Get full message for body
-		fullMsg, err := c.GetMessage(mailbox, fmt.Sprintf("%d", summary.SeqNum))
+		fullMsg, err := c.getMessage(mailbox, fmt.Sprintf("%d", summary.SeqNum), peek)
 		if err != nil {
 			continue
 		}
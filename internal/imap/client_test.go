@@ -1,40 +1,28 @@
 package imap
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/bscott/pm-cli/internal/config"
 	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapclient"
 )
 
-func TestIsLoopbackHost(t *testing.T) {
-	tests := []struct {
-		host string
-		want bool
-	}{
-		{"localhost", true},
-		{"LOCALHOST", true},
-		{"127.0.0.1", true},
-		{"::1", true},
-		{"  127.0.0.1  ", true},
-		{"imap.example.com", false},
-		{"10.0.0.1", false},
-		{"", false},
-		{"localhost.evil.com", false},
-	}
-	for _, tc := range tests {
-		got := isLoopbackHost(tc.host)
-		if got != tc.want {
-			t.Errorf("isLoopbackHost(%q) = %v, want %v", tc.host, got, tc.want)
-		}
-	}
-}
-
 func TestConnectRejectsNonLoopbackHost(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Bridge.Email = "test@example.com"
 	cfg.Bridge.IMAPHost = "imap.example.com"
+	verify := false
+	cfg.Bridge.TLSVerify = &verify
 	cfg.Bridge.IMAPPort = 993
 
 	client, err := NewClient(cfg)
@@ -51,29 +39,86 @@ func TestConnectRejectsNonLoopbackHost(t *testing.T) {
 	}
 }
 
-func TestBuildDraftMessageStripsHeaderInjection(t *testing.T) {
-	draft := &Draft{
-		To:      []string{"victim@example.com\r\nBcc: attacker@evil.example"},
-		CC:      []string{"normal@example.com\r\nX-Evil: 1"},
-		Subject: "Hi\r\nBcc: attacker2@evil.example",
-		Body:    "body",
+func TestDialHonorsIMAPSecurity(t *testing.T) {
+	addr, _ := startTestServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	newTestClient := func(security string) *Client {
+		cfg := config.DefaultConfig()
+		cfg.Bridge.Email = testUser
+		cfg.Bridge.IMAPHost = host
+		cfg.Bridge.IMAPPort, _ = strconv.Atoi(port)
+		cfg.Bridge.IMAPSecurity = security
+		c := &Client{config: cfg, password: testPassword}
+		c.tlsConfig, _ = cfg.TLSConfig(host)
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	// The test server speaks plain IMAP and offers no STARTTLS
+	if err := newTestClient(config.SecurityNone).dial(); err != nil {
+		t.Fatalf("dial() with security none error = %v", err)
 	}
-	out := buildDraftMessage(draft, "sender@example.com\r\nX-Spoof: yes")
-	injectedHeaders := []string{
-		"\r\nBcc:",
-		"\r\nX-Evil:",
-		"\r\nX-Spoof:",
-		"\nBcc:",
-		"\nX-Evil:",
-		"\nX-Spoof:",
+	if err := newTestClient("").dial(); err == nil {
+		t.Error("dial() with the default starttls succeeded against a server without STARTTLS")
 	}
-	for _, h := range injectedHeaders {
-		if strings.Contains(out, h) {
-			t.Errorf("injected header line %q reached draft output:\n%s", h, out)
+}
+
+func TestDialStopsAtDeadline(t *testing.T) {
+	// A server that accepts the connection but never sends a greeting
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	for _, security := range []string{config.SecurityNone, config.SecurityTLS, config.SecuritySTARTTLS} {
+		cfg := config.DefaultConfig()
+		cfg.Bridge.Email = testUser
+		cfg.Bridge.IMAPHost = host
+		cfg.Bridge.IMAPPort, _ = strconv.Atoi(port)
+		cfg.Bridge.IMAPSecurity = security
+		cfg.OverrideTimeout(200 * time.Millisecond)
+		cfg.StartDeadline()
+		c := &Client{config: cfg, password: testPassword}
+		c.tlsConfig, _ = cfg.TLSConfig(host)
+
+		done := make(chan error, 1)
+		go func() { done <- c.dial() }()
+		select {
+		case err := <-done:
+			if err == nil {
+				c.Close()
+				t.Errorf("dial() with security %q succeeded without a greeting", security)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("dial() with security %q still waiting well past the deadline", security)
 		}
 	}
 }
 
+func TestConnectRejectsPlainRemoteHost(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Bridge.Email = "test@example.com"
+	cfg.Bridge.IMAPHost = "imap.example.com"
+	cfg.Bridge.IMAPSecurity = config.SecurityNone
+
+	client, _ := NewClient(cfg)
+	if err := client.Connect(); err == nil || !strings.Contains(err.Error(), "security is none") {
+		t.Fatalf("Connect() error = %v, want a refusal to log in without encryption", err)
+	}
+}
+
 func TestNewClient(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Bridge.Email = "test@example.com"
@@ -196,6 +241,315 @@ func TestClientMethodsRequireConnection(t *testing.T) {
 			t.Error("expected error when not connected")
 		}
 	})
+
+	t.Run("ListRecipients without connection", func(t *testing.T) {
+		_, err := client.ListRecipients("Sent", 10)
+		if err == nil {
+			t.Error("expected error when not connected")
+		}
+	})
+
+	t.Run("Quota without connection", func(t *testing.T) {
+		_, err := client.Quota()
+		if err == nil {
+			t.Error("expected error when not connected")
+		}
+	})
+
+	t.Run("MailboxState without connection", func(t *testing.T) {
+		_, err := client.MailboxState("INBOX")
+		if err == nil {
+			t.Error("expected error when not connected")
+		}
+	})
+
+	t.Run("MessageRefs without connection", func(t *testing.T) {
+		_, err := client.MessageRefs("INBOX", []string{"1"})
+		if err == nil {
+			t.Error("expected error when not connected")
+		}
+	})
+
+	t.Run("FindByMessageID without connection", func(t *testing.T) {
+		_, err := client.FindByMessageID("INBOX", "abc@example.com")
+		if err == nil {
+			t.Error("expected error when not connected")
+		}
+	})
+
+	t.Run("UndeleteMessages without connection", func(t *testing.T) {
+		err := client.UndeleteMessages("INBOX", []string{"1"})
+		if err == nil {
+			t.Error("expected error when not connected")
+		}
+	})
+
+	t.Run("Status without connection", func(t *testing.T) {
+		_, err := client.Status("INBOX")
+		if err == nil {
+			t.Error("expected error when not connected")
+		}
+	})
+}
+
+func TestBuildSearchCriteriaAddresses(t *testing.T) {
+	client := &Client{}
+
+	criteria := client.buildSearchCriteria(SearchOptions{
+		From: "*@example.com",
+		To:   "team@",
+		Cc:   "alice@example.com",
+		Bcc:  "audit@example.com",
+	})
+	want := []imap.SearchCriteriaHeaderField{
+		{Key: "From", Value: "@example.com"},
+		{Key: "To", Value: "team@"},
+		{Key: "Cc", Value: "alice@example.com"},
+		{Key: "Bcc", Value: "audit@example.com"},
+	}
+	if !reflect.DeepEqual(criteria.Header, want) {
+		t.Errorf("Header = %+v, want %+v", criteria.Header, want)
+	}
+
+	t.Run("wildcard only is ignored", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{From: "*"})
+		if len(criteria.Header) != 0 {
+			t.Errorf("Header = %+v, want none", criteria.Header)
+		}
+	})
+
+	t.Run("or", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{UseOr: true, Cc: "a@example.com", Bcc: "b@example.com"})
+		if len(criteria.Or) != 1 {
+			t.Fatalf("Or = %+v, want one pair", criteria.Or)
+		}
+		left, right := criteria.Or[0][0], criteria.Or[0][1]
+		if left.Header[0].Key != "Cc" || right.Header[0].Key != "Bcc" {
+			t.Errorf("Or operands = %+v / %+v", left.Header, right.Header)
+		}
+	})
+}
+
+func TestBuildSearchCriteriaNegatedFilters(t *testing.T) {
+	client := &Client{}
+
+	t.Run("mixed positive and negative", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{
+			From:       "boss@example.com",
+			NotSubject: "lunch",
+			NotBody:    "unsubscribe",
+		})
+		wantHeader := []imap.SearchCriteriaHeaderField{{Key: "From", Value: "boss@example.com"}}
+		if !reflect.DeepEqual(criteria.Header, wantHeader) {
+			t.Errorf("Header = %+v, want %+v", criteria.Header, wantHeader)
+		}
+		wantNot := []imap.SearchCriteria{
+			{Header: []imap.SearchCriteriaHeaderField{{Key: "Subject", Value: "lunch"}}},
+			{Body: []string{"unsubscribe"}},
+		}
+		if !reflect.DeepEqual(criteria.Not, wantNot) {
+			t.Errorf("Not = %+v, want %+v", criteria.Not, wantNot)
+		}
+	})
+
+	t.Run("global negation wraps everything", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{From: "a@example.com", NotTo: "b@example.com", Negate: true})
+		if len(criteria.Not) != 1 || len(criteria.Header) != 0 {
+			t.Fatalf("criteria = %+v, want a single NOT", criteria)
+		}
+		inner := criteria.Not[0]
+		if len(inner.Header) != 1 || len(inner.Not) != 1 || inner.Not[0].Header[0].Key != "To" {
+			t.Errorf("inner = %+v", inner)
+		}
+	})
+
+	t.Run("or", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{UseOr: true, From: "a@example.com", NotFrom: "b@example.com"})
+		if len(criteria.Or) != 1 {
+			t.Fatalf("Or = %+v, want one pair", criteria.Or)
+		}
+		right := criteria.Or[0][1]
+		if len(right.Not) != 1 || right.Not[0].Header[0].Value != "b@example.com" {
+			t.Errorf("second OR operand = %+v", right)
+		}
+	})
+}
+
+func TestBuildSearchCriteriaFlags(t *testing.T) {
+	yes, no := true, false
+	client := &Client{}
+
+	t.Run("and", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{
+			Flagged:  &yes,
+			Seen:     &no,
+			Answered: &yes,
+			Draft:    &no,
+		})
+		wantFlag := []imap.Flag{imap.FlagFlagged, imap.FlagAnswered}
+		wantNotFlag := []imap.Flag{imap.FlagSeen, imap.FlagDraft}
+		if !reflect.DeepEqual(criteria.Flag, wantFlag) {
+			t.Errorf("Flag = %v, want %v", criteria.Flag, wantFlag)
+		}
+		if !reflect.DeepEqual(criteria.NotFlag, wantNotFlag) {
+			t.Errorf("NotFlag = %v, want %v", criteria.NotFlag, wantNotFlag)
+		}
+	})
+
+	t.Run("unset filters add nothing", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{From: "a@example.com"})
+		if len(criteria.Flag) != 0 || len(criteria.NotFlag) != 0 {
+			t.Errorf("Flag = %v, NotFlag = %v, want none", criteria.Flag, criteria.NotFlag)
+		}
+	})
+
+	t.Run("or", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{
+			UseOr:   true,
+			From:    "a@example.com",
+			Flagged: &yes,
+		})
+		if len(criteria.Or) != 1 {
+			t.Fatalf("Or = %+v, want one pair", criteria.Or)
+		}
+		right := criteria.Or[0][1]
+		if !reflect.DeepEqual(right.Flag, []imap.Flag{imap.FlagFlagged}) {
+			t.Errorf("second OR operand Flag = %v", right.Flag)
+		}
+	})
+
+	t.Run("negated", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{Seen: &no, Negate: true})
+		if len(criteria.Not) != 1 || !reflect.DeepEqual(criteria.Not[0].NotFlag, []imap.Flag{imap.FlagSeen}) {
+			t.Errorf("Not = %+v", criteria.Not)
+		}
+	})
+}
+
+func TestBuildSearchCriteriaSizeRange(t *testing.T) {
+	client := &Client{}
+	between := imap.SearchCriteria{Larger: 1 << 20, Smaller: 10 << 20}
+
+	t.Run("and", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{LargerThan: 1 << 20, SmallerThan: 10 << 20})
+		if !reflect.DeepEqual(*criteria, between) {
+			t.Errorf("criteria = %+v, want %+v", *criteria, between)
+		}
+	})
+
+	t.Run("or keeps the range together", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{UseOr: true, LargerThan: 1 << 20, SmallerThan: 10 << 20})
+		if !reflect.DeepEqual(*criteria, between) {
+			t.Errorf("criteria = %+v, want the single range %+v", *criteria, between)
+		}
+	})
+
+	t.Run("or with another filter", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{
+			UseOr:       true,
+			Subject:     "invoice",
+			LargerThan:  1 << 20,
+			SmallerThan: 10 << 20,
+		})
+		if len(criteria.Or) != 1 {
+			t.Fatalf("Or = %+v, want one pair", criteria.Or)
+		}
+		if right := criteria.Or[0][1]; !reflect.DeepEqual(right, between) {
+			t.Errorf("second OR operand = %+v, want the range %+v", right, between)
+		}
+	})
+
+	t.Run("or with one bound", func(t *testing.T) {
+		criteria := client.buildSearchCriteria(SearchOptions{UseOr: true, From: "a@example.com", SmallerThan: 4096})
+		if len(criteria.Or) != 1 || !reflect.DeepEqual(criteria.Or[0][1], imap.SearchCriteria{Smaller: 4096}) {
+			t.Errorf("criteria = %+v, want From OR SMALLER 4096", *criteria)
+		}
+	})
+}
+
+func TestSearchWindow(t *testing.T) {
+	nums := []uint32{7, 2, 9, 4, 5}
+
+	tests := []struct {
+		name   string
+		limit  int
+		offset int
+		want   []uint32
+	}{
+		{"all", 0, 0, []uint32{2, 4, 5, 7, 9}},
+		{"newest two", 2, 0, []uint32{7, 9}},
+		{"second page", 2, 2, []uint32{4, 5}},
+		{"last partial page", 2, 4, []uint32{2}},
+		{"offset past end", 2, 5, nil},
+		{"limit larger than matches", 10, 0, []uint32{2, 4, 5, 7, 9}},
+		{"offset without limit", 0, 3, []uint32{2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := searchWindow(nums, tt.limit, tt.offset)
+			if len(got) != len(tt.want) {
+				t.Fatalf("searchWindow() = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("searchWindow() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCopyUIDMap(t *testing.T) {
+	var src, dest imap.UIDSet
+	src.AddRange(10, 12)
+	dest.AddRange(200, 202)
+
+	got := copyUIDMap(&imap.CopyData{SourceUIDs: src, DestUIDs: dest})
+	want := map[uint32]uint32{10: 200, 11: 201, 12: 202}
+	if len(got) != len(want) {
+		t.Fatalf("copyUIDMap() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("copyUIDMap()[%d] = %d, want %d", k, got[k], v)
+		}
+	}
+
+	if got := copyUIDMap(nil); len(got) != 0 {
+		t.Errorf("copyUIDMap(nil) = %v, want empty", got)
+	}
+
+	var short imap.UIDSet
+	short.AddNum(300)
+	if got := copyUIDMap(&imap.CopyData{SourceUIDs: src, DestUIDs: short}); len(got) != 0 {
+		t.Errorf("copyUIDMap(mismatched) = %v, want empty", got)
+	}
+}
+
+func TestQuotaInfoFromData(t *testing.T) {
+	data := imapclient.QuotaData{
+		Root: "",
+		Resources: map[imap.QuotaResourceType]imapclient.QuotaResourceData{
+			imap.QuotaResourceStorage: {Usage: 512, Limit: 1024},
+			imap.QuotaResourceMessage: {Usage: 42, Limit: 1000},
+		},
+	}
+
+	info := quotaInfoFromData(data)
+	if info.StorageUsed != 512*1024 {
+		t.Errorf("StorageUsed = %d, want %d", info.StorageUsed, 512*1024)
+	}
+	if info.StorageLimit != 1024*1024 {
+		t.Errorf("StorageLimit = %d, want %d", info.StorageLimit, 1024*1024)
+	}
+	if info.MessagesUsed != 42 {
+		t.Errorf("MessagesUsed = %d, want 42", info.MessagesUsed)
+	}
+	if info.MessagesLimit != 1000 {
+		t.Errorf("MessagesLimit = %d, want 1000", info.MessagesLimit)
+	}
 }
 
 func TestAttachmentPartInfo(t *testing.T) {
@@ -320,3 +674,390 @@ func TestBuildNumSetFromIDs(t *testing.T) {
 		}
 	})
 }
+
+// commandLog collects the client's protocol traffic.
+type commandLog struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (l *commandLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// count returns how many commands named name the client sent.
+func (l *commandLog) count(name string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	re := regexp.MustCompile(`(?m)^T\d+ ` + name + ` `)
+	return len(re.FindAllString(l.buf.String(), -1))
+}
+
+func TestBulkIDsUseOneCommand(t *testing.T) {
+	var traffic commandLog
+	client, user := newTestServerWithDebug(t, &traffic)
+	if err := user.Create("Archive", nil); err != nil {
+		t.Fatalf("create Archive: %v", err)
+	}
+
+	ids := make([]string, 1000)
+	for i := range ids {
+		appendTestMessage(t, user, "INBOX", peekTestMessage)
+		// Out of order, as an IDs file computed elsewhere might be
+		ids[i] = fmt.Sprintf("uid:%d", len(ids)-i)
+	}
+
+	if err := client.SetFlagsMultiple("INBOX", ids, true, false, false, false); err != nil {
+		t.Fatalf("SetFlagsMultiple() error = %v", err)
+	}
+	if n := traffic.count("UID STORE"); n != 1 {
+		t.Errorf("flagging 1000 IDs sent %d STORE commands, want 1", n)
+	}
+	if flags := serverFlags(t, client, "INBOX", 1000); !containsFlag(flags, "\\Seen") {
+		t.Errorf("flags of uid 1000 = %v, want \\Seen", flags)
+	}
+
+	destUIDs, err := client.MoveMessagesWithUIDs("INBOX", ids, "Archive")
+	if err != nil {
+		t.Fatalf("MoveMessagesWithUIDs() error = %v", err)
+	}
+	if n := traffic.count("UID COPY"); n != 1 {
+		t.Errorf("moving 1000 IDs sent %d COPY commands, want 1", n)
+	}
+	if len(destUIDs) != len(ids) {
+		t.Errorf("MoveMessagesWithUIDs() mapped %d UIDs, want %d", len(destUIDs), len(ids))
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		in       time.Time
+		loc      *time.Location
+		wantDate string
+		wantISO  string
+	}{
+		{
+			name:     "UTC internal date in New York (EST)",
+			in:       time.Date(2024, 1, 15, 15, 4, 0, 0, time.UTC),
+			loc:      ny,
+			wantDate: "2024-01-15 10:04",
+			wantISO:  "2024-01-15T15:04:00Z",
+		},
+		{
+			name:     "UTC internal date in New York (EDT, crosses midnight)",
+			in:       time.Date(2024, 7, 4, 2, 30, 0, 0, time.UTC),
+			loc:      ny,
+			wantDate: "2024-07-03 22:30",
+			wantISO:  "2024-07-04T02:30:00Z",
+		},
+		{
+			name:     "offset date normalized to UTC for ISO",
+			in:       time.Date(2024, 1, 15, 9, 0, 0, 0, time.FixedZone("CET", 3600)),
+			loc:      time.UTC,
+			wantDate: "2024-01-15 08:00",
+			wantISO:  "2024-01-15T08:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, iso := formatDate(tt.in, tt.loc, "2006-01-02 15:04")
+			if date != tt.wantDate {
+				t.Errorf("date = %q, want %q", date, tt.wantDate)
+			}
+			if iso != tt.wantISO {
+				t.Errorf("iso = %q, want %q", iso, tt.wantISO)
+			}
+		})
+	}
+}
+
+func TestClientLocation(t *testing.T) {
+	cfg := config.DefaultConfig()
+	client, _ := NewClient(cfg)
+	if client.location() != time.Local {
+		t.Error("location() without timezone should be local time")
+	}
+
+	cfg.Defaults.Timezone = "America/New_York"
+	if got := client.location().String(); got != "America/New_York" {
+		t.Errorf("location() = %q, want America/New_York", got)
+	}
+
+	cfg.Defaults.Timezone = "Not/AZone"
+	if client.location() != time.Local {
+		t.Error("location() with invalid timezone should fall back to local time")
+	}
+}
+
+func TestExpireFailsPendingCommands(t *testing.T) {
+	client, _ := newTestServer(t)
+
+	if _, err := client.ListMailboxes(); err != nil {
+		t.Fatalf("ListMailboxes() error = %v", err)
+	}
+	client.expire()
+	if _, err := client.ListMailboxes(); err == nil {
+		t.Fatal("expected ListMailboxes() to fail once the deadline has passed")
+	}
+}
+
+func TestDecodeWords(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Plain subject", "Plain subject"},
+		{"=?UTF-8?Q?Caf=C3=A9_menu?=", "Café menu"},
+		{"=?UTF-8?B?8J+Tpg==?= Parcel", "📦 Parcel"},
+		{"=?windows-1252?Q?Caf=E9_=80?=", "Café €"},
+		{"=?ISO-8859-2?Q?Pawe=B3?=", "Paweł"},
+		{"=?x-unknown?Q?raw?=", "=?x-unknown?Q?raw?="},
+	}
+	for _, tt := range tests {
+		if got := decodeWords(tt.in); got != tt.want {
+			t.Errorf("decodeWords(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEncodedWordEnvelopes(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", `From: =?ISO-8859-2?Q?Pawe=B3?= <pawel@example.com>
+To: user@example.com
+Subject: =?windows-1252?Q?Caf=E9_=80?= and =?UTF-8?Q?na=C3=AFve?=
+Date: Mon, 03 Jun 2024 09:00:00 +0000
+
+Body.
+`)
+	const wantSubject = "Café € and naïve"
+
+	messages, err := client.ListMessages("INBOX", 10, 0, false)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("ListMessages() = %d messages, %v", len(messages), err)
+	}
+	if messages[0].Subject != wantSubject || messages[0].From != "Paweł" {
+		t.Errorf("ListMessages() = subject %q, from %q", messages[0].Subject, messages[0].From)
+	}
+
+	found, _, err := client.Search("INBOX", SearchOptions{From: "pawel"})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("Search() = %d messages, %v", len(found), err)
+	}
+	if found[0].Subject != wantSubject || found[0].From != "Paweł" {
+		t.Errorf("Search() = subject %q, from %q", found[0].Subject, found[0].From)
+	}
+
+	msg, err := client.PeekMessage("INBOX", "1")
+	if err != nil {
+		t.Fatalf("PeekMessage() error = %v", err)
+	}
+	if msg.Subject != wantSubject || msg.From != "Paweł <pawel@example.com>" {
+		t.Errorf("PeekMessage() = subject %q, from %q", msg.Subject, msg.From)
+	}
+}
+
+func TestGetMessageBcc(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", `From: user@example.com
+To: alice@example.com
+Bcc: Bob <bob@example.com>, carol@example.com
+Subject: Sent with Bcc
+Date: Mon, 03 Jun 2024 09:00:00 +0000
+
+Body.
+`)
+	appendTestMessage(t, user, "INBOX", peekTestMessage)
+
+	msgs, err := client.PeekMessages("INBOX", []string{"1", "2"})
+	if err != nil {
+		t.Fatalf("PeekMessages() error = %v", err)
+	}
+	want := []string{"Bob <bob@example.com>", "carol@example.com"}
+	if !reflect.DeepEqual(msgs[0].Bcc, want) {
+		t.Errorf("Bcc = %q, want %q", msgs[0].Bcc, want)
+	}
+	if msgs[1].Bcc != nil {
+		t.Errorf("Bcc of a message without one = %q, want nil", msgs[1].Bcc)
+	}
+}
+
+func TestExtractAttachmentsGuessesType(t *testing.T) {
+	bs := &imap.BodyStructureMultiPart{
+		Subtype: "mixed",
+		Children: []imap.BodyStructure{
+			&imap.BodyStructureSinglePart{Type: "text", Subtype: "plain", Encoding: "7bit", Size: 10},
+			&imap.BodyStructureSinglePart{
+				Type: "application", Subtype: "octet-stream", Encoding: "BASE64", Size: 300,
+				Extended: &imap.BodyStructureSinglePartExt{
+					Disposition: &imap.BodyStructureDisposition{Value: "attachment", Params: map[string]string{"filename": "Report.PDF"}},
+				},
+			},
+			&imap.BodyStructureSinglePart{
+				Type: "image", Subtype: "png", Encoding: "base64", Size: 200,
+				Params: map[string]string{"name": "chart.jpg"},
+			},
+			&imap.BodyStructureSinglePart{
+				Type: "application", Subtype: "octet-stream", Encoding: "base64", Size: 100,
+				Params: map[string]string{"name": "blob.unknownext"},
+			},
+		},
+	}
+
+	index := 0
+	got := extractAttachments(bs, &index, "")
+	if len(got) != 3 {
+		t.Fatalf("extractAttachments() returned %d attachments, want 3", len(got))
+	}
+
+	tests := []struct {
+		filename, guessed, encoding string
+	}{
+		{"Report.PDF", "application/pdf", "base64"},
+		{"chart.jpg", "", "base64"},
+		{"blob.unknownext", "", "base64"},
+	}
+	for i, tt := range tests {
+		if got[i].Filename != tt.filename || got[i].GuessedType != tt.guessed || got[i].Encoding != tt.encoding {
+			t.Errorf("attachment %d = %q guessed %q encoding %q, want %q guessed %q encoding %q",
+				i, got[i].Filename, got[i].GuessedType, got[i].Encoding, tt.filename, tt.guessed, tt.encoding)
+		}
+	}
+}
+
+func TestSearchMinimalEnvelope(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", `Subject: bare
+
+No From or Date header.
+`)
+
+	found, _, err := client.Search("INBOX", SearchOptions{Subject: "bare"})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("Search() = %d messages, %v", len(found), err)
+	}
+	if found[0].From != "(no sender)" || found[0].FromAddress != "" {
+		t.Errorf("Search() from = %q <%s>, want the (no sender) placeholder", found[0].From, found[0].FromAddress)
+	}
+	if strings.HasPrefix(found[0].DateISO, "0001-") || found[0].DateISO == "" {
+		t.Errorf("Search() date = %q, want the internal date instead of the zero time", found[0].DateISO)
+	}
+}
+
+func TestInternalDates(t *testing.T) {
+	client, user := newTestServer(t)
+	for _, day := range []int{1, 10, 20} {
+		raw := strings.NewReader("Subject: day\r\n\r\nBody.\r\n")
+		arrived := time.Date(2024, 6, day, 12, 0, 0, 0, time.UTC)
+		if _, err := user.Append("INBOX", raw, &imap.AppendOptions{Time: arrived}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	dates, err := client.InternalDates("INBOX", time.Date(2024, 6, 10, 18, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("InternalDates() error = %v", err)
+	}
+	if len(dates) != 2 || dates[0].Day() != 10 || dates[1].Day() != 20 {
+		t.Errorf("InternalDates() = %v, want the messages of June 10 and 20", dates)
+	}
+}
+
+func TestAppendMessage(t *testing.T) {
+	client, _ := newTestServer(t)
+
+	arrived := time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC)
+	raw := []byte("From: a@example.com\r\nSubject: carried over\r\n\r\nBody.\r\n")
+	uid, err := client.AppendMessage("INBOX", raw, []string{`\Seen`, "$Label1"}, arrived)
+	if err != nil {
+		t.Fatalf("AppendMessage() error = %v", err)
+	}
+	if uid == 0 {
+		t.Fatal("AppendMessage() returned no UID")
+	}
+
+	flags := serverFlags(t, client, "INBOX", uid)
+	if !containsFlag(flags, `\Seen`) || !containsFlag(flags, "$Label1") {
+		t.Errorf("flags = %v, want \\Seen and $Label1", flags)
+	}
+	dates, err := client.InternalDates("INBOX", arrived.AddDate(0, 0, -1))
+	if err != nil || len(dates) != 1 || !dates[0].Equal(arrived) {
+		t.Errorf("InternalDates() = %v, %v; want %v", dates, err, arrived)
+	}
+}
+
+func TestListMessagesChunked(t *testing.T) {
+	client, user := newTestServer(t)
+	for i := 1; i <= 5; i++ {
+		appendTestMessage(t, user, "INBOX", fmt.Sprintf("From: a@example.com\nSubject: message %d\n\nBody.\n", i))
+	}
+
+	var batches [][]uint32
+	err := client.ListMessagesChunked("INBOX", 1, 2, false, func(batch []MessageSummary) error {
+		seqs := make([]uint32, len(batch))
+		for i, msg := range batch {
+			seqs[i] = msg.SeqNum
+		}
+		batches = append(batches, seqs)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListMessagesChunked() error = %v", err)
+	}
+	// The newest message is skipped by the offset, the rest come newest first
+	want := [][]uint32{{4, 3}, {2, 1}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches = %v, want %v", batches, want)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = client.ListMessagesChunked("INBOX", 0, 2, false, func([]MessageSummary) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("ListMessagesChunked() = %v after %d call(s), want the callback error after 1", err, calls)
+	}
+}
+
+func TestListMessagesAfter(t *testing.T) {
+	client, user := newTestServer(t)
+	for i := 1; i <= 120; i++ {
+		appendTestMessage(t, user, "INBOX", fmt.Sprintf("From: a@example.com\nSubject: message %d\n\nBody.\n", i))
+	}
+
+	// More than any fixed window of recent messages
+	messages, err := client.ListMessagesAfter("INBOX", 5)
+	if err != nil {
+		t.Fatalf("ListMessagesAfter() error = %v", err)
+	}
+	if len(messages) != 115 || messages[0].UID != 120 || messages[len(messages)-1].UID != 6 {
+		t.Errorf("ListMessagesAfter(5) = %d message(s), want UIDs 120 down to 6", len(messages))
+	}
+
+	// UID 121:* would match the newest message, which is not new
+	if messages, err := client.ListMessagesAfter("INBOX", 120); err != nil || len(messages) != 0 {
+		t.Errorf("ListMessagesAfter(120) = %d message(s), %v; want none", len(messages), err)
+	}
+}
+
+func TestListMessagesStatus(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", "From: a@example.com\nSubject: replied\n\nBody.\n", imap.FlagSeen, imap.FlagAnswered, "$Label1")
+
+	messages, err := client.ListMessages("INBOX", 10, 0, false)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("ListMessages() = %d messages, %v", len(messages), err)
+	}
+	if got := strings.Join(messages[0].Status, ","); got != "read,answered" {
+		t.Errorf("Status = %v, want read and answered", messages[0].Status)
+	}
+}
@@ -0,0 +1,104 @@
+package imap
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bscott/pm-cli/internal/config"
+	"github.com/bscott/pm-cli/internal/smtp"
+)
+
+func TestAppendDraftWithAttachment(t *testing.T) {
+	client, user := newTestServer(t)
+	if err := user.Create("Drafts", nil); err != nil {
+		t.Fatalf("create Drafts: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notes.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4 test"), 0600); err != nil {
+		t.Fatalf("failed to create attachment: %v", err)
+	}
+
+	message, err := smtp.NewClient(config.DefaultConfig(), "").Compose(&smtp.Message{
+		From:        testUser,
+		To:          []string{"bob@example.com"},
+		Subject:     "Meeting notes",
+		Body:        "Draft body",
+		Attachments: []string{path},
+	})
+	if err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+
+	uid, err := client.AppendDraft("Drafts", message)
+	if err != nil {
+		t.Fatalf("AppendDraft() error = %v", err)
+	}
+	if uid == 0 {
+		t.Fatal("AppendDraft() returned no UID")
+	}
+
+	if flags := serverFlags(t, client, "Drafts", uid); !containsFlag(flags, "\\Draft") {
+		t.Errorf("flags = %v, want \\Draft", flags)
+	}
+
+	draft, err := client.GetDraft("Drafts", fmt.Sprintf("uid:%d", uid))
+	if err != nil {
+		t.Fatalf("GetDraft() error = %v", err)
+	}
+	if draft.Subject != "Meeting notes" {
+		t.Errorf("Subject = %q, want %q", draft.Subject, "Meeting notes")
+	}
+	if !bytes.Equal(draft.RawBody, message) {
+		t.Errorf("stored draft differs from the appended message:\n%s", draft.RawBody)
+	}
+	if !bytes.Contains(draft.RawBody, []byte("Content-Type: application/pdf")) || !bytes.Contains(draft.RawBody, []byte(`filename="notes.pdf"`)) {
+		t.Errorf("stored draft is missing the attachment part:\n%s", draft.RawBody)
+	}
+
+	// Replacing keeps a single draft
+	newUID, err := client.ReplaceDraft("Drafts", fmt.Sprintf("uid:%d", uid), message)
+	if err != nil {
+		t.Fatalf("ReplaceDraft() error = %v", err)
+	}
+	status, err := client.Status("Drafts")
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Messages != 1 || newUID == uid {
+		t.Errorf("after ReplaceDraft: %d message(s), uid %d -> %d", status.Messages, uid, newUID)
+	}
+}
+
+func TestListDraftsPaging(t *testing.T) {
+	client, user := newTestServer(t)
+	if err := user.Create("Brouillons", nil); err != nil {
+		t.Fatalf("create Brouillons: %v", err)
+	}
+	for i := 1; i <= 5; i++ {
+		appendTestMessage(t, user, "Brouillons", fmt.Sprintf("To: bob@example.com\nSubject: draft %d\n\nBody.\n", i))
+	}
+
+	drafts, err := client.ListDrafts("Brouillons", 2, 2)
+	if err != nil {
+		t.Fatalf("ListDrafts() error = %v", err)
+	}
+	if len(drafts) != 2 || drafts[0].Subject != "draft 3" || drafts[1].Subject != "draft 2" {
+		t.Errorf("ListDrafts(limit 2, offset 2) = %+v, want drafts 3 and 2", drafts)
+	}
+
+	// A listed ID refers to the same mailbox when read or deleted
+	draft, err := client.GetDraft("Brouillons", fmt.Sprintf("%d", drafts[0].SeqNum))
+	if err != nil || draft.Subject != "draft 3" {
+		t.Fatalf("GetDraft(Brouillons) = %+v, %v; want draft 3", draft, err)
+	}
+	if err := client.DeleteDraft("Brouillons", []string{fmt.Sprintf("uid:%d", draft.UID)}); err != nil {
+		t.Fatalf("DeleteDraft(Brouillons) error = %v", err)
+	}
+	if status, err := client.Status("Brouillons"); err != nil || status.Messages != 4 {
+		t.Errorf("after DeleteDraft: Status(Brouillons) = %+v, %v; want 4 messages", status, err)
+	}
+}
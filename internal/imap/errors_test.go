@@ -0,0 +1,57 @@
+package imap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+func TestIsNotFound(t *testing.T) {
+	client, user := newTestServer(t)
+
+	if _, err := client.GetMessage("INBOX", "1"); !errors.Is(err, ErrMailboxEmpty) || !IsNotFound(err) {
+		t.Errorf("GetMessage() on an empty mailbox error = %v, want ErrMailboxEmpty", err)
+	}
+
+	appendTestMessage(t, user, "INBOX", "Subject: one\n\nbody\n")
+	if _, err := client.GetMessage("INBOX", "uid:99"); !errors.Is(err, ErrMessageNotFound) || !IsNotFound(err) {
+		t.Errorf("GetMessage(uid:99) error = %v, want ErrMessageNotFound", err)
+	} else if err.Error() != "message not found: uid:99" {
+		t.Errorf("GetMessage(uid:99) error = %q", err.Error())
+	}
+
+	if _, err := client.SelectMailbox("Missing"); !IsNotFound(err) {
+		t.Errorf("SelectMailbox(Missing) error = %v, want a not-found error", err)
+	}
+
+	if IsNotFound(errors.New("boom")) || IsNotFound(nil) {
+		t.Error("IsNotFound() matched an unrelated error")
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connect", fmt.Errorf("%w: %w", ErrConnect, syscall.ECONNREFUSED), true},
+		{"dropped", fmt.Errorf("in response: %w", io.ErrUnexpectedEOF), true},
+		{"unavailable", &imap.Error{Type: imap.StatusResponseTypeNo, Code: imap.ResponseCodeUnavailable, Text: "try later"}, true},
+		{"auth failure", fmt.Errorf("IMAP login failed: %w", &imap.Error{Type: imap.StatusResponseTypeNo, Code: imap.ResponseCodeAuthenticationFailed}), false},
+		{"nonexistent", &imap.Error{Type: imap.StatusResponseTypeNo, Code: imap.ResponseCodeNonExistent}, false},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package imap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+// IsIDSpec reports whether id names several messages at once, the way
+// IMAP writes sets: ranges ("100:150", "100:*") and comma groups
+// ("1,5,10:20"), optionally after "uid:" ("uid:10:20").
+func IsIDSpec(id string) bool {
+	value := strings.TrimSpace(id)
+	if IsRef(value) {
+		return false
+	}
+	if strings.HasPrefix(strings.ToLower(value), "uid:") {
+		value = value[len("uid:"):]
+	}
+	return strings.ContainsAny(value, ":,*")
+}
+
+// parseIDSpec parses comma-separated sequence numbers and ranges. A range
+// end of * means the last message in the mailbox.
+func parseIDSpec(spec string) (imap.SeqSet, error) {
+	var set imap.SeqSet
+	err := parseRanges(spec, func(start, stop uint32) {
+		set.AddRange(start, stop)
+	})
+	return set, err
+}
+
+// parseUIDSpec is parseIDSpec for UIDs, with spec being the part after
+// "uid:".
+func parseUIDSpec(spec string) (imap.UIDSet, error) {
+	var set imap.UIDSet
+	err := parseRanges(spec, func(start, stop uint32) {
+		set.AddRange(imap.UID(start), imap.UID(stop))
+	})
+	return set, err
+}
+
+// parseRanges calls add for every number or range in spec. Single numbers
+// are passed as start == stop; 0 stands for *.
+func parseRanges(spec string, add func(start, stop uint32)) error {
+	if strings.TrimSpace(spec) == "" {
+		return fmt.Errorf("message ID cannot be empty")
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, ":")
+		start, err := parseSetNumber(first)
+		if err != nil {
+			return fmt.Errorf("invalid ID range %q in %q (expected N, N:M or N:*)", part, spec)
+		}
+		stop := start
+		if isRange {
+			if stop, err = parseSetNumber(last); err != nil {
+				return fmt.Errorf("invalid ID range %q in %q (expected N, N:M or N:*)", part, spec)
+			}
+		}
+		add(start, stop)
+	}
+	return nil
+}
+
+// parseSetNumber parses a positive number, or * as 0.
+func parseSetNumber(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return uint32(n), nil
+}
+
+// ExpandIDs replaces ranges and comma groups in ids with the uid:<uid>
+// selectors of the messages they match in mailbox, in one SEARCH, so
+// callers can count and record the messages they act on. Other IDs are
+// kept; when there are no ranges ids is returned unchanged.
+func (c *Client) ExpandIDs(mailbox string, ids []string) ([]string, error) {
+	hasSpec := false
+	for _, id := range ids {
+		if IsIDSpec(id) {
+			hasSpec = true
+			break
+		}
+	}
+	if !hasSpec {
+		return ids, nil
+	}
+
+	numSet, err := buildNumSetFromIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.SelectMailbox(mailbox); err != nil {
+		return nil, err
+	}
+
+	criteria := &imap.SearchCriteria{}
+	switch set := numSet.(type) {
+	case imap.UIDSet:
+		criteria.UID = []imap.UIDSet{set}
+	case imap.SeqSet:
+		criteria.SeqNum = []imap.SeqSet{set}
+	}
+	data, err := c.client.UIDSearch(criteria, nil).Wait()
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	uids := data.AllUIDs()
+	if len(uids) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, strings.Join(ids, " "))
+	}
+	expanded := make([]string, len(uids))
+	for i, uid := range uids {
+		expanded[i] = fmt.Sprintf("uid:%d", uid)
+	}
+	return expanded, nil
+}
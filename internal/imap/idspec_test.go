@@ -0,0 +1,116 @@
+package imap
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+func TestParseIDSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "100:150", want: "100:150"},
+		{spec: "100:*", want: "100:*"},
+		{spec: "1,5,10:20", want: "1,5,10:20"},
+		{spec: " 3 , 7:9 ", want: "3,7:9"},
+		{spec: "20:10", want: "10:20"},
+		{spec: "*", want: "*"},
+		{spec: "", wantErr: true},
+		{spec: "0:5", wantErr: true},
+		{spec: "1,,2", wantErr: true},
+		{spec: "1:2:3", wantErr: true},
+		{spec: "a:b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		set, err := parseIDSpec(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseIDSpec(%q) = %v, want an error", tt.spec, set)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseIDSpec(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if got := set.String(); got != tt.want {
+			t.Errorf("parseIDSpec(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestIsIDSpec(t *testing.T) {
+	for id, want := range map[string]bool{
+		"42":        false,
+		"uid:42":    false,
+		"ref:YWJj":  false,
+		"1:5":       true,
+		"1,2":       true,
+		"100:*":     true,
+		"uid:10:20": true,
+		"UID:1,2":   true,
+	} {
+		if got := IsIDSpec(id); got != want {
+			t.Errorf("IsIDSpec(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestBuildNumSetFromIDSpecs(t *testing.T) {
+	numSet, err := buildNumSetFromIDs([]string{"3", "10:12,20"})
+	if err != nil {
+		t.Fatalf("buildNumSetFromIDs() error = %v", err)
+	}
+	if seqSet, ok := numSet.(imap.SeqSet); !ok || seqSet.String() != "3,10:12,20" {
+		t.Errorf("buildNumSetFromIDs() = %v, want the sequence set 3,10:12,20", numSet)
+	}
+
+	numSet, err = buildNumSetFromIDs([]string{"uid:7", "uid:100:*"})
+	if err != nil {
+		t.Fatalf("buildNumSetFromIDs(uid) error = %v", err)
+	}
+	if uidSet, ok := numSet.(imap.UIDSet); !ok || uidSet.String() != "7,100:*" {
+		t.Errorf("buildNumSetFromIDs(uid) = %v, want the UID set 7,100:*", numSet)
+	}
+
+	if _, err := buildNumSetFromIDs([]string{"1:3", "uid:4"}); err == nil {
+		t.Error("expected an error when mixing a sequence range with a UID")
+	}
+}
+
+func TestExpandIDs(t *testing.T) {
+	client, user := newTestServer(t)
+	for i := 0; i < 5; i++ {
+		appendTestMessage(t, user, "INBOX", peekTestMessage)
+	}
+
+	ids, err := client.ExpandIDs("INBOX", []string{"1", "3:*"})
+	if err != nil {
+		t.Fatalf("ExpandIDs() error = %v", err)
+	}
+	if want := []string{"uid:1", "uid:3", "uid:4", "uid:5"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ExpandIDs() = %v, want %v", ids, want)
+	}
+
+	plain := []string{"2", "uid:4"}
+	if ids, err := client.ExpandIDs("INBOX", plain); err != nil || !reflect.DeepEqual(ids, plain) {
+		t.Errorf("ExpandIDs(%v) = %v, %v; want the IDs unchanged", plain, ids, err)
+	}
+
+	if err := client.DeleteMessages("INBOX", []string{"uid:2:4"}, true); err != nil {
+		t.Fatalf("DeleteMessages(range) error = %v", err)
+	}
+	if ids, err := client.ExpandIDs("INBOX", []string{"uid:1:*"}); err != nil || !reflect.DeepEqual(ids, []string{"uid:1", "uid:5"}) {
+		t.Errorf("ExpandIDs() after deleting uid:2:4 = %v, %v; want uid:1 and uid:5", ids, err)
+	}
+
+	if _, err := client.ExpandIDs("INBOX", []string{"uid:50:60"}); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("ExpandIDs() for an empty range error = %v, want ErrMessageNotFound", err)
+	}
+}
@@ -0,0 +1,46 @@
+package imap
+
+import "testing"
+
+func TestMarkAnswered(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", peekTestMessage)
+
+	if err := client.MarkAnswered("INBOX", []string{"uid:1"}); err != nil {
+		t.Fatalf("MarkAnswered() error = %v", err)
+	}
+	if flags := serverFlags(t, client, "INBOX", 1); !containsFlag(flags, "\\Answered") {
+		t.Errorf("flags = %v, want \\Answered", flags)
+	}
+}
+
+func TestSetKeyword(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", peekTestMessage)
+	appendTestMessage(t, user, "INBOX", peekTestMessage)
+
+	if err := client.SetKeyword("INBOX", []string{"uid:1", "uid:2"}, "$Junk", true); err != nil {
+		t.Fatalf("SetKeyword(add) error = %v", err)
+	}
+	for _, uid := range []uint32{1, 2} {
+		if flags := serverFlags(t, client, "INBOX", uid); !containsFlag(flags, "$Junk") {
+			t.Errorf("flags of uid %d = %v, want $Junk", uid, flags)
+		}
+	}
+
+	if err := client.SetKeyword("INBOX", []string{"uid:2"}, "$Junk", false); err != nil {
+		t.Fatalf("SetKeyword(remove) error = %v", err)
+	}
+	if flags := serverFlags(t, client, "INBOX", 2); containsFlag(flags, "$Junk") {
+		t.Errorf("flags of uid 2 = %v, want no $Junk", flags)
+	}
+	if flags := serverFlags(t, client, "INBOX", 1); !containsFlag(flags, "$Junk") {
+		t.Errorf("flags of uid 1 = %v, want $Junk kept", flags)
+	}
+
+	for _, keyword := range []string{"", "$Junk \\Seen", "(x)", "\\Seen\r\nA1 LOGOUT"} {
+		if err := client.SetKeyword("INBOX", []string{"uid:1"}, keyword, true); err == nil {
+			t.Errorf("SetKeyword(%q) expected error", keyword)
+		}
+	}
+}
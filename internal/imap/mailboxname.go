@@ -0,0 +1,137 @@
+package imap
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// mutf7 is the base64 alphabet of modified UTF-7 (RFC 3501 section
+// 5.1.3), which uses "," where standard base64 has "/".
+var mutf7 = base64.NewEncoding("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+,").WithPadding(base64.NoPadding)
+
+// EncodeMailboxName encodes a mailbox name in modified UTF-7, the form
+// IMAP servers without UTF8=ACCEPT use on the wire: "Reçus" becomes
+// "Re&AOc-us" and "&" becomes "&-".
+func EncodeMailboxName(name string) string {
+	var sb strings.Builder
+	var run []rune
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		units := utf16.Encode(run)
+		buf := make([]byte, 2*len(units))
+		for i, u := range units {
+			buf[2*i], buf[2*i+1] = byte(u>>8), byte(u)
+		}
+		sb.WriteByte('&')
+		sb.WriteString(mutf7.EncodeToString(buf))
+		sb.WriteByte('-')
+		run = run[:0]
+	}
+
+	for _, r := range name {
+		if r >= 0x20 && r <= 0x7e {
+			flush()
+			if r == '&' {
+				sb.WriteString("&-")
+			} else {
+				sb.WriteRune(r)
+			}
+			continue
+		}
+		run = append(run, r)
+	}
+	flush()
+	return sb.String()
+}
+
+// DecodeMailboxName reverses EncodeMailboxName. It fails on anything that
+// is not valid modified UTF-7, such as raw non-ASCII bytes or an
+// unterminated "&" sequence.
+func DecodeMailboxName(s string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch < 0x20 || ch > 0x7e {
+			return "", fmt.Errorf("invalid modified UTF-7 in %q: byte %#x", s, ch)
+		}
+		if ch != '&' {
+			sb.WriteByte(ch)
+			continue
+		}
+
+		end := strings.IndexByte(s[i+1:], '-')
+		if end < 0 {
+			return "", fmt.Errorf("invalid modified UTF-7 in %q: unterminated &", s)
+		}
+		encoded := s[i+1 : i+1+end]
+		i += end + 1
+		if encoded == "" {
+			sb.WriteByte('&')
+			continue
+		}
+
+		buf, err := mutf7.DecodeString(encoded)
+		if err != nil || len(buf)%2 != 0 {
+			return "", fmt.Errorf("invalid modified UTF-7 in %q: bad sequence &%s-", s, encoded)
+		}
+		units := make([]uint16, len(buf)/2)
+		for j := range units {
+			units[j] = uint16(buf[2*j])<<8 | uint16(buf[2*j+1])
+		}
+		for _, r := range utf16.Decode(units) {
+			// Printable ASCII must not be encoded, and pairs must be whole
+			if r == utf8.RuneError || (r >= 0x20 && r <= 0x7e) {
+				return "", fmt.Errorf("invalid modified UTF-7 in %q: bad sequence &%s-", s, encoded)
+			}
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String(), nil
+}
+
+// NormalizeMailboxName checks a mailbox name given by the user and returns
+// it in the form the IMAP library expects: plain UTF-8, which it encodes
+// for the wire itself. A name pasted in its wire form ("Re&AOc-us") is
+// decoded first so it is not encoded twice.
+func NormalizeMailboxName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("mailbox name is empty")
+	}
+	if !utf8.ValidString(name) {
+		return "", fmt.Errorf("mailbox name %q is not valid UTF-8", name)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return "", fmt.Errorf("mailbox name %q contains a control character", name)
+		}
+	}
+
+	if strings.Contains(name, "&") {
+		if decoded, err := DecodeMailboxName(name); err == nil {
+			name = decoded
+		}
+	}
+	return name, nil
+}
+
+// validateNewMailboxName rejects names a server would refuse or
+// misinterpret when creating a mailbox: LIST wildcards and empty levels
+// of the "/" hierarchy Proton uses, as in "Projects//2024".
+func validateNewMailboxName(name string) error {
+	if strings.ContainsAny(name, "*%") {
+		return fmt.Errorf("mailbox name %q must not contain * or %%", name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.TrimSpace(part) == "" {
+			return fmt.Errorf("mailbox name %q has an empty folder level", name)
+		}
+	}
+	return nil
+}
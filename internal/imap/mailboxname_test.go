@@ -0,0 +1,103 @@
+package imap
+
+import (
+	"testing"
+)
+
+func TestMailboxNameRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"INBOX", "INBOX"},
+		{"Projects/2024", "Projects/2024"},
+		{"Reçus", "Re&AOc-us"},
+		{"R&D", "R&-D"},
+		{"~peter/mail/台北/日本語", "~peter/mail/&U,BTFw-/&ZeVnLIqe-"},
+		{"Inbox 📬", "Inbox &2D3c7A-"},
+	}
+	for _, tt := range tests {
+		if got := EncodeMailboxName(tt.name); got != tt.encoded {
+			t.Errorf("EncodeMailboxName(%q) = %q, want %q", tt.name, got, tt.encoded)
+		}
+		got, err := DecodeMailboxName(tt.encoded)
+		if err != nil || got != tt.name {
+			t.Errorf("DecodeMailboxName(%q) = %q, %v; want %q", tt.encoded, got, err, tt.name)
+		}
+	}
+}
+
+func TestDecodeMailboxNameInvalid(t *testing.T) {
+	for _, s := range []string{
+		"Re&AOc",   // unterminated
+		"Re&AO-",   // odd number of bytes
+		"&AGEAYg-", // encodes printable ASCII ("ab")
+		"&2D0-",    // lone surrogate
+		"Reçus",    // raw UTF-8
+	} {
+		if got, err := DecodeMailboxName(s); err == nil {
+			t.Errorf("DecodeMailboxName(%q) = %q, want an error", s, got)
+		}
+	}
+}
+
+func TestNormalizeMailboxName(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{" Reçus ", "Reçus", false},
+		{"Re&AOc-us", "Reçus", false},
+		{"Tom & Jerry", "Tom & Jerry", false},
+		{"AT&T", "AT&T", false},
+		{"", "", true},
+		{"Bad\nName", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeMailboxName(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeMailboxName(%q) = %q, %v; want %q (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	for _, name := range []string{"Projects//2024", "/Projects", "Folders/*"} {
+		if err := validateNewMailboxName(name); err == nil {
+			t.Errorf("validateNewMailboxName(%q) = nil, want an error", name)
+		}
+	}
+}
+
+func TestNonASCIIMailboxOnServer(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", "From: a@example.com\nSubject: receipt\n\nBody.\n")
+
+	for _, name := range []string{"Reçus", "Projects/2024"} {
+		if err := client.CreateMailbox(name); err != nil {
+			t.Fatalf("CreateMailbox(%q) error = %v", name, err)
+		}
+	}
+
+	mailboxes, err := client.ListMailboxes()
+	if err != nil {
+		t.Fatalf("ListMailboxes() error = %v", err)
+	}
+	found := false
+	for _, mb := range mailboxes {
+		if mb.Name == "Reçus" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("ListMailboxes() = %+v, want Reçus decoded", mailboxes)
+	}
+
+	if err := client.MoveMessages("INBOX", []string{"1"}, "Reçus"); err != nil {
+		t.Fatalf("MoveMessages() error = %v", err)
+	}
+	// The wire form names the same mailbox
+	status, err := client.SelectMailbox("Re&AOc-us")
+	if err != nil || status.Messages != 1 {
+		t.Errorf("SelectMailbox(Re&AOc-us) = %+v, %v; want the moved message", status, err)
+	}
+}
@@ -0,0 +1,118 @@
+package imap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+const peekTestMessage = `From: Alice <alice@example.com>
+To: user@example.com
+Subject: Quarterly numbers
+Message-ID: <q1@example.com>
+Date: Mon, 03 Jun 2024 09:00:00 +0000
+
+Numbers attached.
+`
+
+func TestFullBodySection(t *testing.T) {
+	if s := fullBodySection(true); len(s) != 1 || !s[0].Peek {
+		t.Errorf("fullBodySection(true) = %+v, want one BODY.PEEK[] section", s)
+	}
+	if s := fullBodySection(false); len(s) != 1 || s[0].Peek {
+		t.Errorf("fullBodySection(false) = %+v, want one BODY[] section", s)
+	}
+}
+
+func TestPeekLeavesFlagsUnchanged(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", peekTestMessage)
+	appendTestMessage(t, user, "INBOX", peekTestMessage)
+
+	msg, err := client.PeekMessage("INBOX", "uid:1")
+	if err != nil {
+		t.Fatalf("PeekMessage() error = %v", err)
+	}
+	if msg.Subject != "Quarterly numbers" || len(msg.RawBody) == 0 {
+		t.Errorf("PeekMessage() = subject %q, %d body bytes", msg.Subject, len(msg.RawBody))
+	}
+	if flags := serverFlags(t, client, "INBOX", 1); containsFlag(flags, "\\Seen") {
+		t.Errorf("flags after PeekMessage = %v, want no \\Seen", flags)
+	}
+
+	if _, err := client.PeekMessages("INBOX", []string{"1", "2"}); err != nil {
+		t.Fatalf("PeekMessages() error = %v", err)
+	}
+	if flags := serverFlags(t, client, "INBOX", 2); containsFlag(flags, "\\Seen") {
+		t.Errorf("flags after PeekMessages = %v, want no \\Seen", flags)
+	}
+
+	if _, err := client.GetThread("INBOX", "uid:1", true); err != nil {
+		t.Fatalf("GetThread(peek) error = %v", err)
+	}
+	for _, uid := range []uint32{1, 2} {
+		if flags := serverFlags(t, client, "INBOX", uid); containsFlag(flags, "\\Seen") {
+			t.Errorf("flags of uid %d after GetThread(peek) = %v, want no \\Seen", uid, flags)
+		}
+	}
+
+	// A normal read still marks the message as seen
+	if _, err := client.GetMessage("INBOX", "uid:1"); err != nil {
+		t.Fatalf("GetMessage() error = %v", err)
+	}
+	if flags := serverFlags(t, client, "INBOX", 1); !containsFlag(flags, "\\Seen") {
+		t.Errorf("flags after GetMessage = %v, want \\Seen", flags)
+	}
+}
+
+func TestGetHeadersFetchesOnlyFields(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", peekTestMessage)
+	appendTestMessage(t, user, "INBOX", peekTestMessage)
+
+	msgs, err := client.GetHeaders("INBOX", []string{"uid:2", "uid:1"}, []string{"Subject", "Message-ID"})
+	if err != nil {
+		t.Fatalf("GetHeaders() error = %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].UID != 2 || msgs[1].UID != 1 {
+		t.Fatalf("GetHeaders() returned %d messages, want uid 2 then 1", len(msgs))
+	}
+
+	header := string(msgs[0].RawBody)
+	if !strings.Contains(header, "Subject: Quarterly numbers") || !strings.Contains(header, "Message-ID: <q1@example.com>") {
+		t.Errorf("header block = %q, want the requested fields", header)
+	}
+	if strings.Contains(header, "From:") || strings.Contains(header, "Numbers attached") {
+		t.Errorf("header block = %q, want no other fields and no body", header)
+	}
+	if msgs[0].From == "" || msgs[0].Subject != "Quarterly numbers" {
+		t.Errorf("envelope = from %q, subject %q", msgs[0].From, msgs[0].Subject)
+	}
+
+	for _, uid := range []uint32{1, 2} {
+		if flags := serverFlags(t, client, "INBOX", uid); containsFlag(flags, "\\Seen") {
+			t.Errorf("flags of uid %d after GetHeaders = %v, want no \\Seen", uid, flags)
+		}
+	}
+}
+
+func TestGetFlags(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", peekTestMessage, imap.FlagFlagged, "$Label1")
+	appendTestMessage(t, user, "INBOX", peekTestMessage)
+
+	msgs, err := client.GetFlags("INBOX", []string{"1", "2"})
+	if err != nil || len(msgs) != 2 {
+		t.Fatalf("GetFlags() = %d messages, %v", len(msgs), err)
+	}
+	if !containsFlag(msgs[0].Flags, "\\Flagged") || !containsFlag(msgs[0].Flags, "$Label1") {
+		t.Errorf("flags of message 1 = %v, want \\Flagged and $Label1", msgs[0].Flags)
+	}
+	if len(msgs[1].Flags) != 0 || msgs[1].Subject != "Quarterly numbers" {
+		t.Errorf("message 2 = flags %v, subject %q; want no flags", msgs[1].Flags, msgs[1].Subject)
+	}
+	if flags := serverFlags(t, client, "INBOX", 1); containsFlag(flags, "\\Seen") {
+		t.Errorf("flags after GetFlags = %v, want no \\Seen", flags)
+	}
+}
@@ -0,0 +1,122 @@
+package imap
+
+import (
+	"io"
+	"sync"
+
+	"github.com/bscott/pm-cli/internal/config"
+)
+
+const (
+	// DefaultConcurrency is the default number of parallel IMAP connections
+	// used for per-mailbox fan-out, such as a STATUS for every mailbox.
+	DefaultConcurrency = 4
+	// MaxConcurrency caps parallel connections. Proton Bridge limits
+	// concurrent IMAP sessions per account, and going over the limit makes
+	// logins fail rather than queue.
+	MaxConcurrency = 8
+)
+
+// clampConcurrency bounds the requested worker count to [1, MaxConcurrency]
+// and never starts more workers than there are jobs.
+func clampConcurrency(concurrency, jobs int) int {
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+	if concurrency > MaxConcurrency {
+		concurrency = MaxConcurrency
+	}
+	if concurrency > jobs {
+		concurrency = jobs
+	}
+	return concurrency
+}
+
+// RunParallel runs fn for jobs 0..n-1 over up to concurrency separate IMAP
+// connections. It suits commands that must be issued once per mailbox;
+// messages within one mailbox are cheaper to fetch with a single FETCH on
+// one connection. Each worker owns one connected Client, so fn must only use
+// the client it is given. Results and per-job errors are returned in job
+// order. When no extra connection can be opened, the jobs run one by one on
+// fallback instead; without a fallback they fail with the connection error,
+// which is also returned.
+func RunParallel[T any](cfg *config.Config, fallback *Client, concurrency, n int, fn func(c *Client, i int) (T, error)) ([]T, []error, error) {
+	connect := func() (*Client, error) {
+		client, err := NewClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.Connect(); err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+	var rest func(i int) (T, error)
+	if fallback != nil {
+		rest = func(i int) (T, error) { return fn(fallback, i) }
+	}
+	return runPool(concurrency, n, connect, rest, fn)
+}
+
+// runPool is the connection-agnostic core of RunParallel. Jobs left over
+// because no worker connected go to rest, or fail when rest is nil.
+func runPool[C io.Closer, T any](concurrency, n int, connect func() (C, error), rest func(i int) (T, error), fn func(c C, i int) (T, error)) ([]T, []error, error) {
+	results := make([]T, n)
+	errs := make([]error, n)
+	if n == 0 {
+		return results, errs, nil
+	}
+
+	jobs := make(chan int, n)
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		connectErr error
+	)
+
+	workers := clampConcurrency(concurrency, n)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			conn, err := connect()
+			if err != nil {
+				// Leave the jobs to workers that did connect
+				mu.Lock()
+				if connectErr == nil {
+					connectErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			defer conn.Close()
+
+			for i := range jobs {
+				results[i], errs[i] = fn(conn, i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// A worker that connected drains every job, so any left means none did
+	if len(jobs) == 0 {
+		return results, errs, nil
+	}
+	for i := range jobs {
+		if rest != nil {
+			results[i], errs[i] = rest(i)
+		} else {
+			errs[i] = connectErr
+		}
+	}
+	if rest != nil {
+		return results, errs, nil
+	}
+	return results, errs, connectErr
+}
@@ -0,0 +1,195 @@
+package imap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/config"
+)
+
+type fakeConn struct {
+	open *int32
+}
+
+func (f *fakeConn) Close() error {
+	atomic.AddInt32(f.open, -1)
+	return nil
+}
+
+func TestClampConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		jobs        int
+		want        int
+	}{
+		{"default when zero", 0, 100, DefaultConcurrency},
+		{"default when negative", -3, 100, DefaultConcurrency},
+		{"capped at max", 50, 100, MaxConcurrency},
+		{"fewer jobs than workers", 6, 2, 2},
+		{"requested value", 3, 10, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clampConcurrency(tt.concurrency, tt.jobs); got != tt.want {
+				t.Errorf("clampConcurrency(%d, %d) = %d, want %d", tt.concurrency, tt.jobs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunPoolPreservesOrderAndBoundsConnections(t *testing.T) {
+	var open, peak int32
+	var mu sync.Mutex
+	connect := func() (*fakeConn, error) {
+		n := atomic.AddInt32(&open, 1)
+		mu.Lock()
+		if n > peak {
+			peak = n
+		}
+		mu.Unlock()
+		return &fakeConn{open: &open}, nil
+	}
+
+	const jobs = 40
+	results, errs, err := runPool(20, jobs, connect, nil, func(c *fakeConn, i int) (string, error) {
+		// Finish out of order
+		time.Sleep(time.Duration(jobs-i) * 100 * time.Microsecond)
+		if i == 7 {
+			return "", errors.New("boom")
+		}
+		return fmt.Sprintf("job-%d", i), nil
+	})
+	if err != nil {
+		t.Fatalf("runPool() error = %v", err)
+	}
+
+	for i := 0; i < jobs; i++ {
+		if i == 7 {
+			if errs[i] == nil {
+				t.Error("errs[7] should be set")
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("errs[%d] = %v", i, errs[i])
+		}
+		if want := fmt.Sprintf("job-%d", i); results[i] != want {
+			t.Errorf("results[%d] = %q, want %q", i, results[i], want)
+		}
+	}
+
+	if peak > MaxConcurrency {
+		t.Errorf("peak connections = %d, want <= %d", peak, MaxConcurrency)
+	}
+	if open != 0 {
+		t.Errorf("%d connection(s) left open", open)
+	}
+}
+
+func TestRunPoolConnectFailures(t *testing.T) {
+	t.Run("some workers connect", func(t *testing.T) {
+		var open, attempts int32
+		connect := func() (*fakeConn, error) {
+			if atomic.AddInt32(&attempts, 1) > 1 {
+				return nil, errors.New("too many connections")
+			}
+			atomic.AddInt32(&open, 1)
+			return &fakeConn{open: &open}, nil
+		}
+
+		results, _, err := runPool(4, 10, connect, nil, func(c *fakeConn, i int) (int, error) {
+			return i * i, nil
+		})
+		if err != nil {
+			t.Fatalf("runPool() error = %v, want jobs drained by connected worker", err)
+		}
+		if results[9] != 81 {
+			t.Errorf("results[9] = %d, want 81", results[9])
+		}
+	})
+
+	t.Run("no workers connect", func(t *testing.T) {
+		connect := func() (*fakeConn, error) {
+			return nil, errors.New("login failed")
+		}
+
+		_, errs, err := runPool(4, 10, connect, nil, func(c *fakeConn, i int) (int, error) {
+			return i, nil
+		})
+		if err == nil {
+			t.Error("runPool() should fail when no connection can be opened")
+		}
+		for i, e := range errs {
+			if e == nil {
+				t.Errorf("errs[%d] = nil for a job no worker ran", i)
+			}
+		}
+	})
+
+	t.Run("no workers connect, with fallback", func(t *testing.T) {
+		connect := func() (*fakeConn, error) {
+			return nil, errors.New("login failed")
+		}
+		rest := func(i int) (int, error) {
+			return -i, nil
+		}
+
+		results, errs, err := runPool(4, 10, connect, rest, func(c *fakeConn, i int) (int, error) {
+			return i, nil
+		})
+		if err != nil {
+			t.Fatalf("runPool() error = %v, want jobs run on the fallback", err)
+		}
+		for i := range results {
+			if errs[i] != nil || results[i] != -i {
+				t.Errorf("job %d = (%d, %v), want (%d, nil)", i, results[i], errs[i], -i)
+			}
+		}
+	})
+}
+
+func TestRunParallelFallsBackWhenDialFails(t *testing.T) {
+	client, _ := newTestServer(t)
+
+	// Nothing listens on the port, so every extra connection is refused
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	ln.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Bridge.Email = testUser
+	cfg.Bridge.IMAPHost = "127.0.0.1"
+	cfg.Bridge.IMAPPort, _ = strconv.Atoi(port)
+	cfg.Bridge.IMAPSecurity = config.SecurityNone
+	cfg.Bridge.CredentialStore = config.CredentialStoreFile
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv(config.SecretEnvVar, "test-secret")
+	if err := cfg.SetPassword(testPassword); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+
+	statuses, errs, err := RunParallel(cfg, client, 4, 2, func(c *Client, i int) (*MailboxStatus, error) {
+		return c.Status("INBOX")
+	})
+	if err != nil {
+		t.Fatalf("RunParallel() error = %v, want jobs run on the fallback", err)
+	}
+	for i := range statuses {
+		if errs[i] != nil || statuses[i] == nil {
+			t.Errorf("job %d = (%v, %v), want a status", i, statuses[i], errs[i])
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package imap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-message/charset"
+)
+
+// MessagePreview is the decoded start of a message's first text part.
+type MessagePreview struct {
+	Text string
+	HTML bool
+}
+
+// previewPart locates the text part a preview is taken from.
+type previewPart struct {
+	path     []int
+	encoding string
+	charset  string
+	html     bool
+}
+
+// Previews fetches the first size bytes of the first text part of each
+// message in uids, using BODY.PEEK so messages are not marked read. The
+// BODYSTRUCTURE is fetched first so that the right part is read and decoded;
+// messages are then grouped by part number, one partial fetch per group.
+func (c *Client) Previews(mailbox string, uids []uint32, size int64) (map[uint32]MessagePreview, error) {
+	result := make(map[uint32]MessagePreview)
+	if len(uids) == 0 {
+		return result, nil
+	}
+
+	if _, err := c.SelectMailbox(mailbox); err != nil {
+		return nil, err
+	}
+
+	var uidSet imap.UIDSet
+	for _, uid := range uids {
+		uidSet.AddNum(imap.UID(uid))
+	}
+
+	structures, err := c.client.Fetch(uidSet, &imap.FetchOptions{UID: true, BodyStructure: &imap.FetchItemBodyStructure{}}).Collect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message structure: %w", err)
+	}
+
+	parts := make(map[uint32]previewPart)
+	groups := make(map[string][]uint32)
+	for _, msg := range structures {
+		if msg.BodyStructure == nil {
+			continue
+		}
+		part, ok := findPreviewPart(msg.BodyStructure)
+		if !ok {
+			continue
+		}
+		uid := uint32(msg.UID)
+		parts[uid] = part
+		key := fmt.Sprint(part.path)
+		groups[key] = append(groups[key], uid)
+	}
+
+	for _, group := range groups {
+		path := parts[group[0]].path
+		section := &imap.FetchItemBodySection{
+			Part:    path,
+			Peek:    true,
+			Partial: &imap.SectionPartial{Offset: 0, Size: size},
+		}
+
+		var set imap.UIDSet
+		for _, uid := range group {
+			set.AddNum(imap.UID(uid))
+		}
+
+		messages, err := c.client.Fetch(set, &imap.FetchOptions{UID: true, BodySection: []*imap.FetchItemBodySection{section}}).Collect()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch previews: %w", err)
+		}
+		for _, msg := range messages {
+			uid := uint32(msg.UID)
+			part := parts[uid]
+			result[uid] = MessagePreview{
+				Text: decodePreview(msg.FindBodySection(section), part.encoding, part.charset),
+				HTML: part.html,
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// findPreviewPart returns the first inline text/plain part, falling back to
+// the first text/html part.
+func findPreviewPart(bs imap.BodyStructure) (previewPart, bool) {
+	var plain, htmlPart *previewPart
+	bs.Walk(func(path []int, part imap.BodyStructure) bool {
+		single, ok := part.(*imap.BodyStructureSinglePart)
+		if !ok {
+			return true
+		}
+		if disp := single.Disposition(); disp != nil && strings.EqualFold(disp.Value, "attachment") {
+			return false
+		}
+		p := previewPart{
+			path:     append([]int(nil), path...),
+			encoding: strings.ToLower(single.Encoding),
+			charset:  single.Params["charset"],
+		}
+		switch single.MediaType() {
+		case "text/plain":
+			if plain == nil {
+				plain = &p
+			}
+		case "text/html":
+			if htmlPart == nil {
+				p.html = true
+				htmlPart = &p
+			}
+		}
+		return true
+	})
+
+	if plain != nil {
+		return *plain, true
+	}
+	if htmlPart != nil {
+		return *htmlPart, true
+	}
+	return previewPart{}, false
+}
+
+// decodePreview undoes the transfer encoding and charset of a truncated
+// part. Decoding errors caused by the truncation are ignored.
+func decodePreview(data []byte, encoding, charsetName string) string {
+	switch encoding {
+	case "quoted-printable":
+		decoded, _ := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(data)))
+		data = decoded
+	case "base64":
+		clean := strings.Map(func(r rune) rune {
+			if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+				return -1
+			}
+			return r
+		}, string(data))
+		clean = clean[:len(clean)-len(clean)%4]
+		decoded, err := base64.StdEncoding.DecodeString(clean)
+		if err != nil {
+			return ""
+		}
+		data = decoded
+	}
+
+	if charsetName != "" && !strings.EqualFold(charsetName, "utf-8") && !strings.EqualFold(charsetName, "us-ascii") {
+		if r, err := charset.Reader(charsetName, bytes.NewReader(data)); err == nil {
+			if decoded, err := io.ReadAll(r); err == nil {
+				data = decoded
+			}
+		}
+	}
+
+	// The cut may have split a multi-byte character
+	for i := 0; i < utf8.UTFMax-1 && len(data) > 0; i++ {
+		if r, _ := utf8.DecodeLastRune(data); r != utf8.RuneError {
+			break
+		}
+		data = data[:len(data)-1]
+	}
+	return strings.ToValidUTF8(string(data), "")
+}
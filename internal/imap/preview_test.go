@@ -0,0 +1,95 @@
+package imap
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+func TestFindPreviewPart(t *testing.T) {
+	plain := &imap.BodyStructureSinglePart{Type: "text", Subtype: "plain", Encoding: "QUOTED-PRINTABLE", Params: map[string]string{"charset": "utf-8"}}
+	htmlPart := &imap.BodyStructureSinglePart{Type: "text", Subtype: "html", Encoding: "base64"}
+	textAttachment := &imap.BodyStructureSinglePart{
+		Type:     "text",
+		Subtype:  "plain",
+		Extended: &imap.BodyStructureSinglePartExt{Disposition: &imap.BodyStructureDisposition{Value: "attachment"}},
+	}
+
+	tests := []struct {
+		name     string
+		bs       imap.BodyStructure
+		wantPath []int
+		wantHTML bool
+		wantOK   bool
+	}{
+		{"single part", plain, []int{1}, false, true},
+		{
+			"mixed with alternative",
+			&imap.BodyStructureMultiPart{Subtype: "mixed", Children: []imap.BodyStructure{
+				&imap.BodyStructureMultiPart{Subtype: "alternative", Children: []imap.BodyStructure{plain, htmlPart}},
+				&imap.BodyStructureSinglePart{Type: "application", Subtype: "pdf"},
+			}},
+			[]int{1, 1}, false, true,
+		},
+		{
+			"html only",
+			&imap.BodyStructureMultiPart{Subtype: "mixed", Children: []imap.BodyStructure{htmlPart}},
+			[]int{1}, true, true,
+		},
+		{
+			"text attachment is skipped",
+			&imap.BodyStructureMultiPart{Subtype: "mixed", Children: []imap.BodyStructure{textAttachment, htmlPart}},
+			[]int{2}, true, true,
+		},
+		{"no text", &imap.BodyStructureSinglePart{Type: "image", Subtype: "png"}, nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part, ok := findPreviewPart(tt.bs)
+			if ok != tt.wantOK {
+				t.Fatalf("findPreviewPart() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !reflect.DeepEqual(part.path, tt.wantPath) || part.html != tt.wantHTML {
+				t.Errorf("findPreviewPart() = %+v, want path %v html %v", part, tt.wantPath, tt.wantHTML)
+			}
+		})
+	}
+}
+
+func TestDecodePreview(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		encoding string
+		charset  string
+		want     string
+	}{
+		{"plain", "Hello there", "7bit", "utf-8", "Hello there"},
+		{"quoted-printable cut mid-escape", "Caf=C3=A9 au lait=\r\n and =C3", "quoted-printable", "utf-8", "Café au lait and "},
+		{"base64 cut mid-quantum", "SGVsbG8gd29ybGQh\r\nSGk", "base64", "", "Hello world!"},
+		{"latin-1", "Caf\xe9", "8bit", "iso-8859-1", "Café"},
+		{"split utf-8 character", "Caf\xc3", "8bit", "utf-8", "Caf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodePreview([]byte(tt.data), tt.encoding, tt.charset); got != tt.want {
+				t.Errorf("decodePreview() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewsWithoutConnection(t *testing.T) {
+	client := &Client{}
+
+	previews, err := client.Previews("INBOX", nil, 2048)
+	if err != nil || len(previews) != 0 {
+		t.Errorf("Previews(nil) = %v, %v; want empty map", previews, err)
+	}
+}
@@ -0,0 +1,406 @@
+package imap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+// rawSearchToken is one token of a raw IMAP search string. Quoted strings
+// are never treated as keys or parentheses.
+type rawSearchToken struct {
+	value  string
+	quoted bool
+}
+
+// rawSearchParser turns an RFC 3501 search string into SearchCriteria. The
+// criteria are re-encoded by go-imap, so user input never reaches the wire
+// verbatim.
+type rawSearchParser struct {
+	tokens []rawSearchToken
+	pos    int
+	now    time.Time
+}
+
+// parseRawSearch parses a raw IMAP SEARCH string such as
+// `SINCE 1-Jan-2024 NOT KEYWORD $Phishing`. OLDER and YOUNGER (RFC 5032) are
+// converted to SINCE/BEFORE relative to now, so they match whole days.
+func parseRawSearch(s string, now time.Time) (*imap.SearchCriteria, error) {
+	tokens, err := tokenizeRawSearch(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("raw search is empty")
+	}
+
+	p := &rawSearchParser{tokens: tokens, now: now}
+	criteria := &imap.SearchCriteria{}
+	for p.pos < len(p.tokens) {
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		andCriteria(criteria, key)
+	}
+	return criteria, nil
+}
+
+// andCriteria intersects src into dst. SearchCriteria.And resets Smaller
+// when src has none, so the previous limit is restored.
+func andCriteria(dst, src *imap.SearchCriteria) {
+	smaller := dst.Smaller
+	dst.And(src)
+	if src.Smaller == 0 {
+		dst.Smaller = smaller
+	}
+}
+
+// ValidateRawSearch checks a raw IMAP SEARCH string without running it.
+func ValidateRawSearch(s string) error {
+	_, err := parseRawSearch(s, time.Now())
+	return err
+}
+
+func tokenizeRawSearch(s string) ([]rawSearchToken, error) {
+	var tokens []rawSearchToken
+	for i := 0; i < len(s); {
+		ch := s[i]
+		switch {
+		case ch < 0x20 || ch == 0x7f:
+			if ch == '\t' {
+				i++
+				continue
+			}
+			return nil, errors.New("raw search contains control characters")
+		case ch == ' ':
+			i++
+		case ch == '(' || ch == ')':
+			tokens = append(tokens, rawSearchToken{value: string(ch)})
+			i++
+		case ch == '"':
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(s) {
+				c := s[i]
+				if c < 0x20 || c == 0x7f {
+					return nil, errors.New("raw search contains control characters")
+				}
+				if c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+					b.WriteByte(s[i+1])
+					i += 2
+					continue
+				}
+				if c == '"' {
+					closed = true
+					i++
+					break
+				}
+				b.WriteByte(c)
+				i++
+			}
+			if !closed {
+				return nil, errors.New("raw search has an unterminated quoted string")
+			}
+			tokens = append(tokens, rawSearchToken{value: b.String(), quoted: true})
+		default:
+			start := i
+			for i < len(s) && s[i] != ' ' && s[i] != '\t' && s[i] != '(' && s[i] != ')' && s[i] != '"' {
+				if s[i] < 0x20 || s[i] == 0x7f {
+					return nil, errors.New("raw search contains control characters")
+				}
+				i++
+			}
+			tokens = append(tokens, rawSearchToken{value: s[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+func (p *rawSearchParser) next() (rawSearchToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return rawSearchToken{}, false
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok, true
+}
+
+// arg returns the next token as the argument of key.
+func (p *rawSearchParser) arg(key string) (string, error) {
+	tok, ok := p.next()
+	if !ok || (!tok.quoted && (tok.value == "(" || tok.value == ")")) {
+		return "", fmt.Errorf("%s requires an argument", key)
+	}
+	return tok.value, nil
+}
+
+func (p *rawSearchParser) parseKey() (*imap.SearchCriteria, error) {
+	tok, ok := p.next()
+	if !ok {
+		return nil, errors.New("raw search ends where a search key was expected")
+	}
+	if tok.quoted {
+		return nil, fmt.Errorf("expected a search key, got string %q", tok.value)
+	}
+
+	switch tok.value {
+	case "(":
+		group := &imap.SearchCriteria{}
+		empty := true
+		for {
+			if p.pos >= len(p.tokens) {
+				return nil, errors.New(`raw search is missing ")"`)
+			}
+			if t := p.tokens[p.pos]; !t.quoted && t.value == ")" {
+				p.pos++
+				break
+			}
+			key, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			andCriteria(group, key)
+			empty = false
+		}
+		if empty {
+			return nil, errors.New("raw search has an empty group")
+		}
+		return group, nil
+	case ")":
+		return nil, errors.New(`raw search has an unexpected ")"`)
+	}
+
+	key := strings.ToUpper(tok.value)
+	switch key {
+	case "ALL":
+		return &imap.SearchCriteria{}, nil
+	case "ANSWERED":
+		return &imap.SearchCriteria{Flag: []imap.Flag{imap.FlagAnswered}}, nil
+	case "DELETED":
+		return &imap.SearchCriteria{Flag: []imap.Flag{imap.FlagDeleted}}, nil
+	case "DRAFT":
+		return &imap.SearchCriteria{Flag: []imap.Flag{imap.FlagDraft}}, nil
+	case "FLAGGED":
+		return &imap.SearchCriteria{Flag: []imap.Flag{imap.FlagFlagged}}, nil
+	case "SEEN":
+		return &imap.SearchCriteria{Flag: []imap.Flag{imap.FlagSeen}}, nil
+	case "RECENT":
+		return &imap.SearchCriteria{Flag: []imap.Flag{"\\Recent"}}, nil
+	case "NEW":
+		return &imap.SearchCriteria{Flag: []imap.Flag{"\\Recent"}, NotFlag: []imap.Flag{imap.FlagSeen}}, nil
+	case "OLD":
+		return &imap.SearchCriteria{NotFlag: []imap.Flag{"\\Recent"}}, nil
+	case "UNANSWERED":
+		return &imap.SearchCriteria{NotFlag: []imap.Flag{imap.FlagAnswered}}, nil
+	case "UNDELETED":
+		return &imap.SearchCriteria{NotFlag: []imap.Flag{imap.FlagDeleted}}, nil
+	case "UNDRAFT":
+		return &imap.SearchCriteria{NotFlag: []imap.Flag{imap.FlagDraft}}, nil
+	case "UNFLAGGED":
+		return &imap.SearchCriteria{NotFlag: []imap.Flag{imap.FlagFlagged}}, nil
+	case "UNSEEN":
+		return &imap.SearchCriteria{NotFlag: []imap.Flag{imap.FlagSeen}}, nil
+
+	case "KEYWORD", "UNKEYWORD":
+		value, err := p.arg(key)
+		if err != nil {
+			return nil, err
+		}
+		if !isFlagKeyword(value) {
+			return nil, fmt.Errorf("invalid keyword %q", value)
+		}
+		if key == "KEYWORD" {
+			return &imap.SearchCriteria{Flag: []imap.Flag{imap.Flag(value)}}, nil
+		}
+		return &imap.SearchCriteria{NotFlag: []imap.Flag{imap.Flag(value)}}, nil
+
+	case "BCC", "CC", "FROM", "SUBJECT", "TO":
+		value, err := p.arg(key)
+		if err != nil {
+			return nil, err
+		}
+		field := key[:1] + strings.ToLower(key[1:])
+		return &imap.SearchCriteria{Header: []imap.SearchCriteriaHeaderField{{Key: field, Value: value}}}, nil
+	case "HEADER":
+		field, err := p.arg(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.arg(key)
+		if err != nil {
+			return nil, err
+		}
+		return &imap.SearchCriteria{Header: []imap.SearchCriteriaHeaderField{{Key: field, Value: value}}}, nil
+	case "BODY":
+		value, err := p.arg(key)
+		if err != nil {
+			return nil, err
+		}
+		return &imap.SearchCriteria{Body: []string{value}}, nil
+	case "TEXT":
+		value, err := p.arg(key)
+		if err != nil {
+			return nil, err
+		}
+		return &imap.SearchCriteria{Text: []string{value}}, nil
+
+	case "BEFORE", "ON", "SINCE", "SENTBEFORE", "SENTON", "SENTSINCE":
+		value, err := p.arg(key)
+		if err != nil {
+			return nil, err
+		}
+		date, err := time.Parse("2-Jan-2006", value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid date %q (expected e.g. 1-Jan-2024)", key, value)
+		}
+		return dateCriteria(key, date), nil
+
+	case "OLDER", "YOUNGER":
+		value, err := p.arg(key)
+		if err != nil {
+			return nil, err
+		}
+		seconds, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid number of seconds %q", key, value)
+		}
+		t := p.now.Add(-time.Duration(seconds) * time.Second)
+		date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		if key == "OLDER" {
+			return &imap.SearchCriteria{Before: date}, nil
+		}
+		return &imap.SearchCriteria{Since: date}, nil
+
+	case "LARGER", "SMALLER":
+		value, err := p.arg(key)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s: invalid size %q", key, value)
+		}
+		if key == "LARGER" {
+			return &imap.SearchCriteria{Larger: n}, nil
+		}
+		return &imap.SearchCriteria{Smaller: n}, nil
+
+	case "NOT":
+		inner, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		return &imap.SearchCriteria{Not: []imap.SearchCriteria{*inner}}, nil
+	case "OR":
+		left, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		return &imap.SearchCriteria{Or: [][2]imap.SearchCriteria{{*left, *right}}}, nil
+
+	case "UID":
+		value, err := p.arg(key)
+		if err != nil {
+			return nil, err
+		}
+		ranges, err := parseNumRanges(value)
+		if err != nil {
+			return nil, fmt.Errorf("UID: %w", err)
+		}
+		var set imap.UIDSet
+		for _, r := range ranges {
+			set.AddRange(imap.UID(r[0]), imap.UID(r[1]))
+		}
+		return &imap.SearchCriteria{UID: []imap.UIDSet{set}}, nil
+	}
+
+	// A bare sequence set such as 1:100 or 5,7,9:*
+	if ranges, err := parseNumRanges(tok.value); err == nil {
+		var set imap.SeqSet
+		for _, r := range ranges {
+			set.AddRange(r[0], r[1])
+		}
+		return &imap.SearchCriteria{SeqNum: []imap.SeqSet{set}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown search key %q", tok.value)
+}
+
+// dateCriteria maps a date search key to SearchCriteria. ON and SENTON match
+// the whole day.
+func dateCriteria(key string, date time.Time) *imap.SearchCriteria {
+	nextDay := date.AddDate(0, 0, 1)
+	switch key {
+	case "BEFORE":
+		return &imap.SearchCriteria{Before: date}
+	case "SINCE":
+		return &imap.SearchCriteria{Since: date}
+	case "ON":
+		return &imap.SearchCriteria{Since: date, Before: nextDay}
+	case "SENTBEFORE":
+		return &imap.SearchCriteria{SentBefore: date}
+	case "SENTSINCE":
+		return &imap.SearchCriteria{SentSince: date}
+	default: // SENTON
+		return &imap.SearchCriteria{SentSince: date, SentBefore: nextDay}
+	}
+}
+
+// parseNumRanges parses an IMAP sequence set like "1:5,7,9:*". "*" is
+// returned as 0.
+func parseNumRanges(s string) ([][2]uint32, error) {
+	if s == "" {
+		return nil, errors.New("empty set")
+	}
+	var ranges [][2]uint32
+	for _, part := range strings.Split(s, ",") {
+		startStr, stopStr, isRange := strings.Cut(part, ":")
+		start, err := parseSetNum(startStr)
+		if err != nil {
+			return nil, err
+		}
+		stop := start
+		if isRange {
+			if stop, err = parseSetNum(stopStr); err != nil {
+				return nil, err
+			}
+		}
+		ranges = append(ranges, [2]uint32{start, stop})
+	}
+	return ranges, nil
+}
+
+func parseSetNum(s string) (uint32, error) {
+	if s == "*" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid set number %q", s)
+	}
+	return uint32(n), nil
+}
+
+// isFlagKeyword reports whether s is a valid flag keyword atom.
+func isFlagKeyword(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`(){%*"\]`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
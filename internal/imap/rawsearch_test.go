@@ -0,0 +1,119 @@
+package imap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+func TestParseRawSearch(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	t.Run("since and not keyword", func(t *testing.T) {
+		c, err := parseRawSearch("SINCE 1-Jan-2024 NOT KEYWORD $Phishing", now)
+		if err != nil {
+			t.Fatalf("parseRawSearch() error = %v", err)
+		}
+		if !c.Since.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Since = %v", c.Since)
+		}
+		if len(c.Not) != 1 || len(c.Not[0].Flag) != 1 || c.Not[0].Flag[0] != imap.FlagPhishing {
+			t.Errorf("Not = %+v", c.Not)
+		}
+	})
+
+	t.Run("or with nested group and quoted string", func(t *testing.T) {
+		c, err := parseRawSearch(`OR (FROM alice UNSEEN) SUBJECT "weekly \"report\""`, now)
+		if err != nil {
+			t.Fatalf("parseRawSearch() error = %v", err)
+		}
+		if len(c.Or) != 1 {
+			t.Fatalf("Or = %+v", c.Or)
+		}
+		left, right := c.Or[0][0], c.Or[0][1]
+		if len(left.Header) != 1 || left.Header[0].Key != "From" || left.Header[0].Value != "alice" {
+			t.Errorf("left.Header = %+v", left.Header)
+		}
+		if len(left.NotFlag) != 1 || left.NotFlag[0] != imap.FlagSeen {
+			t.Errorf("left.NotFlag = %+v", left.NotFlag)
+		}
+		if len(right.Header) != 1 || right.Header[0].Value != `weekly "report"` {
+			t.Errorf("right.Header = %+v", right.Header)
+		}
+	})
+
+	t.Run("header, sizes and sets", func(t *testing.T) {
+		c, err := parseRawSearch("header list-id example LARGER 1000 smaller 5000 UID 10:20,30 1:*", now)
+		if err != nil {
+			t.Fatalf("parseRawSearch() error = %v", err)
+		}
+		if len(c.Header) != 1 || c.Header[0].Key != "list-id" || c.Header[0].Value != "example" {
+			t.Errorf("Header = %+v", c.Header)
+		}
+		if c.Larger != 1000 || c.Smaller != 5000 {
+			t.Errorf("Larger/Smaller = %d/%d", c.Larger, c.Smaller)
+		}
+		if len(c.UID) != 1 || c.UID[0].String() != "10:20,30" {
+			t.Errorf("UID = %v", c.UID)
+		}
+		if len(c.SeqNum) != 1 || c.SeqNum[0].String() != "1:*" {
+			t.Errorf("SeqNum = %v", c.SeqNum)
+		}
+	})
+
+	t.Run("on covers one day", func(t *testing.T) {
+		c, err := parseRawSearch("ON 3-Mar-2024", now)
+		if err != nil {
+			t.Fatalf("parseRawSearch() error = %v", err)
+		}
+		if !c.Since.Equal(time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)) || !c.Before.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Since/Before = %v/%v", c.Since, c.Before)
+		}
+	})
+
+	t.Run("older and younger", func(t *testing.T) {
+		c, err := parseRawSearch("OLDER 86400 YOUNGER 864000", now)
+		if err != nil {
+			t.Fatalf("parseRawSearch() error = %v", err)
+		}
+		if !c.Before.Equal(time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Before = %v", c.Before)
+		}
+		if !c.Since.Equal(time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Since = %v", c.Since)
+		}
+	})
+}
+
+func TestParseRawSearchErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", "   "},
+		{"unknown key", "FROBNICATE"},
+		{"missing argument", "FROM"},
+		{"bad date", "SINCE 2024-01-01"},
+		{"bad size", "LARGER big"},
+		{"unterminated quote", `SUBJECT "oops`},
+		{"unbalanced open", "(SEEN"},
+		{"unbalanced close", "SEEN)"},
+		{"empty group", "()"},
+		{"crlf injection", "SEEN\r\nA1 LOGOUT"},
+		{"crlf in quoted string", "SUBJECT \"a\r\nb\""},
+		{"invalid keyword", `KEYWORD \Seen`},
+		{"string as key", `"SEEN"`},
+		{"bad uid set", "UID 0:5"},
+		{"or missing operand", "OR SEEN"},
+	}
+
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseRawSearch(tt.input, now); err == nil {
+				t.Errorf("parseRawSearch(%q) expected error", tt.input)
+			}
+		})
+	}
+}
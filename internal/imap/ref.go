@@ -0,0 +1,113 @@
+package imap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrStaleRef is returned when a ref: token names a mailbox whose
+// UIDVALIDITY has changed since the token was issued, so its UID may now
+// belong to a different message.
+var ErrStaleRef = errors.New("reference stale")
+
+// refPrefix marks a message ID that is a reference token.
+const refPrefix = "ref:"
+
+// Ref is a message reference that stays valid across expunges and works
+// from any mailbox: the mailbox, its UIDVALIDITY and the message UID.
+type Ref struct {
+	Mailbox     string
+	UIDValidity uint32
+	UID         uint32
+}
+
+// EncodeRef returns the token for a message: the mailbox, UIDVALIDITY and
+// UID joined with NUL bytes, in URL-safe base64 without padding. It is
+// reported as the ref field of JSON output and accepted as ref:<token>.
+func EncodeRef(mailbox string, uidValidity, uid uint32) string {
+	if uidValidity == 0 || uid == 0 {
+		return ""
+	}
+	raw := fmt.Sprintf("%s\x00%d\x00%d", mailbox, uidValidity, uid)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// IsRef reports whether id is a ref:<token> selector.
+func IsRef(id string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(id)), refPrefix)
+}
+
+// ParseRef decodes a ref:<token> selector. Padded and standard base64 are
+// accepted too, in case a token was re-encoded along the way.
+func ParseRef(id string) (Ref, error) {
+	value := strings.TrimSpace(id)
+	if !IsRef(value) {
+		return Ref{}, fmt.Errorf("invalid reference %q (expected ref:<token>)", id)
+	}
+	token := strings.TrimRight(value[len(refPrefix):], "=")
+	token = strings.NewReplacer("+", "-", "/", "_").Replace(token)
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Ref{}, fmt.Errorf("invalid reference %q: not base64", id)
+	}
+	parts := bytes.Split(raw, []byte{0})
+	if len(parts) != 3 || len(parts[0]) == 0 {
+		return Ref{}, fmt.Errorf("invalid reference %q", id)
+	}
+	uidValidity, err1 := strconv.ParseUint(string(parts[1]), 10, 32)
+	uid, err2 := strconv.ParseUint(string(parts[2]), 10, 32)
+	if err1 != nil || err2 != nil || uidValidity == 0 || uid == 0 {
+		return Ref{}, fmt.Errorf("invalid reference %q", id)
+	}
+	return Ref{Mailbox: string(parts[0]), UIDValidity: uint32(uidValidity), UID: uint32(uid)}, nil
+}
+
+// ResolveRefs replaces the ref:<token> selectors in ids with uid:<uid>
+// selectors and returns the mailbox they point into, after checking that
+// its UIDVALIDITY still matches. Other IDs are kept and refer to mailbox;
+// when there are no references mailbox and ids are returned unchanged.
+// All references, and any other IDs, must be for the same mailbox.
+func (c *Client) ResolveRefs(mailbox string, ids []string) (string, []string, error) {
+	var refMailbox string
+	var uidValidity uint32
+	plain := false
+	resolved := make([]string, len(ids))
+	for i, id := range ids {
+		if !IsRef(id) {
+			plain = true
+			resolved[i] = id
+			continue
+		}
+		ref, err := ParseRef(id)
+		if err != nil {
+			return "", nil, err
+		}
+		if refMailbox == "" {
+			refMailbox, uidValidity = ref.Mailbox, ref.UIDValidity
+		} else if ref.Mailbox != refMailbox || ref.UIDValidity != uidValidity {
+			return "", nil, fmt.Errorf("references point into different mailboxes (%s and %s); use one command per mailbox", refMailbox, ref.Mailbox)
+		}
+		resolved[i] = fmt.Sprintf("uid:%d", ref.UID)
+	}
+
+	if refMailbox == "" {
+		return mailbox, ids, nil
+	}
+	if plain && refMailbox != mailbox {
+		return "", nil, fmt.Errorf("cannot mix references to %s with message IDs in %s", refMailbox, mailbox)
+	}
+
+	state, err := c.MailboxState(refMailbox)
+	if err != nil {
+		return "", nil, err
+	}
+	if state.UIDValidity != uidValidity {
+		return "", nil, fmt.Errorf("%w: UIDVALIDITY of %s changed from %d to %d; look the message up again", ErrStaleRef, refMailbox, uidValidity, state.UIDValidity)
+	}
+	return refMailbox, resolved, nil
+}
@@ -0,0 +1,135 @@
+package imap
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRefRoundTrip(t *testing.T) {
+	token := EncodeRef("Labels/Work", 1700000000, 42)
+	if strings.ContainsAny(token, "+/=") {
+		t.Errorf("EncodeRef() = %q, want URL-safe base64 without padding", token)
+	}
+
+	want := Ref{Mailbox: "Labels/Work", UIDValidity: 1700000000, UID: 42}
+	for _, id := range []string{"ref:" + token, " REF:" + token + " "} {
+		got, err := ParseRef(id)
+		if err != nil {
+			t.Fatalf("ParseRef(%q) error = %v", id, err)
+		}
+		if got != want {
+			t.Errorf("ParseRef(%q) = %+v, want %+v", id, got, want)
+		}
+	}
+
+	if EncodeRef("INBOX", 0, 42) != "" {
+		t.Error("expected no token without a UIDVALIDITY")
+	}
+}
+
+func TestParseRefRejectsMalformed(t *testing.T) {
+	for _, id := range []string{
+		"uid:42",
+		"ref:",
+		"ref:!!!",
+		"ref:" + EncodeRef("INBOX", 1, 42)[:4],
+		"ref:SU5CT1gAMQ",                 // two fields
+		"ref:ADEANDI",                    // empty mailbox
+		"ref:SU5CT1gAMAA0Mg",             // UIDVALIDITY 0
+		"ref:SU5CT1gAMQB4",               // UID not a number
+		"ref:SU5CT1gAMQA0MgBleHRyYQ",     // four fields
+		"ref:SU5CT1gAMQA5OTk5OTk5OTk5OQ", // UID overflows
+	} {
+		if _, err := ParseRef(id); err == nil {
+			t.Errorf("ParseRef(%q) succeeded, want an error", id)
+		}
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	client, user := newTestServer(t)
+	if err := user.Create("Archive", nil); err != nil {
+		t.Fatalf("create Archive: %v", err)
+	}
+	appendTestMessage(t, user, "Archive", peekTestMessage)
+
+	state, err := client.MailboxState("Archive")
+	if err != nil {
+		t.Fatalf("MailboxState() error = %v", err)
+	}
+	ref := "ref:" + EncodeRef("Archive", state.UIDValidity, 1)
+
+	mailbox, ids, err := client.ResolveRefs("INBOX", []string{ref})
+	if err != nil {
+		t.Fatalf("ResolveRefs() error = %v", err)
+	}
+	if mailbox != "Archive" || !reflect.DeepEqual(ids, []string{"uid:1"}) {
+		t.Errorf("ResolveRefs() = %q, %v; want Archive, [uid:1]", mailbox, ids)
+	}
+
+	msg, err := client.PeekMessage("INBOX", ref)
+	if err != nil {
+		t.Fatalf("PeekMessage(ref) error = %v", err)
+	}
+	if msg.Subject != "Quarterly numbers" || msg.Ref != strings.TrimPrefix(ref, "ref:") {
+		t.Errorf("PeekMessage(ref) = subject %q, ref %q; want the Archive message and its own ref", msg.Subject, msg.Ref)
+	}
+
+	if mailbox, ids, err := client.ResolveRefs("INBOX", []string{"3", "uid:4"}); err != nil || mailbox != "INBOX" || len(ids) != 2 {
+		t.Errorf("ResolveRefs(plain IDs) = %q, %v, %v; want them unchanged", mailbox, ids, err)
+	}
+	if _, _, err := client.ResolveRefs("INBOX", []string{ref, "3"}); err == nil {
+		t.Error("expected an error mixing an Archive ref with INBOX IDs")
+	}
+
+	stale := "ref:" + EncodeRef("Archive", state.UIDValidity+1, 1)
+	_, _, err = client.ResolveRefs("Archive", []string{stale})
+	if !errors.Is(err, ErrStaleRef) {
+		t.Errorf("ResolveRefs(stale) error = %v, want ErrStaleRef", err)
+	}
+	if _, err := client.GetMessage("Archive", stale); !errors.Is(err, ErrStaleRef) {
+		t.Errorf("GetMessage(stale) error = %v, want ErrStaleRef", err)
+	}
+}
+
+func TestListedMessagesCarryRefs(t *testing.T) {
+	client, user := newTestServer(t)
+	appendTestMessage(t, user, "INBOX", peekTestMessage)
+
+	messages, err := client.ListMessages("INBOX", 10, 0, false)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("ListMessages() = %d messages, %v", len(messages), err)
+	}
+	ref, err := ParseRef("ref:" + messages[0].Ref)
+	if err != nil {
+		t.Fatalf("ParseRef(listed ref) error = %v", err)
+	}
+	if ref.Mailbox != "INBOX" || ref.UID != messages[0].UID {
+		t.Errorf("listed ref = %+v, want INBOX uid %d", ref, messages[0].UID)
+	}
+	if ref.UIDValidity == 0 {
+		t.Error("listed ref has no UIDVALIDITY")
+	}
+}
+
+func TestStatusReportsUIDValidity(t *testing.T) {
+	client, _ := newTestServer(t)
+
+	state, err := client.MailboxState("INBOX")
+	if err != nil {
+		t.Fatalf("MailboxState() error = %v", err)
+	}
+	status, err := client.Status("INBOX")
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	selected, err := client.SelectMailbox("INBOX")
+	if err != nil {
+		t.Fatalf("SelectMailbox() error = %v", err)
+	}
+	if state.UIDValidity == 0 || status.UIDValidity != state.UIDValidity || selected.UIDValidity != state.UIDValidity {
+		t.Errorf("UIDVALIDITY from STATUS %d, SELECT %d; want %d from both", status.UIDValidity, selected.UIDValidity, state.UIDValidity)
+	}
+}
@@ -0,0 +1,113 @@
+package imap
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/config"
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapclient"
+	"github.com/emersion/go-imap/v2/imapserver"
+	"github.com/emersion/go-imap/v2/imapserver/imapmemserver"
+)
+
+const (
+	testUser     = "user@example.com"
+	testPassword = "secret"
+)
+
+// newTestServer starts an in-memory IMAP server on a loopback port and
+// returns a Client logged in to it, along with the server-side user so tests
+// can seed mailboxes and inspect state without going through the client.
+func newTestServer(t *testing.T) (*Client, *imapmemserver.User) {
+	t.Helper()
+	return newTestServerWithDebug(t, nil)
+}
+
+// newTestServerWithDebug is newTestServer with the client's raw protocol
+// traffic copied to debug, for tests that check what goes over the wire.
+func newTestServerWithDebug(t *testing.T, debug io.Writer) (*Client, *imapmemserver.User) {
+	t.Helper()
+
+	addr, user := startTestServer(t)
+	conn, err := imapclient.DialInsecure(addr, &imapclient.Options{DebugWriter: debug})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	if err := conn.Login(testUser, testPassword).Wait(); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	client := &Client{client: conn, config: config.DefaultConfig()}
+	t.Cleanup(func() { client.Close() })
+	return client, user
+}
+
+// startTestServer starts an in-memory IMAP server without TLS on a
+// loopback port and returns its address and the server-side user.
+func startTestServer(t *testing.T) (string, *imapmemserver.User) {
+	t.Helper()
+
+	user := imapmemserver.NewUser(testUser, testPassword)
+	if err := user.Create("INBOX", nil); err != nil {
+		t.Fatalf("create INBOX: %v", err)
+	}
+	mem := imapmemserver.New()
+	mem.AddUser(user)
+
+	server := imapserver.New(&imapserver.Options{
+		NewSession: func(*imapserver.Conn) (imapserver.Session, *imapserver.GreetingData, error) {
+			return mem.NewSession(), nil, nil
+		},
+		Caps: imap.CapSet{
+			imap.CapIMAP4rev1: {},
+			imap.CapUIDPlus:   {},
+			imap.CapMove:      {},
+		},
+		InsecureAuth: true,
+		Logger:       log.New(io.Discard, "", 0),
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Close() })
+
+	return ln.Addr().String(), user
+}
+
+// appendTestMessage stores raw, with LF line endings converted to CRLF, in
+// mailbox.
+func appendTestMessage(t *testing.T, user *imapmemserver.User, mailbox, raw string, flags ...imap.Flag) {
+	t.Helper()
+
+	r := bytes.NewReader([]byte(strings.ReplaceAll(raw, "\n", "\r\n")))
+	if _, err := user.Append(mailbox, r, &imap.AppendOptions{Flags: flags, Time: time.Now()}); err != nil {
+		t.Fatalf("append to %s: %v", mailbox, err)
+	}
+}
+
+// serverFlags fetches the current flags of uid without touching the body.
+func serverFlags(t *testing.T, client *Client, mailbox string, uid uint32) []string {
+	t.Helper()
+
+	if _, err := client.SelectMailbox(mailbox); err != nil {
+		t.Fatalf("select %s: %v", mailbox, err)
+	}
+	msgs, err := client.client.Fetch(imap.UIDSetNum(imap.UID(uid)), &imap.FetchOptions{Flags: true}).Collect()
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("fetch flags for uid %d: %v (%d results)", uid, err, len(msgs))
+	}
+	flags := make([]string, len(msgs[0].Flags))
+	for i, f := range msgs[0].Flags {
+		flags[i] = string(f)
+	}
+	return flags
+}
@@ -1,38 +1,116 @@
 package imap
 
+import "strings"
+
 type MailboxInfo struct {
 	Name       string   `json:"name"`
 	Delimiter  string   `json:"delimiter"`
 	Attributes []string `json:"attributes"`
+	// UIDValidity changes when the server recreates the mailbox, which
+	// makes every UID stored for it meaningless. 0 means unknown.
+	UIDValidity uint32         `json:"uid_validity,omitempty"`
+	Status      *MailboxStatus `json:"status,omitempty"`
 }
 
 type MailboxStatus struct {
-	Name     string `json:"name"`
-	Messages uint32 `json:"messages"`
-	Recent   uint32 `json:"recent"`
-	Unseen   uint32 `json:"unseen"`
+	Name        string `json:"name"`
+	Messages    uint32 `json:"messages"`
+	Recent      uint32 `json:"recent"`
+	Unseen      uint32 `json:"unseen"`
+	UIDValidity uint32 `json:"uid_validity,omitempty"`
+}
+
+// MailboxState holds the cheap STATUS identifiers used to detect whether a
+// mailbox changed. HighestModSeq is 0 when the server lacks CONDSTORE.
+type MailboxState struct {
+	UIDValidity   uint32 `json:"uid_validity"`
+	UIDNext       uint32 `json:"uid_next"`
+	HighestModSeq uint64 `json:"highest_modseq,omitempty"`
+	Messages      uint32 `json:"messages"`
+	Unseen        uint32 `json:"unseen"`
+}
+
+// QuotaInfo reports storage and message usage for a quota root.
+// Storage values are in bytes; a limit of 0 means the resource is not limited.
+type QuotaInfo struct {
+	Root          string `json:"root"`
+	StorageUsed   int64  `json:"storage_used"`
+	StorageLimit  int64  `json:"storage_limit"`
+	MessagesUsed  int64  `json:"messages_used"`
+	MessagesLimit int64  `json:"messages_limit"`
+}
+
+// Recipient is a single To/Cc address taken from a message envelope.
+type Recipient struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+}
+
+// MessageRef identifies a message independently of the mailbox it is in.
+type MessageRef struct {
+	UID       uint32 `json:"uid"`
+	MessageID string `json:"message_id"`
+	Subject   string `json:"subject"`
+	From      string `json:"from"`
 }
 
 type MessageSummary struct {
-	UID     uint32 `json:"uid"`
-	SeqNum  uint32 `json:"seq_num"`
-	From    string `json:"from"`
-	Subject string `json:"subject"`
-	Date    string `json:"date"`
-	DateISO string `json:"date_iso,omitempty"`
-	Seen    bool   `json:"seen"`
-	Flagged bool   `json:"flagged"`
+	UID         uint32   `json:"uid"`
+	SeqNum      uint32   `json:"seq_num"`
+	Ref         string   `json:"ref,omitempty"`
+	From        string   `json:"from"`
+	FromAddress string   `json:"from_address,omitempty"`
+	Subject     string   `json:"subject"`
+	MessageID   string   `json:"message_id,omitempty"`
+	Date        string   `json:"date"`
+	DateISO     string   `json:"date_iso,omitempty"`
+	Seen        bool     `json:"seen"`
+	Flagged     bool     `json:"flagged"`
+	Status      []string `json:"status"`
+	Size        int64    `json:"size"`
+	Preview     string   `json:"preview,omitempty"`
+}
+
+// statusNames maps the IMAP system flags to the names reported in status
+// fields, in the order they are listed.
+var statusNames = []struct {
+	flag string
+	name string
+}{
+	{`\Seen`, "read"},
+	{`\Flagged`, "starred"},
+	{`\Answered`, "answered"},
+	{`\Draft`, "draft"},
+	{`\Deleted`, "deleted"},
+}
+
+// MessageStatus translates IMAP system flags into plain names: read,
+// starred, answered, draft and deleted, always in that order. Keywords
+// such as $Junk have no status name; they stay visible in the raw flags.
+func MessageStatus(flags []string) []string {
+	status := []string{}
+	for _, s := range statusNames {
+		for _, f := range flags {
+			if strings.EqualFold(f, s.flag) {
+				status = append(status, s.name)
+				break
+			}
+		}
+	}
+	return status
 }
 
 type Message struct {
 	UID         uint32       `json:"uid"`
 	SeqNum      uint32       `json:"seq_num"`
+	Ref         string       `json:"ref,omitempty"`
 	MessageID   string       `json:"message_id,omitempty"`
 	InReplyTo   string       `json:"in_reply_to,omitempty"`
 	References  []string     `json:"references,omitempty"`
 	From        string       `json:"from"`
 	To          []string     `json:"to"`
 	CC          []string     `json:"cc,omitempty"`
+	Bcc         []string     `json:"bcc,omitempty"` // Usually only on sent mail and drafts
 	Subject     string       `json:"subject"`
 	Date        string       `json:"date"`
 	DateISO     string       `json:"date_iso,omitempty"`
@@ -48,6 +126,10 @@ type Attachment struct {
 	Index       int    `json:"index"`
 	Filename    string `json:"filename"`
 	ContentType string `json:"content_type"`
+	// GuessedType is the type implied by the filename's extension, set
+	// only when ContentType is the generic application/octet-stream.
+	GuessedType string `json:"guessed_type,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
 	Size        int64  `json:"size"`
 	Data        []byte `json:"-"`
 }
@@ -57,6 +139,8 @@ type SearchOptions struct {
 	Query          string // General query (searches body text)
 	From           string // Filter by sender
 	To             string // Filter by recipient
+	Cc             string // Filter by Cc recipient
+	Bcc            string // Filter by Bcc recipient (only present on sent mail)
 	Subject        string // Filter by subject
 	Body           string // Search in message body
 	Since          string // Messages since date (YYYY-MM-DD)
@@ -66,4 +150,15 @@ type SearchOptions struct {
 	SmallerThan    int64  // Messages smaller than this size in bytes
 	UseOr          bool   // Combine filters with OR instead of AND
 	Negate         bool   // Negate the entire search
+	NotFrom        string // Exclude messages from this sender
+	NotTo          string // Exclude messages to this recipient
+	NotSubject     string // Exclude messages with this subject
+	NotBody        string // Exclude messages containing this body text
+	Flagged        *bool  // Starred (true) or not starred (false); nil = either
+	Seen           *bool  // Read (true) or unread (false); nil = either
+	Answered       *bool  // Replied to (true) or not (false); nil = either
+	Draft          *bool  // Draft (true) or not (false); nil = either
+	Raw            string // Raw IMAP SEARCH string; replaces all other filters
+	Limit          int    // Fetch at most this many matches, newest first (0 = all)
+	Offset         int    // Skip this many of the newest matches
 }
@@ -2,6 +2,7 @@ package imap
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -74,6 +75,7 @@ func TestMessageSummaryJSON(t *testing.T) {
 		Date:    "2024-01-15 10:30",
 		Seen:    true,
 		Flagged: false,
+		Size:    2048,
 	}
 
 	data, err := json.Marshal(summary)
@@ -81,6 +83,10 @@ func TestMessageSummaryJSON(t *testing.T) {
 		t.Fatalf("failed to marshal: %v", err)
 	}
 
+	if !strings.Contains(string(data), `"size":2048`) {
+		t.Errorf("JSON should include numeric size, got %s", data)
+	}
+
 	var result MessageSummary
 	if err := json.Unmarshal(data, &result); err != nil {
 		t.Fatalf("failed to unmarshal: %v", err)
@@ -107,6 +113,9 @@ func TestMessageSummaryJSON(t *testing.T) {
 	if result.Flagged != summary.Flagged {
 		t.Errorf("Flagged = %v, want %v", result.Flagged, summary.Flagged)
 	}
+	if result.Size != summary.Size {
+		t.Errorf("Size = %d, want %d", result.Size, summary.Size)
+	}
 }
 
 func TestMessageJSON(t *testing.T) {
@@ -250,3 +259,22 @@ func TestMailboxInfoAttributes(t *testing.T) {
 		})
 	}
 }
+
+func TestMessageStatus(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  []string
+	}{
+		{nil, []string{}},
+		{[]string{`\Seen`}, []string{"read"}},
+		{[]string{`\Deleted`, `\Draft`, `\Answered`, `\Flagged`, `\Seen`}, []string{"read", "starred", "answered", "draft", "deleted"}},
+		{[]string{`\FLAGGED`, `\seen`}, []string{"read", "starred"}},
+		{[]string{"$Junk", `\Recent`, "$Label1"}, []string{}},
+	}
+	for _, tt := range tests {
+		got := MessageStatus(tt.flags)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || got == nil {
+			t.Errorf("MessageStatus(%v) = %#v, want %v", tt.flags, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package outbox keeps messages that could not be sent because the SMTP
+// server was unreachable, one JSON file per message, until they are
+// flushed or discarded.
+package outbox
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bscott/pm-cli/internal/smtp"
+)
+
+// ErrNotFound is returned when no queued message has the given ID.
+var ErrNotFound = errors.New("no queued message with that ID")
+
+// Entry is a queued message.
+type Entry struct {
+	ID        string        `json:"id"`
+	QueuedAt  time.Time     `json:"queued_at"`
+	Attempts  int           `json:"attempts"`
+	LastError string        `json:"last_error,omitempty"`
+	Message   *smtp.Message `json:"message"`
+}
+
+// Dir returns the directory that holds the queued messages.
+func Dir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "pm-cli", "outbox"), nil
+}
+
+// Add queues msg after a failed send. Attachment files are read into the
+// entry, so the queued copy does not depend on them still being there when
+// it is flushed.
+func Add(msg *smtp.Message, cause error) (*Entry, error) {
+	queued := *msg
+	queued.Attachments = nil
+	queued.AttachmentData = nil
+	for _, path := range msg.Attachments {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment %s: %w", path, err)
+		}
+		queued.AttachmentData = append(queued.AttachmentData, smtp.Attachment{Filename: filepath.Base(path), Data: data})
+	}
+	queued.AttachmentData = append(queued.AttachmentData, msg.AttachmentData...)
+
+	id, err := newID(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	entry := &Entry{ID: id, QueuedAt: time.Now().UTC(), Attempts: 1, Message: &queued}
+	if cause != nil {
+		entry.LastError = cause.Error()
+	}
+	if err := entry.Save(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// newID returns a queue ID that sorts by the time it was made, with a
+// random suffix so that messages queued in the same second do not clash.
+func newID(now time.Time) (string, error) {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate outbox ID: %w", err)
+	}
+	return now.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix), nil
+}
+
+// List returns the queued messages, oldest first.
+func List() ([]Entry, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, nil
+		}
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	entries := []Entry{}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		entry, err := load(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].QueuedAt.Equal(entries[j].QueuedAt) {
+			return entries[i].QueuedAt.Before(entries[j].QueuedAt)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+func load(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queued message: %w", err)
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Message == nil {
+		return nil, fmt.Errorf("failed to parse queued message %s", filepath.Base(path))
+	}
+	return &entry, nil
+}
+
+// Save writes the entry to the outbox, replacing an earlier copy. The file
+// is written next to its final name and renamed into place, so a crash
+// never leaves half a message behind.
+func (e *Entry) Save() error {
+	path, err := entryPath(e.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal queued message: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write queued message: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write queued message: %w", err)
+	}
+	return nil
+}
+
+// Remove drops the queued message with the given ID.
+func Remove(id string) error {
+	path, err := entryPath(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return fmt.Errorf("failed to remove queued message: %w", err)
+	}
+	return nil
+}
+
+// entryPath returns the file for id, refusing IDs that would point
+// outside the outbox directory.
+func entryPath(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid outbox ID %q", id)
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, id+".json"), nil
+}
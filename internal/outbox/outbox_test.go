@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bscott/pm-cli/internal/smtp"
+)
+
+func TestAddListRemove(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	attachment := filepath.Join(tmpDir, "report.csv")
+	if err := os.WriteFile(attachment, []byte("a,b\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Add(&smtp.Message{
+		From:           "me@example.com",
+		To:             []string{"you@example.com"},
+		Subject:        "Report",
+		Body:           "Attached.",
+		Attachments:    []string{attachment},
+		AttachmentData: []smtp.Attachment{{Filename: "stdin.txt", Data: []byte("piped")}},
+		ContentTypes:   map[string]string{"report.csv": "text/csv"},
+	}, errors.New("failed to connect to SMTP server"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	second, err := Add(&smtp.Message{To: []string{"other@example.com"}, Subject: "Later"}, nil)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// The queued copy keeps the attachment after the file is gone
+	if err := os.Remove(attachment); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != first.ID || entries[1].ID != second.ID {
+		t.Fatalf("List() = %+v, want %s then %s", entries, first.ID, second.ID)
+	}
+	msg := entries[0].Message
+	if len(msg.Attachments) != 0 || len(msg.AttachmentData) != 2 {
+		t.Fatalf("queued attachments = %v files and %d in memory, want 0 and 2", msg.Attachments, len(msg.AttachmentData))
+	}
+	if att := msg.AttachmentData[0]; att.Filename != "report.csv" || string(att.Data) != "a,b\n" {
+		t.Errorf("first attachment = %s %q, want report.csv with the file content", att.Filename, att.Data)
+	}
+	if msg.ContentTypes["report.csv"] != "text/csv" || entries[0].LastError == "" || entries[0].Attempts != 1 {
+		t.Errorf("entry = %+v, want the content type override, last error and one attempt", entries[0])
+	}
+
+	if err := Remove(first.ID); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := Remove(first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Remove() error = %v, want ErrNotFound", err)
+	}
+	if err := Remove("../config"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Remove(../config) error = %v, want an invalid ID error", err)
+	}
+	if entries, err := List(); err != nil || len(entries) != 1 {
+		t.Errorf("List() after Remove = %d entries, %v; want 1", len(entries), err)
+	}
+}
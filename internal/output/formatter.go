@@ -1,6 +1,7 @@
 package output
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -126,13 +127,20 @@ func (f *Formatter) Verbosef(format string, args ...interface{}) {
 
 type TableWriter struct {
 	w         *tabwriter.Writer
+	buf       *bytes.Buffer
+	out       io.Writer
 	headers   []string
 	formatter *Formatter
+	lines     int
+	notes     map[int][]string
 }
 
 func (f *Formatter) NewTable(headers ...string) *TableWriter {
+	buf := &bytes.Buffer{}
 	tw := &TableWriter{
-		w:         tabwriter.NewWriter(f.Writer, 0, 0, 2, ' ', 0),
+		w:         tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0),
+		buf:       buf,
+		out:       f.Writer,
 		headers:   headers,
 		formatter: f,
 	}
@@ -143,16 +151,44 @@ func (f *Formatter) NewTable(headers ...string) *TableWriter {
 			coloredHeaders[i] = f.Bold(h)
 		}
 		fmt.Fprintln(tw.w, strings.Join(coloredHeaders, "\t"))
+		tw.lines++
 	}
 	return tw
 }
 
 func (t *TableWriter) AddRow(values ...string) {
 	fmt.Fprintln(t.w, strings.Join(values, "\t"))
+	t.lines++
+}
+
+// AddNote prints text on its own line below the last row. Notes are not
+// part of the table, so they never widen its columns.
+func (t *TableWriter) AddNote(text string) {
+	if t.notes == nil {
+		t.notes = make(map[int][]string)
+	}
+	t.notes[t.lines-1] = append(t.notes[t.lines-1], text)
 }
 
 func (t *TableWriter) Flush() {
 	t.w.Flush()
+	defer func() {
+		t.buf.Reset()
+		t.lines = 0
+		t.notes = nil
+	}()
+
+	if len(t.notes) == 0 {
+		t.out.Write(t.buf.Bytes())
+		return
+	}
+
+	for i, line := range strings.SplitAfter(t.buf.String(), "\n") {
+		io.WriteString(t.out, line)
+		for _, note := range t.notes[i] {
+			fmt.Fprintln(t.out, note)
+		}
+	}
 }
 
 type JSONResponse struct {
@@ -282,6 +282,22 @@ func TestTableWriter(t *testing.T) {
 			t.Error("expected row data in output")
 		}
 	})
+	t.Run("notes do not widen columns", func(t *testing.T) {
+		var buf bytes.Buffer
+		f := New(false, false, false, false)
+		f.Writer = &buf
+
+		table := f.NewTable()
+		table.AddRow("1", "Alice")
+		table.AddNote("  a note much longer than any column")
+		table.AddRow("22", "Bob")
+		table.Flush()
+
+		want := "1   Alice\n  a note much longer than any column\n22  Bob\n"
+		if got := buf.String(); got != want {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	})
 }
 
 func TestSuccess(t *testing.T) {
@@ -0,0 +1,153 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// DefaultPager is used when $PAGER is unset. -R passes color codes through.
+const DefaultPager = "less -R"
+
+// Pager sends standard output through $PAGER once it grows past the
+// terminal height. Shorter output is written to the terminal unchanged
+// when the command finishes.
+type Pager struct {
+	stdout *os.File
+	w      *os.File
+	done   chan struct{}
+}
+
+// StartPager starts paging standard output when it is a terminal and f is
+// in plain text mode. It replaces os.Stdout, and f.Writer when that is
+// os.Stdout, until Stop is called. It returns nil when output is not paged.
+func StartPager(f *Formatter) *Pager {
+	if f.JSON || f.Quiet {
+		return nil
+	}
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return nil
+	}
+	width, height, err := term.GetSize(fd)
+	if err != nil || height <= 1 {
+		return nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil
+	}
+
+	p := &Pager{stdout: os.Stdout, w: w, done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		// Keep the last line free for the shell prompt
+		pageOutput(r, p.stdout, height-1, width, startPagerCommand)
+		r.Close()
+	}()
+
+	os.Stdout = w
+	if f.Writer == p.stdout {
+		f.Writer = w
+	}
+	return p
+}
+
+// Stop restores standard output and waits until the pager, if one was
+// started, exits.
+func (p *Pager) Stop(f *Formatter) {
+	if p == nil {
+		return
+	}
+	if f.Writer == p.w {
+		f.Writer = p.stdout
+	}
+	os.Stdout = p.stdout
+	p.w.Close()
+	<-p.done
+}
+
+// pageOutput copies r to out, unless r holds more than height lines of
+// width columns; then it starts a pager and copies everything to that.
+func pageOutput(r io.Reader, out io.Writer, height, width int, start func(out io.Writer) (io.WriteCloser, func() error, error)) {
+	var held bytes.Buffer
+	lines := 0
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		held.Write(buf[:n])
+		lines += countLines(buf[:n], width)
+		if lines > height {
+			break
+		}
+		if err != nil {
+			out.Write(held.Bytes())
+			return
+		}
+	}
+
+	pager, wait, err := start(out)
+	if err != nil {
+		out.Write(held.Bytes())
+		io.Copy(out, r)
+		return
+	}
+	pager.Write(held.Bytes())
+	// A pager quit early stops reading; drain r so the command can finish
+	if _, err := io.Copy(pager, r); err != nil {
+		io.Copy(io.Discard, r)
+	}
+	pager.Close()
+	wait()
+}
+
+// countLines estimates the terminal lines data takes up, counting a line
+// longer than width as wrapping. Color codes are counted as text, which
+// only makes paging start a little early.
+func countLines(data []byte, width int) int {
+	lines := 0
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if line[len(line)-1] == '\n' {
+			lines++
+		}
+		if width > 0 {
+			lines += (utf8.RuneCount(line) - 1) / width
+		}
+	}
+	return lines
+}
+
+// startPagerCommand runs $PAGER, or DefaultPager, writing to out.
+func startPagerCommand(out io.Writer) (io.WriteCloser, func() error, error) {
+	command := strings.TrimSpace(os.Getenv("PAGER"))
+	if command == "" {
+		command = DefaultPager
+	}
+	args := strings.Fields(command)
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		// Show colors when $PAGER is a plain "less"
+		cmd.Env = append(os.Environ(), "LESS=R")
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+	return stdin, cmd.Wait, nil
+}
@@ -0,0 +1,80 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestPageOutput(t *testing.T) {
+	long := strings.Repeat("line\n", 30)
+
+	tests := []struct {
+		name      string
+		input     string
+		startErr  error
+		wantPaged bool
+	}{
+		{"short output is written directly", "one\ntwo\n", nil, false},
+		{"long output is paged", long, nil, true},
+		{"long wrapped line is paged", strings.Repeat("x", 80*25) + "\n", nil, true},
+		{"pager that fails to start", long, errors.New("no pager"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, paged bytes.Buffer
+			started := false
+			start := func(io.Writer) (io.WriteCloser, func() error, error) {
+				started = true
+				if tt.startErr != nil {
+					return nil, nil, tt.startErr
+				}
+				return nopWriteCloser{&paged}, func() error { return nil }, nil
+			}
+
+			pageOutput(strings.NewReader(tt.input), &out, 20, 80, start)
+
+			if tt.wantPaged {
+				if paged.String() != tt.input || out.Len() != 0 {
+					t.Errorf("paged %d bytes, wrote %d directly; want all %d paged", paged.Len(), out.Len(), len(tt.input))
+				}
+				return
+			}
+			if out.String() != tt.input {
+				t.Errorf("wrote %q directly, want %q", out.String(), tt.input)
+			}
+			if tt.startErr == nil && started {
+				t.Error("pager started for output that fits the terminal")
+			}
+		})
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	tests := []struct {
+		data  string
+		width int
+		want  int
+	}{
+		{"", 80, 0},
+		{"one\ntwo\n", 80, 2},
+		{"no newline", 80, 0},
+		{strings.Repeat("x", 200) + "\n", 80, 3},
+		{strings.Repeat("é", 100) + "\n", 80, 2},
+	}
+
+	for _, tt := range tests {
+		if got := countLines([]byte(tt.data), tt.width); got != tt.want {
+			t.Errorf("countLines(%d bytes, %d) = %d, want %d", len(tt.data), tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Package retry re-runs operations against Proton Bridge that failed for a
+// transient reason, such as a dropped connection, with exponential backoff.
+package retry
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Policy says how many times a failed operation is retried and how long to
+// wait before the first retry. The wait doubles after every attempt.
+type Policy struct {
+	Retries int
+	Delay   time.Duration
+}
+
+// sleep is replaced in tests.
+var sleep = time.Sleep
+
+// Do runs fn, retrying it while it fails with an error for which transient
+// returns true. It returns fn's last error.
+func Do(p Policy, transient func(error) bool, fn func() error) error {
+	delay := p.Delay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Retries || !transient(err) {
+			return err
+		}
+		sleep(delay)
+		delay *= 2
+	}
+}
+
+// IsNetworkError reports whether err is a connection-level failure that
+// may succeed when tried again: a timeout, a refused or reset connection,
+// or a connection closed mid-response.
+func IsNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,111 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("transient")
+
+func isTransient(err error) bool { return errors.Is(err, errTransient) }
+
+func stubSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var slept []time.Duration
+	old := sleep
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	t.Cleanup(func() { sleep = old })
+	return &slept
+}
+
+func TestDo(t *testing.T) {
+	policy := Policy{Retries: 3, Delay: 100 * time.Millisecond}
+
+	t.Run("succeeds after transient failures", func(t *testing.T) {
+		slept := stubSleep(t)
+		calls := 0
+		err := Do(policy, isTransient, func() error {
+			calls++
+			if calls < 3 {
+				return errTransient
+			}
+			return nil
+		})
+		if err != nil || calls != 3 {
+			t.Fatalf("Do() = %v after %d calls, want success after 3", err, calls)
+		}
+		if want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}; !reflect.DeepEqual(*slept, want) {
+			t.Errorf("slept %v, want %v", *slept, want)
+		}
+	})
+
+	t.Run("gives up after the retries", func(t *testing.T) {
+		slept := stubSleep(t)
+		calls := 0
+		err := Do(policy, isTransient, func() error {
+			calls++
+			return errTransient
+		})
+		if !errors.Is(err, errTransient) || calls != 4 {
+			t.Fatalf("Do() = %v after %d calls, want the error after 4", err, calls)
+		}
+		if len(*slept) != 3 {
+			t.Errorf("slept %d times, want 3", len(*slept))
+		}
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		stubSleep(t)
+		calls := 0
+		permanent := errors.New("authentication failed")
+		err := Do(policy, isTransient, func() error {
+			calls++
+			return permanent
+		})
+		if err != permanent || calls != 1 {
+			t.Fatalf("Do() = %v after %d calls, want the error after 1", err, calls)
+		}
+	})
+
+	t.Run("zero retries", func(t *testing.T) {
+		stubSleep(t)
+		calls := 0
+		Do(Policy{}, isTransient, func() error {
+			calls++
+			return errTransient
+		})
+		if calls != 1 {
+			t.Errorf("calls = %d, want 1", calls)
+		}
+	})
+}
+
+func TestIsNetworkError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"refused", fmt.Errorf("dial: %w", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), true},
+		{"reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"timeout", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, true},
+		{"eof", fmt.Errorf("in response: %w", io.ErrUnexpectedEOF), true},
+		{"other", errors.New("535 authentication failed"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNetworkError(tt.err); got != tt.want {
+				t.Errorf("IsNetworkError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,41 +4,27 @@ import (
 	"bytes"
 	"crypto/tls"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
+	"mime/quotedprintable"
 	"net"
-	"net/smtp"
+	"net/mail"
 	"net/textproto"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/bscott/pm-cli/internal/config"
+	"github.com/bscott/pm-cli/internal/retry"
 	"github.com/bscott/pm-cli/internal/safetext"
 )
 
-// isLoopbackHost reports whether host is a loopback address. Accepts the
-// literal "localhost" (case-insensitive) or any IP that parses as loopback.
-// Does not resolve DNS, because DNS is itself untrusted and we want a hard
-// guarantee that we are speaking to a local process (Proton Bridge).
-func isLoopbackHost(host string) bool {
-	h := strings.ToLower(strings.TrimSpace(host))
-	if h == "" {
-		return false
-	}
-	if h == "localhost" {
-		return true
-	}
-	if ip := net.ParseIP(h); ip != nil {
-		return ip.IsLoopback()
-	}
-	return false
-}
-
 type Client struct {
 	config   *config.Config
 	password string
@@ -52,8 +38,59 @@ type Message struct {
 	Subject     string
 	Body        string
 	Attachments []string
-	InReplyTo   string
-	References  string
+	// AttachmentData holds in-memory attachments, added after the files
+	// in Attachments
+	AttachmentData []Attachment
+	// ContentTypes overrides the content type of attachments, keyed by
+	// filename (the base name for Attachments)
+	ContentTypes map[string]string
+	// MaxAttachmentSize is the largest attachment accepted, in bytes; 0
+	// means no limit
+	MaxAttachmentSize int64
+	InReplyTo         string
+	References        string
+}
+
+// Attachment is an attachment whose content is already in memory. An empty
+// ContentType is guessed from the Filename extension.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// AttachmentTooLargeError reports an attachment over MaxAttachmentSize.
+type AttachmentTooLargeError struct {
+	Filename string
+	Size     int64
+	Limit    int64
+}
+
+func (e *AttachmentTooLargeError) Error() string {
+	return fmt.Sprintf("attachment %s is %.1f MB, over the %.1f MB limit", e.Filename, float64(e.Size)/(1<<20), float64(e.Limit)/(1<<20))
+}
+
+// checkAttachmentSizes enforces MaxAttachmentSize. Files are checked with
+// stat, so an oversized file is rejected before it is read into memory.
+func (m *Message) checkAttachmentSizes() error {
+	if m.MaxAttachmentSize <= 0 {
+		return nil
+	}
+	for _, path := range m.Attachments {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read attachment %s: %w", path, err)
+		}
+		if info.Size() > m.MaxAttachmentSize {
+			return &AttachmentTooLargeError{Filename: path, Size: info.Size(), Limit: m.MaxAttachmentSize}
+		}
+	}
+	for _, att := range m.AttachmentData {
+		if size := int64(len(att.Data)); size > m.MaxAttachmentSize {
+			return &AttachmentTooLargeError{Filename: att.Filename, Size: size, Limit: m.MaxAttachmentSize}
+		}
+	}
+	return nil
 }
 
 func NewClient(cfg *config.Config, password string) *Client {
@@ -64,35 +101,76 @@ func NewClient(cfg *config.Config, password string) *Client {
 }
 
 func (c *Client) Send(msg *Message) error {
-	// TLS skip-verify below is only safe against a locally-running Proton
-	// Bridge. Refuse to speak plaintext credentials to anything that is not
-	// a loopback address, in case the user's config was tampered with or
-	// redirected via --config.
-	if !isLoopbackHost(c.config.Bridge.SMTPHost) {
-		return fmt.Errorf("refusing to connect: SMTP host %q is not a loopback address (Proton Bridge runs on localhost; InsecureSkipVerify is unsafe for remote hosts)", c.config.Bridge.SMTPHost)
+	// Refuses an unverified or unencrypted remote host before any
+	// credentials are sent
+	if err := config.CheckSecurity(c.config.SMTPSecurity(), c.config.Bridge.SMTPHost); err != nil {
+		return err
 	}
+	tlsConfig, err := c.config.TLSConfig(c.config.Bridge.SMTPHost)
+	if err != nil {
+		return err
+	}
+
+	// Fail before connecting rather than in the middle of DATA
+	if err := msg.checkAttachmentSizes(); err != nil {
+		return err
+	}
+
+	return retry.Do(c.config.RetryPolicy(), c.isTransient, func() error {
+		return c.send(msg, tlsConfig)
+	})
+}
+
+// deliveryError is a failure after DATA started. It is never retried, as
+// the server may already have accepted the message.
+type deliveryError struct {
+	err error
+}
+
+func (e *deliveryError) Error() string { return e.err.Error() }
 
+func (e *deliveryError) Unwrap() error { return e.err }
+
+// isTransient reports whether a failed send is worth retrying: the server
+// could not be reached, the connection dropped, or it answered with a 4xx
+// (temporary) reply. Authentication failures and 5xx replies are final.
+func isTransient(err error) bool {
+	var delivery *deliveryError
+	if errors.As(err, &delivery) {
+		return false
+	}
+	if errors.Is(err, ErrConnect) {
+		return true
+	}
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return reply.Code >= 400 && reply.Code < 500
+	}
+	return retry.IsNetworkError(err)
+}
+
+// isTransient also gives up once the configured deadline has passed, as a
+// retry could not finish in time.
+func (c *Client) isTransient(err error) bool {
+	if deadline := c.config.Deadline(); !deadline.IsZero() && !time.Now().Before(deadline) {
+		return false
+	}
+	return isTransient(err)
+}
+
+func (c *Client) send(msg *Message, tlsConfig *tls.Config) error {
 	addr := net.JoinHostPort(c.config.Bridge.SMTPHost, strconv.Itoa(c.config.Bridge.SMTPPort))
 
-	// Connect to SMTP server using STARTTLS
 	// Proton Bridge SMTP uses STARTTLS (connect plain, then upgrade)
-	client, err := DialClient(addr, c.config.Bridge.SMTPHost)
+	// unless bridge.smtp_security says otherwise
+	client, err := Dial(addr, c.config.Bridge.SMTPHost, c.config.SMTPSecurity(), tlsConfig, c.config.ConnectTimeout(), c.config.Deadline())
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 
-	// Upgrade to TLS via STARTTLS (Proton Bridge uses self-signed cert)
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
-		ServerName:         c.config.Bridge.SMTPHost,
-	}
-	if err := client.StartTLS(tlsConfig); err != nil {
-		return fmt.Errorf("STARTTLS failed: %w", err)
-	}
-
 	// Authenticate
-	auth := smtp.PlainAuth("", c.config.Bridge.Email, c.password, c.config.Bridge.SMTPHost)
+	auth := PlainAuth("", c.config.Bridge.Email, c.password, c.config.Bridge.SMTPHost)
 	if err := client.Auth(auth); err != nil {
 		return fmt.Errorf("SMTP authentication failed: %w", err)
 	}
@@ -112,11 +190,13 @@ func (c *Client) Send(msg *Message) error {
 	allRecipients = append(allRecipients, msg.CC...)
 	allRecipients = append(allRecipients, msg.BCC...)
 
+	if err := ValidateAddresses(allRecipients); err != nil {
+		return err
+	}
 	for _, rcpt := range allRecipients {
-		if strings.ContainsAny(rcpt, "\r\n") {
-			return fmt.Errorf("invalid recipient address: contains CR/LF")
-		}
-		if err := client.Rcpt(rcpt); err != nil {
+		// RCPT TO takes the bare address, not the "Name <addr>" form
+		envelope, _ := mail.ParseAddress(rcpt)
+		if err := client.Rcpt(envelope.Address); err != nil {
 			return fmt.Errorf("failed to add recipient %s: %w", rcpt, err)
 		}
 	}
@@ -129,27 +209,47 @@ func (c *Client) Send(msg *Message) error {
 
 	if err := c.writeMessage(data, msg); err != nil {
 		data.Close()
-		return fmt.Errorf("failed to write message: %w", err)
+		return &deliveryError{fmt.Errorf("failed to write message: %w", err)}
 	}
 
 	if err := data.Close(); err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return &deliveryError{fmt.Errorf("failed to send message: %w", err)}
 	}
 
-	return client.Quit()
+	if err := client.Quit(); err != nil {
+		return &deliveryError{err}
+	}
+	return nil
+}
+
+// Compose renders msg exactly as Send would transmit it, without
+// connecting to the server.
+func (c *Client) Compose(msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.writeMessage(&buf, msg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 func (c *Client) writeMessage(w io.Writer, msg *Message) error {
-	hasAttachments := len(msg.Attachments) > 0
+	if err := msg.checkAttachmentSizes(); err != nil {
+		return err
+	}
+
+	hasAttachments := len(msg.Attachments) > 0 || len(msg.AttachmentData) > 0
 
 	// Headers — sanitize every value for CR/LF. Subject/InReplyTo/References
 	// can carry attacker-controlled data (e.g., the Message-ID of a received
 	// email copied into In-Reply-To on reply); without sanitization an
 	// attacker can inject additional headers or body content.
 	fmt.Fprintf(w, "From: %s\r\n", sanitizeAddressList([]string{msg.From}))
-	fmt.Fprintf(w, "To: %s\r\n", sanitizeAddressList(msg.To))
+	// Drafts may not have recipients yet
+	if len(msg.To) > 0 {
+		io.WriteString(w, foldAddressHeader("To", msg.To))
+	}
 	if len(msg.CC) > 0 {
-		fmt.Fprintf(w, "Cc: %s\r\n", sanitizeAddressList(msg.CC))
+		io.WriteString(w, foldAddressHeader("Cc", msg.CC))
 	}
 	fmt.Fprintf(w, "Subject: %s\r\n", encodeSubject(safetext.SanitizeHeaderValue(msg.Subject)))
 	fmt.Fprintf(w, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
@@ -165,7 +265,7 @@ func (c *Client) writeMessage(w io.Writer, msg *Message) error {
 		fmt.Fprintf(w, "Content-Type: text/plain; charset=utf-8\r\n")
 		fmt.Fprintf(w, "Content-Transfer-Encoding: quoted-printable\r\n")
 		fmt.Fprintf(w, "\r\n")
-		fmt.Fprintf(w, "%s\r\n", msg.Body)
+		fmt.Fprintf(w, "%s\r\n", encodeBody(msg.Body))
 		return nil
 	}
 
@@ -185,45 +285,70 @@ func (c *Client) writeMessage(w io.Writer, msg *Message) error {
 	if err != nil {
 		return err
 	}
-	part.Write([]byte(msg.Body))
+	part.Write(encodeBody(msg.Body))
 
 	// Attachment parts
+	attachments := make([]Attachment, 0, len(msg.Attachments)+len(msg.AttachmentData))
 	for _, attachPath := range msg.Attachments {
-		file, err := os.Open(attachPath)
-		if err != nil {
-			return fmt.Errorf("failed to open attachment %s: %w", attachPath, err)
-		}
-
-		content, err := io.ReadAll(file)
-		file.Close()
+		content, err := os.ReadFile(attachPath)
 		if err != nil {
 			return fmt.Errorf("failed to read attachment %s: %w", attachPath, err)
 		}
+		attachments = append(attachments, Attachment{Filename: filepath.Base(attachPath), Data: content})
+	}
+	attachments = append(attachments, msg.AttachmentData...)
 
-		filename := filepath.Base(attachPath)
-		contentType := mime.TypeByExtension(filepath.Ext(filename))
+	for _, att := range attachments {
+		contentType := att.ContentType
+		if override, ok := msg.ContentTypes[att.Filename]; ok {
+			contentType = override
+		}
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(att.Filename))
+		}
 		if contentType == "" {
 			contentType = "application/octet-stream"
 		}
 
+		// RFC 2046 forbids base64 for message/rfc822, so an attached email
+		// is included as-is with CRLF line endings. 7bit and 8bit cannot
+		// carry lines over 998 octets, so such an email goes out base64
+		// encoded as a plain file instead.
+		isMessage := strings.HasPrefix(strings.ToLower(contentType), "message/rfc822")
+		if isMessage && hasLongLine(att.Data) {
+			isMessage = false
+			contentType = "application/octet-stream"
+		}
+		encoding := "base64"
+		if isMessage {
+			encoding = "7bit"
+			if !isASCII(att.Data) {
+				encoding = "8bit"
+			}
+		}
+
 		header := make(textproto.MIMEHeader)
 		header.Set("Content-Type", contentType)
-		header.Set("Content-Transfer-Encoding", "base64")
-		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		header.Set("Content-Transfer-Encoding", encoding)
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", att.Filename))
 
 		part, err := mpWriter.CreatePart(header)
 		if err != nil {
 			return err
 		}
 
-		encoded := base64.StdEncoding.EncodeToString(content)
-		// Write base64 in chunks of 76 characters
-		for len(encoded) > 76 {
-			part.Write([]byte(encoded[:76] + "\r\n"))
-			encoded = encoded[76:]
+		if isMessage {
+			part.Write(toCRLF(att.Data))
+			continue
 		}
-		if len(encoded) > 0 {
-			part.Write([]byte(encoded))
+
+		encoded := base64.StdEncoding.EncodeToString(att.Data)
+		// Write base64 in CRLF-terminated lines of at most 76 characters,
+		// including the last one, so the boundary starts on its own line
+		for len(encoded) > 0 {
+			n := min(len(encoded), 76)
+			part.Write([]byte(encoded[:n] + "\r\n"))
+			encoded = encoded[n:]
 		}
 	}
 
@@ -245,6 +370,108 @@ func sanitizeAddressList(addrs []string) string {
 	return strings.Join(clean, ", ")
 }
 
+// ValidateAddresses checks that every address parses as a single RFC 5322
+// address ("user@example.com" or "Name <user@example.com>") and contains no
+// control characters. All bad addresses are reported in one error.
+func ValidateAddresses(addrs []string) error {
+	var bad []string
+	for _, a := range addrs {
+		if strings.IndexFunc(a, unicode.IsControl) >= 0 {
+			bad = append(bad, strconv.Quote(a))
+			continue
+		}
+		if _, err := mail.ParseAddress(a); err != nil {
+			bad = append(bad, strconv.Quote(a))
+		}
+	}
+
+	if len(bad) > 0 {
+		return fmt.Errorf("invalid recipient address(es): %s", strings.Join(bad, ", "))
+	}
+	return nil
+}
+
+// isASCII reports whether data contains only 7-bit bytes.
+func isASCII(data []byte) bool {
+	for _, b := range data {
+		if b > 127 {
+			return false
+		}
+	}
+	return true
+}
+
+// maxLineLength is the RFC 5322 limit for any line of a message, excluding
+// the CRLF.
+const maxLineLength = 998
+
+// hasLongLine reports whether data has a line longer than maxLineLength.
+func hasLongLine(data []byte) bool {
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		if len(bytes.TrimSuffix(line, []byte("\r"))) > maxLineLength {
+			return true
+		}
+	}
+	return false
+}
+
+// toCRLF normalizes line endings to CRLF and makes sure data ends with one.
+func toCRLF(data []byte) []byte {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		data = append(data, '\r', '\n')
+	}
+	return data
+}
+
+// maxHeaderLineLength is the RFC 5322 recommended limit for a header line,
+// excluding the CRLF.
+const maxHeaderLineLength = 78
+
+// foldAddressHeader renders an address header terminated by CRLF. When the
+// addresses do not fit on one line, the header is folded between addresses
+// onto continuation lines starting with a space (RFC 5322 section 2.2.3).
+// An address longer than a line is never split.
+func foldAddressHeader(name string, addrs []string) string {
+	var sb strings.Builder
+	sb.WriteString(name + ":")
+	lineLen := len(name) + 1
+
+	for i, a := range addrs {
+		a = safetext.SanitizeHeaderValue(a)
+		if i > 0 {
+			sb.WriteString(",")
+			lineLen++
+			if lineLen+1+len(a) > maxHeaderLineLength {
+				sb.WriteString("\r\n")
+				lineLen = 0
+			}
+		}
+		sb.WriteString(" " + a)
+		lineLen += 1 + len(a)
+	}
+
+	sb.WriteString("\r\n")
+	return sb.String()
+}
+
+// encodeBody quoted-printable encodes a text body to match its
+// Content-Transfer-Encoding header. Line endings become CRLF.
+func encodeBody(body string) []byte {
+	var buf bytes.Buffer
+	qp := quotedprintable.NewWriter(&buf)
+	qp.Write([]byte(body))
+	qp.Close()
+	return buf.Bytes()
+}
+
 func encodeSubject(subject string) string {
 	// Check if encoding is needed (non-ASCII characters)
 	needsEncoding := false
@@ -2,11 +2,27 @@ package smtp
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -48,7 +64,7 @@ func TestDialClientUsesConnectTimeout(t *testing.T) {
 		return nil, errors.New("dial failed")
 	}
 
-	_, err := DialClient("smtp.example.com:1025", "smtp.example.com")
+	_, err := DialClient("smtp.example.com:1025", "smtp.example.com", ConnectTimeout, time.Time{})
 	if err == nil {
 		t.Fatal("expected dial error")
 	}
@@ -85,6 +101,7 @@ func TestSendUsesTimeoutAwareDial(t *testing.T) {
 	cfg.Bridge.Email = "test@example.com"
 	cfg.Bridge.SMTPHost = "127.0.0.1"
 	cfg.Bridge.SMTPPort = 1025
+	cfg.Defaults.RetryDelay = "1ms"
 
 	client := NewClient(cfg, "testpassword")
 	err := client.Send(&Message{
@@ -104,13 +121,72 @@ func TestSendUsesTimeoutAwareDial(t *testing.T) {
 	}
 }
 
+func TestSendRetriesConnectFailures(t *testing.T) {
+	oldDialTimeout := dialTimeout
+	defer func() {
+		dialTimeout = oldDialTimeout
+	}()
+
+	dials := 0
+	dialTimeout = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		dials++
+		return nil, &net.OpError{Op: "dial", Net: network, Err: syscall.ECONNREFUSED}
+	}
+
+	retries := 3
+	cfg := config.DefaultConfig()
+	cfg.Bridge.Email = "test@example.com"
+	cfg.Bridge.SMTPHost = "127.0.0.1"
+	cfg.Bridge.SMTPPort = 1025
+	cfg.Defaults.Retries = &retries
+	cfg.Defaults.RetryDelay = "1ms"
+
+	err := NewClient(cfg, "testpassword").Send(&Message{
+		From: "test@example.com",
+		To:   []string{"to@example.com"},
+	})
+	if !errors.Is(err, ErrConnect) {
+		t.Fatalf("expected connection error, got %v", err)
+	}
+	if dials != retries+1 {
+		t.Fatalf("dialed %d times, want %d", dials, retries+1)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connect", fmt.Errorf("%w: %w", ErrConnect, errors.New("dial failed")), true},
+		{"connection reset", fmt.Errorf("failed to set sender: %w", syscall.ECONNRESET), true},
+		{"temporary reply", fmt.Errorf("failed to add recipient: %w", &textproto.Error{Code: 451, Msg: "try again later"}), true},
+		{"auth failure", fmt.Errorf("SMTP authentication failed: %w", &textproto.Error{Code: 535, Msg: "bad credentials"}), false},
+		{"permanent reply", &textproto.Error{Code: 550, Msg: "no such user"}, false},
+		{"after data", &deliveryError{fmt.Errorf("failed to send message: %w", io.ErrUnexpectedEOF)}, false},
+		{"other", errors.New("invalid sender address"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSendRejectsNonLoopbackHost(t *testing.T) {
 	// Sanity check: Send must refuse to transmit credentials when the
-	// configured SMTP host is not a loopback address. InsecureSkipVerify is
-	// only safe under the Proton Bridge-on-localhost trust assumption.
+	// configured SMTP host is not a loopback address and TLS verification
+	// is off. InsecureSkipVerify is only safe under the Proton
+	// Bridge-on-localhost trust assumption.
 	cfg := config.DefaultConfig()
 	cfg.Bridge.Email = "test@example.com"
 	cfg.Bridge.SMTPHost = "smtp.example.com"
+	verify := false
+	cfg.Bridge.TLSVerify = &verify
 	cfg.Bridge.SMTPPort = 1025
 
 	client := NewClient(cfg, "testpassword")
@@ -126,28 +202,95 @@ func TestSendRejectsNonLoopbackHost(t *testing.T) {
 	}
 }
 
-func TestIsLoopbackHost(t *testing.T) {
+func TestSendRejectsPlainRemoteHost(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Bridge.Email = "test@example.com"
+	cfg.Bridge.SMTPHost = "smtp.example.com"
+	cfg.Bridge.SMTPSecurity = config.SecurityNone
+
+	err := NewClient(cfg, "testpassword").Send(&Message{
+		From: "test@example.com",
+		To:   []string{"to@example.com"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "security is none") {
+		t.Fatalf("Send() error = %v, want a refusal to send without encryption", err)
+	}
+}
+
+func TestPlainAuthLoopback(t *testing.T) {
 	tests := []struct {
-		host string
-		want bool
+		host    string
+		tls     bool
+		wantErr bool
 	}{
-		{"localhost", true},
-		{"LOCALHOST", true},
-		{"127.0.0.1", true},
-		{"127.1.2.3", true},
-		{"::1", true},
-		{"  127.0.0.1  ", true},
-		{"smtp.example.com", false},
-		{"10.0.0.1", false},
-		{"0.0.0.0", false},
-		{"", false},
-		{"localhost.evil.com", false},
-	}
-	for _, tc := range tests {
-		got := isLoopbackHost(tc.host)
-		if got != tc.want {
-			t.Errorf("isLoopbackHost(%q) = %v, want %v", tc.host, got, tc.want)
+		{"127.0.0.1", false, false},
+		{"127.0.0.2", false, false},
+		{"localhost", false, false},
+		{"::1", false, false},
+		{"smtp.example.com", false, true},
+		{"smtp.example.com", true, false},
+	}
+	for _, tt := range tests {
+		auth := PlainAuth("", "user@proton.me", "secret", tt.host)
+		mech, resp, err := auth.Start(&smtp.ServerInfo{Name: tt.host, TLS: tt.tls})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Start(%s, tls %v) error = %v, wantErr %v", tt.host, tt.tls, err, tt.wantErr)
+			continue
 		}
+		if err == nil && (mech != "PLAIN" || string(resp) != "\x00user@proton.me\x00secret") {
+			t.Errorf("Start(%s) = %q %q", tt.host, mech, resp)
+		}
+	}
+
+	auth := PlainAuth("", "", "", "127.0.0.1")
+	if _, _, err := auth.Start(&smtp.ServerInfo{Name: "127.0.0.2"}); err == nil {
+		t.Error("Start() should refuse a server other than the configured host")
+	}
+}
+
+func TestDialImplicitTLS(t *testing.T) {
+	oldDialTimeout := dialTimeout
+	defer func() {
+		dialTimeout = oldDialTimeout
+	}()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+
+	handshake := make(chan error, 1)
+	dialTimeout = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		clientConn, serverConn := net.Pipe()
+		go func() {
+			conn := tls.Server(serverConn, serverConfig)
+			err := conn.Handshake()
+			handshake <- err
+			if err == nil {
+				io.WriteString(conn, "220 smtp.test ESMTP\r\n")
+			}
+		}()
+		return clientConn, nil
+	}
+
+	client, err := Dial("127.0.0.1:465", "127.0.0.1", config.SecurityTLS, &tls.Config{InsecureSkipVerify: true}, ConnectTimeout, time.Time{})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+	if err := <-handshake; err != nil {
+		t.Fatalf("server handshake error = %v", err)
 	}
 }
 
@@ -264,6 +407,32 @@ func TestWriteMessageSimple(t *testing.T) {
 	}
 }
 
+func TestWriteMessageQuotedPrintableBody(t *testing.T) {
+	client := NewClient(config.DefaultConfig(), "testpassword")
+
+	body := "price=10 €\nsecond line with a long tail " + strings.Repeat("x", 100)
+	var buf bytes.Buffer
+	if err := client.writeMessage(&buf, &Message{From: "sender@example.com", Subject: "Draft", Body: body}); err != nil {
+		t.Fatalf("writeMessage() error = %v", err)
+	}
+
+	if strings.Contains(buf.String(), "\r\nTo:") {
+		t.Error("output should not contain an empty To header")
+	}
+
+	parsed, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got := strings.TrimRight(string(decoded), "\r\n"); got != strings.ReplaceAll(body, "\n", "\r\n") {
+		t.Errorf("decoded body = %q, want %q", got, body)
+	}
+}
+
 func TestWriteMessageWithCC(t *testing.T) {
 	cfg := config.DefaultConfig()
 	client := NewClient(cfg, "testpassword")
@@ -361,6 +530,254 @@ func TestWriteMessageWithAttachment(t *testing.T) {
 	}
 }
 
+func TestWriteMessageAttachmentRoundTrip(t *testing.T) {
+	cfg := config.DefaultConfig()
+	client := NewClient(cfg, "testpassword")
+	tmpDir := t.TempDir()
+
+	// 100 bytes encode to 136 base64 characters: one full 76-character line
+	// and a 60-character remainder
+	sizes := []int{1, 57, 100, 1000}
+	var paths []string
+	contents := make(map[string][]byte)
+	for _, size := range sizes {
+		content := make([]byte, size)
+		for i := range content {
+			content[i] = byte(i * 7)
+		}
+		name := fmt.Sprintf("file-%d.bin", size)
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("failed to create attachment: %v", err)
+		}
+		paths = append(paths, path)
+		contents[name] = content
+	}
+
+	msg := &Message{
+		From:        "sender@example.com",
+		To:          []string{"recipient@example.com"},
+		Subject:     "Binary attachments",
+		Body:        "See attached",
+		Attachments: paths,
+	}
+
+	var buf bytes.Buffer
+	if err := client.writeMessage(&buf, msg); err != nil {
+		t.Fatalf("writeMessage() error = %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("failed to parse Content-Type: %v", err)
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	found := 0
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		if part.FileName() == "" {
+			continue
+		}
+
+		raw, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", part.FileName(), err)
+		}
+		if !bytes.HasSuffix(raw, []byte("\r\n")) {
+			t.Errorf("%s: base64 body does not end with CRLF: %q", part.FileName(), raw)
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(string(raw), "\r\n"), "\r\n") {
+			if len(line) > 76 {
+				t.Errorf("%s: base64 line is %d characters, want at most 76", part.FileName(), len(line))
+			}
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(raw), "\r\n", ""))
+		if err != nil {
+			t.Fatalf("%s: invalid base64: %v", part.FileName(), err)
+		}
+		if !bytes.Equal(decoded, contents[part.FileName()]) {
+			t.Errorf("%s: decoded attachment does not match the original", part.FileName())
+		}
+		found++
+	}
+	if found != len(sizes) {
+		t.Errorf("found %d attachment parts, want %d", found, len(sizes))
+	}
+}
+
+func TestWriteMessageWithAttachmentData(t *testing.T) {
+	cfg := config.DefaultConfig()
+	client := NewClient(cfg, "testpassword")
+
+	msg := &Message{
+		From:    "sender@example.com",
+		To:      []string{"recipient@example.com"},
+		Subject: "Report",
+		Body:    "Report attached",
+		AttachmentData: []Attachment{
+			{Filename: "report.csv", Data: []byte("a,b\n1,2\n")},
+			{Filename: "data", ContentType: "application/json", Data: []byte("{}")},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := client.writeMessage(&buf, msg); err != nil {
+		t.Fatalf("writeMessage() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "Content-Type: multipart/mixed") {
+		t.Error("output should be multipart/mixed for in-memory attachments")
+	}
+	if !strings.Contains(output, `filename="report.csv"`) || !strings.Contains(output, "Content-Type: text/csv") {
+		t.Error("output should contain report.csv with a type guessed from its extension")
+	}
+	if !strings.Contains(output, `filename="data"`) || !strings.Contains(output, "Content-Type: application/json") {
+		t.Error("output should contain data with its explicit content type")
+	}
+	if !strings.Contains(output, base64.StdEncoding.EncodeToString([]byte("a,b\n1,2\n"))) {
+		t.Error("output should contain the base64-encoded attachment data")
+	}
+}
+
+func TestWriteMessageContentTypeOverride(t *testing.T) {
+	cfg := config.DefaultConfig()
+	client := NewClient(cfg, "testpassword")
+
+	attachPath := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(attachPath, []byte("a,b\n"), 0644); err != nil {
+		t.Fatalf("failed to create attachment: %v", err)
+	}
+
+	msg := &Message{
+		From:           "sender@example.com",
+		To:             []string{"recipient@example.com"},
+		Subject:        "Override",
+		Body:           "Body",
+		Attachments:    []string{attachPath},
+		AttachmentData: []Attachment{{Filename: "blob", ContentType: "application/json", Data: []byte("x")}},
+		ContentTypes:   map[string]string{"report.txt": "text/csv", "blob": "text/plain"},
+	}
+
+	var buf bytes.Buffer
+	if err := client.writeMessage(&buf, msg); err != nil {
+		t.Fatalf("writeMessage() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "Content-Type: text/csv") {
+		t.Error("override should win over the type guessed from the .txt extension")
+	}
+	if !strings.Contains(output, "Content-Type: text/plain\r\n") || strings.Contains(output, "application/json") {
+		t.Error("override should win over the attachment's own content type")
+	}
+}
+
+func TestWriteMessageForwardedEmailAttachment(t *testing.T) {
+	cfg := config.DefaultConfig()
+	client := NewClient(cfg, "testpassword")
+
+	original := "From: alice@example.com\nSubject: Original\nContent-Type: multipart/mixed; boundary=inner\n\n" +
+		"--inner\nContent-Type: text/plain\n\nOriginal body\n" +
+		"--inner\nContent-Type: application/pdf\nContent-Disposition: attachment; filename=\"a.pdf\"\nContent-Transfer-Encoding: base64\n\nJVBERi0=\n" +
+		"--inner--\n"
+
+	msg := &Message{
+		From:           "sender@example.com",
+		To:             []string{"recipient@example.com"},
+		Subject:        "Fwd: Original",
+		Body:           "FYI",
+		AttachmentData: []Attachment{{Filename: "forwarded.eml", ContentType: "message/rfc822", Data: []byte(original)}},
+	}
+
+	var buf bytes.Buffer
+	if err := client.writeMessage(&buf, msg); err != nil {
+		t.Fatalf("writeMessage() error = %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	_, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+
+	var eml *multipart.Part
+	for {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			break
+		}
+		if part.FileName() == "forwarded.eml" {
+			eml = part
+			break
+		}
+	}
+	if eml == nil {
+		t.Fatal("forwarded.eml part not found")
+	}
+	if got := eml.Header.Get("Content-Type"); got != "message/rfc822" {
+		t.Errorf("Content-Type = %q, want message/rfc822", got)
+	}
+	if got := eml.Header.Get("Content-Transfer-Encoding"); got != "7bit" {
+		t.Errorf("Content-Transfer-Encoding = %q, want 7bit", got)
+	}
+
+	inner, err := mail.ReadMessage(eml)
+	if err != nil {
+		t.Fatalf("failed to parse forwarded message: %v", err)
+	}
+	if got := inner.Header.Get("Subject"); got != "Original" {
+		t.Errorf("forwarded Subject = %q, want %q", got, "Original")
+	}
+	innerBody, _ := io.ReadAll(inner.Body)
+	if !strings.Contains(string(innerBody), `filename="a.pdf"`) || !strings.Contains(string(innerBody), "JVBERi0=") {
+		t.Error("forwarded message should keep its own attachment")
+	}
+}
+
+func TestWriteMessageForwardedEmailLongLine(t *testing.T) {
+	client := NewClient(config.DefaultConfig(), "testpassword")
+
+	original := "From: alice@example.com\nSubject: Newsletter\nContent-Type: text/html\n\n<p>" + strings.Repeat("x", 1200) + "</p>\n"
+	msg := &Message{
+		From:           "sender@example.com",
+		To:             []string{"recipient@example.com"},
+		Subject:        "Fwd: Newsletter",
+		Body:           "FYI",
+		AttachmentData: []Attachment{{Filename: "forwarded.eml", ContentType: "message/rfc822", Data: []byte(original)}},
+	}
+
+	var buf bytes.Buffer
+	if err := client.writeMessage(&buf, msg); err != nil {
+		t.Fatalf("writeMessage() error = %v", err)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 998 {
+			t.Fatalf("message has a %d-octet line", len(line))
+		}
+	}
+	if !strings.Contains(buf.String(), "Content-Type: application/octet-stream") || strings.Contains(buf.String(), "message/rfc822") {
+		t.Errorf("long-lined email should be attached as a base64 file:\n%s", buf.String())
+	}
+
+	if !hasLongLine([]byte("short\r\n"+strings.Repeat("y", 999))) || hasLongLine([]byte(strings.Repeat("y", 998)+"\r\nshort")) {
+		t.Error("hasLongLine() must flag only lines over 998 octets")
+	}
+}
+
 func TestWriteMessageAttachmentNotFound(t *testing.T) {
 	cfg := config.DefaultConfig()
 	client := NewClient(cfg, "testpassword")
@@ -380,6 +797,54 @@ func TestWriteMessageAttachmentNotFound(t *testing.T) {
 	}
 }
 
+func TestWriteMessageAttachmentTooLarge(t *testing.T) {
+	cfg := config.DefaultConfig()
+	client := NewClient(cfg, "testpassword")
+
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, make([]byte, 2048), 0600); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		msg      Message
+		filename string
+	}{
+		{"file", Message{Attachments: []string{path}}, path},
+		{"in memory", Message{AttachmentData: []Attachment{{Filename: "stdin.bin", Data: make([]byte, 2048)}}}, "stdin.bin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.msg
+			msg.From = "sender@example.com"
+			msg.To = []string{"recipient@example.com"}
+			msg.MaxAttachmentSize = 1024
+
+			var buf bytes.Buffer
+			err := client.writeMessage(&buf, &msg)
+			var tooLarge *AttachmentTooLargeError
+			if !errors.As(err, &tooLarge) {
+				t.Fatalf("writeMessage() error = %v, want AttachmentTooLargeError", err)
+			}
+			if tooLarge.Filename != tt.filename || tooLarge.Size != 2048 || tooLarge.Limit != 1024 {
+				t.Errorf("error = %+v", tooLarge)
+			}
+
+			// At the limit, or with no limit, the message is written
+			msg.MaxAttachmentSize = 2048
+			if err := client.writeMessage(&buf, &msg); err != nil {
+				t.Errorf("writeMessage() at limit error = %v", err)
+			}
+			msg.MaxAttachmentSize = 0
+			if err := client.writeMessage(&buf, &msg); err != nil {
+				t.Errorf("writeMessage() without limit error = %v", err)
+			}
+		})
+	}
+}
+
 func TestWriteMessageMultipleRecipients(t *testing.T) {
 	cfg := config.DefaultConfig()
 	client := NewClient(cfg, "testpassword")
@@ -403,6 +868,79 @@ func TestWriteMessageMultipleRecipients(t *testing.T) {
 	}
 }
 
+func TestWriteMessageFoldsLongAddressHeaders(t *testing.T) {
+	cfg := config.DefaultConfig()
+	client := NewClient(cfg, "testpassword")
+
+	var to, cc []string
+	for i := 0; i < 30; i++ {
+		to = append(to, fmt.Sprintf("Recipient Number %d <recipient%d@example.com>", i, i))
+		cc = append(cc, fmt.Sprintf("cc%d@example.com", i))
+	}
+
+	msg := &Message{
+		From:    "sender@example.com",
+		To:      to,
+		CC:      cc,
+		Subject: "Many recipients",
+		Body:    "Body",
+	}
+
+	var buf bytes.Buffer
+	if err := client.writeMessage(&buf, msg); err != nil {
+		t.Fatalf("writeMessage() error = %v", err)
+	}
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 78 {
+			t.Errorf("header line is %d octets, want at most 78: %q", len(line), line)
+		}
+	}
+
+	parsed, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	for header, want := range map[string][]string{"To": to, "Cc": cc} {
+		addrs, err := parsed.Header.AddressList(header)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", header, err)
+		}
+		if len(addrs) != len(want) {
+			t.Errorf("%s has %d addresses after unfolding, want %d", header, len(addrs), len(want))
+		}
+	}
+}
+
+func TestFoldAddressHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		addrs []string
+		want  string
+	}{
+		{"single", []string{"a@example.com"}, "To: a@example.com\r\n"},
+		{"fits on one line", []string{"a@example.com", "b@example.com"}, "To: a@example.com, b@example.com\r\n"},
+		{
+			"folds between addresses",
+			[]string{strings.Repeat("a", 40) + "@example.com", strings.Repeat("b", 40) + "@example.com"},
+			"To: " + strings.Repeat("a", 40) + "@example.com,\r\n " + strings.Repeat("b", 40) + "@example.com\r\n",
+		},
+		{
+			"overlong address is not split",
+			[]string{strings.Repeat("x", 90) + "@example.com"},
+			"To: " + strings.Repeat("x", 90) + "@example.com\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := foldAddressHeader("To", tt.addrs); got != tt.want {
+				t.Errorf("foldAddressHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestWriteMessageDateHeader(t *testing.T) {
 	cfg := config.DefaultConfig()
 	client := NewClient(cfg, "testpassword")
@@ -493,6 +1031,47 @@ func TestWriteMessageStripsHeaderInjection(t *testing.T) {
 	}
 }
 
+func TestValidateAddresses(t *testing.T) {
+	tests := []struct {
+		name    string
+		addrs   []string
+		wantErr bool
+	}{
+		{"plain address", []string{"user@example.com"}, false},
+		{"name and address", []string{"Jane Doe <jane@example.com>", `"Doe, Jane" <jane@example.com>`}, false},
+		{"none", nil, false},
+		{"missing @", []string{"user.example.com"}, true},
+		{"comma typo", []string{"user@exmaple,com"}, true},
+		{"embedded newline", []string{"victim@example.com\nBcc: attacker@evil.example"}, true},
+		{"embedded CR", []string{"victim@example.com\r"}, true},
+		{"empty", []string{""}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAddresses(tt.addrs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAddresses(%q) error = %v, wantErr %v", tt.addrs, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("lists every bad address", func(t *testing.T) {
+		err := ValidateAddresses([]string{"ok@example.com", "bad-one", "bad@two,com"})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		for _, want := range []string{`"bad-one"`, `"bad@two,com"`} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not mention %s", err, want)
+			}
+		}
+		if strings.Contains(err.Error(), "ok@example.com") {
+			t.Errorf("error %q mentions a valid address", err)
+		}
+	})
+}
+
 func TestClientConfigValues(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Bridge.SMTPHost = "smtp.example.com"
@@ -1,23 +1,35 @@
 package smtp
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"net/smtp"
 	"time"
+
+	"github.com/bscott/pm-cli/internal/config"
 )
 
-// ConnectTimeout is the maximum time allowed for establishing SMTP TCP connections.
-const ConnectTimeout = 5 * time.Second
+// ConnectTimeout is the default limit for establishing SMTP TCP
+// connections; a configured timeout replaces it.
+const ConnectTimeout = config.DefaultConnectTimeout
 
 var dialTimeout = net.DialTimeout
 
-// DialClient connects to an SMTP server with an explicit timeout and
-// returns a client that can be upgraded with STARTTLS.
-func DialClient(addr, host string) (*smtp.Client, error) {
-	conn, err := dialTimeout("tcp", addr, ConnectTimeout)
+// ErrConnect is returned when the SMTP server cannot be reached.
+var ErrConnect = errors.New("failed to connect to SMTP server")
+
+// DialClient connects to an SMTP server within timeout and returns a
+// client that can be upgraded with STARTTLS. A non-zero deadline is set on
+// the connection, so every later command fails once it has passed.
+func DialClient(addr, host string, timeout time.Duration, deadline time.Time) (*smtp.Client, error) {
+	conn, err := dialTimeout("tcp", addr, timeout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrConnect, err)
+	}
+	if !deadline.IsZero() {
+		conn.SetDeadline(deadline)
 	}
 
 	client, err := smtp.NewClient(conn, host)
@@ -28,3 +40,79 @@ func DialClient(addr, host string) (*smtp.Client, error) {
 
 	return client, nil
 }
+
+// Dial connects to an SMTP server and secures the connection as security
+// says: upgraded with STARTTLS, TLS from the start, or not at all (see
+// config.CheckSecurity).
+func Dial(addr, host, security string, tlsConfig *tls.Config, timeout time.Duration, deadline time.Time) (*smtp.Client, error) {
+	if security != config.SecurityTLS {
+		client, err := DialClient(addr, host, timeout, deadline)
+		if err != nil {
+			return nil, err
+		}
+		if security == config.SecurityNone {
+			return client, nil
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+		return client, nil
+	}
+
+	conn, err := dialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConnect, err)
+	}
+	if !deadline.IsZero() {
+		conn.SetDeadline(deadline)
+	}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+
+	client, err := smtp.NewClient(tlsConn, host)
+	if err != nil {
+		tlsConn.Close()
+		return nil, fmt.Errorf("failed to create SMTP client: %w", err)
+	}
+
+	return client, nil
+}
+
+// plainAuth is smtp.PlainAuth with the loopback check of
+// config.IsLoopbackHost. The standard one only skips the TLS requirement
+// for "localhost", 127.0.0.1 and ::1, so security none would fail to log
+// in to any other 127/8 address it accepts.
+type plainAuth struct {
+	identity string
+	username string
+	password string
+	host     string
+}
+
+// PlainAuth returns the PLAIN authentication pm-cli uses: smtp.PlainAuth,
+// except that plain text is allowed to every loopback host.
+func PlainAuth(identity, username, password, host string) smtp.Auth {
+	return &plainAuth{identity: identity, username: username, password: password, host: host}
+}
+
+func (a *plainAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !config.IsLoopbackHost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "PLAIN", []byte(a.identity + "\x00" + a.username + "\x00" + a.password), nil
+}
+
+func (a *plainAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		return nil, errors.New("unexpected server challenge")
+	}
+	return nil, nil
+}
@@ -0,0 +1,119 @@
+// Package snooze keeps the local record of snoozed messages and when they
+// should return to their mailbox.
+package snooze
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Entry is a snoozed message. Messages are matched by Message-ID because
+// moving a message between folders changes its UID.
+type Entry struct {
+	MessageID string    `json:"message_id"`
+	Subject   string    `json:"subject,omitempty"`
+	From      string    `json:"from,omitempty"`
+	Mailbox   string    `json:"mailbox"`
+	Folder    string    `json:"folder"`
+	WakeAt    time.Time `json:"wake_at"`
+	SnoozedAt time.Time `json:"snoozed_at"`
+}
+
+// Store manages the snooze list.
+type Store struct {
+	Entries []Entry `json:"entries"`
+	path    string
+}
+
+// storePath returns the path to the snooze JSON file.
+func storePath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "pm-cli", "snoozed.json"), nil
+}
+
+// Load reads the snooze store from disk.
+func Load() (*Store, error) {
+	path, err := storePath()
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{
+		Entries: []Entry{},
+		path:    path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read snooze list: %w", err)
+	}
+
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse snooze list: %w", err)
+	}
+
+	store.path = path
+	return store, nil
+}
+
+// Save writes the snooze store to disk.
+func (s *Store) Save() error {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snooze list: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write snooze list: %w", err)
+	}
+
+	return nil
+}
+
+// Add records a snoozed message, replacing any earlier entry for the same
+// Message-ID.
+func (s *Store) Add(e Entry) {
+	s.Remove(e.MessageID)
+	s.Entries = append(s.Entries, e)
+}
+
+// Remove drops the entry for messageID, if any.
+func (s *Store) Remove(messageID string) {
+	kept := s.Entries[:0]
+	for _, e := range s.Entries {
+		if e.MessageID != messageID {
+			kept = append(kept, e)
+		}
+	}
+	s.Entries = kept
+}
+
+// Due returns the entries whose wake time is at or before now, oldest
+// first.
+func (s *Store) Due(now time.Time) []Entry {
+	var due []Entry
+	for _, e := range s.Entries {
+		if !e.WakeAt.After(now) {
+			due = append(due, e)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].WakeAt.Before(due[j].WakeAt)
+	})
+	return due
+}
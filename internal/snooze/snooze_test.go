@@ -0,0 +1,56 @@
+package snooze
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreDueAndRemove(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	store, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	store.Add(Entry{MessageID: "late@example.com", WakeAt: now.Add(time.Hour)})
+	store.Add(Entry{MessageID: "due@example.com", WakeAt: now.Add(-time.Hour)})
+	store.Add(Entry{MessageID: "exact@example.com", WakeAt: now})
+	store.Add(Entry{MessageID: "older@example.com", WakeAt: now.Add(-48 * time.Hour)})
+
+	// Re-snoozing replaces the earlier entry
+	store.Add(Entry{MessageID: "late@example.com", WakeAt: now.Add(2 * time.Hour)})
+	if len(store.Entries) != 4 {
+		t.Fatalf("len(Entries) = %d, want 4", len(store.Entries))
+	}
+
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	due := loaded.Due(now)
+	want := []string{"older@example.com", "due@example.com", "exact@example.com"}
+	if len(due) != len(want) {
+		t.Fatalf("Due() returned %d entries, want %d", len(due), len(want))
+	}
+	for i, id := range want {
+		if due[i].MessageID != id {
+			t.Errorf("Due()[%d] = %s, want %s", i, due[i].MessageID, id)
+		}
+	}
+
+	for _, e := range due {
+		loaded.Remove(e.MessageID)
+	}
+	if len(loaded.Entries) != 1 || loaded.Entries[0].MessageID != "late@example.com" {
+		t.Errorf("after Remove, Entries = %+v", loaded.Entries)
+	}
+}
@@ -0,0 +1,443 @@
+// Package tui is the full-screen terminal interface behind 'pm-cli tui': a
+// message list above a reading pane, driven by single keystrokes.
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bscott/pm-cli/internal/imap"
+	"github.com/bscott/pm-cli/internal/safetext"
+	"golang.org/x/term"
+)
+
+// Mailbox is what the interface needs from the mail server. The cli
+// package implements it on top of imap.Client, so every action behaves
+// like the matching command.
+type Mailbox interface {
+	// Name is shown in the title bar.
+	Name() string
+	List() ([]imap.MessageSummary, error)
+	// Search takes a query in the 'mail search' syntax.
+	Search(query string) ([]imap.MessageSummary, error)
+	// Read returns the message as text and marks it read.
+	Read(msg imap.MessageSummary) (string, error)
+	SetStarred(msg imap.MessageSummary, starred bool) error
+	Delete(msg imap.MessageSummary) error
+	Archive(msg imap.MessageSummary) error
+}
+
+const helpText = "↑/↓ move  enter read  space/b scroll  s star  d delete  a archive  / search  r reload  q quit"
+
+// Run shows the interface on the terminal until the user quits.
+func Run(mb Mailbox) error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return errors.New("tui needs an interactive terminal")
+	}
+
+	m := &model{mailbox: mb}
+	if err := m.load(); err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(in, state)
+
+	w := bufio.NewWriter(os.Stdout)
+	// Alternate screen, hidden cursor
+	fmt.Fprint(w, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(w, "\x1b[?25h\x1b[?1049l")
+		w.Flush()
+	}()
+
+	buf := make([]byte, 256)
+	for !m.quit {
+		if width, height, err := term.GetSize(out); err == nil {
+			m.width, m.height = width, height
+		}
+		draw(w, m.view())
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			m.update(k)
+			if m.quit {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// draw repaints the screen with lines, one per terminal row.
+func draw(w *bufio.Writer, lines []string) {
+	fmt.Fprint(w, "\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			fmt.Fprint(w, "\r\n")
+		}
+		fmt.Fprint(w, line, "\x1b[K")
+	}
+	fmt.Fprint(w, "\x1b[J")
+	w.Flush()
+}
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyInterrupt
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// parseKeys splits terminal input into keys. Arrow and page keys arrive as
+// escape sequences; an escape on its own is the Esc key.
+func parseKeys(data []byte) []key {
+	var keys []key
+	for len(data) > 0 {
+		if data[0] == 0x1b {
+			if len(data) >= 3 && data[1] == '[' {
+				switch {
+				case data[2] == 'A':
+					keys, data = append(keys, key{code: keyUp}), data[3:]
+					continue
+				case data[2] == 'B':
+					keys, data = append(keys, key{code: keyDown}), data[3:]
+					continue
+				case len(data) >= 4 && data[2] == '5' && data[3] == '~':
+					keys, data = append(keys, key{code: keyPageUp}), data[4:]
+					continue
+				case len(data) >= 4 && data[2] == '6' && data[3] == '~':
+					keys, data = append(keys, key{code: keyPageDown}), data[4:]
+					continue
+				}
+				// Skip any other sequence up to its final byte
+				end := 2
+				for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+					end++
+				}
+				data = data[min(end+1, len(data)):]
+				continue
+			}
+			keys, data = append(keys, key{code: keyEscape}), data[1:]
+			continue
+		}
+
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		switch r {
+		case '\r', '\n':
+			keys = append(keys, key{code: keyEnter})
+		case 0x7f, 0x08:
+			keys = append(keys, key{code: keyBackspace})
+		case 0x03:
+			keys = append(keys, key{code: keyInterrupt})
+		default:
+			if r >= ' ' {
+				keys = append(keys, key{code: keyRune, r: r})
+			}
+		}
+	}
+	return keys
+}
+
+type model struct {
+	mailbox  Mailbox
+	messages []imap.MessageSummary
+	cursor   int
+	top      int // first message shown in the list
+
+	// The message in the reading pane
+	openUID uint32
+	body    string
+	bodyTop int
+
+	query     string // search shown instead of the whole mailbox
+	searching bool   // typing a search
+	input     string
+
+	status        string
+	quit          bool
+	width, height int
+}
+
+// load fills the list from the mailbox, or from the active search.
+func (m *model) load() error {
+	var messages []imap.MessageSummary
+	var err error
+	if m.query != "" {
+		messages, err = m.mailbox.Search(m.query)
+	} else {
+		messages, err = m.mailbox.List()
+	}
+	if err != nil {
+		return err
+	}
+	m.messages = messages
+	m.cursor = min(m.cursor, max(len(messages)-1, 0))
+	return nil
+}
+
+func (m *model) selected() (imap.MessageSummary, bool) {
+	if m.cursor < len(m.messages) {
+		return m.messages[m.cursor], true
+	}
+	return imap.MessageSummary{}, false
+}
+
+func (m *model) update(k key) {
+	if m.searching {
+		m.updateSearch(k)
+		return
+	}
+
+	m.status = ""
+	switch {
+	case k.code == keyInterrupt || k.r == 'q':
+		m.quit = true
+	case k.code == keyUp || k.r == 'k':
+		m.cursor = max(m.cursor-1, 0)
+	case k.code == keyDown || k.r == 'j':
+		m.cursor = min(m.cursor+1, max(len(m.messages)-1, 0))
+	case k.code == keyPageDown || k.r == ' ':
+		m.bodyTop += max(m.bodyHeight()-1, 1)
+	case k.code == keyPageUp || k.r == 'b':
+		m.bodyTop = max(m.bodyTop-max(m.bodyHeight()-1, 1), 0)
+	case k.code == keyEnter:
+		m.open()
+	case k.r == 's':
+		m.toggleStar()
+	case k.r == 'd':
+		m.remove("Deleted", m.mailbox.Delete)
+	case k.r == 'a':
+		m.remove("Archived", m.mailbox.Archive)
+	case k.r == '/':
+		m.searching, m.input = true, m.query
+	case k.r == 'r':
+		m.reload()
+	case k.code == keyEscape && m.query != "":
+		m.query = ""
+		m.reload()
+	}
+}
+
+func (m *model) updateSearch(k key) {
+	switch k.code {
+	case keyInterrupt:
+		m.quit = true
+	case keyEscape:
+		m.searching = false
+	case keyEnter:
+		m.searching = false
+		m.query = strings.TrimSpace(m.input)
+		m.cursor = 0
+		m.reload()
+	case keyBackspace:
+		if _, size := utf8.DecodeLastRuneInString(m.input); size > 0 {
+			m.input = m.input[:len(m.input)-size]
+		}
+	case keyRune:
+		m.input += string(k.r)
+	}
+}
+
+func (m *model) reload() {
+	if err := m.load(); err != nil {
+		m.status = "Error: " + err.Error()
+	}
+}
+
+func (m *model) open() {
+	msg, ok := m.selected()
+	if !ok {
+		return
+	}
+	body, err := m.mailbox.Read(msg)
+	if err != nil {
+		m.status = "Error: " + err.Error()
+		return
+	}
+	m.openUID, m.body, m.bodyTop = msg.UID, body, 0
+	m.messages[m.cursor].Seen = true
+}
+
+func (m *model) toggleStar() {
+	msg, ok := m.selected()
+	if !ok {
+		return
+	}
+	if err := m.mailbox.SetStarred(msg, !msg.Flagged); err != nil {
+		m.status = "Error: " + err.Error()
+		return
+	}
+	m.messages[m.cursor].Flagged = !msg.Flagged
+}
+
+// remove runs action, a delete or archive, on the selected message and
+// drops it from the list.
+func (m *model) remove(done string, action func(imap.MessageSummary) error) {
+	msg, ok := m.selected()
+	if !ok {
+		return
+	}
+	if err := action(msg); err != nil {
+		m.status = "Error: " + err.Error()
+		return
+	}
+	m.messages = append(m.messages[:m.cursor], m.messages[m.cursor+1:]...)
+	m.cursor = min(m.cursor, max(len(m.messages)-1, 0))
+	if msg.UID == m.openUID {
+		m.openUID, m.body = 0, ""
+	}
+	m.status = fmt.Sprintf("%s: %s", done, msg.Subject)
+}
+
+// listHeight is the number of list rows: two fifths of the space between
+// the title bar and the status line, and at least 3.
+func (m *model) listHeight() int {
+	return max((m.height-3)*2/5, 3)
+}
+
+func (m *model) bodyHeight() int {
+	return max(m.height-3-m.listHeight(), 1)
+}
+
+// view renders the screen: title bar, message list, divider, reading pane
+// and status line.
+func (m *model) view() []string {
+	width := max(m.width, 20)
+	var lines []string
+
+	title := fmt.Sprintf("pm-cli  %s  (%d messages)", m.mailbox.Name(), len(m.messages))
+	if m.query != "" {
+		title = fmt.Sprintf("pm-cli  %s  search: %s  (%d matches, esc clears)", m.mailbox.Name(), m.query, len(m.messages))
+	}
+	lines = append(lines, inverse(fit(safetext.SanitizeForTerminal(title), width)))
+
+	listHeight := m.listHeight()
+	if m.cursor < m.top {
+		m.top = m.cursor
+	}
+	if m.cursor >= m.top+listHeight {
+		m.top = m.cursor - listHeight + 1
+	}
+	for i := m.top; i < m.top+listHeight; i++ {
+		if i >= len(m.messages) {
+			if i == 0 {
+				lines = append(lines, "  No messages")
+				continue
+			}
+			lines = append(lines, "")
+			continue
+		}
+		row := fit(messageRow(m.messages[i], width-2), width-2)
+		if i == m.cursor {
+			lines = append(lines, inverse("> "+row))
+		} else {
+			lines = append(lines, "  "+row)
+		}
+	}
+
+	divider := strings.Repeat("─", width)
+	lines = append(lines, divider)
+
+	body := wrap(safetext.SanitizeForTerminal(m.body), width)
+	bodyHeight := m.bodyHeight()
+	m.bodyTop = min(m.bodyTop, max(len(body)-bodyHeight, 0))
+	for i := m.bodyTop; i < m.bodyTop+bodyHeight; i++ {
+		if i < len(body) {
+			lines = append(lines, body[i])
+		} else {
+			lines = append(lines, "")
+		}
+	}
+
+	switch {
+	case m.searching:
+		lines = append(lines, fit("/"+m.input, width))
+	case m.status != "":
+		// Errors can carry server text
+		lines = append(lines, fit(safetext.SanitizeForTerminal(m.status), width))
+	default:
+		lines = append(lines, fit(helpText, width))
+	}
+	return lines
+}
+
+// messageRow formats a message for the list: unread and star markers,
+// sender, subject and date.
+func messageRow(msg imap.MessageSummary, width int) string {
+	marks := []rune("  ")
+	if !msg.Seen {
+		marks[0] = '●'
+	}
+	if msg.Flagged {
+		marks[1] = '★'
+	}
+	from := fit(safetext.SanitizeForTerminal(msg.From), 22)
+	date := safetext.SanitizeForTerminal(msg.Date)
+	// Markers, sender column and the gaps take 29 columns
+	subjectWidth := max(width-utf8.RuneCountInString(date)-29, 10)
+	subject := fit(safetext.SanitizeForTerminal(msg.Subject), subjectWidth)
+	return fmt.Sprintf("%s %-22s  %-*s  %s", string(marks), from, subjectWidth, subject, date)
+}
+
+// fit cuts s to at most width runes.
+func fit(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	if width <= 1 {
+		return string(r[:max(width, 0)])
+	}
+	return string(r[:width-1]) + "…"
+}
+
+// wrap breaks text into lines of at most width runes.
+func wrap(text string, width int) []string {
+	if text == "" {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line = strings.ReplaceAll(strings.TrimRight(line, "\r"), "\t", "    ")
+		r := []rune(line)
+		for len(r) > width {
+			lines = append(lines, string(r[:width]))
+			r = r[width:]
+		}
+		lines = append(lines, string(r))
+	}
+	return lines
+}
+
+func inverse(s string) string {
+	return "\x1b[7m" + s + "\x1b[0m"
+}
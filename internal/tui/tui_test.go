@@ -0,0 +1,201 @@
+package tui
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bscott/pm-cli/internal/imap"
+)
+
+// fakeMailbox records the actions the model asks for.
+type fakeMailbox struct {
+	messages []imap.MessageSummary
+	queries  []string
+	starred  map[uint32]bool
+	deleted  []uint32
+	archived []uint32
+	failWith error
+}
+
+func (f *fakeMailbox) Name() string { return "INBOX" }
+
+func (f *fakeMailbox) List() ([]imap.MessageSummary, error) {
+	return append([]imap.MessageSummary(nil), f.messages...), nil
+}
+
+func (f *fakeMailbox) Search(query string) ([]imap.MessageSummary, error) {
+	f.queries = append(f.queries, query)
+	return f.messages[:1], nil
+}
+
+func (f *fakeMailbox) Read(msg imap.MessageSummary) (string, error) {
+	return "Subject: " + msg.Subject + "\n\nbody of " + msg.Subject, nil
+}
+
+func (f *fakeMailbox) SetStarred(msg imap.MessageSummary, starred bool) error {
+	if f.failWith != nil {
+		return f.failWith
+	}
+	if f.starred == nil {
+		f.starred = make(map[uint32]bool)
+	}
+	f.starred[msg.UID] = starred
+	return nil
+}
+
+func (f *fakeMailbox) Delete(msg imap.MessageSummary) error {
+	f.deleted = append(f.deleted, msg.UID)
+	return nil
+}
+
+func (f *fakeMailbox) Archive(msg imap.MessageSummary) error {
+	f.archived = append(f.archived, msg.UID)
+	return nil
+}
+
+func newTestModel(t *testing.T) (*model, *fakeMailbox) {
+	t.Helper()
+	mb := &fakeMailbox{messages: []imap.MessageSummary{
+		{UID: 30, From: "Alice", Subject: "Lunch", Date: "Jun 3"},
+		{UID: 20, From: "Bob", Subject: "Report", Date: "Jun 2", Seen: true},
+		{UID: 10, From: "Carol", Subject: "Hello", Date: "Jun 1", Flagged: true},
+	}}
+	m := &model{mailbox: mb, width: 80, height: 24}
+	if err := m.load(); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	return m, mb
+}
+
+func press(m *model, input string) {
+	for _, k := range parseKeys([]byte(input)) {
+		m.update(k)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("j\x1b[A\x1b[B\x1b[5~\x1b[6~\r\x1b\x7f\x03\x1b[1;5Cé"))
+	want := []key{
+		{code: keyRune, r: 'j'},
+		{code: keyUp},
+		{code: keyDown},
+		{code: keyPageUp},
+		{code: keyPageDown},
+		{code: keyEnter},
+		{code: keyEscape},
+		{code: keyBackspace},
+		{code: keyInterrupt},
+		{code: keyRune, r: 'é'},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestModelNavigationAndActions(t *testing.T) {
+	m, mb := newTestModel(t)
+
+	press(m, "k")
+	if m.cursor != 0 {
+		t.Fatalf("cursor = %d after moving up from the top, want 0", m.cursor)
+	}
+	press(m, "jjj")
+	if m.cursor != 2 {
+		t.Fatalf("cursor = %d after moving past the end, want 2", m.cursor)
+	}
+
+	press(m, "s")
+	if starred, ok := mb.starred[10]; !ok || starred || m.messages[2].Flagged {
+		t.Errorf("s on a starred message: starred = %v, %v; want it unstarred", starred, ok)
+	}
+
+	press(m, "\x1b[A\r")
+	if m.openUID != 20 || !strings.Contains(m.body, "body of Report") {
+		t.Errorf("enter opened UID %d with body %q, want Report", m.openUID, m.body)
+	}
+
+	press(m, "d")
+	if !reflect.DeepEqual(mb.deleted, []uint32{20}) || len(m.messages) != 2 {
+		t.Fatalf("deleted %v, %d left; want UID 20 removed", mb.deleted, len(m.messages))
+	}
+	if m.openUID != 0 || m.body != "" {
+		t.Error("expected the reading pane to close when its message is deleted")
+	}
+	if m.messages[m.cursor].UID != 10 {
+		t.Errorf("cursor on UID %d after delete, want the next message (10)", m.messages[m.cursor].UID)
+	}
+
+	press(m, "a")
+	if !reflect.DeepEqual(mb.archived, []uint32{10}) || m.cursor != 0 {
+		t.Errorf("archived %v with cursor %d, want UID 10 and cursor 0", mb.archived, m.cursor)
+	}
+
+	press(m, "q")
+	if !m.quit {
+		t.Error("expected q to quit")
+	}
+}
+
+func TestModelSearch(t *testing.T) {
+	m, mb := newTestModel(t)
+
+	press(m, "/from:alicx\x7fe\r")
+	if !reflect.DeepEqual(mb.queries, []string{"from:alice"}) {
+		t.Fatalf("searched %q, want from:alice", mb.queries)
+	}
+	if m.searching || len(m.messages) != 1 {
+		t.Errorf("searching = %v with %d results, want the search applied", m.searching, len(m.messages))
+	}
+
+	press(m, "\x1b")
+	if m.query != "" || len(m.messages) != 3 {
+		t.Errorf("esc left query %q and %d messages, want the full mailbox", m.query, len(m.messages))
+	}
+}
+
+func TestModelShowsErrors(t *testing.T) {
+	m, mb := newTestModel(t)
+	mb.failWith = errors.New("[UNAVAILABLE] \x1b]8;;evil\x07try later")
+
+	press(m, "s")
+	status := m.view()[len(m.view())-1]
+	if !strings.Contains(status, "try later") || strings.Contains(status, "\x1b]") {
+		t.Errorf("status line = %q, want the sanitized error", status)
+	}
+	if m.messages[0].Flagged {
+		t.Error("star must not change when the server refuses it")
+	}
+}
+
+func TestModelView(t *testing.T) {
+	m, _ := newTestModel(t)
+	m.width, m.height = 60, 12
+	press(m, "\r")
+
+	lines := m.view()
+	if len(lines) != m.height {
+		t.Fatalf("view() has %d lines, want %d", len(lines), m.height)
+	}
+	for i, line := range lines {
+		plain := strings.NewReplacer("\x1b[7m", "", "\x1b[0m", "").Replace(line)
+		if n := len([]rune(plain)); n > m.width {
+			t.Errorf("line %d is %d columns wide, over %d: %q", i, n, m.width, plain)
+		}
+	}
+	if !strings.Contains(lines[1], "Lunch") || !strings.HasPrefix(lines[1], "\x1b[7m> ") {
+		t.Errorf("first row = %q, want the highlighted Lunch message", lines[1])
+	}
+	if !strings.Contains(strings.Join(lines, "\n"), "body of Lunch") {
+		t.Error("expected the reading pane to show the open message")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	got := wrap("short\n"+strings.Repeat("x", 25)+"\n\ttab\n", 10)
+	want := []string{"short", "xxxxxxxxxx", "xxxxxxxxxx", "xxxxx", "    tab"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrap() = %q, want %q", got, want)
+	}
+}
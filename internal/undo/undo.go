@@ -0,0 +1,103 @@
+// Package undo records the last mutating mail operation so that it can be
+// reversed with 'pm-cli mail undo'.
+package undo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Commands that can be recorded.
+const (
+	CommandMove   = "move"
+	CommandDelete = "delete"
+)
+
+// Message is one message affected by an action. UID is its UID in the source
+// mailbox; DestUID is its UID in the destination when the server reported
+// one. MessageID is used to find the message when no UID is usable.
+type Message struct {
+	UID       uint32 `json:"uid"`
+	DestUID   uint32 `json:"dest_uid,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+}
+
+// Action is the last mutating operation.
+type Action struct {
+	Command     string    `json:"command"`
+	Mailbox     string    `json:"mailbox"`
+	Destination string    `json:"destination,omitempty"`
+	Permanent   bool      `json:"permanent,omitempty"`
+	Messages    []Message `json:"messages"`
+	At          time.Time `json:"at"`
+}
+
+// Path returns the location of last_action.json.
+func Path() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "pm-cli", "last_action.json"), nil
+}
+
+// Load returns the recorded action, or nil if there is none.
+func Load() (*Action, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read last action: %w", err)
+	}
+
+	var action Action
+	if err := json.Unmarshal(data, &action); err != nil {
+		return nil, fmt.Errorf("failed to parse last action: %w", err)
+	}
+	return &action, nil
+}
+
+// Save replaces the recorded action with a.
+func Save(a *Action) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal last action: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write last action: %w", err)
+	}
+	return nil
+}
+
+// Clear forgets the recorded action.
+func Clear() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove last action: %w", err)
+	}
+	return nil
+}
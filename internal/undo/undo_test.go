@@ -0,0 +1,57 @@
+package undo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSaveLoadClear(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	got, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got != nil {
+		t.Fatalf("Load() with no file = %+v, want nil", got)
+	}
+
+	action := &Action{
+		Command:     CommandMove,
+		Mailbox:     "INBOX",
+		Destination: "Archive",
+		Messages: []Message{
+			{UID: 10, DestUID: 200, MessageID: "a@example.com"},
+			{UID: 11, MessageID: "b@example.com"},
+		},
+		At: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC),
+	}
+	if err := Save(action); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got == nil || got.Command != CommandMove || got.Destination != "Archive" || len(got.Messages) != 2 {
+		t.Fatalf("Load() = %+v", got)
+	}
+	if got.Messages[0].DestUID != 200 || got.Messages[1].MessageID != "b@example.com" {
+		t.Errorf("Load() messages = %+v", got.Messages)
+	}
+
+	if err := Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if got, _ := Load(); got != nil {
+		t.Errorf("Load() after Clear = %+v, want nil", got)
+	}
+
+	// Clearing twice is not an error
+	if err := Clear(); err != nil {
+		t.Errorf("second Clear() error = %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Package watchstate keeps the high-water marks of mail watch, so a
+// restarted watch reports the mail that arrived while it was down and
+// nothing older.
+package watchstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Mark is the newest UID mail watch has handled in a mailbox. It is only
+// meaningful while the mailbox keeps the same UIDVALIDITY.
+type Mark struct {
+	UIDValidity uint32    `json:"uid_validity"`
+	LastUID     uint32    `json:"last_uid"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Store holds the marks of every watched mailbox, per account.
+type Store struct {
+	Accounts map[string]map[string]Mark `json:"accounts"`
+	path     string
+}
+
+// storePath returns the path to the watch state JSON file.
+func storePath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "pm-cli", "watch.json"), nil
+}
+
+// Load reads the watch state from disk.
+func Load() (*Store, error) {
+	path, err := storePath()
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{
+		Accounts: map[string]map[string]Mark{},
+		path:     path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read watch state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse watch state: %w", err)
+	}
+	if store.Accounts == nil {
+		store.Accounts = map[string]map[string]Mark{}
+	}
+
+	store.path = path
+	return store, nil
+}
+
+// Save writes the watch state to disk.
+func (s *Store) Save() error {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watch state: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the stored mark for mailbox of account.
+func (s *Store) Get(account, mailbox string) (Mark, bool) {
+	mark, ok := s.Accounts[account][mailbox]
+	return mark, ok
+}
+
+// Set records the mark for mailbox of account.
+func (s *Store) Set(account, mailbox string, mark Mark) {
+	if s.Accounts[account] == nil {
+		s.Accounts[account] = map[string]Mark{}
+	}
+	s.Accounts[account][mailbox] = mark
+}
+
+// Reset forgets the mark for mailbox of account.
+func (s *Store) Reset(account, mailbox string) {
+	delete(s.Accounts[account], mailbox)
+	if len(s.Accounts[account]) == 0 {
+		delete(s.Accounts, account)
+	}
+}
@@ -0,0 +1,53 @@
+package watchstate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	store, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, ok := store.Get("me@example.com", "INBOX"); ok {
+		t.Fatal("expected no mark before anything is stored")
+	}
+
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	store.Set("me@example.com", "INBOX", Mark{UIDValidity: 7, LastUID: 120, UpdatedAt: now})
+	store.Set("me@example.com", "Labels/Work", Mark{UIDValidity: 9, LastUID: 4, UpdatedAt: now})
+	store.Set("other@example.com", "INBOX", Mark{UIDValidity: 1, LastUID: 55, UpdatedAt: now})
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	mark, ok := loaded.Get("me@example.com", "INBOX")
+	if !ok || mark.UIDValidity != 7 || mark.LastUID != 120 || !mark.UpdatedAt.Equal(now) {
+		t.Errorf("Get(me, INBOX) = %+v, %v", mark, ok)
+	}
+	if mark, ok := loaded.Get("other@example.com", "INBOX"); !ok || mark.LastUID != 55 {
+		t.Errorf("Get(other, INBOX) = %+v, %v; accounts must be kept apart", mark, ok)
+	}
+
+	loaded.Reset("me@example.com", "INBOX")
+	if _, ok := loaded.Get("me@example.com", "INBOX"); ok {
+		t.Error("expected Reset to drop the mark")
+	}
+	if _, ok := loaded.Get("me@example.com", "Labels/Work"); !ok {
+		t.Error("expected Reset to keep the other mailboxes")
+	}
+
+	loaded.Reset("other@example.com", "INBOX")
+	if _, ok := loaded.Accounts["other@example.com"]; ok {
+		t.Error("expected an account without marks to be removed")
+	}
+}